@@ -0,0 +1,70 @@
+// etsy-sim 本地 Etsy API 模拟服务
+//
+// 启动后按提示设置环境变量，主服务即直连模拟服务：
+//
+//	go run ./cmd/etsy-sim -addr :8090 -seed
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"etsy_dev_v1_202512/pkg/etsysim"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "监听地址")
+	latency := flag.Duration("latency", 0, "每个请求的固定延迟")
+	jitter := flag.Duration("jitter", 0, "在固定延迟上追加的随机抖动上限")
+	perSecond := flag.Int("rate-limit", 0, "每个 API Key 每秒请求上限，0 表示不限")
+	perDay := flag.Int("daily-limit", 10000, "每个 API Key 每日请求上限")
+	every429 := flag.Int("429-every", 0, "每 N 个请求注入一次 429，0 表示关闭")
+	errorRate := flag.Float64("error-rate", 0, "随机错误比例 [0,1]")
+	errorStatus := flag.Int("error-status", http.StatusInternalServerError, "随机错误的状态码")
+	lenient := flag.Bool("lenient", false, "接受任意 Token 并自动创建未知店铺（对接已有数据库）")
+	seed := flag.Bool("seed", false, "预置演示店铺、商品与订单")
+	flag.Parse()
+
+	opts := []etsysim.Option{
+		etsysim.WithLatency(*latency, *jitter),
+		etsysim.WithRateLimit(*perSecond),
+		etsysim.WithDailyLimit(*perDay),
+		etsysim.WithRateLimitEvery(*every429),
+		etsysim.WithErrorRate(*errorRate, *errorStatus),
+	}
+	if *lenient {
+		opts = append(opts, etsysim.WithLenientAuth())
+	}
+	sim := etsysim.New(opts...)
+
+	if *seed {
+		shopID, accessToken, refreshToken := sim.SeedDemo()
+		log.Printf("[etsy-sim] 演示店铺 shop_id=%d", shopID)
+		log.Printf("[etsy-sim]   access_token=%s", accessToken)
+		log.Printf("[etsy-sim]   refresh_token=%s", refreshToken)
+	}
+
+	host, port, err := net.SplitHostPort(*addr)
+	if err != nil {
+		log.Fatalf("[etsy-sim] 监听地址无效: %v", err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	root := "http://" + net.JoinHostPort(host, port)
+	log.Printf("[etsy-sim] 监听 %s，主服务环境变量：", *addr)
+	log.Printf("  ETSY_NO_PROXY=true")
+	log.Printf("  ETSY_API_BASE_URL=%s%s", root, etsysim.ApplicationPath)
+	log.Printf("  ETSY_OAUTH_TOKEN_URL=%s%s", root, etsysim.TokenPath)
+	log.Printf("  ETSY_OAUTH_CONNECT_URL=%s%s", root, etsysim.ConnectPath)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           sim,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Fatal(srv.ListenAndServe())
+}
@@ -2,42 +2,67 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/controller"
+	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/internal/router"
 	"etsy_dev_v1_202512/internal/service"
 	"etsy_dev_v1_202512/internal/task"
 	"etsy_dev_v1_202512/pkg/database"
+	"etsy_dev_v1_202512/pkg/etsy"
+	"etsy_dev_v1_202512/pkg/geoip"
 	"etsy_dev_v1_202512/pkg/net"
+	"etsy_dev_v1_202512/pkg/utils"
 )
 
 func main() {
+	// 0. 加载字段加密密钥环（数据库读写加密字段前必须完成）
+	initEncryption()
+
 	// 1. 初始化数据库
 	db := database.InitDatabase()
 
 	// 2. 初始化依赖
 	deps := initDependencies(db)
 
+	// 密钥轮换：`app reencrypt` 将存量数据迁移到当前主密钥后退出
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		runReencrypt(deps)
+		return
+	}
+	// 代理批量导入：`app import-proxies -file proxies.txt -region US [-check]` 输出逐行结果后退出
+	if len(os.Args) > 1 && os.Args[1] == "import-proxies" {
+		runImportProxies(deps, os.Args[2:])
+		return
+	}
+
 	// 4. 启动业务同步任务
 	startInfraTasks(deps)
 	deps.TaskManager.Start()
+	deps.DraftJobWorker.Start()
 
 	// 4. 初始化路由
 	r := router.SetupRouter(deps.Controllers)
 
 	// 5. 启动服务
-	startServer(r, deps.TaskManager)
+	startServer(r, deps)
 }
 
 // ==================== 依赖容器 ====================
@@ -47,9 +72,13 @@ type Dependencies struct {
 	DB          *gorm.DB
 	Repos       *Repositories
 	Dispatcher  net.Dispatcher
+	EtsyClient  *etsy.Client
 	Controllers *router.Controllers
 	Services    *Services
 	TaskManager *task.TaskManager
+
+	// 草稿 AI 处理队列
+	DraftJobWorker *task.DraftJobWorker
 }
 
 // Repositories 仓库集合
@@ -69,11 +98,19 @@ type Repositories struct {
 	DraftTask       repository.DraftTaskRepository
 	DraftProduct    repository.DraftProductRepository
 	DraftImage      repository.DraftImageRepository
+	DraftUpload     repository.DraftImageUploadRepository
 	Order           repository.OrderRepository
 	OrderItem       repository.OrderItemRepository
 	Shipment        repository.ShipmentRepository
 	TrackingEvent   repository.TrackingEventRepository
 	AiCallLog       repository.AICallLogRepository
+	AIBudget        repository.AIBudgetRepository
+	SyncState       repository.SyncStateRepository
+	Notification    repository.NotificationRepository
+	Encryption      repository.EncryptionRepository
+	Stock           repository.StockRepository
+	OAuthState      repository.OAuthStateRepository
+	DomainPool      repository.DomainPoolRepository
 }
 
 // Services 服务集合
@@ -92,7 +129,17 @@ type Services struct {
 	Karrio       *service.KarrioClient
 	Storage      *service.StorageService
 	AI           *service.AIService
+	AIUsage      *service.AIUsageService
+	AIBudget     *service.AIBudgetService
 	OneBound     *service.OneBoundService
+	Notification *service.NotificationService
+	ShopMember   *service.ShopMemberService
+	Encryption   *service.EncryptionService
+	Quota        *service.QuotaService
+	Stock        *service.StockService
+	Domain       *service.DomainService
+
+	DeveloperHealth *service.DeveloperHealthService
 }
 
 // ==================== 初始化函数 ====================
@@ -104,14 +151,45 @@ func initDependencies(db *gorm.DB) *Dependencies {
 
 	// -------- 基础服务 --------
 	proxyService := service.NewProxyService(repos.Proxy, repos.Shop)
+	proxyService.SetMaxShopsPerSharedProxy(getEnvInt("PROXY_MAX_SHOPS_PER_SHARED", repository.DefaultMaxShopsPerSharedProxy))
+	initProxyGeo(proxyService)
 	networkProvider := service.NewNetworkProvider(repos.Shop, proxyService)
-	dispatcher := net.NewDispatcher(networkProvider)
+	quotaSvc := service.NewQuotaService(repos.Developer)
+	dispatcherOpts := []net.DispatcherOption{net.WithQuotaReporter(quotaSvc), net.WithProxyMetricsReporter(proxyService)}
+	// ETSY_NO_PROXY=true 时直连，配合 cmd/etsy-sim 本地联调
+	if getEnv("ETSY_NO_PROXY", "false") == "true" {
+		dispatcherOpts = append(dispatcherOpts, net.WithoutProxy())
+	}
+	dispatcher := net.NewDispatcher(networkProvider, dispatcherOpts...)
+
+	// -------- 通知中心 --------
+	notificationSvc := service.NewNotificationService(repos.Notification, repos.ShopMember)
+
+	// -------- Etsy 客户端 --------
+	// API Key 连续被拒绝时自动封禁开发者并迁移店铺
+	developerHealthSvc := service.NewDeveloperHealthService(repos.Developer, repos.Shop, repos.User)
+	developerHealthSvc.SetNotifier(notificationSvc)
+	etsyClient := etsy.NewClient(dispatcher,
+		etsy.WithBaseURL(getEnv("ETSY_API_BASE_URL", etsy.DefaultBaseURL)),
+		etsy.WithAuthReporter(developerHealthSvc),
+	)
 
 	// -------- 存储 & AI 服务 --------
 	storageSvc := initStorageService()
 	aiSvc := service.NewAIService(&service.AIConfig{
-		ApiKey: getEnv("GEMINI_API_KEY", ""),
+		Provider: getEnv("AI_PROVIDER", service.AIProviderGemini),
+		ApiKey:   getEnv("GEMINI_API_KEY", ""),
+		OpenAI: service.OpenAIConfig{
+			BaseURL:    getEnv("OPENAI_BASE_URL", ""),
+			ApiKey:     getEnv("OPENAI_API_KEY", ""),
+			TextModel:  getEnv("OPENAI_TEXT_MODEL", ""),
+			ImageModel: getEnv("OPENAI_IMAGE_MODEL", ""),
+		},
+		Prices: loadAIModelPrices(),
 	}, storageSvc, repos.AiCallLog)
+	aiBudgetSvc := service.NewAIBudgetService(repos.AIBudget, repos.AiCallLog)
+	aiSvc.SetBudgetChecker(aiBudgetSvc)
+	aiBudgetSvc.SetWarningHandler(notificationSvc.HandleAIBudgetWarning)
 	oneBoundSvc := service.NewOneBoundService(&service.OneBoundConfig{
 		APIKey:    getEnv("ONEBOUND_API_KEY", ""),
 		APISecret: getEnv("ONEBOUND_API_SECRET", ""),
@@ -125,37 +203,75 @@ func initDependencies(db *gorm.DB) *Dependencies {
 		Proxy:    proxyService,
 		Storage:  storageSvc,
 		AI:       aiSvc,
+		AIUsage:  service.NewAIUsageService(repos.AiCallLog),
+		AIBudget: aiBudgetSvc,
 		OneBound: oneBoundSvc,
 		Karrio:   karrioClient,
+
+		Notification: notificationSvc,
+		Encryption:   service.NewEncryptionService(repos.Encryption, utils.GetKeyRing()),
+		Quota:        quotaSvc,
+
+		DeveloperHealth: developerHealthSvc,
 	}
 
 	services.User = service.NewUserService(repos.User)
-	services.Developer = service.NewDeveloperService(repos.Developer, repos.Shop, dispatcher)
+	services.ShopMember = service.NewShopMemberService(service.ShopMemberRepos{
+		Member:          repos.ShopMember,
+		User:            repos.User,
+		Shop:            repos.Shop,
+		Product:         repos.Product,
+		Order:           repos.Order,
+		Shipment:        repos.Shipment,
+		Section:         repos.ShopSection,
+		ShippingProfile: repos.ShippingProfile,
+		ShippingUpgrade: repos.ShippingUpgrade,
+		ReturnPolicy:    repos.ReturnPolicy,
+		DraftTask:       repos.DraftTask,
+		DraftProduct:    repos.DraftProduct,
+		DraftJob:        repos.DraftUow.Jobs,
+	})
+	services.Developer = service.NewDeveloperService(repos.Developer, repos.Shop, etsyClient)
+	services.Domain = service.NewDomainService(repos.DomainPool)
 	services.Shipping = service.NewShippingProfileService(
 		repos.ShippingProfile, repos.ShippingDest, repos.ShippingUpgrade,
-		repos.Shop, repos.Developer, dispatcher,
+		repos.Shop, repos.Developer, etsyClient,
 	)
 	services.ReturnPolicy = service.NewReturnPolicyService(
-		repos.ReturnPolicy, repos.Shop, repos.Developer, dispatcher,
+		repos.ReturnPolicy, repos.Shop, repos.Developer, etsyClient,
 	)
 	services.Shop = service.NewShopService(
 		repos.Shop, repos.ShopSection,
 		repos.ShippingProfile, repos.ShippingDest, repos.ShippingUpgrade,
-		repos.ReturnPolicy, repos.Developer, dispatcher, repos.Proxy,
+		repos.ReturnPolicy, repos.Developer, etsyClient, repos.Proxy,
 	)
+	services.Shop.SetProxyBinder(proxyService)
 	services.Auth = service.NewAuthService(services.Shop, dispatcher)
-	services.Product = service.NewProductService(repos.Product, repos.Shop, aiSvc, storageSvc, dispatcher)
+	services.Auth.SetNotifier(notificationSvc)
+	services.Auth.SetOAuthEndpoints(getEnv("ETSY_OAUTH_CONNECT_URL", ""), getEnv("ETSY_OAUTH_TOKEN_URL", ""))
+	services.Auth.SetMemberRepo(repos.ShopMember)
+	services.Auth.SetStateStore(repos.OAuthState)
+	services.Auth.SetAuthReporter(developerHealthSvc)
+	services.Product = service.NewProductService(repos.Product, repos.Shop, aiSvc, storageSvc, etsyClient)
 	services.Draft = service.NewDraftService(repos.DraftUow, repos.Shop, oneBoundSvc, aiSvc, storageSvc)
+	services.Draft.SetBudgetChecker(aiBudgetSvc)
+	services.Draft.SetJobMaxAttempts(getEnvInt("DRAFT_JOB_MAX_ATTEMPTS", 3))
 	services.Order = service.NewOrderService(
-		repos.Order, repos.OrderItem, repos.Shipment, repos.Shop, dispatcher,
+		repos.Order, repos.OrderItem, repos.Shipment, repos.Shop, repos.SyncState, etsyClient,
 	)
+	services.Stock = service.NewStockService(repos.Stock, repos.Product, services.Product)
+	services.Order.SetStockConsumer(services.Stock)
 	services.Shipment = service.NewShipmentService(
 		repos.Shipment, repos.TrackingEvent, repos.Order, repos.Shop,
-		karrioClient, nil, // EtsyShipmentSyncer 可后续实现
+		karrioClient, service.NewEtsyShipmentService(repos.Shop, etsyClient),
 	)
+	services.Shipment.SetNotifier(notificationSvc)
 
 	// -------- TaskManager（业务同步任务）--------
 	taskManager := initTaskManager(repos, services)
+	// -------- 草稿 AI 处理队列 --------
+	draftJobWorker := task.NewDraftJobWorker(repos.DraftUow.Jobs, services.Draft)
+	draftJobWorker.SetConcurrency(getEnvInt("DRAFT_JOB_CONCURRENCY", 3))
 	// -------- Controller 层 --------
 	controllers := initControllers(services, taskManager)
 
@@ -163,9 +279,12 @@ func initDependencies(db *gorm.DB) *Dependencies {
 		DB:          db,
 		Repos:       repos,
 		Dispatcher:  dispatcher,
+		EtsyClient:  etsyClient,
 		Controllers: controllers,
 		Services:    services,
 		TaskManager: taskManager,
+
+		DraftJobWorker: draftJobWorker,
 	}
 }
 
@@ -187,25 +306,109 @@ func initRepositories(db *gorm.DB) *Repositories {
 		DraftTask:       repository.NewDraftTaskRepository(db),
 		DraftProduct:    repository.NewDraftProductRepository(db),
 		DraftImage:      repository.NewDraftImageRepository(db),
+		DraftUpload:     repository.NewDraftImageUploadRepository(db),
 		Order:           repository.NewOrderRepository(db),
 		OrderItem:       repository.NewOrderItemRepository(db),
 		Shipment:        repository.NewShipmentRepository(db),
 		TrackingEvent:   repository.NewTrackingEventRepository(db),
 		AiCallLog:       repository.NewAICallLogRepository(db),
+		AIBudget:        repository.NewAIBudgetRepository(db),
+		SyncState:       repository.NewSyncStateRepository(db),
+		Notification:    repository.NewNotificationRepository(db),
+		Encryption:      repository.NewEncryptionRepository(db),
+		Stock:           repository.NewStockRepository(db),
+		OAuthState:      repository.NewOAuthStateRepository(db),
+		DomainPool:      repository.NewDomainPoolRepository(db),
+	}
+}
+
+// initEncryption 加载字段加密密钥环（ENCRYPTION_KEY_FILE 或 ENCRYPTION_KEYS）
+func initEncryption() {
+	ring, err := utils.LoadKeyRing()
+	if err != nil {
+		log.Fatalf("加载加密密钥失败: %v\n"+
+			"请设置 ENCRYPTION_KEYS=\"1:<base64 32 字节>\"（可用 `openssl rand -base64 32` 生成，多个版本用逗号分隔，"+
+			"ENCRYPTION_PRIMARY_VERSION 指定主密钥，默认最大版本），或 ENCRYPTION_KEY_FILE 指向 JSON 密钥文件，详见 readme.md", err)
+	}
+	utils.SetKeyRing(ring)
+	log.Printf("加密密钥环已加载: 主密钥 v%d, 可用版本 %v", ring.PrimaryVersion(), ring.Versions())
+}
+
+// runReencrypt 使用当前主密钥重新加密存量数据
+func runReencrypt(deps *Dependencies) {
+	report, err := deps.Services.Encryption.Reencrypt(context.Background())
+	if err != nil {
+		log.Fatalf("重新加密失败: %v", err)
+	}
+	if report.Failed > 0 {
+		log.Fatalf("重新加密完成但有 %d 个字段失败，请检查旧版本密钥是否仍在密钥环中", report.Failed)
+	}
+}
+
+// runImportProxies 从文件（- 表示标准输入）批量导入代理
+func runImportProxies(deps *Dependencies, args []string) {
+	fs := flag.NewFlagSet("import-proxies", flag.ExitOnError)
+	file := fs.String("file", "-", "代理列表文件，每行一个，- 表示标准输入")
+	region := fs.String("region", "", "地区代码（必填），如 US")
+	capacity := fs.Int("capacity", model.PROXY_SHARED, "容量类型 0-独享 1-共享")
+	protocol := fs.String("protocol", "http", "行内未写协议时使用的协议")
+	proxyType := fs.String("type", model.ProxyTypeStatic, "代理类型 static-固定出口 gateway-轮换网关（用户名需含 {session}）")
+	tlsInsecure := fs.Bool("tls-insecure", false, "不校验目标站点证书，仅用于会做 TLS 中间人的代理")
+	check := fs.Bool("check", false, "入库前测试连通性，不通的不入库")
+	concurrency := fs.Int("concurrency", 10, "连通性测试并发数")
+	_ = fs.Parse(args)
+
+	var content []byte
+	var err error
+	if *file == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		log.Fatalf("读取代理列表失败: %v", err)
+	}
+
+	report, err := deps.Services.Proxy.ImportProxies(context.Background(), dto.ImportProxiesReq{
+		Content:     string(content),
+		Region:      *region,
+		Capacity:    capacity,
+		Protocol:    *protocol,
+		Type:        *proxyType,
+		TLSInsecure: *tlsInsecure,
+		Check:       *check,
+		Concurrency: *concurrency,
+	}, 0)
+	if err != nil {
+		log.Fatalf("导入失败: %v", err)
 	}
+	for _, r := range report.Results {
+		fmt.Printf("%5d  %-11s  %s:%s  %s\n", r.Line, r.Status, r.IP, r.Port, r.Error)
+	}
+	fmt.Printf("共 %d 行: 新增 %d, 重复 %d, 无效 %d, 不可达 %d\n",
+		report.Total, report.Created, report.Duplicate, report.Invalid, report.Unreachable)
 }
 
 // initStorageService 初始化存储服务
 func initStorageService() *service.StorageService {
+	provider := getEnv("STORAGE_PROVIDER", "s3")
+
+	// 本地存储：Endpoint 为对外访问前缀，BasePath 为磁盘目录
+	defaultEndpoint, defaultBasePath := "https://s3.amazonaws.com", "etsy-erp"
+	if provider == "local" {
+		defaultEndpoint, defaultBasePath = "http://localhost:8080/uploads", "./uploads"
+	}
+
 	storageSvc, err := service.NewStorageService(&service.StorageConfig{
-		Provider:  getEnv("STORAGE_PROVIDER", "s3"),
-		Endpoint:  getEnv("STORAGE_ENDPOINT", "https://s3.amazonaws.com"),
+		Provider:  provider,
+		Endpoint:  getEnv("STORAGE_ENDPOINT", defaultEndpoint),
 		Region:    getEnv("STORAGE_REGION", ""),
 		AccessKey: getEnv("STORAGE_ACCESS_KEY", ""),
 		SecretKey: getEnv("STORAGE_SECRET_KEY", ""),
 		Bucket:    getEnv("STORAGE_BUCKET", ""),
 		CDNDomain: getEnv("STORAGE_CDN_DOMAIN", ""),
-		BasePath:  getEnv("STORAGE_BASE_PATH", "etsy-erp"),
+		BasePath:  getEnv("STORAGE_BASE_PATH", defaultBasePath),
+		Private:   getEnv("STORAGE_PRIVATE", "false") == "true",
 	})
 	if err != nil {
 		log.Printf("警告: 存储服务初始化失败: %v", err)
@@ -214,6 +417,22 @@ func initStorageService() *service.StorageService {
 	return storageSvc
 }
 
+// loadAIModelPrices 读取 AI 模型价格覆盖配置
+// AI_MODEL_PRICES 示例: {"gemini-3-flash":{"input_per_million":0.5,"output_per_million":3}}
+func loadAIModelPrices() map[string]service.AIModelPrice {
+	raw := getEnv("AI_MODEL_PRICES", "")
+	if raw == "" {
+		return nil
+	}
+
+	var prices map[string]service.AIModelPrice
+	if err := json.Unmarshal([]byte(raw), &prices); err != nil {
+		log.Printf("警告: AI_MODEL_PRICES 解析失败，使用默认价格: %v", err)
+		return nil
+	}
+	return prices
+}
+
 // initKarrioClient 初始化 Karrio 客户端
 func initKarrioClient() *service.KarrioClient {
 	baseURL := getEnv("KARRIO_BASE_URL", "")
@@ -231,6 +450,24 @@ func initKarrioClient() *service.KarrioClient {
 	})
 }
 
+// initProxyGeo 配置代理出口检测：回显接口获取出口 IP，本地 mmdb 库查询国家与 ASN
+// GEOIP_COUNTRY_DB / GEOIP_ASN_DB 分别指向 GeoLite2-Country、GeoLite2-ASN（或 City / ISP 等合并库）
+func initProxyGeo(proxyService *service.ProxyService) {
+	proxyService.SetIPEchoURL(getEnv("PROXY_IP_ECHO_URL", service.DefaultProxyIPEchoURL))
+
+	countryDB, asnDB := getEnv("GEOIP_COUNTRY_DB", ""), getEnv("GEOIP_ASN_DB", "")
+	if countryDB == "" && asnDB == "" {
+		log.Println("警告: GEOIP_COUNTRY_DB 未配置，代理巡检只记录出口 IP，不校验国家")
+		return
+	}
+	locator, err := geoip.OpenLocator(countryDB, asnDB)
+	if err != nil {
+		log.Printf("警告: 加载 GeoIP 库失败，代理巡检不校验国家: %v", err)
+		return
+	}
+	proxyService.SetGeoLocator(locator)
+}
+
 // initControllers 初始化所有控制器
 func initControllers(svc *Services, taskManager *task.TaskManager) *router.Controllers {
 	return &router.Controllers{
@@ -247,6 +484,14 @@ func initControllers(svc *Services, taskManager *task.TaskManager) *router.Contr
 		Shipment:     controller.NewShipmentController(svc.Shipment),
 		Karrio:       controller.NewKarrioController(svc.Karrio),
 		Sync:         controller.NewSyncController(taskManager),
+		Storage:      controller.NewStorageController(svc.Storage),
+		AIUsage:      controller.NewAIUsageController(svc.AIUsage),
+		AIBudget:     controller.NewAIBudgetController(svc.AIBudget),
+		Notification: controller.NewNotificationController(svc.Notification),
+		ShopMember:   controller.NewShopMemberController(svc.ShopMember),
+		Encryption:   controller.NewEncryptionController(svc.Encryption),
+		Stock:        controller.NewStockController(svc.Stock),
+		Domain:       controller.NewDomainController(svc.Domain),
 	}
 }
 
@@ -266,6 +511,12 @@ func initTaskManager(repos *Repositories, services *Services) *task.TaskManager
 			ProductService:  services.Product,
 			OrderService:    services.Order,
 			ShipmentService: services.Shipment,
+
+			// 同步失败通知
+			Notifier: services.Notification,
+
+			// API 配额不足时推迟定时同步
+			QuotaChecker: services.Quota,
 		},
 		&task.TaskManagerConfig{
 			// Shop 同步
@@ -328,13 +579,27 @@ func startInfraTasks(deps *Dependencies) {
 		cleanupTask.Start()
 	}
 
+	// 5. 草稿提交任务（已确认草稿推送到 Etsy）
+	draftSubmitTask := task.NewDraftSubmitTask(
+		deps.Repos.DraftProduct,
+		deps.Repos.DraftTask,
+		deps.Repos.DraftImage,
+		deps.Repos.DraftUpload,
+		deps.Repos.Product,
+		deps.Repos.Shop,
+		deps.EtsyClient,
+		deps.Services.Notification,
+	)
+	draftSubmitTask.SetRetryPolicy(getEnvInt("DRAFT_SUBMIT_MAX_ATTEMPTS", 5), 0, 0)
+	draftSubmitTask.Start()
+
 	log.Println("[Tasks] 基础设施层任务已启动")
 }
 
 // ==================== 服务启动 ====================
 
 // startServer 启动服务
-func startServer(r *gin.Engine, taskManager *task.TaskManager) {
+func startServer(r *gin.Engine, deps *Dependencies) {
 	port := getEnv("SERVER_PORT", "8080")
 
 	srv := &http.Server{
@@ -358,8 +623,13 @@ func startServer(r *gin.Engine, taskManager *task.TaskManager) {
 	log.Println("正在关闭服务...")
 
 	// 停止业务同步任务
-	if taskManager != nil {
-		taskManager.Stop()
+	if deps.TaskManager != nil {
+		deps.TaskManager.Stop()
+	}
+
+	// 停止草稿队列（执行中的任务释放租约，由下次启动续跑）
+	if deps.DraftJobWorker != nil {
+		deps.DraftJobWorker.Stop()
 	}
 
 	// 优雅关闭 HTTP 服务，最多等待 30 秒
@@ -381,3 +651,10 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// ================== AI Budget DTO ==================
+
+// SetAIBudgetReq 设置预算请求（金额为 0 表示不限）
+type SetAIBudgetReq struct {
+	DailyLimitUSD   float64 `json:"daily_limit_usd" binding:"min=0"`
+	MonthlyLimitUSD float64 `json:"monthly_limit_usd" binding:"min=0"`
+	WarnPercents    []int   `json:"warn_percents"`
+	Enabled         *bool   `json:"enabled"` // 默认启用
+}
+
+// OverrideAIBudgetReq 管理员放行请求（until 为空表示取消放行）
+type OverrideAIBudgetReq struct {
+	Until  *time.Time `json:"until"`
+	Reason string     `json:"reason" binding:"max=255"`
+}
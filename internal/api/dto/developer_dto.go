@@ -48,8 +48,26 @@ type DeveloperResp struct {
 
 	// 防关联生成结果 (前端需要复制此链接到 Etsy)
 	CallbackURL string `json:"callback_url"`
+	// 回调连通性校验结果，校验通过后才能启用
+	CallbackVerifiedAt *time.Time `json:"callback_verified_at"`
+	CallbackCheckError string     `json:"callback_check_error"`
 
 	// 状态 (前端根据此字段显示 待配置/正常/封禁)
 	Status     int    `json:"status"`
 	StatusText string `json:"status_text"` // 可选：后端处理好文本直接给前端
+
+	// 最近一次自动封禁的时间与原因
+	BannedAt  *time.Time `json:"banned_at"`
+	BanReason string     `json:"ban_reason"`
+
+	// Etsy API 配额快照（同 Key 下所有店铺共享）
+	Quota DeveloperQuotaResp `json:"quota"`
+}
+
+// DeveloperQuotaResp 配额快照，RemainingToday 为 -1 表示尚未观测到
+type DeveloperQuotaResp struct {
+	LimitPerSecond int        `json:"limit_per_second"`
+	LimitPerDay    int        `json:"limit_per_day"`
+	RemainingToday int        `json:"remaining_today"`
+	UpdatedAt      *time.Time `json:"updated_at"`
 }
@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// ==================== 请求 DTO ====================
+
+// CreateDomainReq 新增回调域名（需已配置泛解析 *.host 指向反向代理）
+type CreateDomainReq struct {
+	Host          string `json:"host" binding:"required,fqdn"`
+	IsActive      *bool  `json:"is_active"`                                // 默认启用
+	MaxDevelopers *int   `json:"max_developers" binding:"omitempty,gte=0"` // 默认 10，0 表示不限
+	Remark        string `json:"remark" binding:"max=255"`
+}
+
+// UpdateDomainReq 更新回调域名（域名本身不可修改，已分配的回调地址依赖它）
+type UpdateDomainReq struct {
+	IsActive      *bool   `json:"is_active"`
+	MaxDevelopers *int    `json:"max_developers" binding:"omitempty,gte=0"`
+	Remark        *string `json:"remark" binding:"omitempty,max=255"`
+}
+
+// ==================== 响应 DTO ====================
+
+// DomainResp 回调域名
+type DomainResp struct {
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Host           string    `json:"host"`
+	IsActive       bool      `json:"is_active"`
+	MaxDevelopers  int       `json:"max_developers"`
+	DeveloperCount int64     `json:"developer_count"`
+	Remark         string    `json:"remark"`
+}
@@ -13,6 +13,11 @@ type CreateDraftRequest struct {
 	Quantity    int     `json:"quantity" binding:"required,min=1"` // 库存数量
 	StyleHint   string  `json:"style_hint"`
 	ExtraPrompt string  `json:"extra_prompt"`
+
+	// AI Provider / 模型（可选，覆盖店铺配置）
+	AIProvider   string `json:"ai_provider" binding:"omitempty,oneof=gemini openai fake"`
+	AITextModel  string `json:"ai_text_model" binding:"max=64"`
+	AIImageModel string `json:"ai_image_model" binding:"max=64"`
 }
 
 // UpdateDraftProductRequest 更新草稿商品请求
@@ -40,6 +45,18 @@ type ListDraftTasksRequest struct {
 	Status   string `form:"status"`
 	Page     int    `form:"page,default=1"`
 	PageSize int    `form:"page_size,default=20"`
+
+	ShopIDs []int64 `form:"-"` // 可见店铺范围（由权限决定），nil 表示不限制
+}
+
+// ListSubmitFailuresRequest 提交失败草稿查询请求
+type ListSubmitFailuresRequest struct {
+	ShopID     int64 `form:"shop_id"`
+	SyncStatus int   `form:"sync_status"` // 3=失败 4=死信，为空时两者都查
+	Page       int   `form:"page,default=1"`
+	PageSize   int   `form:"page_size,default=20"`
+
+	ShopIDs []int64 `form:"-"` // 可见店铺范围（由权限决定），nil 表示不限制
 }
 
 // ==================== 响应 DTO ====================
@@ -96,6 +113,21 @@ type DraftProductVO struct {
 	SelectedImages    []string `json:"selected_images"`
 	ListingID         int64    `json:"listing_id,omitempty"`
 	SyncError         string   `json:"sync_error,omitempty"`
+	SubmitAttempts    int      `json:"submit_attempts"`
+	NextSubmitAt      string   `json:"next_submit_at,omitempty"`
+
+	// 提交到 Etsy 后每张图片的上传情况
+	ImageUploads []DraftImageUploadVO `json:"image_uploads,omitempty"`
+}
+
+// DraftImageUploadVO 图片上传状态视图对象
+type DraftImageUploadVO struct {
+	Rank         int    `json:"rank"`
+	SourceURL    string `json:"source_url"`
+	Status       string `json:"status"` // pending, uploaded, failed
+	Attempts     int    `json:"attempts"`
+	EtsyImageID  int64  `json:"etsy_image_id,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
 }
 
 // ScrapedProductVO 抓取商品视图对象
@@ -139,8 +171,9 @@ type DraftImageVO struct {
 
 // ProgressEvent SSE进度事件
 type ProgressEvent struct {
+	EventID  int64       `json:"event_id,omitempty"` // 持久化事件ID（SSE id，用于断线续传）
 	TaskID   int64       `json:"task_id"`
-	Stage    string      `json:"stage"` // fetching, generating_text, generating_images, saving, done, failed
+	Stage    string      `json:"stage"` // queued, fetching, generating, saving, retrying, done, failed
 	Progress int         `json:"progress"`
 	Message  string      `json:"message"`
 	Data     interface{} `json:"data,omitempty"`
@@ -0,0 +1,41 @@
+package dto
+
+import "encoding/json"
+
+// ==================== 请求 DTO ====================
+
+// ListNotificationsRequest 通知列表查询请求
+type ListNotificationsRequest struct {
+	Event      string `form:"event"`
+	UnreadOnly bool   `form:"unread_only"`
+	Page       int    `form:"page,default=1"`
+	PageSize   int    `form:"page_size,default=20"`
+}
+
+// MarkNotificationsReadRequest 标记已读请求
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids" binding:"required,min=1,max=500"`
+}
+
+// ==================== 响应 DTO ====================
+
+// NotificationVO 通知视图对象
+type NotificationVO struct {
+	ID        int64           `json:"id"`
+	ShopID    int64           `json:"shop_id,omitempty"`
+	Event     string          `json:"event"`
+	Title     string          `json:"title"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Read      bool            `json:"read"`
+	ReadAt    string          `json:"read_at,omitempty"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	List     []NotificationVO `json:"list"`
+	Total    int64            `json:"total"`
+	Unread   int64            `json:"unread"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
@@ -126,7 +126,8 @@ type SyncOrdersRequest struct {
 	ShopID     int64  `json:"shop_id" binding:"required"`
 	MinCreated string `json:"min_created,omitempty"` // Unix timestamp
 	MaxCreated string `json:"max_created,omitempty"`
-	ForceSync  bool   `json:"force_sync,omitempty"` // 强制全量同步
+	ForceSync  bool   `json:"force_sync,omitempty"`  // 强制覆盖本地较新的订单
+	FullResync bool   `json:"full_resync,omitempty"` // 忽略检查点，全量重新拉取
 }
 
 // SyncOrdersResponse 同步订单响应
@@ -134,6 +135,7 @@ type SyncOrdersResponse struct {
 	TotalFetched  int      `json:"total_fetched"`
 	NewOrders     int      `json:"new_orders"`
 	UpdatedOrders int      `json:"updated_orders"`
+	Checkpoint    int64    `json:"checkpoint,omitempty"` // 同步后的 last_modified 高水位
 	Errors        []string `json:"errors,omitempty"`
 }
 
@@ -184,112 +186,3 @@ type OrderStatsResponse struct {
 	CanceledOrders   int64   `json:"canceled_orders"`
 	AvgOrderValue    float64 `json:"avg_order_value"`
 }
-
-// ==================== Etsy Receipt 映射 ====================
-
-// EtsyReceiptData Etsy 订单原始数据（用于同步解析）
-type EtsyReceiptData struct {
-	ReceiptID          int64                 `json:"receipt_id"`
-	ReceiptType        int                   `json:"receipt_type"`
-	SellerUserID       int64                 `json:"seller_user_id"`
-	SellerEmail        string                `json:"seller_email"`
-	BuyerUserID        int64                 `json:"buyer_user_id"`
-	BuyerEmail         string                `json:"buyer_email"`
-	Name               string                `json:"name"`
-	FirstLine          string                `json:"first_line"`
-	SecondLine         string                `json:"second_line"`
-	City               string                `json:"city"`
-	State              string                `json:"state"`
-	Zip                string                `json:"zip"`
-	Status             string                `json:"status"`
-	FormattedAddress   string                `json:"formatted_address"`
-	CountryISO         string                `json:"country_iso"`
-	PaymentMethod      string                `json:"payment_method"`
-	PaymentEmail       string                `json:"payment_email"`
-	MessageFromSeller  string                `json:"message_from_seller"`
-	MessageFromBuyer   string                `json:"message_from_buyer"`
-	MessageFromPayment string                `json:"message_from_payment"`
-	IsPaid             bool                  `json:"is_paid"`
-	IsShipped          bool                  `json:"is_shipped"`
-	CreateTimestamp    int64                 `json:"create_timestamp"`
-	CreatedTimestamp   int64                 `json:"created_timestamp"`
-	UpdateTimestamp    int64                 `json:"update_timestamp"`
-	UpdatedTimestamp   int64                 `json:"updated_timestamp"`
-	IsGift             bool                  `json:"is_gift"`
-	GiftMessage        string                `json:"gift_message"`
-	GrandTotal         EtsyMoney             `json:"grandtotal"`
-	Subtotal           EtsyMoney             `json:"subtotal"`
-	TotalPrice         EtsyMoney             `json:"total_price"`
-	TotalShippingCost  EtsyMoney             `json:"total_shipping_cost"`
-	TotalTaxCost       EtsyMoney             `json:"total_tax_cost"`
-	TotalVatCost       EtsyMoney             `json:"total_vat_cost"`
-	DiscountAmt        EtsyMoney             `json:"discount_amt"`
-	GiftWrapPrice      EtsyMoney             `json:"gift_wrap_price"`
-	Shipments          []EtsyShipmentData    `json:"shipments"`
-	Transactions       []EtsyTransactionData `json:"transactions"`
-}
-
-// EtsyMoney Etsy 金额
-type EtsyMoney struct {
-	Amount       int    `json:"amount"`
-	Divisor      int    `json:"divisor"`
-	CurrencyCode string `json:"currency_code"`
-}
-
-// ToFloat 转换为浮点数
-func (m EtsyMoney) ToFloat() float64 {
-	if m.Divisor == 0 {
-		return 0
-	}
-	return float64(m.Amount) / float64(m.Divisor)
-}
-
-// EtsyShipmentData Etsy 发货数据
-type EtsyShipmentData struct {
-	ReceiptShippingID             int64  `json:"receipt_shipping_id"`
-	ShipmentNotificationTimestamp int64  `json:"shipment_notification_timestamp"`
-	CarrierName                   string `json:"carrier_name"`
-	TrackingCode                  string `json:"tracking_code"`
-}
-
-// EtsyTransactionData Etsy 交易数据（订单项）
-type EtsyTransactionData struct {
-	TransactionID    int64             `json:"transaction_id"`
-	Title            string            `json:"title"`
-	Description      string            `json:"description"`
-	SellerUserID     int64             `json:"seller_user_id"`
-	BuyerUserID      int64             `json:"buyer_user_id"`
-	CreateTimestamp  int64             `json:"create_timestamp"`
-	CreatedTimestamp int64             `json:"created_timestamp"`
-	PaidTimestamp    int64             `json:"paid_timestamp"`
-	ShippedTimestamp int64             `json:"shipped_timestamp"`
-	Quantity         int               `json:"quantity"`
-	ListingImageID   int64             `json:"listing_image_id"`
-	ReceiptID        int64             `json:"receipt_id"`
-	IsDigital        bool              `json:"is_digital"`
-	FileData         string            `json:"file_data"`
-	ListingID        int64             `json:"listing_id"`
-	SKU              string            `json:"sku"`
-	ProductID        int64             `json:"product_id"`
-	TransactionType  string            `json:"transaction_type"`
-	Price            EtsyMoney         `json:"price"`
-	ShippingCost     EtsyMoney         `json:"shipping_cost"`
-	Variations       []EtsyVariation   `json:"variations"`
-	ProductData      []EtsyProductData `json:"product_data"`
-}
-
-// EtsyVariation Etsy 变体
-type EtsyVariation struct {
-	PropertyID     int64  `json:"property_id"`
-	ValueID        int64  `json:"value_id"`
-	FormattedName  string `json:"formatted_name"`
-	FormattedValue string `json:"formatted_value"`
-}
-
-// EtsyProductData Etsy 产品数据
-type EtsyProductData struct {
-	PropertyID   int64    `json:"property_id"`
-	PropertyName string   `json:"property_name"`
-	ValueIDs     []int64  `json:"value_ids"`
-	Values       []string `json:"values"`
-}
@@ -119,6 +119,42 @@ type ProductListReq struct {
 	PageSize int    `form:"page_size"` // 默认20
 }
 
+// UpdateProductInventoryReq 全量更新商品变体矩阵（覆盖 Etsy 库存）
+type UpdateProductInventoryReq struct {
+	PriceOnProperty    []int64             `json:"price_on_property"`    // 影响价格的属性ID
+	QuantityOnProperty []int64             `json:"quantity_on_property"` // 影响库存的属性ID
+	SkuOnProperty      []int64             `json:"sku_on_property"`      // 影响SKU的属性ID
+	Variants           []ProductVariantReq `json:"variants" binding:"required,min=1,dive"`
+}
+
+// ProductVariantReq 单个变体（属性值组合 + 报价）
+type ProductVariantReq struct {
+	PropertyValues []VariantPropertyReq `json:"property_values" binding:"max=2,dive"`
+	Price          float64              `json:"price" binding:"gt=0"`
+	Quantity       int                  `json:"quantity" binding:"gte=0,lte=999"`
+	SKU            string               `json:"sku" binding:"max=32"`
+	LocalSKU       string               `json:"local_sku" binding:"max=100"`
+	IsEnabled      *bool                `json:"is_enabled"` // 缺省为启用
+}
+
+// VariantPropertyReq 变体属性值
+type VariantPropertyReq struct {
+	PropertyID   int64   `json:"property_id" binding:"required"`
+	PropertyName string  `json:"property_name"` // 自定义属性(513/514)必填
+	ScaleID      *int64  `json:"scale_id"`
+	ValueIDs     []int64 `json:"value_ids"`
+	Value        string  `json:"value" binding:"required,max=45"`
+}
+
+// UpdateProductVariantReq 修改单个变体的价格 / 库存 / SKU / 启用状态
+type UpdateProductVariantReq struct {
+	Price     *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
+	Quantity  *int     `json:"quantity,omitempty" binding:"omitempty,gte=0,lte=999"`
+	SKU       *string  `json:"sku,omitempty" binding:"omitempty,max=32"`
+	LocalSKU  *string  `json:"local_sku,omitempty" binding:"omitempty,max=100"`
+	IsEnabled *bool    `json:"is_enabled,omitempty"`
+}
+
 // ==================== 响应 DTO ====================
 
 // ProductResp 商品详情响应
@@ -211,6 +247,27 @@ type ProductVariantResp struct {
 	LocalSKU       string                 `json:"local_sku"`
 	EtsySKU        string                 `json:"etsy_sku"`
 	IsEnabled      bool                   `json:"is_enabled"`
+	Properties     []VariantPropertyResp  `json:"properties,omitempty"`
+}
+
+// VariantPropertyResp 变体属性值（与 Etsy property_values 对应）
+type VariantPropertyResp struct {
+	PropertyID   int64   `json:"property_id"`
+	PropertyName string  `json:"property_name"`
+	ScaleID      *int64  `json:"scale_id,omitempty"`
+	ValueIDs     []int64 `json:"value_ids"`
+	Value        string  `json:"value"`
+}
+
+// ProductInventoryResp 商品变体矩阵响应
+type ProductInventoryResp struct {
+	ProductID          int64                `json:"product_id"`
+	ListingID          int64                `json:"listing_id"`
+	HasVariations      bool                 `json:"has_variations"`
+	PriceOnProperty    []int64              `json:"price_on_property"`
+	QuantityOnProperty []int64              `json:"quantity_on_property"`
+	SkuOnProperty      []int64              `json:"sku_on_property"`
+	Variants           []ProductVariantResp `json:"variants"`
 }
 
 // ProductStatsResp 商品统计响应
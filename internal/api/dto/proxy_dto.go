@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // Request DTO (前端传进来的数据)
 
 // CreateProxyReq 创建代理
@@ -9,8 +11,13 @@ type CreateProxyReq struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	// 协议限制校验
-	Protocol string `json:"protocol" binding:"omitempty,oneof=http https socks5"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http https socks5 socks5h"`
 	Region   string `json:"region" binding:"required"` // 如 "US"
+
+	// static-固定出口（默认）；gateway-轮换网关，Username 需包含 {session} 占位符
+	Type string `json:"type" binding:"omitempty,oneof=static gateway"`
+	// 不校验目标站点证书，仅用于会做 TLS 中间人的代理
+	TLSInsecure bool `json:"tls_insecure"`
 }
 
 // UpdateProxyReq 更新代理请求
@@ -20,9 +27,12 @@ type UpdateProxyReq struct {
 	Port     string `json:"port"`
 	Username string `json:"username"`
 	Password string `json:"password"`
-	Protocol string `json:"protocol" binding:"omitempty,oneof=http https socks5"`
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http https socks5 socks5h"`
 	Region   string `json:"region"`
 	Status   int    `json:"status" binding:"omitempty,oneof=1 2 3 4"`
+
+	Type        string `json:"type" binding:"omitempty,oneof=static gateway"`
+	TLSInsecure *bool  `json:"tls_insecure"` // 不传保持不变
 }
 
 // Response DTO (返回给前端的数据)
@@ -43,6 +53,15 @@ type ProxyResp struct {
 	IsActive      bool   `json:"is_active"`
 	CreatedAt     int64  `json:"created_at"` // 建议转为时间戳返回，前端格式化
 
+	// 代理类型与证书校验策略
+	Type        string `json:"type"`
+	TLSInsecure bool   `json:"tls_insecure"`
+
+	// 质量评分（巡检时根据最近一小时的巡检与业务请求计算）
+	Quality ProxyQualityResp `json:"quality"`
+	// 出口 IP 与地理位置
+	Exit ProxyExitResp `json:"exit"`
+
 	// --- 审计信息 ---
 	CreatedBy     int64  `json:"created_by"`
 	CreatedByName string `json:"created_by_name"` // 需要 Service 层填充名字
@@ -58,6 +77,45 @@ type ProxyResp struct {
 	BoundDevelopers []BoundDeveloperItem `json:"bound_developers,omitempty"`
 }
 
+// ProxyQualityResp 代理质量评分
+type ProxyQualityResp struct {
+	Score          float64    `json:"score"` // 0-100，分配代理时优先高分
+	SuccessRate    float64    `json:"success_rate"`
+	LatencyP50Ms   int        `json:"latency_p50_ms"`
+	LatencyP95Ms   int        `json:"latency_p95_ms"`
+	Recent403      int        `json:"recent_403"`
+	Recent429      int        `json:"recent_429"`
+	SampleCount    int        `json:"sample_count"`
+	ScoreUpdatedAt *time.Time `json:"score_updated_at"`
+}
+
+// ProxyExitResp 代理出口 IP 与地理位置
+type ProxyExitResp struct {
+	ExitIP      string     `json:"exit_ip"`
+	Country     string     `json:"country"` // ISO 3166-1 alpha-2
+	ASN         int64      `json:"asn"`
+	ASOrg       string     `json:"as_org"`
+	GeoMismatch bool       `json:"geo_mismatch"` // 出口国家与 Region 不符
+	CheckedAt   *time.Time `json:"checked_at"`
+}
+
+// SharedExitGroup 多个代理共用同一出口 IP，其下店铺在 Etsy 看来来自同一网络，可能被关联
+type SharedExitGroup struct {
+	ExitIP  string            `json:"exit_ip"`
+	Country string            `json:"country"`
+	ASN     int64             `json:"asn"`
+	Proxies []SharedExitProxy `json:"proxies"`
+	Shops   []BoundShopItem   `json:"shops"`
+}
+
+// SharedExitProxy 共用出口的代理
+type SharedExitProxy struct {
+	ID     int64  `json:"id"`
+	IP     string `json:"ip"`
+	Port   string `json:"port"`
+	Region string `json:"region"`
+}
+
 // BoundShopItem 代理下的店铺简要信息
 type BoundShopItem struct {
 	ShopID     int64  `json:"shop_id"`      // ERP 内部 ID
@@ -72,3 +130,63 @@ type BoundDeveloperItem struct {
 	Name   string `json:"name"`    // 开发者应用备注名
 	APIKey string `json:"api_key"` // KeyString
 }
+
+// ImportProxiesReq 批量导入代理
+// Content 每行一个代理，支持供应商常见格式：
+//
+//	ip:port
+//	ip:port:user:pass
+//	user:pass@ip:port
+//	socks5://user:pass@ip:port
+//
+// 空行与 # 开头的注释行忽略
+type ImportProxiesReq struct {
+	Content  string `json:"content" binding:"required"`
+	Region   string `json:"region" binding:"required"` // 整批统一地区，如 "US"
+	Capacity *int   `json:"capacity" binding:"omitempty,oneof=0 1"`
+	// 行内未写协议时使用，默认 http
+	Protocol string `json:"protocol" binding:"omitempty,oneof=http https socks5 socks5h"`
+	// 整批统一代理类型与证书校验策略，gateway 的用户名需包含 {session}
+	Type        string `json:"type" binding:"omitempty,oneof=static gateway"`
+	TLSInsecure bool   `json:"tls_insecure"`
+	// 入库前并发测试连通性，不通的不入库
+	Check       bool `json:"check"`
+	Concurrency int  `json:"concurrency" binding:"omitempty,min=1,max=100"`
+}
+
+// ImportProxyLineResult 单行导入结果
+type ImportProxyLineResult struct {
+	Line     int    `json:"line"` // 行号（从 1 开始）
+	IP       string `json:"ip,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Status   string `json:"status"` // created / duplicate / invalid / unreachable
+	ProxyID  int64  `json:"proxy_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ImportProxiesResp 批量导入报告
+type ImportProxiesResp struct {
+	Total       int                     `json:"total"`
+	Created     int                     `json:"created"`
+	Duplicate   int                     `json:"duplicate"`
+	Invalid     int                     `json:"invalid"`
+	Unreachable int                     `json:"unreachable"`
+	Results     []ImportProxyLineResult `json:"results"`
+}
+
+// ProxyBindingResp 店铺代理绑定历史
+type ProxyBindingResp struct {
+	ID           int64      `json:"id"`
+	ProxyID      int64      `json:"proxy_id"`
+	IP           string     `json:"ip"`
+	Port         string     `json:"port"`
+	Region       string     `json:"region"`
+	ExitIP       string     `json:"exit_ip"`
+	Session      string     `json:"session,omitempty"` // 网关代理的会话 ID
+	BoundAt      time.Time  `json:"bound_at"`
+	BindReason   string     `json:"bind_reason"`
+	UnboundAt    *time.Time `json:"unbound_at"`
+	UnbindReason string     `json:"unbind_reason"`
+	Active       bool       `json:"active"` // 当前绑定
+}
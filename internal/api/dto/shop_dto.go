@@ -12,6 +12,9 @@ type ShopListReq struct {
 	Status      int    `form:"status,default=-1"`
 	ProxyID     int64  `form:"proxy_id"`
 	DeveloperID int64  `form:"developer_id"`
+
+	// 可见店铺范围（由控制器按店铺权限填充，nil 表示不限制）
+	ShopIDs []int64 `form:"-"`
 }
 
 // ShopUpdateToEtsyReq 推送到 Etsy（仅 Etsy 可写字段）
@@ -22,6 +25,13 @@ type ShopUpdateToEtsyReq struct {
 	DigitalSaleMessage string `json:"digital_sale_message"`
 }
 
+// ShopAISettingsReq 店铺 AI 配置（空值表示使用系统默认）
+type ShopAISettingsReq struct {
+	AIProvider   string `json:"ai_provider" binding:"omitempty,oneof=gemini openai fake"`
+	AITextModel  string `json:"ai_text_model" binding:"max=64"`
+	AIImageModel string `json:"ai_image_model" binding:"max=64"`
+}
+
 // ShopStopReq 停用店铺请求（可选备注）
 type ShopStopReq struct {
 	Reason string `json:"reason"` // 停用原因（可选）
@@ -63,6 +73,11 @@ type ShopResp struct {
 	ProxyRegion   string `json:"proxy_region"`
 	DeveloperID   int64  `json:"developer_id"`
 	DeveloperName string `json:"developer_name"`
+
+	// AI 配置
+	AIProvider   string `json:"ai_provider"`
+	AITextModel  string `json:"ai_text_model"`
+	AIImageModel string `json:"ai_image_model"`
 }
 
 // ShopDetailResp 店铺详情响应（含关联数据）
@@ -326,3 +341,25 @@ type ReturnPolicyListResp struct {
 	Total int64              `json:"total"`
 	List  []ReturnPolicyResp `json:"list"`
 }
+
+// ================== Shop Member DTO ==================
+
+// AddShopMemberReq 添加店铺成员请求
+type AddShopMemberReq struct {
+	UserID int64  `json:"user_id" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=owner manager viewer"`
+}
+
+// UpdateShopMemberReq 修改店铺成员角色请求
+type UpdateShopMemberReq struct {
+	Role string `json:"role" binding:"required,oneof=owner manager viewer"`
+}
+
+// ShopMemberResp 店铺成员响应
+type ShopMemberResp struct {
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Nickname  string    `json:"nickname"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
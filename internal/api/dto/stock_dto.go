@@ -0,0 +1,43 @@
+package dto
+
+// ==================== 请求 DTO ====================
+
+// CreateStockItemReq 创建主库存
+type CreateStockItemReq struct {
+	LocalSKU string `json:"local_sku" binding:"required,max=100"`
+	Name     string `json:"name" binding:"max=255"`
+	OnHand   int    `json:"on_hand" binding:"gte=0"`
+}
+
+// AdjustStockReq 人工增减库存（正数入库，负数出库）
+type AdjustStockReq struct {
+	Delta int    `json:"delta" binding:"required"`
+	Note  string `json:"note" binding:"max=255"`
+}
+
+// CountStockReq 盘点：直接设置库存，version 为读取时的版本号
+type CountStockReq struct {
+	OnHand  *int   `json:"on_hand" binding:"required,gte=0"`
+	Version *int64 `json:"version" binding:"required"`
+	Note    string `json:"note" binding:"max=255"`
+}
+
+// ListStockReq 主库存列表查询
+type ListStockReq struct {
+	Keyword  string `form:"keyword"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// ==================== 响应 DTO ====================
+
+// StockLinkVO 主库存关联的商品 / 变体
+type StockLinkVO struct {
+	ProductID int64   `json:"product_id"`
+	ShopID    int64   `json:"shop_id"`
+	ListingID int64   `json:"listing_id"`
+	Title     string  `json:"title"`
+	State     string  `json:"state"`
+	VariantID []int64 `json:"variant_ids,omitempty"` // 为空表示整个商品关联
+	Quantity  int     `json:"quantity"`              // 本地记录的 listing 库存
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"errors"
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AIBudgetController AI 预算控制器
+type AIBudgetController struct {
+	budgetService *service.AIBudgetService
+}
+
+// NewAIBudgetController 创建 AI 预算控制器
+func NewAIBudgetController(budgetService *service.AIBudgetService) *AIBudgetController {
+	return &AIBudgetController{budgetService: budgetService}
+}
+
+// List 预算列表
+// @Summary AI 预算列表
+// @Description 非管理员只返回默认预算、所属店铺预算和本人预算
+// @Tags AI Budget
+// @Produce json
+// @Param scope query string false "范围 (shop/user)"
+// @Success 200 {object} map[string]interface{} "{"data": []model.AIBudget}"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /api/ai/budgets [get]
+func (h *AIBudgetController) List(c *gin.Context) {
+	shopIDs, err := middleware.ScopedShopIDs(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	budgets, err := h.budgetService.ListBudgets(c.Request.Context(), repository.AIBudgetFilter{
+		Scope:   c.Query("scope"),
+		ShopIDs: shopIDs,
+		UserID:  middleware.GetUserID(c),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": budgets})
+}
+
+// GetStatus 预算使用情况
+// @Summary AI 预算使用情况
+// @Description 返回生效预算（专属或默认）及今日/本月已花费；店铺预算需 viewer 权限，用户预算仅本人或管理员可查
+// @Tags AI Budget
+// @Produce json
+// @Param scope path string true "范围 (shop/user)"
+// @Param scope_id path int true "店铺ID/用户ID"
+// @Success 200 {object} map[string]interface{} "{"data": service.AIBudgetStatus}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 403 {object} map[string]string "无权限"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /api/ai/budgets/{scope}/{scope_id} [get]
+func (h *AIBudgetController) GetStatus(c *gin.Context) {
+	scope, scopeID, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+	if !authorizeBudgetScope(c, scope, scopeID) {
+		return
+	}
+
+	status, err := h.budgetService.GetStatus(c.Request.Context(), scope, scopeID)
+	if errors.Is(err, service.ErrInvalidBudgetScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// Set 设置预算
+// @Summary 设置 AI 预算（管理员）
+// @Description scope_id=0 为该范围的默认预算，金额为 0 表示不限
+// @Tags AI Budget
+// @Accept json
+// @Produce json
+// @Param scope path string true "范围 (shop/user)"
+// @Param scope_id path int true "店铺ID/用户ID"
+// @Param request body dto.SetAIBudgetReq true "预算配置"
+// @Success 200 {object} map[string]interface{} "{"data": model.AIBudget}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Router /api/ai/budgets/{scope}/{scope_id} [put]
+func (h *AIBudgetController) Set(c *gin.Context) {
+	scope, scopeID, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetAIBudgetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	budget, err := h.budgetService.SetBudget(c.Request.Context(), &model.AIBudget{
+		Scope:           scope,
+		ScopeID:         scopeID,
+		DailyLimitUSD:   req.DailyLimitUSD,
+		MonthlyLimitUSD: req.MonthlyLimitUSD,
+		WarnPercents:    req.WarnPercents,
+		Enabled:         enabled,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": budget})
+}
+
+// Delete 删除预算
+// @Summary 删除 AI 预算（管理员）
+// @Tags AI Budget
+// @Param scope path string true "范围 (shop/user)"
+// @Param scope_id path int true "店铺ID/用户ID"
+// @Success 200 {object} map[string]string "{"message": "success"}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Router /api/ai/budgets/{scope}/{scope_id} [delete]
+func (h *AIBudgetController) Delete(c *gin.Context) {
+	scope, scopeID, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.budgetService.DeleteBudget(c.Request.Context(), scope, scopeID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+// Override 临时放行
+// @Summary 临时放行 AI 预算（管理员）
+// @Description 放行截止前超出预算也不拦截；until 为空时取消放行。仅继承默认预算时按默认限额创建专属预算
+// @Tags AI Budget
+// @Accept json
+// @Produce json
+// @Param scope path string true "范围 (shop/user)"
+// @Param scope_id path int true "店铺ID/用户ID"
+// @Param request body dto.OverrideAIBudgetReq true "放行参数"
+// @Success 200 {object} map[string]interface{} "{"data": model.AIBudget}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Router /api/ai/budgets/{scope}/{scope_id}/override [post]
+func (h *AIBudgetController) Override(c *gin.Context) {
+	scope, scopeID, ok := parseBudgetScope(c)
+	if !ok {
+		return
+	}
+
+	var req dto.OverrideAIBudgetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var until time.Time
+	if req.Until != nil {
+		until = *req.Until
+	}
+
+	budget, err := h.budgetService.Override(c.Request.Context(), scope, scopeID, until, req.Reason, middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": budget})
+}
+
+// authorizeBudgetScope 店铺预算需 viewer 权限，用户预算仅本人或管理员可查（默认预算所有人可查）
+// 失败时已写入响应并中止
+func authorizeBudgetScope(c *gin.Context, scope string, scopeID int64) bool {
+	if scopeID == 0 || middleware.IsAdmin(c) {
+		return true
+	}
+	if scope == model.AIBudgetScopeShop {
+		return middleware.AuthorizeShop(c, scopeID, model.ShopMemberRoleViewer)
+	}
+	if scopeID != middleware.GetUserID(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "只能查看本人的预算"})
+		return false
+	}
+	return true
+}
+
+// parseBudgetScope 解析路径中的 scope / scope_id
+func parseBudgetScope(c *gin.Context) (string, int64, bool) {
+	scope := c.Param("scope")
+	if scope != model.AIBudgetScopeShop && scope != model.AIBudgetScopeUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的预算范围"})
+		return "", 0, false
+	}
+
+	scopeID, err := strconv.ParseInt(c.Param("scope_id"), 10, 64)
+	if err != nil || scopeID < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return "", 0, false
+	}
+
+	return scope, scopeID, true
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"errors"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AIUsageController AI 用量控制器
+type AIUsageController struct {
+	usageService *service.AIUsageService
+}
+
+// NewAIUsageController 创建 AI 用量控制器
+func NewAIUsageController(usageService *service.AIUsageService) *AIUsageController {
+	return &AIUsageController{usageService: usageService}
+}
+
+// ShopUsage 店铺 AI 用量
+// @Summary 店铺 AI 用量统计
+// @Description 按店铺汇总 AI 调用次数、token、图片数与成本
+// @Tags AI Usage
+// @Produce json
+// @Param id path int true "店铺ID"
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "{"data": repository.AIUsageStats}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /api/ai/usage/shops/{id} [get]
+func (h *AIUsageController) ShopUsage(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的店铺ID"})
+		return
+	}
+
+	startTime, endTime, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.usageService.GetShopUsage(c.Request.Context(), shopID, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// TaskUsage 草稿任务 AI 用量
+// @Summary 草稿任务 AI 用量统计
+// @Tags AI Usage
+// @Produce json
+// @Param id path int true "草稿任务ID"
+// @Success 200 {object} map[string]interface{} "{"data": repository.AIUsageStats}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /api/ai/usage/tasks/{id} [get]
+func (h *AIUsageController) TaskUsage(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	stats, err := h.usageService.GetTaskUsage(c.Request.Context(), taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
+
+// DailyUsage 每日 AI 用量
+// @Summary 每日 AI 用量统计
+// @Description 默认最近 30 天，同时返回区间总成本
+// @Tags AI Usage
+// @Produce json
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "{"data": []repository.DailyUsageStats, "total_cost_usd": 0}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /api/ai/usage/daily [get]
+func (h *AIUsageController) DailyUsage(c *gin.Context) {
+	startTime, endTime, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if startTime.IsZero() {
+		startTime = endTime.AddDate(0, 0, -30)
+	}
+
+	// 非管理员只统计所属店铺
+	shopIDs, err := middleware.ScopedShopIDs(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	stats, err := h.usageService.GetDailyUsage(ctx, startTime, endTime, shopIDs)
+	if errors.Is(err, service.ErrInvalidDateRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	totalCost, err := h.usageService.GetTotalCost(ctx, startTime, endTime, shopIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":           stats,
+		"total_cost_usd": totalCost,
+	})
+}
+
+// parseDateRange 解析 start_date / end_date（YYYY-MM-DD），end_date 包含当天
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	var startTime, endTime time.Time
+
+	if v := c.Query("start_date"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return startTime, endTime, err
+		}
+		startTime = t
+	}
+	if v := c.Query("end_date"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return startTime, endTime, err
+		}
+		endTime = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return startTime, endTime, nil
+}
@@ -1,6 +1,9 @@
 package controller
 
 import (
+	"errors"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/service"
 	"log"
 	"net/http"
@@ -48,7 +51,7 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 	}
 
 	// 2. 调用 Service
-	url, err := ctrl.authService.GenerateLoginURL(c.Request.Context(), shopID, region)
+	url, err := ctrl.authService.GenerateLoginURL(c.Request.Context(), shopID, region, middleware.GetUserID(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":  "生成失败",
@@ -92,6 +95,57 @@ func (ctrl *AuthController) Callback(c *gin.Context) {
 
 	// 调用业务层换 Token
 	shop, err := ctrl.authService.HandleCallback(c.Request.Context(), code, state)
+	ctrl.respondCallback(c, shop, err)
+}
+
+// DeveloperCallback
+// @Summary Etsy 授权回调（开发者专属地址）
+// @Description 每个开发者在 Etsy 后台填写独立的回调地址，按 callback_path 找到开发者并校验 state 由其发起
+// @Tags Auth (授权模块)
+// @Produce json
+// @Param path path string true "开发者回调路径 (Developer.CallbackPath)"
+// @Param code query string true "授权码"
+// @Param state query string true "安全校验码"
+// @Param verify query string false "回调连通性校验，原样返回"
+// @Success 200 {object} map[string]interface{} "授权成功信息"
+// @Failure 400 {object} map[string]string "拒绝授权/参数错误"
+// @Failure 404 {object} map[string]string "回调地址无效"
+// @Router /{path}/oauth/callback [get]
+func (ctrl *AuthController) DeveloperCallback(c *gin.Context) {
+	// 开发者回调校验：确认反向代理已路由到本服务
+	if verify := c.Query("verify"); verify != "" {
+		exists, err := ctrl.authService.CallbackPathExists(c.Request.Context(), c.Param("path"))
+		if err != nil || !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "回调地址无效"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"verify": verify})
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	errParam := c.Query("error")
+
+	if errParam != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户拒绝了授权", "etsy_msg": errParam})
+		return
+	}
+
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少必要参数 code 或 state"})
+		return
+	}
+
+	shop, err := ctrl.authService.HandleDeveloperCallback(c.Request.Context(), c.Param("path"), code, state)
+	if errors.Is(err, service.ErrOAuthCallbackMismatch) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "回调地址无效"})
+		return
+	}
+	ctrl.respondCallback(c, shop, err)
+}
+
+func (ctrl *AuthController) respondCallback(c *gin.Context, shop *model.Shop, err error) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":  "授权失败",
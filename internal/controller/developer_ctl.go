@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/internal/service"
@@ -161,6 +162,7 @@ func (d *DeveloperController) Update(c *gin.Context) {
 // @Param request body dto.UpdateDevStatusReq true "状态参数"
 // @Success 200 {object} map[string]string "message: success"
 // @Failure 400 {object} map[string]string "参数错误"
+// @Failure 409 {object} map[string]string "回调未校验，不能启用"
 // @Failure 500 {object} map[string]string "更新失败"
 // @Router /api/developers/{id}/status [patch]
 func (d *DeveloperController) UpdateStatus(c *gin.Context) {
@@ -176,7 +178,12 @@ func (d *DeveloperController) UpdateStatus(c *gin.Context) {
 		return
 	}
 
-	if err := d.developerService.UpdateStatus(c.Request.Context(), id, req.Status); err != nil {
+	err = d.developerService.UpdateStatus(c.Request.Context(), id, req.Status)
+	if errors.Is(err, service.ErrCallbackNotVerified) {
+		c.JSON(http.StatusConflict, gin.H{"error": "回调地址尚未通过校验，请先执行回调校验"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -245,3 +252,59 @@ func (d *DeveloperController) TestConnectivity(c *gin.Context) {
 		"message":    "API Key 验证成功",
 	})
 }
+
+// VerifyCallback 校验回调地址
+// @Summary 校验回调地址
+// @Description 访问开发者回调地址，确认反向代理已将其路由到本服务；未配置的开发者校验通过后自动启用
+// @Tags Developer (开发者账号)
+// @Produce json
+// @Param id path int true "开发者账号 ID"
+// @Success 200 {object} map[string]interface{} "data: DeveloperResp"
+// @Failure 400 {object} map[string]string "ID 格式错误"
+// @Failure 422 {object} map[string]string "回调不可达"
+// @Router /api/developers/{id}/callback/verify [post]
+func (d *DeveloperController) VerifyCallback(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	data, err := d.developerService.VerifyCallback(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RegenerateCallback 重新分配回调地址
+// @Summary 重新分配回调地址
+// @Description 重新分配域名、子域名与回调路径，需重新填写到 Etsy 后台并校验
+// @Tags Developer (开发者账号)
+// @Produce json
+// @Param id path int true "开发者账号 ID"
+// @Success 200 {object} map[string]interface{} "callback_url + 管理页地址"
+// @Failure 400 {object} map[string]string "ID 格式错误"
+// @Failure 500 {object} map[string]string "分配失败"
+// @Router /api/developers/{id}/callback/regenerate [post]
+func (d *DeveloperController) RegenerateCallback(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	url, err := d.developerService.RegenerateCallback(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "success",
+		"callback_url": url,
+		"manager_url":  AppManageURL,
+	})
+}
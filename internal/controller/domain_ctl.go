@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultProxyUpstream 导出反向代理配置时默认的本服务地址
+const DefaultProxyUpstream = "http://127.0.0.1:8080"
+
+// DomainController 回调域名池控制器
+type DomainController struct {
+	domainService *service.DomainService
+}
+
+// NewDomainController 创建回调域名池控制器
+func NewDomainController(domainService *service.DomainService) *DomainController {
+	return &DomainController{domainService: domainService}
+}
+
+// List 域名列表
+// @Summary 回调域名列表
+// @Tags Domain (回调域名池)
+// @Produce json
+// @Success 200 {object} map[string]interface{} "data: []dto.DomainResp"
+// @Router /api/domains [get]
+func (h *DomainController) List(c *gin.Context) {
+	list, err := h.domainService.ListDomains(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// Create 新增域名
+// @Summary 新增回调域名
+// @Description 域名需已配置泛解析 *.host 指向反向代理
+// @Tags Domain (回调域名池)
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateDomainReq true "域名"
+// @Success 201 {object} map[string]interface{} "data: dto.DomainResp"
+// @Failure 400 {object} map[string]string "参数错误 / 域名已存在"
+// @Router /api/domains [post]
+func (h *DomainController) Create(c *gin.Context) {
+	var req dto.CreateDomainReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.domainService.CreateDomain(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": data})
+}
+
+// Update 更新域名
+// @Summary 更新回调域名（启用状态 / 开发者上限 / 备注）
+// @Tags Domain (回调域名池)
+// @Accept json
+// @Produce json
+// @Param id path int true "域名ID"
+// @Param request body dto.UpdateDomainReq true "更新参数"
+// @Success 200 {object} map[string]interface{} "data: dto.DomainResp"
+// @Failure 404 {object} map[string]string "不存在"
+// @Router /api/domains/{id} [patch]
+func (h *DomainController) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req dto.UpdateDomainReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.domainService.UpdateDomain(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Delete 删除域名
+// @Summary 删除回调域名（仍有开发者使用时拒绝）
+// @Tags Domain (回调域名池)
+// @Produce json
+// @Param id path int true "域名ID"
+// @Success 200 {object} map[string]string "message: success"
+// @Failure 409 {object} map[string]string "仍有开发者使用"
+// @Router /api/domains/{id} [delete]
+func (h *DomainController) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.domainService.DeleteDomain(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+// ExportProxyConfig 导出反向代理配置
+// @Summary 导出域名下开发者回调的反向代理配置
+// @Tags Domain (回调域名池)
+// @Produce plain
+// @Param id path int true "域名ID"
+// @Param format query string false "nginx / caddy" default(nginx)
+// @Param upstream query string false "本服务地址" default(http://127.0.0.1:8080)
+// @Success 200 {string} string "配置文本"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Router /api/domains/{id}/proxy-config [get]
+func (h *DomainController) ExportProxyConfig(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	format := c.DefaultQuery("format", service.ProxyConfigNginx)
+	config, err := h.domainService.ExportProxyConfig(c.Request.Context(), id, format, c.DefaultQuery("upstream", DefaultProxyUpstream))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.String(http.StatusOK, config)
+}
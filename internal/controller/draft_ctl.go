@@ -2,13 +2,17 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
 	"net/http"
 	"strconv"
 	"time"
 
 	"etsy_dev_v1_202512/internal/service"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 )
 
@@ -43,13 +47,26 @@ func (ctrl *DraftController) CreateDraft(c *gin.Context) {
 		return
 	}
 
-	// TODO: 从JWT中获取UserID
+	// 以登录用户为准（预算按用户统计）
+	if userID := middleware.GetUserID(c); userID > 0 {
+		req.UserID = userID
+	}
 	if req.UserID == 0 {
 		req.UserID = 1 // 临时默认值
 	}
+	if !middleware.AuthorizeShops(c, req.ShopIDs, model.ShopMemberRoleManager) {
+		return
+	}
 
 	ctx := c.Request.Context()
 	result, err := ctrl.draftService.CreateDraft(ctx, &req)
+	if errors.Is(err, service.ErrAIBudgetExhausted) {
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"code":    402,
+			"message": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500,
@@ -174,6 +191,115 @@ func (ctrl *DraftController) ConfirmDraftProduct(c *gin.Context) {
 	})
 }
 
+// ListSubmitFailures 提交失败的草稿列表
+// @Summary 查询提交失败 / 死信的草稿商品
+// @Tags Draft
+// @Param shop_id query int false "店铺ID"
+// @Param sync_status query int false "3=失败(不可重试错误) 4=死信(超过重试次数)，为空时两者都查"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/drafts/submissions/failed [get]
+func (ctrl *DraftController) ListSubmitFailures(c *gin.Context) {
+	var req dto.ListSubmitFailuresRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 非管理员只能看到所属店铺的草稿
+	shopIDs, err := middleware.ScopedShopIDs(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+	req.ShopIDs = shopIDs
+
+	products, total, err := ctrl.draftService.ListSubmitFailures(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":     0,
+		"message":  "success",
+		"data":     products,
+		"total":    total,
+		"page":     req.Page,
+		"pageSize": req.PageSize,
+	})
+}
+
+// RetrySubmit 重试提交
+// @Summary 将提交失败 / 死信 / 已放弃的草稿重新加入提交队列
+// @Tags Draft
+// @Param product_id path int true "草稿商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/drafts/products/{product_id}/retry-submit [post]
+func (ctrl *DraftController) RetrySubmit(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的商品ID",
+		})
+		return
+	}
+
+	if err := ctrl.draftService.RetrySubmit(c.Request.Context(), productID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "已重新加入提交队列",
+	})
+}
+
+// AbandonSubmit 放弃提交
+// @Summary 放弃提交失败的草稿（不再自动重试）
+// @Tags Draft
+// @Param product_id path int true "草稿商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/drafts/products/{product_id}/abandon-submit [post]
+func (ctrl *DraftController) AbandonSubmit(c *gin.Context) {
+	productID, err := strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil || productID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": "无效的商品ID",
+		})
+		return
+	}
+
+	if err := ctrl.draftService.AbandonSubmit(c.Request.Context(), productID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "已放弃提交",
+	})
+}
+
 // ConfirmAllDrafts 确认任务下所有草稿
 // @Summary 确认任务下所有草稿商品
 // @Tags Draft
@@ -265,21 +391,62 @@ func (ctrl *DraftController) StreamProgress(c *gin.Context) {
 		return
 	}
 
+	// 断线重连时浏览器会带上 Last-Event-ID，从该事件之后继续推送
+	lastEventID, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+	if lastEventID == 0 {
+		lastEventID, _ = strconv.ParseInt(c.Query("last_event_id"), 10, 64)
+	}
+
 	// 设置 SSE 响应头
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 
-	// 订阅进度
+	// 先订阅再重放，避免两者之间的事件丢失（重复事件按 EventID 去重）
 	progressCh := ctrl.draftService.Subscribe(taskID)
 	defer ctrl.draftService.Unsubscribe(taskID, progressCh)
 
+	ctx := c.Request.Context()
+
+	// send 推送事件，返回任务是否已结束
+	send := func(event dto.ProgressEvent) bool {
+		if event.EventID != 0 {
+			if event.EventID <= lastEventID {
+				return false
+			}
+			lastEventID = event.EventID
+		}
+		writeProgressEvent(c, event)
+		return event.Stage == "done" || event.Stage == "failed"
+	}
+
+	// replay 推送已持久化事件（也用于接收其他实例上 Worker 产生的进度）
+	replay := func() bool {
+		events, err := ctrl.draftService.ReplayProgress(ctx, taskID, lastEventID)
+		if err != nil {
+			return false
+		}
+		for _, event := range events {
+			if send(event) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if replay() {
+		return
+	}
+
 	// 发送心跳和进度
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	clientGone := c.Request.Context().Done()
+	pollTicker := time.NewTicker(3 * time.Second)
+	defer pollTicker.Stop()
+
+	clientGone := ctx.Done()
 
 	for {
 		select {
@@ -289,22 +456,33 @@ func (ctrl *DraftController) StreamProgress(c *gin.Context) {
 			// 心跳
 			c.SSEvent("heartbeat", gin.H{"time": time.Now().Unix()})
 			c.Writer.Flush()
+		case <-pollTicker.C:
+			if replay() {
+				return
+			}
 		case event, ok := <-progressCh:
 			if !ok {
 				return
 			}
-			data, _ := json.Marshal(event)
-			c.SSEvent("progress", string(data))
-			c.Writer.Flush()
-
 			// 如果任务完成或失败，关闭连接
-			if event.Stage == "done" || event.Stage == "failed" {
+			if send(event) {
 				return
 			}
 		}
 	}
 }
 
+// writeProgressEvent 写出带 id 的 SSE 进度事件
+func writeProgressEvent(c *gin.Context, event dto.ProgressEvent) {
+	data, _ := json.Marshal(event)
+	sseEvent := sse.Event{Event: "progress", Data: string(data)}
+	if event.EventID != 0 {
+		sseEvent.Id = strconv.FormatInt(event.EventID, 10)
+	}
+	c.Render(-1, sseEvent)
+	c.Writer.Flush()
+}
+
 // GetSupportedPlatforms 获取支持的平台列表
 // @Summary 获取支持的来源平台
 // @Tags Draft
@@ -333,15 +511,25 @@ func (ctrl *DraftController) ListDraftTasks(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	status := c.Query("status")
 
-	// TODO: 从JWT获取UserID
-	userID := int64(1)
+	// 非管理员只能看到自己创建的或所属店铺的任务
+	shopIDs, err := middleware.ScopedShopIDs(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
 
 	ctx := c.Request.Context()
 	req := &dto.ListDraftTasksRequest{
-		UserID:   userID,
 		Status:   status,
 		Page:     page,
 		PageSize: pageSize,
+		ShopIDs:  shopIDs,
+	}
+	if shopIDs != nil {
+		req.UserID = middleware.GetUserID(c)
 	}
 
 	tasks, total, err := ctrl.draftService.ListTasks(ctx, req)
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"etsy_dev_v1_202512/internal/service"
+)
+
+// EncryptionController 字段加密维护控制器（仅管理员）
+type EncryptionController struct {
+	encryptionService *service.EncryptionService
+}
+
+// NewEncryptionController 创建加密维护控制器
+func NewEncryptionController(encryptionService *service.EncryptionService) *EncryptionController {
+	return &EncryptionController{encryptionService: encryptionService}
+}
+
+// Status 加密状态
+// @Summary 加密密钥与待迁移数据统计
+// @Description 返回主密钥版本、可用密钥版本，以及各加密列中仍为明文或旧版本密文的行数
+// @Tags Encryption
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"data": service.EncryptionStatus}"
+// @Failure 500 {object} map[string]string "服务器错误"
+// @Router /api/admin/encryption/status [get]
+func (h *EncryptionController) Status(c *gin.Context) {
+	status, err := h.encryptionService.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// Reencrypt 重新加密存量数据
+// @Summary 使用当前主密钥重新加密存量数据
+// @Description 切换主密钥版本后调用；旧版本密钥需保留在密钥环中直至执行完成
+// @Tags Encryption
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"data": service.ReencryptReport}"
+// @Failure 500 {object} map[string]interface{} "执行失败（含已完成部分的统计）"
+// @Router /api/admin/encryption/reencrypt [post]
+func (h *EncryptionController) Reencrypt(c *gin.Context) {
+	report, err := h.encryptionService.Reencrypt(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
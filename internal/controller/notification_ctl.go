@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/service"
+)
+
+// NotificationController 站内通知控制器
+type NotificationController struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationController 创建通知控制器
+func NewNotificationController(notificationService *service.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: notificationService}
+}
+
+// List 通知列表
+// @Summary 当前用户的通知列表
+// @Tags Notification
+// @Produce json
+// @Param event query string false "事件类型"
+// @Param unread_only query bool false "仅未读"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{} "{"data": dto.NotificationListResponse}"
+// @Router /api/notifications [get]
+func (h *NotificationController) List(c *gin.Context) {
+	var req dto.ListNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.notificationService.List(c.Request.Context(), middleware.GetUserID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// UnreadCount 未读数量
+// @Summary 当前用户的未读通知数量
+// @Tags Notification
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"data": {"unread": 0}}"
+// @Router /api/notifications/unread-count [get]
+func (h *NotificationController) UnreadCount(c *gin.Context) {
+	count, err := h.notificationService.UnreadCount(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"unread": count}})
+}
+
+// MarkRead 标记已读
+// @Summary 将指定通知标记为已读
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Param request body dto.MarkNotificationsReadRequest true "通知ID列表"
+// @Success 200 {object} map[string]interface{} "{"data": {"updated": 0}}"
+// @Router /api/notifications/read [post]
+func (h *NotificationController) MarkRead(c *gin.Context) {
+	var req dto.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.notificationService.MarkRead(c.Request.Context(), middleware.GetUserID(c), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"updated": updated}})
+}
+
+// MarkAllRead 全部已读
+// @Summary 将当前用户的全部通知标记为已读
+// @Tags Notification
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"data": {"updated": 0}}"
+// @Router /api/notifications/read-all [post]
+func (h *NotificationController) MarkAllRead(c *gin.Context) {
+	updated, err := h.notificationService.MarkAllRead(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"updated": updated}})
+}
+
+// StreamTicket 获取 SSE 连接票据
+// @Summary 获取一次性 SSE 连接票据
+// @Description EventSource 无法设置请求头，先用 Access Token 换取短期票据，再以 ticket 参数连接 /api/notifications/stream
+// @Tags Notification
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"data": {"ticket": "", "expires_in": 30}}"
+// @Router /api/notifications/stream-ticket [post]
+func (h *NotificationController) StreamTicket(c *gin.Context) {
+	ticket, err := middleware.GenerateStreamTicket(middleware.GetUserID(c), middleware.GetUsername(c), middleware.GetUserRole(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"ticket":     ticket,
+		"expires_in": int(middleware.StreamTicketTTL.Seconds()),
+	}})
+}
+
+// Stream SSE 实时推送通知
+// @Summary SSE 实时推送当前用户的通知
+// @Description 通过 ticket 参数（POST /api/notifications/stream-ticket 获取，一次性）认证；
+// @Description 断线重连时需重新获取票据，并通过 Last-Event-ID（或 last_event_id 参数）补发遗漏的通知
+// @Tags Notification
+// @Produce text/event-stream
+// @Param ticket query string true "SSE 连接票据"
+// @Router /api/notifications/stream [get]
+func (h *NotificationController) Stream(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	lastEventID, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+	if lastEventID == 0 {
+		lastEventID, _ = strconv.ParseInt(c.Query("last_event_id"), 10, 64)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	// 先订阅再重放，避免两者之间的通知丢失（重复通知按 ID 去重）
+	notifyCh := h.notificationService.Subscribe(userID)
+	defer h.notificationService.Unsubscribe(userID, notifyCh)
+
+	ctx := c.Request.Context()
+
+	send := func(n dto.NotificationVO) {
+		if n.ID <= lastEventID {
+			return
+		}
+		lastEventID = n.ID
+		writeNotificationEvent(c, n)
+	}
+
+	// replay 推送已落库通知（也用于接收其他实例产生的通知）
+	replay := func() {
+		notifications, err := h.notificationService.Replay(ctx, userID, lastEventID)
+		if err != nil {
+			return
+		}
+		for _, n := range notifications {
+			send(n)
+		}
+	}
+
+	// 首次连接（无 Last-Event-ID）只推送新通知，历史通知走列表接口
+	if lastEventID > 0 {
+		replay()
+	} else {
+		lastEventID, _ = h.notificationService.LatestID(ctx, userID)
+		c.SSEvent("ready", gin.H{"last_event_id": lastEventID})
+		c.Writer.Flush()
+	}
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	pollTicker := time.NewTicker(5 * time.Second)
+	defer pollTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.SSEvent("heartbeat", gin.H{"time": time.Now().Unix()})
+			c.Writer.Flush()
+		case <-pollTicker.C:
+			replay()
+		case n, ok := <-notifyCh:
+			if !ok {
+				return
+			}
+			send(n)
+		}
+	}
+}
+
+// writeNotificationEvent 写出带 id 的 SSE 通知事件
+func writeNotificationEvent(c *gin.Context, n dto.NotificationVO) {
+	data, _ := json.Marshal(n)
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(n.ID, 10),
+		Event: "notification",
+		Data:  string(data),
+	})
+	c.Writer.Flush()
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/internal/service"
 )
@@ -154,6 +156,9 @@ func (c *OrderController) SyncOrders(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !middleware.AuthorizeShop(ctx, req.ShopID, model.ShopMemberRoleManager) {
+		return
+	}
 
 	result, err := c.svc.SyncOrders(ctx, &req)
 	if err != nil {
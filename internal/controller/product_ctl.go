@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/service"
 	"strconv"
@@ -143,9 +145,16 @@ func (ctrl *ProductController) GenerateAIDraft(c *gin.Context) {
 		c.JSON(400, gin.H{"code": 400, "message": "参数错误: " + err.Error()})
 		return
 	}
+	if !middleware.AuthorizeShop(c, req.ShopID, model.ShopMemberRoleManager) {
+		return
+	}
 
 	ctx := c.Request.Context()
 	product, err := ctrl.productService.GenerateAIDraft(ctx, &req)
+	if errors.Is(err, service.ErrAIBudgetExhausted) {
+		c.JSON(402, gin.H{"code": 402, "message": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(500, gin.H{"code": 500, "message": "生成失败: " + err.Error()})
 		return
@@ -197,6 +206,9 @@ func (ctrl *ProductController) CreateProduct(c *gin.Context) {
 		c.JSON(400, gin.H{"code": 400, "message": "参数错误: " + err.Error()})
 		return
 	}
+	if !middleware.AuthorizeShop(c, req.ShopID, model.ShopMemberRoleManager) {
+		return
+	}
 
 	ctx := c.Request.Context()
 	product, err := ctrl.productService.CreateDraftListing(ctx, &req)
@@ -398,3 +410,140 @@ func (ctrl *ProductController) UploadImage(c *gin.Context) {
 		},
 	})
 }
+
+// ==================== 库存 / 变体接口 ====================
+
+// GetInventory 获取商品变体矩阵
+// @Summary 获取商品本地变体矩阵（价格 / 库存 / SKU）
+// @Tags Product
+// @Param id path int true "商品ID"
+// @Success 200 {object} dto.ProductInventoryResp
+// @Router /api/products/{id}/inventory [get]
+func (ctrl *ProductController) GetInventory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(400, gin.H{"code": 400, "message": "无效的商品ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	product, err := ctrl.productService.GetProductByID(ctx, id)
+	if err != nil {
+		c.JSON(404, gin.H{"code": 404, "message": "商品不存在"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    ctrl.productService.ToProductInventoryResp(product),
+	})
+}
+
+// UpdateInventory 全量更新商品变体矩阵
+// @Summary 校验并覆盖 Etsy 库存（未提交的变体会被删除）
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param body body dto.UpdateProductInventoryReq true "变体矩阵"
+// @Success 200 {object} dto.ProductInventoryResp
+// @Router /api/products/{id}/inventory [put]
+func (ctrl *ProductController) UpdateInventory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(400, gin.H{"code": 400, "message": "无效的商品ID"})
+		return
+	}
+
+	var req dto.UpdateProductInventoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"code": 400, "message": "参数错误: " + err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	product, err := ctrl.productService.UpdateProductInventory(ctx, id, &req)
+	ctrl.respondInventory(c, product, err)
+}
+
+// UpdateVariant 修改单个变体
+// @Summary 修改单个变体的价格 / 库存 / SKU / 启用状态并推送 Etsy
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param variant_id path int true "变体ID"
+// @Param body body dto.UpdateProductVariantReq true "修改内容"
+// @Success 200 {object} dto.ProductInventoryResp
+// @Router /api/products/{id}/variants/{variant_id} [patch]
+func (ctrl *ProductController) UpdateVariant(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(400, gin.H{"code": 400, "message": "无效的商品ID"})
+		return
+	}
+	variantID, err := strconv.ParseInt(c.Param("variant_id"), 10, 64)
+	if err != nil || variantID <= 0 {
+		c.JSON(400, gin.H{"code": 400, "message": "无效的变体ID"})
+		return
+	}
+
+	var req dto.UpdateProductVariantReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"code": 400, "message": "参数错误: " + err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	product, err := ctrl.productService.UpdateProductVariant(ctx, id, variantID, &req)
+	ctrl.respondInventory(c, product, err)
+}
+
+// SyncInventory 从 Etsy 拉取商品库存
+// @Summary 从 Etsy 拉取单个商品的库存与变体
+// @Tags Product
+// @Param id path int true "商品ID"
+// @Success 200 {object} dto.ProductInventoryResp
+// @Router /api/products/{id}/inventory/sync [post]
+func (ctrl *ProductController) SyncInventory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(400, gin.H{"code": 400, "message": "无效的商品ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	product, err := ctrl.productService.SyncListingInventory(ctx, id)
+	if err != nil {
+		c.JSON(500, gin.H{"code": 500, "message": "同步失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    ctrl.productService.ToProductInventoryResp(product),
+	})
+}
+
+// respondInventory 库存更新结果：校验失败 400，其余错误 500
+func (ctrl *ProductController) respondInventory(c *gin.Context, product *model.Product, err error) {
+	if errors.Is(err, service.ErrInvalidInventory) {
+		c.JSON(400, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"code": 500, "message": "更新失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    ctrl.productService.ToProductInventoryResp(product),
+	})
+}
@@ -1,12 +1,14 @@
 package controller
 
 import (
+	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/internal/service"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -80,6 +82,63 @@ func (h *ProxyController) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "success"})
 }
 
+// Delete 删除代理
+// @Summary 删除代理
+// @Description 软删除代理；仍绑定店铺时拒绝，需先迁移店铺
+// @Tags Proxy
+// @Produce json
+// @Param id path int true "代理 ID"
+// @Success 200 {object} map[string]string "{"message": "success"}"
+// @Failure 400 {object} map[string]string "ID 格式错误"
+// @Failure 409 {object} map[string]string "代理仍绑定店铺"
+// @Failure 500 {object} map[string]string "服务器内部错误"
+// @Router /api/proxies/{id} [delete]
+func (h *ProxyController) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.proxyService.DeleteProxy(c.Request.Context(), id); err != nil {
+		if errors.Is(err, service.ErrProxyHasShops) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+// Import 批量导入代理
+// @Summary 批量导入代理 IP
+// @Description 按行解析供应商格式（ip:port、ip:port:user:pass、user:pass@ip:port、socks5://...），按 IP+Port 去重，可选入库前并发测试连通性，返回逐行结果
+// @Tags Proxy
+// @Accept json
+// @Produce json
+// @Param request body dto.ImportProxiesReq true "导入参数"
+// @Success 200 {object} map[string]dto.ImportProxiesResp "{"data": dto.ImportProxiesResp}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 500 {object} map[string]string "服务器内部错误"
+// @Router /api/proxies/import [post]
+func (h *ProxyController) Import(c *gin.Context) {
+	var req dto.ImportProxiesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.proxyService.ImportProxies(c.Request.Context(), req, h.getUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
 // ==========================================
 // 2. 读操作 (List / Detail)
 // ==========================================
@@ -96,6 +155,8 @@ func (h *ProxyController) Update(c *gin.Context) {
 // @Param region query string false "地区代码 (如 US)"
 // @Param status query int false "状态 (1:正常 2:过期...)"
 // @Param capacity query int false "容量 (1:独享 2:共享)"
+// @Param geo_mismatch query bool false "只看出口国家与地区不符的代理"
+// @Param sort query string false "排序 (score: 按质量评分从高到低)"
 // @Success 200 {object} map[string]interface{} "{"data": [dto.ProxyResp], "total": 100, "page": 1}"
 // @Failure 500 {object} map[string]string "服务器错误"
 // @Router /api/proxies [get]
@@ -114,6 +175,8 @@ func (h *ProxyController) GetList(c *gin.Context) {
 		Status:   status,
 		Capacity: capacity,
 	}
+	filter.GeoMismatch = c.Query("geo_mismatch") == "true"
+	filter.SortByScore = c.Query("sort") == "score"
 
 	list, total, err := h.proxyService.GetProxyList(c.Request.Context(), filter)
 	if err != nil {
@@ -156,6 +219,82 @@ func (h *ProxyController) GetDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
+// ScoreHistory 代理评分历史
+// @Summary 获取代理质量评分历史
+// @Description 每次巡检记录一条评分快照（成功率、延迟分位数、403/429 次数），用于观察评分变化趋势
+// @Tags Proxy
+// @Produce json
+// @Param id path int true "代理 ID"
+// @Param hours query int false "最近多少小时 (默认24，最多720)"
+// @Success 200 {object} map[string]interface{} "{"data": [model.ProxyScoreSnapshot]}"
+// @Failure 400 {object} map[string]string "ID 格式错误"
+// @Failure 500 {object} map[string]string "查询失败"
+// @Router /api/proxies/{id}/scores [get]
+func (h *ProxyController) ScoreHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
+	if hours <= 0 || hours > 720 {
+		hours = 24
+	}
+
+	list, err := h.proxyService.GetScoreHistory(c.Request.Context(), id, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// SharedExits 共用出口 IP 的代理
+// @Summary 检测共用出口 IP 的代理
+// @Description 不同代理的巡检出口 IP 相同时，其下店铺在 Etsy 看来来自同一网络，可能被关联
+// @Tags Proxy
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"data": [dto.SharedExitGroup]}"
+// @Failure 500 {object} map[string]string "查询失败"
+// @Router /api/proxies/shared-exits [get]
+func (h *ProxyController) SharedExits(c *gin.Context) {
+	groups, err := h.proxyService.DetectSharedExitIPs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// ShopBindings 店铺代理绑定历史
+// @Summary 获取店铺的代理绑定历史
+// @Description 店铺绑定过的全部代理（最新在前），含绑定 / 解绑时间与原因。被其他店铺用过的代理不会再分配给该店铺
+// @Tags Proxy
+// @Produce json
+// @Param shop_id path int true "店铺 ID"
+// @Success 200 {object} map[string]interface{} "{"data": [dto.ProxyBindingResp]}"
+// @Failure 400 {object} map[string]string "ID 格式错误"
+// @Failure 403 {object} map[string]string "无该店铺权限"
+// @Failure 500 {object} map[string]string "查询失败"
+// @Router /api/proxies/shops/{shop_id}/bindings [get]
+func (h *ProxyController) ShopBindings(c *gin.Context) {
+	shopID, err := strconv.ParseInt(c.Param("shop_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shop_id"})
+		return
+	}
+
+	list, err := h.proxyService.GetShopBindings(c.Request.Context(), shopID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
 // getUserID 辅助方法
 func (h *ProxyController) getUserID(c *gin.Context) int64 {
 	if v, exists := c.Get("userID"); exists {
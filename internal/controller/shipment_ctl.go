@@ -2,6 +2,7 @@ package controller
 
 import (
 	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/internal/service"
@@ -40,6 +41,9 @@ func (c *ShipmentController) Create(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !middleware.AuthorizeResource(ctx, middleware.ShopResourceOrder, req.OrderID, model.ShopMemberRoleManager) {
+		return
+	}
 
 	shipment, err := c.svc.CreateShipment(ctx, req.OrderID, req.CarrierCode, req.ServiceCode, req.TrackingNumber, req.Weight)
 	if err != nil {
@@ -69,6 +73,9 @@ func (c *ShipmentController) CreateWithLabel(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !middleware.AuthorizeResource(ctx, middleware.ShopResourceOrder, req.OrderID, model.ShopMemberRoleManager) {
+		return
+	}
 
 	shipment, err := c.svc.CreateShipmentWithLabel(ctx, req.OrderID, req.CarrierCode, req.ServiceCode)
 	if err != nil {
@@ -180,6 +187,14 @@ func (c *ShipmentController) List(ctx *gin.Context) {
 	filter.Page, _ = strconv.Atoi(ctx.DefaultQuery("page", "1"))
 	filter.PageSize, _ = strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
 
+	// 非管理员只能看到所属店铺的发货记录
+	shopIDs, err := middleware.ScopedShopIDs(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filter.ShopIDs = shopIDs
+
 	shipments, total, err := c.svc.List(ctx, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
 	"etsy_dev_v1_202512/internal/service"
 	"net/http"
 	"strconv"
@@ -47,6 +48,14 @@ func (c *ShopController) GetShopList(ctx *gin.Context) {
 		req.PageSize = 20
 	}
 
+	// 非管理员只能看到自己是成员的店铺
+	shopIDs, err := middleware.ScopedShopIDs(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	req.ShopIDs = shopIDs
+
 	resp, err := c.shopSvc.GetShopList(ctx.Request.Context(), req)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -115,6 +124,39 @@ func (c *ShopController) UpdateShopToEtsy(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
 
+// UpdateAISettings 更新店铺 AI 配置
+// @Summary 更新店铺 AI 配置
+// @Description 设置该店铺草稿生成使用的 AI Provider 与模型，留空使用系统默认
+// @Tags Shop (店铺管理)
+// @Accept json
+// @Produce json
+// @Param id path int true "店铺ID"
+// @Param request body dto.ShopAISettingsReq true "AI 配置"
+// @Success 200 {object} map[string]string "{"message": "更新成功"}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 500 {object} map[string]string "更新失败"
+// @Router /api/v1/shops/{id}/ai-settings [put]
+func (c *ShopController) UpdateAISettings(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的店铺ID"})
+		return
+	}
+
+	var req dto.ShopAISettingsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		return
+	}
+
+	if err := c.shopSvc.UpdateAISettings(ctx.Request.Context(), id, req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
 // StopShop 停用店铺
 // @Summary 停用店铺
 // @Description 停用店铺，停止Token刷新和数据同步
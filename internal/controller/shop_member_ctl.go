@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"errors"
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShopMemberController 店铺成员管理
+type ShopMemberController struct {
+	memberSvc *service.ShopMemberService
+}
+
+// NewShopMemberController 创建店铺成员控制器
+func NewShopMemberController(memberSvc *service.ShopMemberService) *ShopMemberController {
+	return &ShopMemberController{memberSvc: memberSvc}
+}
+
+// AccessChecker 店铺权限数据源（供路由注入 middleware.ShopAuth）
+func (c *ShopMemberController) AccessChecker() middleware.ShopAccessChecker {
+	return c.memberSvc
+}
+
+// ListMembers 店铺成员列表
+// @Summary 店铺成员列表
+// @Tags Shop (店铺管理)
+// @Produce json
+// @Param id path int true "店铺ID"
+// @Success 200 {object} map[string]interface{} "{"data": []dto.ShopMemberResp}"
+// @Failure 403 {object} map[string]interface{} "无权限"
+// @Router /api/shops/{id}/members [get]
+func (c *ShopMemberController) ListMembers(ctx *gin.Context) {
+	shopID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的店铺ID"})
+		return
+	}
+
+	members, err := c.memberSvc.ListMembers(ctx.Request.Context(), shopID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// AddMember 添加店铺成员
+// @Summary 添加店铺成员（owner）
+// @Tags Shop (店铺管理)
+// @Accept json
+// @Produce json
+// @Param id path int true "店铺ID"
+// @Param request body dto.AddShopMemberReq true "成员信息"
+// @Success 200 {object} map[string]string "{"message": "添加成功"}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Failure 409 {object} map[string]string "已是成员"
+// @Router /api/shops/{id}/members [post]
+func (c *ShopMemberController) AddMember(ctx *gin.Context) {
+	shopID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的店铺ID"})
+		return
+	}
+
+	var req dto.AddShopMemberReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		return
+	}
+
+	if err := c.memberSvc.AddMember(ctx.Request.Context(), shopID, &req); err != nil {
+		ctx.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "添加成功"})
+}
+
+// UpdateMember 修改成员角色
+// @Summary 修改店铺成员角色（owner）
+// @Tags Shop (店铺管理)
+// @Accept json
+// @Produce json
+// @Param id path int true "店铺ID"
+// @Param user_id path int true "用户ID"
+// @Param request body dto.UpdateShopMemberReq true "角色"
+// @Success 200 {object} map[string]string "{"message": "更新成功"}"
+// @Failure 404 {object} map[string]string "成员不存在"
+// @Failure 409 {object} map[string]string "最后一名 owner"
+// @Router /api/shops/{id}/members/{user_id} [put]
+func (c *ShopMemberController) UpdateMember(ctx *gin.Context) {
+	shopID, userID, ok := parseMemberParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateShopMemberReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		return
+	}
+
+	if err := c.memberSvc.UpdateMemberRole(ctx.Request.Context(), shopID, userID, req.Role); err != nil {
+		ctx.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// RemoveMember 移除店铺成员
+// @Summary 移除店铺成员（owner）
+// @Tags Shop (店铺管理)
+// @Produce json
+// @Param id path int true "店铺ID"
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} map[string]string "{"message": "移除成功"}"
+// @Failure 404 {object} map[string]string "成员不存在"
+// @Failure 409 {object} map[string]string "最后一名 owner"
+// @Router /api/shops/{id}/members/{user_id} [delete]
+func (c *ShopMemberController) RemoveMember(ctx *gin.Context) {
+	shopID, userID, ok := parseMemberParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.memberSvc.RemoveMember(ctx.Request.Context(), shopID, userID); err != nil {
+		ctx.JSON(memberErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+func parseMemberParams(ctx *gin.Context) (shopID, userID int64, ok bool) {
+	shopID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的店铺ID"})
+		return 0, 0, false
+	}
+	userID, err = strconv.ParseInt(ctx.Param("user_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, 0, false
+	}
+	return shopID, userID, true
+}
+
+func memberErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrShopMemberNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrShopMemberExists), errors.Is(err, service.ErrShopLastOwner):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"errors"
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StockController 跨店铺主库存控制器
+type StockController struct {
+	stockService *service.StockService
+}
+
+// NewStockController 创建主库存控制器
+func NewStockController(stockService *service.StockService) *StockController {
+	return &StockController{stockService: stockService}
+}
+
+// List 主库存列表
+// @Summary 主库存列表
+// @Tags Stock
+// @Produce json
+// @Param keyword query string false "SKU / 名称搜索"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{} "{"data": []model.StockItem, "total": 0}"
+// @Router /api/stock [get]
+func (h *StockController) List(c *gin.Context) {
+	var req dto.ListStockReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, total, err := h.stockService.ListItems(c.Request.Context(), req.Keyword, req.Page, req.PageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items, "total": total, "page": req.Page, "page_size": req.PageSize})
+}
+
+// Get 主库存详情
+// @Summary 主库存详情（含关联商品）
+// @Tags Stock
+// @Produce json
+// @Param id path int true "主库存ID"
+// @Success 200 {object} map[string]interface{} "{"data": model.StockItem, "links": []dto.StockLinkVO}"
+// @Failure 404 {object} map[string]string "不存在"
+// @Router /api/stock/{id} [get]
+func (h *StockController) Get(c *gin.Context) {
+	id, ok := parseStockID(c)
+	if !ok {
+		return
+	}
+
+	item, products, err := h.stockService.GetItem(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	links := make([]dto.StockLinkVO, 0, len(products))
+	for _, p := range products {
+		link := dto.StockLinkVO{
+			ProductID: p.ID,
+			ShopID:    p.ShopID,
+			ListingID: p.ListingID,
+			Title:     p.Title,
+			State:     string(p.State),
+			Quantity:  p.Quantity,
+		}
+		if p.LocalSKU != item.LocalSKU {
+			link.Quantity = 0
+			for _, v := range p.Variants {
+				if v.LocalSKU == item.LocalSKU {
+					link.VariantID = append(link.VariantID, v.ID)
+					link.Quantity += v.Quantity
+				}
+			}
+		}
+		links = append(links, link)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item, "links": links})
+}
+
+// Create 创建主库存
+// @Summary 创建主库存（SKU 与商品 / 变体的 local_sku 对应）
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateStockItemReq true "主库存"
+// @Success 201 {object} map[string]interface{} "{"data": model.StockItem}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Router /api/stock [post]
+func (h *StockController) Create(c *gin.Context) {
+	var req dto.CreateStockItemReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.stockService.CreateItem(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": item})
+}
+
+// Adjust 增减库存
+// @Summary 人工增减库存并推送到所有关联 listing
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param id path int true "主库存ID"
+// @Param request body dto.AdjustStockReq true "变动数量"
+// @Success 200 {object} map[string]interface{} "{"data": model.StockItem, "push": []service.StockPushResult}"
+// @Failure 400 {object} map[string]string "参数错误"
+// @Router /api/stock/{id}/adjust [post]
+func (h *StockController) Adjust(c *gin.Context) {
+	id, ok := parseStockID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.AdjustStockReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, results, err := h.stockService.AdjustStock(c.Request.Context(), id, req.Delta, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item, "push": results})
+}
+
+// Count 盘点
+// @Summary 盘点：按版本号设置库存并推送（版本不一致返回 409）
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param id path int true "主库存ID"
+// @Param request body dto.CountStockReq true "盘点数量"
+// @Success 200 {object} map[string]interface{} "{"data": model.StockItem, "push": []service.StockPushResult}"
+// @Failure 409 {object} map[string]string "库存已被修改"
+// @Router /api/stock/{id}/count [post]
+func (h *StockController) Count(c *gin.Context) {
+	id, ok := parseStockID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CountStockReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, results, err := h.stockService.CountStock(c.Request.Context(), id, *req.OnHand, *req.Version, req.Note)
+	if errors.Is(err, repository.ErrStockVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "库存已被其他操作修改，请刷新后重试"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item, "push": results})
+}
+
+// Push 手动推送
+// @Summary 把当前库存推送到所有关联 listing
+// @Tags Stock
+// @Produce json
+// @Param id path int true "主库存ID"
+// @Success 200 {object} map[string]interface{} "{"data": []service.StockPushResult}"
+// @Failure 404 {object} map[string]string "不存在"
+// @Router /api/stock/{id}/push [post]
+func (h *StockController) Push(c *gin.Context) {
+	id, ok := parseStockID(c)
+	if !ok {
+		return
+	}
+
+	results, err := h.stockService.PushStock(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// Movements 库存流水
+// @Summary 库存流水（审计）
+// @Tags Stock
+// @Produce json
+// @Param id path int true "主库存ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{} "{"data": []model.StockMovement, "total": 0}"
+// @Router /api/stock/{id}/movements [get]
+func (h *StockController) Movements(c *gin.Context) {
+	id, ok := parseStockID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	movements, total, err := h.stockService.ListMovements(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": movements, "total": total, "page": page, "page_size": pageSize})
+}
+
+func parseStockID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的库存ID"})
+		return 0, false
+	}
+	return id, true
+}
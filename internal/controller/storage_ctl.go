@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"etsy_dev_v1_202512/internal/service"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageController 本地存储文件访问控制器
+// 仅在 STORAGE_PROVIDER=local 时生效，S3/COS 由对象存储自身提供访问
+type StorageController struct {
+	local *service.LocalStorage
+}
+
+// NewStorageController 创建存储控制器
+func NewStorageController(storageSvc *service.StorageService) *StorageController {
+	if storageSvc == nil {
+		return &StorageController{}
+	}
+	return &StorageController{local: storageSvc.LocalProvider()}
+}
+
+// Enabled 是否启用本地文件路由
+func (h *StorageController) Enabled() bool {
+	return h.local != nil
+}
+
+// RoutePrefix 路由前缀
+func (h *StorageController) RoutePrefix() string {
+	return h.local.RoutePrefix()
+}
+
+// ServeFile 访问本地存储文件
+// @Summary 访问本地存储文件
+// @Description 私有存储需携带 GetSignedURL 生成的 expires/sig 参数
+// @Tags Storage
+// @Param key path string true "文件 Key"
+// @Param expires query int false "签名过期时间 (Unix 秒)"
+// @Param sig query string false "签名"
+// @Success 200 {file} binary
+// @Failure 403 {object} map[string]string "签名无效或已过期"
+// @Failure 404 {object} map[string]string "文件不存在"
+// @Router /uploads/{key} [get]
+func (h *StorageController) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	if err := h.local.VerifyAccess(key, c.Query("expires"), c.Query("sig")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	fullPath, err := h.local.ResolvePath(key)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 签名链接只在有效期内缓存且不进共享缓存；公开的内容寻址文件不会变化，可长期缓存
+	if c.Query("sig") != "" {
+		expiresAt, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+		maxAge := max(expiresAt-time.Now().Unix(), 0)
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+	} else {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	c.File(fullPath)
+}
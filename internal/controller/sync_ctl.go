@@ -118,7 +118,8 @@ func (c *SyncController) SyncAllProducts(ctx *gin.Context) {
 // @Summary 手动同步单个店铺订单
 // @Tags Sync
 // @Param shop_id path int true "店铺 ID"
-// @Param force query bool false "是否强制同步"
+// @Param full query bool false "是否忽略检查点全量同步"
+// @Param force query bool false "是否覆盖本地较新的订单（与 full 相互独立）"
 // @Success 200 {object} map[string]interface{}
 // @Failure 429 {object} map[string]interface{} "限流中"
 // @Router /api/v1/sync/orders/{shop_id} [post]
@@ -128,9 +129,10 @@ func (c *SyncController) SyncOrders(ctx *gin.Context) {
 		return
 	}
 
+	fullResync := ctx.Query("full") == "true"
 	forceSync := ctx.Query("force") == "true"
 
-	resp, err := c.taskManager.TriggerOrderSync(ctx.Request.Context(), shopID, forceSync)
+	resp, err := c.taskManager.TriggerOrderSync(ctx.Request.Context(), shopID, fullResync, forceSync)
 	if err != nil {
 		ctx.JSON(500, gin.H{"code": 500, "message": err.Error()})
 		return
@@ -140,10 +142,14 @@ func (c *SyncController) SyncOrders(ctx *gin.Context) {
 		"code":    200,
 		"message": "订单同步完成",
 		"data": gin.H{
-			"shop_id":    shopID,
-			"new_orders": resp.NewOrders,
-			"updated":    resp.UpdatedOrders,
-			"force_sync": forceSync,
+			"shop_id":     shopID,
+			"fetched":     resp.TotalFetched,
+			"new_orders":  resp.NewOrders,
+			"updated":     resp.UpdatedOrders,
+			"checkpoint":  resp.Checkpoint,
+			"errors":      resp.Errors,
+			"full_resync": fullResync,
+			"force_sync":  forceSync,
 		},
 	})
 }
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// ==================== 店铺级权限 ====================
+
+// 店铺资源类型（用于解析请求资源所属店铺）
+const (
+	ShopResourceShop            = "shop"
+	ShopResourceProduct         = "product"
+	ShopResourceOrder           = "order"
+	ShopResourceShipment        = "shipment"
+	ShopResourceSection         = "section"
+	ShopResourceShippingProfile = "shipping_profile"
+	ShopResourceShippingUpgrade = "shipping_upgrade"
+	ShopResourceReturnPolicy    = "return_policy"
+	ShopResourceDraftTask       = "draft_task" // 一个任务可生成多个店铺的草稿，需对全部店铺有权限
+	ShopResourceDraftProduct    = "draft_product"
+)
+
+// ContextKeyShopChecker 店铺权限校验器
+const ContextKeyShopChecker = "shop_checker"
+
+// ShopAccessChecker 店铺权限数据源
+type ShopAccessChecker interface {
+	// ShopRole 用户在店铺中的角色，非成员返回空字符串
+	ShopRole(ctx context.Context, userID, shopID int64) (string, error)
+	// UserShopIDs 用户所属的全部店铺
+	UserShopIDs(ctx context.Context, userID int64) ([]int64, error)
+	// ResolveShopIDs 解析资源所属店铺（草稿任务可能对应多个店铺），资源不存在时返回 gorm.ErrRecordNotFound
+	ResolveShopIDs(ctx context.Context, resource string, id int64) ([]int64, error)
+}
+
+// shopRoleRank 店铺角色等级：owner > manager > viewer
+var shopRoleRank = map[string]int{
+	model.ShopMemberRoleViewer:  1,
+	model.ShopMemberRoleManager: 2,
+	model.ShopMemberRoleOwner:   3,
+}
+
+// ShopRoleSatisfies 角色是否满足最低要求
+func ShopRoleSatisfies(role, minRole string) bool {
+	return shopRoleRank[role] > 0 && shopRoleRank[role] >= shopRoleRank[minRole]
+}
+
+// ShopAuth 注入店铺权限校验器（需在 JWTAuth 之后）
+func ShopAuth(checker ShopAccessChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ContextKeyShopChecker, checker)
+		c.Next()
+	}
+}
+
+// RequireShopRole 路由级店铺权限校验
+// 从路径参数（或同名查询参数）读取资源 ID，解析所属店铺后校验角色；管理员不受限
+func RequireShopRole(minRole, resource, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Param(param)
+		if raw == "" {
+			raw = c.Query(param)
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "无效的 " + param,
+			})
+			return
+		}
+
+		if !AuthorizeResource(c, resource, id, minRole) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthorizeShop 校验当前用户对店铺的权限，失败时已写入响应并中止
+func AuthorizeShop(c *gin.Context, shopID int64, minRole string) bool {
+	return AuthorizeResource(c, ShopResourceShop, shopID, minRole)
+}
+
+// AuthorizeResource 校验当前用户对资源所属店铺的权限（用于请求体中的店铺/订单 ID）
+// 失败时已写入响应并中止
+func AuthorizeResource(c *gin.Context, resource string, id int64, minRole string) bool {
+	if IsAdmin(c) {
+		return true
+	}
+
+	checker := getShopChecker(c)
+	if checker == nil {
+		abortShopForbidden(c)
+		return false
+	}
+
+	ctx := c.Request.Context()
+	shopIDs := []int64{id}
+	if resource != ShopResourceShop {
+		var err error
+		shopIDs, err = checker.ResolveShopIDs(ctx, resource, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+					"code":    404,
+					"message": "资源不存在",
+				})
+				return false
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "权限校验失败: " + err.Error(),
+			})
+			return false
+		}
+	}
+
+	if len(shopIDs) == 0 {
+		abortShopForbidden(c)
+		return false
+	}
+	for _, shopID := range shopIDs {
+		role, err := checker.ShopRole(ctx, GetUserID(c), shopID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "权限校验失败: " + err.Error(),
+			})
+			return false
+		}
+		if !ShopRoleSatisfies(role, minRole) {
+			abortShopForbidden(c)
+			return false
+		}
+	}
+	return true
+}
+
+// AuthorizeShops 校验当前用户对多个店铺的权限（如创建草稿时选择的店铺），失败时已写入响应并中止
+func AuthorizeShops(c *gin.Context, shopIDs []int64, minRole string) bool {
+	for _, shopID := range shopIDs {
+		if !AuthorizeShop(c, shopID, minRole) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopedShopIDs 列表接口可见的店铺范围
+// 管理员返回 nil（不限制）；其他用户返回所属店铺（可能为空切片）
+func ScopedShopIDs(c *gin.Context) ([]int64, error) {
+	if IsAdmin(c) {
+		return nil, nil
+	}
+
+	checker := getShopChecker(c)
+	if checker == nil {
+		return []int64{}, nil
+	}
+
+	ids, err := checker.UserShopIDs(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		return nil, err
+	}
+	if ids == nil {
+		ids = []int64{}
+	}
+	return ids, nil
+}
+
+// IsAdmin 当前用户是否为系统管理员
+func IsAdmin(c *gin.Context) bool {
+	return GetUserRole(c) == string(model.UserRoleAdmin)
+}
+
+func getShopChecker(c *gin.Context) ShopAccessChecker {
+	if v, exists := c.Get(ContextKeyShopChecker); exists {
+		if checker, ok := v.(ShopAccessChecker); ok {
+			return checker
+		}
+	}
+	return nil
+}
+
+func abortShopForbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"code":    403,
+		"message": "无该店铺的操作权限",
+	})
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StreamTicketTTL SSE 连接票据有效期
+// EventSource 无法设置请求头，长期 Access Token 放进 URL 会进入访问日志与浏览器历史，
+// 因此先用 Access Token 换取短期票据，再以 ?ticket= 建立连接
+const StreamTicketTTL = 30 * time.Second
+
+// streamTicketSubject 票据 Token 的 Subject，与 access / refresh 区分
+const streamTicketSubject = "stream"
+
+// ErrStreamTicketUsed 票据已被使用
+var ErrStreamTicketUsed = errors.New("票据已使用")
+
+// usedStreamTickets 已消费的票据 ID（保留到票据过期，之后票据本身已无法通过校验）
+var usedStreamTickets = struct {
+	sync.Mutex
+	ids map[string]time.Time
+}{ids: make(map[string]time.Time)}
+
+// GenerateStreamTicket 生成一次性 SSE 连接票据
+func GenerateStreamTicket(userID int64, username, role string) (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &UserClaims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwtConfig.Issuer,
+			Subject:   streamTicketSubject,
+			ID:        hex.EncodeToString(buf[:]),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(StreamTicketTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(jwtConfig.SecretKey))
+}
+
+// ConsumeStreamTicket 校验并消费票据，同一票据只能使用一次
+func ConsumeStreamTicket(ticket string) (*UserClaims, error) {
+	claims, err := ParseToken(ticket)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != streamTicketSubject || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, errors.New("invalid ticket")
+	}
+
+	now := time.Now()
+	usedStreamTickets.Lock()
+	defer usedStreamTickets.Unlock()
+
+	for id, expiresAt := range usedStreamTickets.ids {
+		if now.After(expiresAt) {
+			delete(usedStreamTickets.ids, id)
+		}
+	}
+	if _, used := usedStreamTickets.ids[claims.ID]; used {
+		return nil, ErrStreamTicketUsed
+	}
+	usedStreamTickets.ids[claims.ID] = claims.ExpiresAt.Time
+	return claims, nil
+}
+
+// StreamTicketAuth SSE 票据认证中间件（替代 JWTAuth，票据通过 ticket 参数传递）
+func StreamTicketAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "未提供连接票据",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := ConsumeStreamTicket(ticket)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "连接票据无效、已过期或已使用",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextKeyUserID, claims.UserID)
+		c.Set(ContextKeyUsername, claims.Username)
+		c.Set(ContextKeyRole, claims.Role)
+		c.Set(ContextKeyClaims, claims)
+
+		c.Next()
+	}
+}
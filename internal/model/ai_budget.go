@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// AIBudget AI 花费预算
+// ScopeID=0 表示该范围的默认预算（对每个店铺/用户分别生效）
+type AIBudget struct {
+	BaseModel
+
+	Scope   string `gorm:"size:16;not null;uniqueIndex:idx_ai_budget_scope;comment:范围(shop/user)" json:"scope"`
+	ScopeID int64  `gorm:"not null;uniqueIndex:idx_ai_budget_scope;comment:店铺ID/用户ID(0为默认)" json:"scope_id"`
+
+	// 限额（美元，0 表示不限）
+	DailyLimitUSD   float64 `gorm:"type:decimal(12,4);default:0;comment:每日预算(美元)" json:"daily_limit_usd"`
+	MonthlyLimitUSD float64 `gorm:"type:decimal(12,4);default:0;comment:每月预算(美元)" json:"monthly_limit_usd"`
+
+	// 告警阈值（百分比，如 [80, 95]）
+	WarnPercents datatypes.JSONSlice[int] `gorm:"type:jsonb;comment:告警阈值百分比" json:"warn_percents"`
+
+	Enabled bool `gorm:"comment:是否启用" json:"enabled"`
+
+	// 管理员临时放行（到期前不做硬性拦截）
+	OverrideUntil  *time.Time `gorm:"comment:放行截止时间" json:"override_until"`
+	OverrideReason string     `gorm:"size:255;comment:放行原因" json:"override_reason"`
+	OverrideBy     int64      `gorm:"comment:放行操作人" json:"override_by"`
+}
+
+func (AIBudget) TableName() string {
+	return "ai_budgets"
+}
+
+// IsOverridden 当前是否处于放行期
+func (b *AIBudget) IsOverridden(now time.Time) bool {
+	return b.OverrideUntil != nil && now.Before(*b.OverrideUntil)
+}
+
+// ==================== 预算范围常量 ====================
+
+const (
+	AIBudgetScopeShop = "shop"
+	AIBudgetScopeUser = "user"
+)
+
+// ==================== 预算周期常量 ====================
+
+const (
+	AIBudgetPeriodDaily   = "daily"
+	AIBudgetPeriodMonthly = "monthly"
+)
@@ -7,6 +7,7 @@ type AICallLog struct {
 	// 关联
 	ShopID int64 `gorm:"index;comment:店铺ID"`
 	TaskID int64 `gorm:"index;comment:草稿任务ID"`
+	UserID int64 `gorm:"index;comment:发起用户ID"`
 
 	// 调用信息
 	CallType  string `gorm:"size:32;index;comment:调用类型(text/image)"`
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Developer 状态常量
 const (
 	DeveloperStatusPending = 0 // 未配置（用户未回填 CallbackURL 到 Etsy）
@@ -7,31 +9,46 @@ const (
 	DeveloperStatusBanned  = 2 // 异常/封禁
 )
 
+// DomainPool 回调域名池：每个开发者分配 {subdomain}.{host} 作为独立回调域名（防关联）
 type DomainPool struct {
 	BaseModel
-	Host       string      `gorm:"size:255;unique"`
-	IsActive   bool        `gorm:"default:true"`
-	Developers []Developer `gorm:"foreignkey:DomainPoolID"`
+	Host     string `gorm:"size:255;unique"`
+	IsActive bool   `gorm:"default:true"`
+	// 同一域名下最多分配的开发者数量，0 表示不限
+	MaxDevelopers int         `gorm:"default:10"`
+	Remark        string      `gorm:"size:255"`
+	Developers    []Developer `gorm:"foreignkey:DomainPoolID"`
 }
 type Developer struct {
 	BaseModel
 	// 1. 账号基础信息 (登录 Etsy 开发者后台用)
 	Name       string `gorm:"size:50"` // 备注名称，如 "开发者账号A"
 	LoginEmail string `gorm:"uniqueIndex;size:100;not null"`
-	LoginPwd   string `gorm:"size:255;not null"`
+	LoginPwd   string `gorm:"type:text;not null;serializer:encrypted"`
 
 	// 状态管理: 0.未配置 1.正常启用 2.异常(被封)
 	Status int `gorm:"default:0;index"`
 
 	// 2. API 凭证 (核心资产)
 	ApiKey       string `gorm:"size:100;index;"`
-	SharedSecret string `gorm:"size:100"`
+	SharedSecret string `gorm:"type:text;serializer:encrypted"`
 	// 防关联
 	DomainPoolID int64       `gorm:"index"`
 	DomainPoll   *DomainPool `gorm:"foreignkey:DomainPoolID"`
 	SubDomain    string      `gorm:"size:50"`
 	CallbackPath string      `gorm:"size:50"`
 	CallbackURL  string      `gorm:"size:255"`
+	// 回调连通性校验：通过后才能启用（Pending -> Active）
+	CallbackVerifiedAt *time.Time
+	CallbackCheckError string `gorm:"size:255"`
+	// 最近一次自动封禁（Etsy 连续拒绝 API Key）
+	BannedAt  *time.Time
+	BanReason string `gorm:"size:255"`
+	// Etsy API 配额快照（Dispatcher 根据响应头更新，同 Key 下所有店铺共享）
+	QuotaPerSecond      int `gorm:"default:0"`
+	QuotaPerDay         int `gorm:"default:0"`
+	QuotaRemainingToday int `gorm:"default:-1;comment:-1 表示未知"`
+	QuotaUpdatedAt      *time.Time
 	// 3. 关联关系
 	// 一个开发者 Key 可以授权给多个店铺使用
 	Shops []Shop `gorm:"foreignKey:DeveloperID"`
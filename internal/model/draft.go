@@ -2,6 +2,7 @@ package model
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/datatypes"
 )
@@ -31,15 +32,34 @@ const (
 	DraftStatusExpired   = "expired"
 
 	// 同步状态
-	DraftSyncStatusNone    = 0 // 未同步
-	DraftSyncStatusPending = 1 // 待同步
-	DraftSyncStatusDone    = 2 // 已同步
-	DraftSyncStatusFailed  = 3 // 同步失败
+	DraftSyncStatusNone      = 0 // 未同步
+	DraftSyncStatusPending   = 1 // 待同步（含可重试失败后的退避等待）
+	DraftSyncStatusDone      = 2 // 已同步
+	DraftSyncStatusFailed    = 3 // 同步失败（不可重试错误，需修改后重试）
+	DraftSyncStatusDead      = 4 // 死信（超过最大重试次数）
+	DraftSyncStatusAbandoned = 5 // 已放弃提交
 
 	// 图片状态
 	ImageStatusPending = "pending"
 	ImageStatusReady   = "ready"
 	ImageStatusFailed  = "failed"
+
+	// 图片上传 Etsy 状态
+	ImageUploadStatusPending  = "pending"
+	ImageUploadStatusUploaded = "uploaded"
+	ImageUploadStatusFailed   = "failed"
+
+	// 草稿任务队列状态
+	DraftJobStatusQueued    = "queued"    // 等待执行（含重试退避中）
+	DraftJobStatusRunning   = "running"   // 已被 Worker 租用
+	DraftJobStatusSucceeded = "succeeded" // 已完成
+	DraftJobStatusDead      = "dead"      // 超过最大次数或不可重试，放弃
+
+	// 草稿任务处理阶段（按阶段断点续跑）
+	DraftJobStageFetching   = "fetching"   // 抓取源商品
+	DraftJobStageGenerating = "generating" // 逐店铺生成并落库
+	DraftJobStageSaving     = "saving"     // 汇总结果、更新任务状态
+	DraftJobStageDone       = "done"
 )
 
 // ==================== 数据库模型 ====================
@@ -60,12 +80,57 @@ type DraftTask struct {
 	AITextResult   datatypes.JSON              `gorm:"type:jsonb;comment:AI文案结果"`
 	AIImages       datatypes.JSONSlice[string] `gorm:"type:jsonb;comment:AI生成图片URL"`
 	AIErrorMessage string                      `gorm:"size:1024;comment:AI处理错误信息"`
+
+	// AI 配置（为空时使用店铺配置）
+	AIProvider   string `gorm:"size:32;comment:AI Provider"`
+	AITextModel  string `gorm:"size:64;comment:AI 文本模型"`
+	AIImageModel string `gorm:"size:64;comment:AI 图片模型"`
 }
 
 func (*DraftTask) TableName() string {
 	return "draft_tasks"
 }
 
+// DraftJob 草稿 AI 处理队列（每个 DraftTask 一条）
+// Worker 通过租约领取，租约期内定时心跳续期；租约过期视为 Worker 已失联，任务可被重新领取
+type DraftJob struct {
+	BaseModel
+	TaskID      int64                      `gorm:"uniqueIndex;not null;comment:任务ID"`
+	ShopIDs     datatypes.JSONSlice[int64] `gorm:"type:jsonb;comment:目标店铺ID"`
+	Quantity    int                        `gorm:"default:1;comment:库存数量"`
+	Stage       string                     `gorm:"size:32;default:fetching;comment:当前阶段"`
+	Status      string                     `gorm:"size:32;index:idx_draft_job_poll,priority:1;default:queued;comment:队列状态"`
+	Attempts    int                        `gorm:"default:0;comment:已执行次数"`
+	MaxAttempts int                        `gorm:"default:3;comment:最大执行次数"`
+	NextRunAt   time.Time                  `gorm:"index:idx_draft_job_poll,priority:2;comment:下次可执行时间"`
+	LastError   string                     `gorm:"size:1024;comment:最近一次错误"`
+
+	// 租约
+	LeaseOwner     string     `gorm:"size:128;comment:租约持有者"`
+	LeaseExpiresAt *time.Time `gorm:"index;comment:租约过期时间"`
+	HeartbeatAt    *time.Time `gorm:"comment:最近心跳时间"`
+	FinishedAt     *time.Time `gorm:"comment:结束时间"`
+}
+
+func (*DraftJob) TableName() string {
+	return "draft_jobs"
+}
+
+// DraftTaskEvent 草稿任务进度事件（持久化，供 SSE 断线重放）
+type DraftTaskEvent struct {
+	ID        int64          `gorm:"primaryKey;autoIncrement"`
+	TaskID    int64          `gorm:"index;not null;comment:任务ID"`
+	Stage     string         `gorm:"size:32;comment:阶段"`
+	Progress  int            `gorm:"comment:进度(0-100)"`
+	Message   string         `gorm:"size:1024;comment:消息"`
+	Data      datatypes.JSON `gorm:"type:jsonb;comment:附加数据"`
+	CreatedAt time.Time
+}
+
+func (*DraftTaskEvent) TableName() string {
+	return "draft_task_events"
+}
+
 // DraftProduct 草稿商品
 type DraftProduct struct {
 	BaseModel
@@ -85,6 +150,8 @@ type DraftProduct struct {
 	Status            string                      `gorm:"size:32;index;default:draft;comment:状态"`
 	SyncStatus        int                         `gorm:"default:0;index;comment:同步状态"`
 	SyncError         string                      `gorm:"size:1024;comment:同步错误信息"`
+	SubmitAttempts    int                         `gorm:"default:0;comment:提交尝试次数"`
+	NextSubmitAt      *time.Time                  `gorm:"index;comment:下次提交时间（退避/处理中租约）"`
 	ProductID         int64                       `gorm:"index;comment:同步后的产品ID"`
 	ListingID         int64                       `gorm:"index;comment:Etsy listing ID"`
 
@@ -118,6 +185,25 @@ func (*DraftImage) TableName() string {
 	return "draft_images"
 }
 
+// DraftImageUpload 草稿提交时每张图片的 Etsy 上传记录
+// 按 (草稿商品, 排序) 唯一；重试提交时只重传未成功的图片
+type DraftImageUpload struct {
+	BaseModel
+	DraftProductID int64  `gorm:"uniqueIndex:idx_draft_image_upload_rank;not null;comment:草稿商品ID"`
+	Rank           int    `gorm:"uniqueIndex:idx_draft_image_upload_rank;comment:排序(1为主图)"`
+	SourceURL      string `gorm:"size:2048;comment:源图片URL"`
+	Status         string `gorm:"size:32;index;default:pending;comment:上传状态"`
+	Attempts       int    `gorm:"default:0;comment:上传尝试次数"`
+	EtsyImageID    int64  `gorm:"comment:Etsy listing_image_id"`
+	EtsyImageURL   string `gorm:"size:1024;comment:Etsy 图片地址"`
+	ProductImageID int64  `gorm:"index;comment:关联 ProductImage ID"`
+	ErrorMessage   string `gorm:"size:1024;comment:最近一次错误"`
+}
+
+func (*DraftImageUpload) TableName() string {
+	return "draft_image_uploads"
+}
+
 // ==================== 辅助方法 ====================
 
 // GetPrice 获取价格（浮点数）
@@ -170,3 +256,12 @@ func (p *DraftProduct) MarkSyncFailed(err string) {
 	p.SyncStatus = DraftSyncStatusFailed
 	p.SyncError = err
 }
+
+// CanRetrySubmit 是否可以人工重试提交
+func (p *DraftProduct) CanRetrySubmit() bool {
+	switch p.SyncStatus {
+	case DraftSyncStatusFailed, DraftSyncStatusDead, DraftSyncStatusAbandoned:
+		return p.Status == DraftStatusConfirmed
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Notification 站内通知（按用户投递，支持已读/未读）
+type Notification struct {
+	BaseModel
+
+	UserID int64          `gorm:"not null;index:idx_notification_user_read;comment:接收用户ID" json:"user_id"`
+	ShopID int64          `gorm:"index;comment:关联店铺ID(0为无)" json:"shop_id"`
+	Event  string         `gorm:"size:64;not null;index;comment:事件类型" json:"event"`
+	Title  string         `gorm:"size:255;comment:标题" json:"title"`
+	Data   datatypes.JSON `gorm:"type:jsonb;comment:事件数据" json:"data"`
+	ReadAt *time.Time     `gorm:"index:idx_notification_user_read;comment:已读时间" json:"read_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// IsRead 是否已读
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// ==================== 通知事件类型 ====================
+
+const (
+	NotificationEventDraftSubmitted    = "draft_submitted"     // 草稿提交成功
+	NotificationEventDraftSubmitFailed = "draft_submit_failed" // 草稿提交失败（不可重试）
+	NotificationEventDraftSubmitDead   = "draft_submit_dead"   // 草稿提交重试耗尽
+	NotificationEventSyncFailed        = "sync_failed"         // 店铺/商品/订单同步失败
+	NotificationEventTokenExpired      = "token_expired"       // 店铺授权失效
+	NotificationEventShipmentException = "shipment_exception"  // 物流异常
+	NotificationEventAIBudgetWarning   = "ai_budget_warning"   // AI 预算告警
+	NotificationEventDeveloperBanned   = "developer_banned"    // 开发者 Key 被 Etsy 拒绝，已自动封禁
+)
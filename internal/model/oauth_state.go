@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// OAuthState Etsy OAuth 授权流程的临时状态（PKCE verifier 等）
+// 存数据库而非进程内存，回调落在其他实例或服务重启后仍可完成授权；回调时一次性消费
+type OAuthState struct {
+	BaseModel
+
+	State        string `gorm:"size:64;not null;uniqueIndex;comment:OAuth state"`
+	CodeVerifier string `gorm:"size:128;not null;comment:PKCE verifier"`
+
+	ShopID      int64  `gorm:"not null;comment:授权店铺ID"`
+	DeveloperID int64  `gorm:"index;comment:授权使用的开发者ID"`
+	Region      string `gorm:"size:20;comment:店铺地区"`
+	// 授权链接中的 redirect_uri，换 Token 时必须原样提交
+	RedirectURI string `gorm:"size:255;comment:回调地址"`
+	// 发起授权的系统用户（SysUser），0 表示系统发起
+	UserID int64 `gorm:"index;comment:发起人ID"`
+
+	ExpiresAt time.Time `gorm:"index;not null;comment:过期时间"`
+}
+
+func (*OAuthState) TableName() string {
+	return "oauth_states"
+}
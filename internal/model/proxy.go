@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -10,6 +11,16 @@ const (
 	PROXY_SHARED  = 1
 	PROXY_PRIVATE = 0
 )
+
+// 代理类型
+const (
+	ProxyTypeStatic  = "static"  // 固定出口 IP
+	ProxyTypeGateway = "gateway" // 轮换网关：同一入口按用户名中的会话 ID 分配出口，每个店铺一个粘性会话
+)
+
+// ProxySessionPlaceholder 网关用户名模板中的会话占位符，如 "user-abc-session-{session}"
+const ProxySessionPlaceholder = "{session}"
+
 const (
 	PROXY_STATUS_ACTIVE = 1
 	PROXY_STATUS_FAILED = 2
@@ -22,8 +33,13 @@ type Proxy struct {
 	IP       string `gorm:"size:100;not null;index"` // IP 必须索引，防重复录入
 	Port     string `gorm:"size:10;not null"`        // String 类型兼容性更好
 	Username string `gorm:"size:100"`
-	Password string `gorm:"size:255"`
-	Protocol string `gorm:"size:10;default:'http'"` // http, https, socks5
+	Password string `gorm:"type:text;serializer:encrypted"`
+	Protocol string `gorm:"size:10;default:'http'"` // http, https, socks5, socks5h
+
+	// 代理类型：static / gateway；网关的 Username 为会话模板，支持 {session}、{region} 占位符
+	Type string `gorm:"size:16;default:'static';index"`
+	// TLSInsecure 不校验目标站点证书，仅用于会做 TLS 中间人的代理
+	TLSInsecure bool `gorm:"default:false"`
 
 	// 2. 状态管理
 	// status: 1.正常 2.暂时故障 3.彻底凉凉 Task 不再巡检，人工介入
@@ -39,6 +55,27 @@ type Proxy struct {
 
 	// 软删除外的业务开关
 	IsActive bool `gorm:"default:true"`
+
+	// 质量评分（巡检与业务请求结果的滚动窗口统计，巡检时落库）
+	// 未有样本的新代理默认 50 分，分配时优先高分代理
+	Score          float64 `gorm:"default:50;index"`
+	SuccessRate    float64
+	LatencyP50Ms   int
+	LatencyP95Ms   int
+	Recent403      int // 窗口内 Etsy 403 次数
+	Recent429      int // 窗口内 Etsy 429 次数
+	SampleCount    int
+	ScoreUpdatedAt *time.Time
+
+	// 出口 IP 与地理位置（巡检时经 IP 回显接口获取真实出口，本地 GeoIP 库查询）
+	// 出口国家与 Region 不符时标记 GeoMismatch，存在店铺关联风险
+	ExitIP       string `gorm:"size:64;index"`
+	ExitCountry  string `gorm:"size:2"`
+	ExitASN      int64  `gorm:"column:exit_asn"`
+	ExitASOrg    string `gorm:"column:exit_as_org;size:255"`
+	GeoMismatch  bool   `gorm:"default:false;index"`
+	GeoCheckedAt *time.Time
+
 	// 4. 关联关系
 	// 一个代理可以给多个店铺使用（取决于 Capacity）
 	Shops []Shop `gorm:"foreignKey:ProxyID"`
@@ -48,6 +85,22 @@ func (*Proxy) TableName() string {
 	return "proxies"
 }
 
+// IsGateway 是否轮换网关（不受独享 / 共享容量与店铺亲和规则限制，靠会话隔离出口）
+func (p *Proxy) IsGateway() bool {
+	return p.Type == ProxyTypeGateway
+}
+
+// SessionURL 网关代理按会话渲染用户名模板，静态代理等同 ProxyToURL
+func (p *Proxy) SessionURL(session string) (*url.URL, error) {
+	proxyURL, err := p.ProxyToURL()
+	if err != nil || !p.IsGateway() || proxyURL.User == nil {
+		return proxyURL, err
+	}
+	username := strings.NewReplacer(ProxySessionPlaceholder, session, "{region}", strings.ToLower(p.Region)).Replace(p.Username)
+	proxyURL.User = url.UserPassword(username, p.Password)
+	return proxyURL, nil
+}
+
 func (p *Proxy) ProxyToURL() (*url.URL, error) {
 	scheme := p.Protocol
 	if scheme == "" {
@@ -66,3 +119,52 @@ func (p *Proxy) ProxyToURL() (*url.URL, error) {
 
 	return proxyURL, nil
 }
+
+// ProxyScoreSnapshot 代理评分历史（每次巡检落库一条，用于观察评分变化趋势）
+type ProxyScoreSnapshot struct {
+	BaseModel
+
+	ProxyID      int64   `gorm:"index;not null;comment:代理ID" json:"proxy_id"`
+	Score        float64 `gorm:"comment:评分 0-100" json:"score"`
+	SuccessRate  float64 `gorm:"comment:成功率" json:"success_rate"`
+	LatencyP50Ms int     `gorm:"comment:延迟P50(ms)" json:"latency_p50_ms"`
+	LatencyP95Ms int     `gorm:"comment:延迟P95(ms)" json:"latency_p95_ms"`
+	Recent403    int     `gorm:"comment:窗口内403次数" json:"recent_403"`
+	Recent429    int     `gorm:"comment:窗口内429次数" json:"recent_429"`
+	SampleCount  int     `gorm:"comment:样本数" json:"sample_count"`
+}
+
+func (ProxyScoreSnapshot) TableName() string {
+	return "proxy_score_snapshots"
+}
+
+// 代理绑定原因（解绑时记录导致解绑的操作）
+const (
+	ProxyBindReasonShopCreated = "shop_created" // 新店铺授权
+	ProxyBindReasonLazy        = "lazy"         // 首次请求惰性绑定
+	ProxyBindReasonMigrate     = "migrate"      // 代理故障迁移
+	ProxyBindReasonProxyError  = "proxy_error"  // 请求失败上报后解绑（代理未被判定故障前不视为被其他店铺用过）
+	ProxyBindReasonProxyFailed = "proxy_failed" // 请求失败上报后解绑，且代理随后被巡检判定故障
+	ProxyBindReasonLegacy      = "legacy"       // 绑定历史上线前已有的绑定，解绑时补录
+)
+
+// ProxyBinding 店铺与代理的绑定历史
+// 代理被某店铺用过（已解绑）后不再分配给其他店铺，避免 Etsy 通过 IP 把店铺关联起来
+type ProxyBinding struct {
+	BaseModel
+
+	ShopID       int64      `gorm:"index;not null;comment:店铺ID" json:"shop_id"`
+	ProxyID      int64      `gorm:"index;not null;comment:代理ID" json:"proxy_id"`
+	BoundAt      time.Time  `gorm:"not null;comment:绑定时间" json:"bound_at"`
+	BindReason   string     `gorm:"size:32;comment:绑定原因" json:"bind_reason"`
+	UnboundAt    *time.Time `gorm:"index;comment:解绑时间，为空表示当前绑定" json:"unbound_at"`
+	UnbindReason string     `gorm:"size:32;comment:解绑原因" json:"unbind_reason"`
+	// Session 网关代理的粘性会话 ID，重新绑定时更换（换出口 IP）
+	Session string `gorm:"size:64;comment:网关会话ID" json:"session,omitempty"`
+
+	Proxy *Proxy `gorm:"foreignKey:ProxyID" json:"-"`
+}
+
+func (ProxyBinding) TableName() string {
+	return "proxy_bindings"
+}
@@ -61,6 +61,9 @@ type Shipment struct {
 	EtsySyncedAt  *time.Time
 	EtsySyncError string `gorm:"type:text"`
 
+	EtsyReceiptShippingID int64          // Etsy receipt_shipping_id
+	EtsySyncResponse      datatypes.JSON `gorm:"type:jsonb"` // Etsy 原始响应
+
 	// 最后跟踪信息
 	LastTrackingStatus   string `gorm:"size:64"`
 	LastTrackingTime     *time.Time
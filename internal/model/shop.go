@@ -51,20 +51,25 @@ type Shop struct {
 
 	// 6. 基础设施绑定 (外键)
 	// --- 代理关系 ---
-	ProxyID int64  `gorm:"index"`
+	ProxyID int64  `gorm:"index;default:null;comment:未绑定时为 NULL"`
 	Proxy   *Proxy `gorm:"foreignKey:ProxyID"`
 
 	// --- 开发者账号关系 ---
-	DeveloperID int64      `gorm:"index"`
+	DeveloperID int64      `gorm:"index;default:null;comment:未绑定时为 NULL"`
 	Developer   *Developer `gorm:"foreignKey:DeveloperID"`
 
 	// 7. API Token
 	// 周期检测 token 是否过期
 	TokenStatus    string    `gorm:"index;size:20;default:'auth_invalid'"`
-	AccessToken    string    `gorm:"size:255"`
-	RefreshToken   string    `gorm:"size:255"`
+	AccessToken    string    `gorm:"type:text;serializer:encrypted"`
+	RefreshToken   string    `gorm:"type:text;serializer:encrypted"`
 	TokenExpiresAt time.Time // Token 具体的过期时间点
 
+	// 8. AI 配置（为空时使用系统默认）
+	AIProvider   string `gorm:"size:32;comment:AI Provider"`
+	AITextModel  string `gorm:"size:64;comment:AI 文本模型"`
+	AIImageModel string `gorm:"size:64;comment:AI 图片模型"`
+
 	// 6. 关联关系
 
 	// 1. 账号敏感数据 (Has One)
@@ -91,13 +96,13 @@ type ShopAccount struct {
 	ShopID        int64  `gorm:"index;not null"`
 	Shop          *Shop  `gorm:"foreignKey:ShopID"`
 	LoginEmail    string `gorm:"size:100"`
-	LoginPwd      string `gorm:"size:255"` // 加密
-	RecoveryEmail string `gorm:"size:100"` // 辅助邮箱
-	TwoFASecret   string `gorm:"size:100"` // 2FA 密钥 (OTP)
+	LoginPwd      string `gorm:"type:text;serializer:encrypted"`
+	RecoveryEmail string `gorm:"size:100"`                       // 辅助邮箱
+	TwoFASecret   string `gorm:"type:text;serializer:encrypted"` // 2FA 密钥 (OTP)
 
 	// --- 指纹环境 ---
 	UserAgent string `gorm:"type:text"`
-	Cookies   string `gorm:"type:text"` // 加密
+	Cookies   string `gorm:"type:text;serializer:encrypted"`
 
 	// 备注
 	Note string `gorm:"type:text"`
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// StockItem 跨店铺主库存（按 ERP 内部 SKU 归集）
+// 同一实物在多个店铺上架时，Product / ProductVariant 的 LocalSKU 指向同一条主库存，
+// 库存变动后统一推送到所有关联 listing，避免超卖
+type StockItem struct {
+	BaseModel
+
+	LocalSKU string `gorm:"size:100;not null;uniqueIndex;comment:ERP内部SKU" json:"local_sku"`
+	Name     string `gorm:"size:255;comment:名称" json:"name"`
+
+	// 现货数量，订单并发扣减后可能为负（表示已超卖数量）
+	OnHand int `gorm:"not null;default:0;comment:现货数量" json:"on_hand"`
+	// 每次变动 +1，人工盘点按版本号做乐观锁
+	Version int64 `gorm:"not null;default:0;comment:版本号" json:"version"`
+
+	// 最近一次推送到关联 listing 的结果
+	LastPushedAt  *time.Time `gorm:"comment:最近推送时间" json:"last_pushed_at"`
+	LastPushError string     `gorm:"size:500;comment:最近推送错误" json:"last_push_error"`
+}
+
+func (StockItem) TableName() string {
+	return "stock_items"
+}
+
+// StockMovement 库存流水（审计）
+type StockMovement struct {
+	BaseModel
+
+	StockItemID int64  `gorm:"index;not null;comment:主库存ID" json:"stock_item_id"`
+	LocalSKU    string `gorm:"size:100;index;comment:ERP内部SKU" json:"local_sku"`
+
+	Delta  int `gorm:"not null;comment:变动数量" json:"delta"`
+	Before int `gorm:"not null;comment:变动前数量" json:"before"`
+	After  int `gorm:"not null;comment:变动后数量" json:"after"`
+
+	Reason string `gorm:"size:20;index;comment:原因 order/adjust/count" json:"reason"`
+	// 来源单据，如订单扣减时为 Etsy transaction_id
+	RefType string `gorm:"size:20;comment:来源类型" json:"ref_type"`
+	RefID   int64  `gorm:"index;comment:来源ID" json:"ref_id"`
+	ShopID  int64  `gorm:"index;comment:来源店铺" json:"shop_id"`
+	Note    string `gorm:"size:255;comment:备注" json:"note"`
+
+	// 幂等键（同一来源只记一次），人工操作为空
+	IdempotencyKey *string `gorm:"size:100;uniqueIndex;comment:幂等键" json:"-"`
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+// ==================== 库存流水原因 ====================
+
+const (
+	StockReasonOrder  = "order"  // 订单扣减
+	StockReasonAdjust = "adjust" // 人工增减
+	StockReasonCount  = "count"  // 盘点（直接设置数量）
+)
+
+// StockRefTypeEtsyTransaction 流水来源：Etsy 订单交易
+const StockRefTypeEtsyTransaction = "etsy_transaction"
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// SyncResource 增量同步资源类型
+const (
+	SyncResourceOrders = "orders" // Etsy Receipts
+)
+
+// SyncState 状态常量
+const (
+	SyncStateIdle    = "idle"    // 空闲
+	SyncStateRunning = "running" // 同步中
+	SyncStateFailed  = "failed"  // 上次失败（下次从检查点续传）
+)
+
+// SyncState 店铺级增量同步检查点
+// 每个店铺每种资源一行，Cursor 为已完整处理的最高 last_modified（Unix 秒）
+type SyncState struct {
+	BaseModel
+	ShopID   int64  `gorm:"uniqueIndex:idx_sync_state_shop_resource;not null"`
+	Resource string `gorm:"uniqueIndex:idx_sync_state_shop_resource;size:32;not null"`
+
+	// 高水位：下次同步使用 min_last_modified = Cursor
+	Cursor int64 `gorm:"default:0"`
+
+	// 运行状态
+	Status    string `gorm:"size:20;default:'idle'"`
+	LastError string `gorm:"type:text"`
+
+	// 统计
+	LastFetched    int `gorm:"default:0"`
+	LastStartedAt  *time.Time
+	LastFinishedAt *time.Time
+	LastFullSyncAt *time.Time
+}
+
+func (*SyncState) TableName() string {
+	return "sync_states"
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// ==================== 仓储接口 ====================
+
+// AIBudgetFilter 预算列表过滤条件
+type AIBudgetFilter struct {
+	Scope string
+
+	// ShopIDs 非 nil 时只返回默认预算、这些店铺的预算和 UserID 本人的预算
+	ShopIDs []int64
+	UserID  int64
+}
+
+// AIBudgetRepository AI 预算仓储接口
+type AIBudgetRepository interface {
+	List(ctx context.Context, filter AIBudgetFilter) ([]model.AIBudget, error)
+	// GetByScope 获取预算，不存在时返回 nil
+	GetByScope(ctx context.Context, scope string, scopeID int64) (*model.AIBudget, error)
+	// Upsert 按 (scope, scope_id) 创建或覆盖限额配置
+	Upsert(ctx context.Context, budget *model.AIBudget) error
+	// CreateIfAbsent 按 (scope, scope_id) 创建，已存在时不做修改
+	CreateIfAbsent(ctx context.Context, budget *model.AIBudget) error
+	UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error
+	Delete(ctx context.Context, scope string, scopeID int64) error
+}
+
+// ==================== 仓储实现 ====================
+
+type aiBudgetRepo struct {
+	db *gorm.DB
+}
+
+// NewAIBudgetRepository 创建 AI 预算仓储
+func NewAIBudgetRepository(db *gorm.DB) AIBudgetRepository {
+	return &aiBudgetRepo{db: db}
+}
+
+func (r *aiBudgetRepo) List(ctx context.Context, filter AIBudgetFilter) ([]model.AIBudget, error) {
+	var budgets []model.AIBudget
+	query := r.db.WithContext(ctx).Model(&model.AIBudget{})
+	if filter.Scope != "" {
+		query = query.Where("scope = ?", filter.Scope)
+	}
+	if filter.ShopIDs != nil {
+		visible := r.db.Where("scope_id = 0").
+			Or("scope = ? AND scope_id = ?", model.AIBudgetScopeUser, filter.UserID)
+		if len(filter.ShopIDs) > 0 {
+			visible = visible.Or("scope = ? AND scope_id IN ?", model.AIBudgetScopeShop, filter.ShopIDs)
+		}
+		query = query.Where(visible)
+	}
+	err := query.Order("scope ASC, scope_id ASC").Find(&budgets).Error
+	return budgets, err
+}
+
+func (r *aiBudgetRepo) GetByScope(ctx context.Context, scope string, scopeID int64) (*model.AIBudget, error) {
+	var budget model.AIBudget
+	err := r.db.WithContext(ctx).
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		First(&budget).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *aiBudgetRepo) Upsert(ctx context.Context, budget *model.AIBudget) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}, {Name: "scope_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"daily_limit_usd", "monthly_limit_usd", "warn_percents", "enabled", "updated_at", "updated_by",
+		}),
+	}).Create(budget).Error
+}
+
+func (r *aiBudgetRepo) CreateIfAbsent(ctx context.Context, budget *model.AIBudget) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "scope"}, {Name: "scope_id"}},
+		DoNothing: true,
+	}).Create(budget).Error
+}
+
+func (r *aiBudgetRepo) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.AIBudget{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *aiBudgetRepo) Delete(ctx context.Context, scope string, scopeID int64) error {
+	// 物理删除，避免唯一索引与软删除记录冲突
+	return r.db.WithContext(ctx).Unscoped().
+		Where("scope = ? AND scope_id = ?", scope, scopeID).
+		Delete(&model.AIBudget{}).Error
+}
@@ -19,8 +19,18 @@ type AICallLogRepository interface {
 	// 统计查询
 	GetUsageByShop(ctx context.Context, shopID int64, startTime, endTime time.Time) (*AIUsageStats, error)
 	GetUsageByTask(ctx context.Context, taskID int64) (*AIUsageStats, error)
-	GetDailyUsage(ctx context.Context, startDate, endDate time.Time) ([]DailyUsageStats, error)
-	GetTotalCost(ctx context.Context, startTime, endTime time.Time) (float64, error)
+	// shopIDs 为可见店铺范围，nil 表示不限制
+	GetDailyUsage(ctx context.Context, startDate, endDate time.Time, shopIDs []int64) ([]DailyUsageStats, error)
+	GetTotalCost(ctx context.Context, startTime, endTime time.Time, shopIDs []int64) (float64, error)
+	// GetCost 按店铺/用户汇总成本（预算检查用）
+	GetCost(ctx context.Context, filter AICostFilter) (float64, error)
+}
+
+// AICostFilter 成本汇总条件（零值字段不参与过滤）
+type AICostFilter struct {
+	ShopID    int64
+	UserID    int64
+	StartTime time.Time
 }
 
 // ==================== 统计结构 ====================
@@ -119,12 +129,16 @@ func (r *aiCallLogRepo) GetUsageByTask(ctx context.Context, taskID int64) (*AIUs
 	return &stats, err
 }
 
-func (r *aiCallLogRepo) GetDailyUsage(ctx context.Context, startDate, endDate time.Time) ([]DailyUsageStats, error) {
+func (r *aiCallLogRepo) GetDailyUsage(ctx context.Context, startDate, endDate time.Time, shopIDs []int64) ([]DailyUsageStats, error) {
 	var stats []DailyUsageStats
 
-	err := r.db.WithContext(ctx).Model(&model.AICallLog{}).
-		Where("created_at >= ? AND created_at <= ?", startDate, endDate).
-		Select(`
+	query := r.db.WithContext(ctx).Model(&model.AICallLog{}).
+		Where("created_at >= ? AND created_at <= ?", startDate, endDate)
+	if shopIDs != nil {
+		query = query.Where("shop_id IN ?", shopIDs)
+	}
+
+	err := query.Select(`
 			DATE(created_at) as date,
 			COUNT(*) as total_calls,
 			COALESCE(SUM(cost_usd), 0) as total_cost_usd,
@@ -138,10 +152,13 @@ func (r *aiCallLogRepo) GetDailyUsage(ctx context.Context, startDate, endDate ti
 	return stats, err
 }
 
-func (r *aiCallLogRepo) GetTotalCost(ctx context.Context, startTime, endTime time.Time) (float64, error) {
+func (r *aiCallLogRepo) GetTotalCost(ctx context.Context, startTime, endTime time.Time, shopIDs []int64) (float64, error) {
 	var totalCost float64
 
 	query := r.db.WithContext(ctx).Model(&model.AICallLog{})
+	if shopIDs != nil {
+		query = query.Where("shop_id IN ?", shopIDs)
+	}
 	if !startTime.IsZero() {
 		query = query.Where("created_at >= ?", startTime)
 	}
@@ -152,3 +169,21 @@ func (r *aiCallLogRepo) GetTotalCost(ctx context.Context, startTime, endTime tim
 	err := query.Select("COALESCE(SUM(cost_usd), 0)").Scan(&totalCost).Error
 	return totalCost, err
 }
+
+func (r *aiCallLogRepo) GetCost(ctx context.Context, filter AICostFilter) (float64, error) {
+	var cost float64
+
+	query := r.db.WithContext(ctx).Model(&model.AICallLog{})
+	if filter.ShopID > 0 {
+		query = query.Where("shop_id = ?", filter.ShopID)
+	}
+	if filter.UserID > 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if !filter.StartTime.IsZero() {
+		query = query.Where("created_at >= ?", filter.StartTime)
+	}
+
+	err := query.Select("COALESCE(SUM(cost_usd), 0)").Scan(&cost).Error
+	return cost, err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -24,15 +25,30 @@ type DeveloperRepository interface {
 	// 查询
 	FindByApiKey(ctx context.Context, apiKey string) (*model.Developer, error)
 	FindByCallbackURL(ctx context.Context, callbackURL string) (*model.Developer, error)
+	// FindByCallbackPath 按回调路径查找开发者，不存在时返回 nil
+	FindByCallbackPath(ctx context.Context, path string) (*model.Developer, error)
 	FindBestDev(ctx context.Context) (*model.Developer, error)
+	// FindBestDevForRegion 同地区可接收店铺的启用开发者（未满额且没有其他地区店铺），优先负载最低
+	FindBestDevForRegion(ctx context.Context, region string, excludeID int64) (*model.Developer, error)
 
 	// 关联操作
 	UnbindShops(ctx context.Context, developerID int64) error
 
+	// Etsy 配额
+	UpdateQuotaByApiKey(ctx context.Context, apiKey string, fields map[string]interface{}) error
+	// ListLowQuotaIDs since 之后更新过、每日剩余配额不高于 ratio 的开发者
+	ListLowQuotaIDs(ctx context.Context, ratio float64, since time.Time) ([]int64, error)
+
 	// 域名池
+	// GetRandomActiveDomain 随机取一个启用且未达到开发者上限的域名
 	GetRandomActiveDomain(ctx context.Context) (*model.DomainPool, error)
+	// CallbackTaken 子域名（同一域名下）或回调路径是否已被占用，含已删除的开发者
+	CallbackTaken(ctx context.Context, domainID int64, subDomain, callbackPath string) (bool, error)
 }
 
+// MaxShopsPerDeveloper 单个开发者 Key 最多授权的店铺数（防关联）
+const MaxShopsPerDeveloper = 2
+
 // ==================== 过滤条件 ====================
 
 // DeveloperFilter 开发者过滤条件
@@ -137,6 +153,22 @@ func (r *developerRepo) FindByApiKey(ctx context.Context, apiKey string) (*model
 	return &dev, nil
 }
 
+func (r *developerRepo) UpdateQuotaByApiKey(ctx context.Context, apiKey string, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.Developer{}).
+		Where("api_key = ?", apiKey).
+		UpdateColumns(fields).Error
+}
+
+func (r *developerRepo) ListLowQuotaIDs(ctx context.Context, ratio float64, since time.Time) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).Model(&model.Developer{}).
+		Where("quota_per_day > 0 AND quota_remaining_today >= 0").
+		Where("quota_remaining_today <= quota_per_day * ?", ratio).
+		Where("quota_updated_at > ?", since).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
 func (r *developerRepo) FindByCallbackURL(ctx context.Context, callbackURL string) (*model.Developer, error) {
 	var dev model.Developer
 	err := r.db.WithContext(ctx).
@@ -151,6 +183,20 @@ func (r *developerRepo) FindByCallbackURL(ctx context.Context, callbackURL strin
 	return &dev, nil
 }
 
+func (r *developerRepo) FindByCallbackPath(ctx context.Context, path string) (*model.Developer, error) {
+	var dev model.Developer
+	err := r.db.WithContext(ctx).
+		Where("callback_path = ?", path).
+		First(&dev).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dev, nil
+}
+
 func (r *developerRepo) FindBestDev(ctx context.Context) (*model.Developer, error) {
 	var dev model.Developer
 	err := r.db.WithContext(ctx).
@@ -159,7 +205,7 @@ func (r *developerRepo) FindBestDev(ctx context.Context) (*model.Developer, erro
 		Joins("LEFT JOIN shops ON shops.developer_id = developers.id").
 		Where("developers.status != ?", model.DeveloperStatusBanned).
 		Group("developers.id").
-		Having("COUNT(developers.id) < ?", 2).
+		Having("COUNT(developers.id) < ?", MaxShopsPerDeveloper).
 		Order("COUNT(developers.id) ASC").
 		Take(&dev).Error
 	if err != nil {
@@ -168,20 +214,46 @@ func (r *developerRepo) FindBestDev(ctx context.Context) (*model.Developer, erro
 	return &dev, nil
 }
 
+func (r *developerRepo) FindBestDevForRegion(ctx context.Context, region string, excludeID int64) (*model.Developer, error) {
+	var dev model.Developer
+	shopCount := r.db.Model(&model.Shop{}).
+		Select("COUNT(*)").
+		Where("shops.developer_id = developers.id")
+	otherRegion := r.db.Model(&model.Shop{}).
+		Select("1").
+		Where("shops.developer_id = developers.id AND shops.region <> ?", region)
+	err := r.db.WithContext(ctx).
+		Model(&model.Developer{}).
+		Select("developers.*, (?) AS shop_count", shopCount).
+		Where("status = ? AND id <> ?", model.DeveloperStatusActive, excludeID).
+		Where("(?) < ?", shopCount, MaxShopsPerDeveloper).
+		Where("NOT EXISTS (?)", otherRegion).
+		Order("shop_count ASC, id ASC").
+		Take(&dev).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dev, nil
+}
+
 func (r *developerRepo) UnbindShops(ctx context.Context, developerID int64) error {
 	return r.db.WithContext(ctx).
 		Model(&model.Shop{}).
 		Where("developer_id = ?", developerID).
 		Updates(map[string]interface{}{
-			"developer_id": 0,
+			"developer_id": nil,
 			"token_status": model.ShopTokenStatusInvalid,
 		}).Error
 }
 
 func (r *developerRepo) GetRandomActiveDomain(ctx context.Context) (*model.DomainPool, error) {
 	var domain model.DomainPool
+	developers := r.db.Model(&model.Developer{}).
+		Select("COUNT(*)").
+		Where("developers.domain_pool_id = domain_pools.id")
 	err := r.db.WithContext(ctx).
 		Where("is_active = ?", true).
+		Where("max_developers = 0 OR max_developers > (?)", developers).
 		Order("RANDOM()").
 		Take(&domain).Error
 	if err != nil {
@@ -189,3 +261,11 @@ func (r *developerRepo) GetRandomActiveDomain(ctx context.Context) (*model.Domai
 	}
 	return &domain, nil
 }
+
+func (r *developerRepo) CallbackTaken(ctx context.Context, domainID int64, subDomain, callbackPath string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.Developer{}).
+		Where("(domain_pool_id = ? AND LOWER(sub_domain) = LOWER(?)) OR callback_path = ?", domainID, subDomain, callbackPath).
+		Count(&count).Error
+	return count > 0, err
+}
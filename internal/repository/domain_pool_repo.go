@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// ==================== 接口定义 ====================
+
+// DomainPoolRepository 回调域名池仓储接口
+type DomainPoolRepository interface {
+	Create(ctx context.Context, domain *model.DomainPool) error
+	GetByID(ctx context.Context, id int64) (*model.DomainPool, error)
+	// GetByHost 按域名查找（含已删除，域名唯一），不存在时返回 nil
+	GetByHost(ctx context.Context, host string) (*model.DomainPool, error)
+	List(ctx context.Context) ([]model.DomainPool, error)
+	UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error
+	Delete(ctx context.Context, id int64) error
+
+	// CountDevelopers 各域名下的开发者数量（domain_id -> count）
+	CountDevelopers(ctx context.Context, domainIDs []int64) (map[int64]int64, error)
+	ListDevelopers(ctx context.Context, domainID int64) ([]model.Developer, error)
+}
+
+// ==================== 仓储实现 ====================
+
+type domainPoolRepo struct {
+	db *gorm.DB
+}
+
+// NewDomainPoolRepository 创建回调域名池仓储
+func NewDomainPoolRepository(db *gorm.DB) DomainPoolRepository {
+	return &domainPoolRepo{db: db}
+}
+
+func (r *domainPoolRepo) Create(ctx context.Context, domain *model.DomainPool) error {
+	return r.db.WithContext(ctx).Create(domain).Error
+}
+
+func (r *domainPoolRepo) GetByID(ctx context.Context, id int64) (*model.DomainPool, error) {
+	var domain model.DomainPool
+	if err := r.db.WithContext(ctx).First(&domain, id).Error; err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *domainPoolRepo) GetByHost(ctx context.Context, host string) (*model.DomainPool, error) {
+	var domain model.DomainPool
+	err := r.db.WithContext(ctx).Unscoped().Where("host = ?", host).First(&domain).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *domainPoolRepo) List(ctx context.Context) ([]model.DomainPool, error) {
+	var list []model.DomainPool
+	err := r.db.WithContext(ctx).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *domainPoolRepo) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.DomainPool{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *domainPoolRepo) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&model.DomainPool{}, id).Error
+}
+
+func (r *domainPoolRepo) CountDevelopers(ctx context.Context, domainIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(domainIDs))
+	if len(domainIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		DomainPoolID int64
+		Count        int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Developer{}).
+		Select("domain_pool_id, COUNT(*) AS count").
+		Where("domain_pool_id IN ?", domainIDs).
+		Group("domain_pool_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.DomainPoolID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *domainPoolRepo) ListDevelopers(ctx context.Context, domainID int64) ([]model.Developer, error) {
+	var list []model.Developer
+	err := r.db.WithContext(ctx).
+		Where("domain_pool_id = ?", domainID).
+		Order("id ASC").
+		Find(&list).Error
+	return list, err
+}
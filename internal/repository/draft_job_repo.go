@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// ErrDraftJobLeaseLost 租约已失效（过期后被其他 Worker 领取）
+var ErrDraftJobLeaseLost = errors.New("draft job lease lost")
+
+// ErrDraftJobLeaseExhausted 租约过期时已达最大执行次数（Worker 反复崩溃或丢失租约）
+var ErrDraftJobLeaseExhausted = errors.New("执行中断且已达最大执行次数")
+
+// ==================== 仓储接口 ====================
+
+// DraftJobRepository 草稿任务队列仓储接口
+type DraftJobRepository interface {
+	Create(ctx context.Context, job *model.DraftJob) error
+	GetByTaskID(ctx context.Context, taskID int64) (*model.DraftJob, error)
+
+	// Acquire 领取到期的任务（含租约已过期且未达最大执行次数的运行中任务），并发 Worker 间互斥
+	Acquire(ctx context.Context, owner string, lease time.Duration, limit int) ([]model.DraftJob, error)
+	// Heartbeat 续租，租约已不属于 owner 时返回 ErrDraftJobLeaseLost
+	Heartbeat(ctx context.Context, id int64, owner string, lease time.Duration) error
+	UpdateStage(ctx context.Context, id int64, owner, stage string) error
+	Complete(ctx context.Context, id int64, owner string) error
+	// Retry 释放租约并在 nextRunAt 后重新排队
+	Retry(ctx context.Context, id int64, owner, errMsg string, nextRunAt time.Time) error
+	// Kill 标记为放弃（不再重试）
+	Kill(ctx context.Context, id int64, owner, errMsg string) error
+
+	// RecoverExpired 租约已过期的运行中任务：未达最大执行次数的重新排队，已达上限的标记放弃并返回
+	RecoverExpired(ctx context.Context) (requeued int64, dead []model.DraftJob, err error)
+}
+
+// DraftTaskEventRepository 草稿任务进度事件仓储接口
+type DraftTaskEventRepository interface {
+	Create(ctx context.Context, event *model.DraftTaskEvent) error
+	// ListAfter 按 ID 升序返回 afterID 之后的事件
+	ListAfter(ctx context.Context, taskID, afterID int64) ([]model.DraftTaskEvent, error)
+	DeleteByTaskID(ctx context.Context, taskID int64) error
+}
+
+// ==================== DraftJob 仓储实现 ====================
+
+type draftJobRepo struct {
+	db *gorm.DB
+}
+
+// NewDraftJobRepository 创建草稿任务队列仓储
+func NewDraftJobRepository(db *gorm.DB) DraftJobRepository {
+	return &draftJobRepo{db: db}
+}
+
+func (r *draftJobRepo) Create(ctx context.Context, job *model.DraftJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *draftJobRepo) GetByTaskID(ctx context.Context, taskID int64) (*model.DraftJob, error) {
+	var job model.DraftJob
+	err := r.db.WithContext(ctx).Where("task_id = ?", taskID).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *draftJobRepo) Acquire(ctx context.Context, owner string, lease time.Duration, limit int) ([]model.DraftJob, error) {
+	var jobs []model.DraftJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_run_at <= ?) OR (status = ? AND lease_expires_at < ? AND attempts < max_attempts)",
+				model.DraftJobStatusQueued, now, model.DraftJobStatusRunning, now).
+			Order("next_run_at ASC").
+			Limit(limit).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+		}
+
+		expiresAt := now.Add(lease)
+		if err := tx.Model(&model.DraftJob{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":           model.DraftJobStatusRunning,
+			"lease_owner":      owner,
+			"lease_expires_at": expiresAt,
+			"heartbeat_at":     now,
+			"attempts":         gorm.Expr("attempts + 1"),
+		}).Error; err != nil {
+			return err
+		}
+
+		for i := range jobs {
+			jobs[i].Status = model.DraftJobStatusRunning
+			jobs[i].LeaseOwner = owner
+			jobs[i].LeaseExpiresAt = &expiresAt
+			jobs[i].HeartbeatAt = &now
+			jobs[i].Attempts++
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+func (r *draftJobRepo) Heartbeat(ctx context.Context, id int64, owner string, lease time.Duration) error {
+	now := time.Now()
+	return r.updateLeased(ctx, id, owner, map[string]interface{}{
+		"heartbeat_at":     now,
+		"lease_expires_at": now.Add(lease),
+	})
+}
+
+func (r *draftJobRepo) UpdateStage(ctx context.Context, id int64, owner, stage string) error {
+	return r.updateLeased(ctx, id, owner, map[string]interface{}{"stage": stage})
+}
+
+func (r *draftJobRepo) Complete(ctx context.Context, id int64, owner string) error {
+	return r.updateLeased(ctx, id, owner, map[string]interface{}{
+		"status":           model.DraftJobStatusSucceeded,
+		"stage":            model.DraftJobStageDone,
+		"last_error":       "",
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+		"finished_at":      time.Now(),
+	})
+}
+
+func (r *draftJobRepo) Retry(ctx context.Context, id int64, owner, errMsg string, nextRunAt time.Time) error {
+	return r.updateLeased(ctx, id, owner, map[string]interface{}{
+		"status":           model.DraftJobStatusQueued,
+		"next_run_at":      nextRunAt,
+		"last_error":       errMsg,
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+	})
+}
+
+func (r *draftJobRepo) Kill(ctx context.Context, id int64, owner, errMsg string) error {
+	return r.updateLeased(ctx, id, owner, map[string]interface{}{
+		"status":           model.DraftJobStatusDead,
+		"last_error":       errMsg,
+		"lease_owner":      "",
+		"lease_expires_at": nil,
+		"finished_at":      time.Now(),
+	})
+}
+
+func (r *draftJobRepo) RecoverExpired(ctx context.Context) (int64, []model.DraftJob, error) {
+	var requeued int64
+	var dead []model.DraftJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// 反复崩溃或丢失租约的任务不再领取，避免无限重跑
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND lease_expires_at < ? AND attempts >= max_attempts", model.DraftJobStatusRunning, now).
+			Find(&dead).Error
+		if err != nil {
+			return err
+		}
+		if len(dead) > 0 {
+			ids := make([]int64, len(dead))
+			for i := range dead {
+				ids[i] = dead[i].ID
+			}
+			if err := tx.Model(&model.DraftJob{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"status":           model.DraftJobStatusDead,
+				"last_error":       ErrDraftJobLeaseExhausted.Error(),
+				"lease_owner":      "",
+				"lease_expires_at": nil,
+				"finished_at":      now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&model.DraftJob{}).
+			Where("status = ? AND lease_expires_at < ?", model.DraftJobStatusRunning, now).
+			Updates(map[string]interface{}{
+				"status":           model.DraftJobStatusQueued,
+				"next_run_at":      now,
+				"lease_owner":      "",
+				"lease_expires_at": nil,
+			})
+		requeued = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return requeued, dead, nil
+}
+
+// updateLeased 仅在 owner 仍持有租约时更新
+func (r *draftJobRepo) updateLeased(ctx context.Context, id int64, owner string, fields map[string]interface{}) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.DraftJob{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", id, model.DraftJobStatusRunning, owner).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDraftJobLeaseLost
+	}
+	return nil
+}
+
+// ==================== DraftTaskEvent 仓储实现 ====================
+
+type draftTaskEventRepo struct {
+	db *gorm.DB
+}
+
+// NewDraftTaskEventRepository 创建进度事件仓储
+func NewDraftTaskEventRepository(db *gorm.DB) DraftTaskEventRepository {
+	return &draftTaskEventRepo{db: db}
+}
+
+func (r *draftTaskEventRepo) Create(ctx context.Context, event *model.DraftTaskEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *draftTaskEventRepo) ListAfter(ctx context.Context, taskID, afterID int64) ([]model.DraftTaskEvent, error) {
+	var events []model.DraftTaskEvent
+	err := r.db.WithContext(ctx).
+		Where("task_id = ? AND id > ?", taskID, afterID).
+		Order("id ASC").
+		Find(&events).Error
+	return events, err
+}
+
+func (r *draftTaskEventRepo) DeleteByTaskID(ctx context.Context, taskID int64) error {
+	return r.db.WithContext(ctx).Where("task_id = ?", taskID).Delete(&model.DraftTaskEvent{}).Error
+}
@@ -24,6 +24,9 @@ type DraftTaskRepository interface {
 	// 过期清理相关
 	FindExpired(ctx context.Context, before time.Time) ([]model.DraftTask, error)
 	MarkExpired(ctx context.Context, id int64) error
+
+	// FindOrphaned 查找处于待处理/处理中但没有队列记录的任务（队列上线前创建的任务）
+	FindOrphaned(ctx context.Context) ([]model.DraftTask, error)
 }
 
 // DraftProductRepository 草稿商品仓储接口
@@ -40,13 +43,35 @@ type DraftProductRepository interface {
 
 	// 提交任务相关
 	FindPendingSubmit(ctx context.Context, limit int) ([]model.DraftProduct, error)
+	// ClaimSubmit 领取待提交草稿：尝试次数 +1，并以 next_submit_at 作为处理中租约，返回是否领取成功
+	ClaimSubmit(ctx context.Context, id int64, leaseUntil time.Time) (bool, error)
 	UpdateSyncStatus(ctx context.Context, id int64, status int) error
 	MarkSubmitted(ctx context.Context, id int64, listingID int64) error
 	MarkFailed(ctx context.Context, id int64, errMsg string) error
+	MarkDead(ctx context.Context, id int64, errMsg string) error
+	ScheduleRetry(ctx context.Context, id int64, errMsg string, nextAt time.Time) error
+	// ResetSubmit 重新加入提交队列（清零尝试次数）
+	ResetSubmit(ctx context.Context, id int64) error
+	FindSubmitFailed(ctx context.Context, filter SubmitFailedFilter) ([]model.DraftProduct, int64, error)
 	UpdateProductID(ctx context.Context, id int64, productID int64) error
+	// UpdateListingID Listing 创建后立即记录，重试时复用
+	UpdateListingID(ctx context.Context, id int64, listingID int64) error
 	DeleteByTaskID(ctx context.Context, taskID int64) error
 }
 
+// DraftImageUploadRepository 草稿图片上传记录仓储接口
+type DraftImageUploadRepository interface {
+	GetByDraftProductID(ctx context.Context, draftProductID int64) ([]model.DraftImageUpload, error)
+	Create(ctx context.Context, upload *model.DraftImageUpload) error
+	// ResetSource 更换未上传成功记录的源图片
+	ResetSource(ctx context.Context, id int64, sourceURL string) error
+	// MarkEtsyUploaded 记录已上传到 Etsy 的图片（尚未关联 ProductImage，重试时不再重复上传）
+	MarkEtsyUploaded(ctx context.Context, id int64, etsyImageID int64, etsyImageURL string) error
+	MarkUploaded(ctx context.Context, id int64, etsyImageID, productImageID int64) error
+	MarkFailed(ctx context.Context, id int64, errMsg string) error
+	DeleteByDraftProductID(ctx context.Context, draftProductID int64) error
+}
+
 // DraftImageRepository 草稿图片仓储接口
 type DraftImageRepository interface {
 	Create(ctx context.Context, image *model.DraftImage) error
@@ -69,6 +94,19 @@ type TaskFilter struct {
 	AIStatus string
 	Page     int
 	PageSize int
+
+	// ShopIDs 非 nil 时只返回 UserID 创建的或包含这些店铺草稿的任务
+	ShopIDs []int64
+}
+
+// SubmitFailedFilter 提交失败草稿过滤条件
+type SubmitFailedFilter struct {
+	ShopID     int64
+	SyncStatus int // 0 表示失败 + 死信
+	Page       int
+	PageSize   int
+
+	ShopIDs []int64 // 可见店铺范围，nil 表示不限制
 }
 
 // ==================== DraftTask 仓储实现 ====================
@@ -112,7 +150,10 @@ func (r *draftTaskRepo) List(ctx context.Context, filter TaskFilter) ([]model.Dr
 
 	query := r.db.WithContext(ctx).Model(&model.DraftTask{})
 
-	if filter.UserID > 0 {
+	if filter.ShopIDs != nil {
+		shopTasks := r.db.Model(&model.DraftProduct{}).Select("task_id").Where("shop_id IN ?", filter.ShopIDs)
+		query = query.Where("user_id = ? OR id IN (?)", filter.UserID, shopTasks)
+	} else if filter.UserID > 0 {
 		query = query.Where("user_id = ?", filter.UserID)
 	}
 	if filter.Status != "" {
@@ -170,6 +211,17 @@ func (r *draftTaskRepo) MarkExpired(ctx context.Context, id int64) error {
 		Update("status", model.TaskStatusExpired).Error
 }
 
+// FindOrphaned 查找没有队列记录的未完成任务
+func (r *draftTaskRepo) FindOrphaned(ctx context.Context) ([]model.DraftTask, error) {
+	var tasks []model.DraftTask
+	err := r.db.WithContext(ctx).
+		Model(&model.DraftTask{}).
+		Where("status IN ?", []string{model.TaskStatusPending, model.TaskStatusProcessing}).
+		Where("NOT EXISTS (SELECT 1 FROM draft_jobs j WHERE j.task_id = draft_tasks.id)").
+		Find(&tasks).Error
+	return tasks, err
+}
+
 // ==================== DraftProduct 仓储实现 ====================
 
 type draftProductRepo struct {
@@ -241,11 +293,26 @@ func (r *draftProductRepo) FindPendingSubmit(ctx context.Context, limit int) ([]
 	err := r.db.WithContext(ctx).
 		Model(&model.DraftProduct{}).
 		Where("status = ? AND sync_status = ?", model.DraftStatusConfirmed, model.DraftSyncStatusPending).
+		Where("next_submit_at IS NULL OR next_submit_at <= ?", time.Now()).
+		Order("next_submit_at ASC NULLS FIRST, id ASC").
 		Limit(limit).
 		Find(&products).Error
 	return products, err
 }
 
+// ClaimSubmit 领取待提交草稿（条件更新，多实例/多轮次间互斥）
+func (r *draftProductRepo) ClaimSubmit(ctx context.Context, id int64, leaseUntil time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.DraftProduct{}).
+		Where("id = ? AND status = ? AND sync_status = ?", id, model.DraftStatusConfirmed, model.DraftSyncStatusPending).
+		Where("next_submit_at IS NULL OR next_submit_at <= ?", time.Now()).
+		Updates(map[string]interface{}{
+			"submit_attempts": gorm.Expr("submit_attempts + 1"),
+			"next_submit_at":  leaseUntil,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
 // UpdateSyncStatus 更新同步状态
 func (r *draftProductRepo) UpdateSyncStatus(ctx context.Context, id int64, status int) error {
 	return r.db.WithContext(ctx).
@@ -260,10 +327,11 @@ func (r *draftProductRepo) MarkSubmitted(ctx context.Context, id int64, listingI
 		Model(&model.DraftProduct{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"status":      model.DraftStatusSubmitted,
-			"sync_status": model.DraftSyncStatusDone,
-			"listing_id":  listingID,
-			"sync_error":  "",
+			"status":         model.DraftStatusSubmitted,
+			"sync_status":    model.DraftSyncStatusDone,
+			"listing_id":     listingID,
+			"sync_error":     "",
+			"next_submit_at": nil,
 		}).Error
 }
 
@@ -273,11 +341,85 @@ func (r *draftProductRepo) MarkFailed(ctx context.Context, id int64, errMsg stri
 		Model(&model.DraftProduct{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"sync_status": model.DraftSyncStatusFailed,
-			"sync_error":  errMsg,
+			"sync_status":    model.DraftSyncStatusFailed,
+			"sync_error":     errMsg,
+			"next_submit_at": nil,
 		}).Error
 }
 
+// MarkDead 标记为死信
+func (r *draftProductRepo) MarkDead(ctx context.Context, id int64, errMsg string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftProduct{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sync_status":    model.DraftSyncStatusDead,
+			"sync_error":     errMsg,
+			"next_submit_at": nil,
+		}).Error
+}
+
+// ScheduleRetry 记录错误并在 nextAt 后重试
+func (r *draftProductRepo) ScheduleRetry(ctx context.Context, id int64, errMsg string, nextAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftProduct{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sync_status":    model.DraftSyncStatusPending,
+			"sync_error":     errMsg,
+			"next_submit_at": nextAt,
+		}).Error
+}
+
+// ResetSubmit 重新加入提交队列
+func (r *draftProductRepo) ResetSubmit(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftProduct{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sync_status":     model.DraftSyncStatusPending,
+			"sync_error":      "",
+			"submit_attempts": 0,
+			"next_submit_at":  nil,
+		}).Error
+}
+
+// FindSubmitFailed 查询提交失败 / 死信的草稿
+func (r *draftProductRepo) FindSubmitFailed(ctx context.Context, filter SubmitFailedFilter) ([]model.DraftProduct, int64, error) {
+	var products []model.DraftProduct
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.DraftProduct{}).Where("status = ?", model.DraftStatusConfirmed)
+	if filter.SyncStatus != 0 {
+		query = query.Where("sync_status = ?", filter.SyncStatus)
+	} else {
+		query = query.Where("sync_status IN ?", []int{model.DraftSyncStatusFailed, model.DraftSyncStatusDead})
+	}
+	if filter.ShopID > 0 {
+		query = query.Where("shop_id = ?", filter.ShopID)
+	}
+	if filter.ShopIDs != nil {
+		query = query.Where("shop_id IN ?", filter.ShopIDs)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	err := query.Order("updated_at DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&products).Error
+	return products, total, err
+}
+
 // UpdateProductID 更新关联的 Product ID
 func (r *draftProductRepo) UpdateProductID(ctx context.Context, id int64, productID int64) error {
 	return r.db.WithContext(ctx).
@@ -286,6 +428,14 @@ func (r *draftProductRepo) UpdateProductID(ctx context.Context, id int64, produc
 		Update("product_id", productID).Error
 }
 
+// UpdateListingID 更新关联的 Etsy Listing ID
+func (r *draftProductRepo) UpdateListingID(ctx context.Context, id int64, listingID int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftProduct{}).
+		Where("id = ?", id).
+		Update("listing_id", listingID).Error
+}
+
 // DeleteByTaskID 按任务ID删除
 func (r *draftProductRepo) DeleteByTaskID(ctx context.Context, taskID int64) error {
 	return r.db.WithContext(ctx).
@@ -360,6 +510,84 @@ func (r *draftImageRepo) DeleteByGroup(ctx context.Context, taskID int64, groupI
 		Delete(&model.DraftImage{}).Error
 }
 
+// ==================== DraftImageUpload 仓储实现 ====================
+
+type draftImageUploadRepo struct {
+	db *gorm.DB
+}
+
+// NewDraftImageUploadRepository 创建图片上传记录仓储
+func NewDraftImageUploadRepository(db *gorm.DB) DraftImageUploadRepository {
+	return &draftImageUploadRepo{db: db}
+}
+
+func (r *draftImageUploadRepo) GetByDraftProductID(ctx context.Context, draftProductID int64) ([]model.DraftImageUpload, error) {
+	var uploads []model.DraftImageUpload
+	err := r.db.WithContext(ctx).
+		Where("draft_product_id = ?", draftProductID).
+		Order("rank ASC").
+		Find(&uploads).Error
+	return uploads, err
+}
+
+func (r *draftImageUploadRepo) Create(ctx context.Context, upload *model.DraftImageUpload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+func (r *draftImageUploadRepo) ResetSource(ctx context.Context, id int64, sourceURL string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftImageUpload{}).
+		Where("id = ? AND status <> ?", id, model.ImageUploadStatusUploaded).
+		Updates(map[string]interface{}{
+			"source_url":     sourceURL,
+			"status":         model.ImageUploadStatusPending,
+			"attempts":       0,
+			"etsy_image_id":  0,
+			"etsy_image_url": "",
+			"error_message":  "",
+		}).Error
+}
+
+func (r *draftImageUploadRepo) MarkEtsyUploaded(ctx context.Context, id int64, etsyImageID int64, etsyImageURL string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftImageUpload{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"etsy_image_id":  etsyImageID,
+			"etsy_image_url": etsyImageURL,
+		}).Error
+}
+
+func (r *draftImageUploadRepo) MarkUploaded(ctx context.Context, id int64, etsyImageID, productImageID int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftImageUpload{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":           model.ImageUploadStatusUploaded,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"etsy_image_id":    etsyImageID,
+			"product_image_id": productImageID,
+			"error_message":    "",
+		}).Error
+}
+
+func (r *draftImageUploadRepo) MarkFailed(ctx context.Context, id int64, errMsg string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.DraftImageUpload{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        model.ImageUploadStatusFailed,
+			"attempts":      gorm.Expr("attempts + 1"),
+			"error_message": errMsg,
+		}).Error
+}
+
+func (r *draftImageUploadRepo) DeleteByDraftProductID(ctx context.Context, draftProductID int64) error {
+	return r.db.WithContext(ctx).
+		Where("draft_product_id = ?", draftProductID).
+		Delete(&model.DraftImageUpload{}).Error
+}
+
 // ==================== 事务支持 ====================
 
 // DraftUnitOfWork 草稿工作单元（事务）
@@ -368,6 +596,9 @@ type DraftUnitOfWork struct {
 	Tasks    DraftTaskRepository
 	Products DraftProductRepository
 	Images   DraftImageRepository
+	Jobs     DraftJobRepository
+	Events   DraftTaskEventRepository
+	Uploads  DraftImageUploadRepository
 }
 
 // NewDraftUnitOfWork 创建工作单元
@@ -377,6 +608,9 @@ func NewDraftUnitOfWork(db *gorm.DB) *DraftUnitOfWork {
 		Tasks:    NewDraftTaskRepository(db),
 		Products: NewDraftProductRepository(db),
 		Images:   NewDraftImageRepository(db),
+		Jobs:     NewDraftJobRepository(db),
+		Events:   NewDraftTaskEventRepository(db),
+		Uploads:  NewDraftImageUploadRepository(db),
 	}
 }
 
@@ -388,6 +622,9 @@ func (u *DraftUnitOfWork) Transaction(ctx context.Context, fn func(uow *DraftUni
 			Tasks:    NewDraftTaskRepository(tx),
 			Products: NewDraftProductRepository(tx),
 			Images:   NewDraftImageRepository(tx),
+			Jobs:     NewDraftJobRepository(tx),
+			Events:   NewDraftTaskEventRepository(tx),
+			Uploads:  NewDraftImageUploadRepository(tx),
 		}
 		return fn(txUow)
 	})
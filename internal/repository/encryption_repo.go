@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/pkg/database"
+)
+
+// EncryptedTable 含加密列的表
+type EncryptedTable struct {
+	Table   string
+	Columns []string
+}
+
+// RawEncryptedRow 加密列原始值（未解密）
+type RawEncryptedRow struct {
+	ID     int64
+	Values map[string]string
+}
+
+// ==================== 接口定义 ====================
+
+// EncryptionRepository 加密列维护（密钥轮换时直接读写密文，不经过序列化器）
+type EncryptionRepository interface {
+	// EncryptedTables 解析模型中使用加密序列化器的列
+	EncryptedTables(models ...interface{}) ([]EncryptedTable, error)
+	HasTable(ctx context.Context, table string) bool
+	// CountPending 统计未使用指定前缀（主密钥版本）加密的非空值
+	CountPending(ctx context.Context, table, column, prefix string) (int64, error)
+	// ListRawBatch 按主键顺序读取原始列值（含软删除记录）
+	ListRawBatch(ctx context.Context, table EncryptedTable, afterID int64, limit int) ([]RawEncryptedRow, error)
+	// UpdateRawIfUnchanged 原值未被并发修改时写入新密文
+	UpdateRawIfUnchanged(ctx context.Context, table, column string, id int64, oldValue, newValue string) (bool, error)
+}
+
+// ==================== 仓储实现 ====================
+
+type encryptionRepo struct {
+	db *gorm.DB
+}
+
+// NewEncryptionRepository 创建加密列维护仓储
+func NewEncryptionRepository(db *gorm.DB) EncryptionRepository {
+	return &encryptionRepo{db: db}
+}
+
+func (r *encryptionRepo) EncryptedTables(models ...interface{}) ([]EncryptedTable, error) {
+	tables := make([]EncryptedTable, 0, len(models))
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(m); err != nil {
+			return nil, fmt.Errorf("解析模型失败: %w", err)
+		}
+
+		t := EncryptedTable{Table: stmt.Schema.Table}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && database.IsEncryptedField(field) {
+				t.Columns = append(t.Columns, field.DBName)
+			}
+		}
+		if len(t.Columns) > 0 {
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+func (r *encryptionRepo) HasTable(ctx context.Context, table string) bool {
+	return r.db.WithContext(ctx).Migrator().HasTable(table)
+}
+
+func (r *encryptionRepo) CountPending(ctx context.Context, table, column, prefix string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table(table).
+		Where(fmt.Sprintf("%s IS NOT NULL AND %s <> '' AND %s NOT LIKE ?", column, column, column), prefix+"%").
+		Count(&count).Error
+	return count, err
+}
+
+func (r *encryptionRepo) ListRawBatch(ctx context.Context, table EncryptedTable, afterID int64, limit int) ([]RawEncryptedRow, error) {
+	var rows []map[string]interface{}
+	err := r.db.WithContext(ctx).Table(table.Table).
+		Select(append([]string{"id"}, table.Columns...)).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]RawEncryptedRow, 0, len(rows))
+	for _, row := range rows {
+		raw := RawEncryptedRow{Values: make(map[string]string, len(table.Columns))}
+		switch id := row["id"].(type) {
+		case int64:
+			raw.ID = id
+		case int32:
+			raw.ID = int64(id)
+		case int:
+			raw.ID = int64(id)
+		}
+		for _, col := range table.Columns {
+			switch v := row[col].(type) {
+			case string:
+				raw.Values[col] = v
+			case []byte:
+				raw.Values[col] = string(v)
+			}
+		}
+		result = append(result, raw)
+	}
+	return result, nil
+}
+
+func (r *encryptionRepo) UpdateRawIfUnchanged(ctx context.Context, table, column string, id int64, oldValue, newValue string) (bool, error) {
+	result := r.db.WithContext(ctx).Table(table).
+		Where(fmt.Sprintf("id = ? AND %s = ?", column), id, oldValue).
+		UpdateColumn(column, newValue)
+	return result.RowsAffected > 0, result.Error
+}
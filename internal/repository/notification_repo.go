@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// NotificationRepository 站内通知仓储接口
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	List(ctx context.Context, filter NotificationFilter) ([]model.Notification, int64, error)
+	// ListAfter 按 ID 升序返回 afterID 之后的通知（SSE 断线重放）
+	ListAfter(ctx context.Context, userID, afterID int64, limit int) ([]model.Notification, error)
+	// LatestID 用户最新一条通知的 ID（无通知时为 0）
+	LatestID(ctx context.Context, userID int64) (int64, error)
+	CountUnread(ctx context.Context, userID int64) (int64, error)
+	// MarkRead 仅标记属于该用户的通知，返回实际更新条数
+	MarkRead(ctx context.Context, userID int64, ids []int64) (int64, error)
+	MarkAllRead(ctx context.Context, userID int64) (int64, error)
+}
+
+// NotificationFilter 通知查询条件
+type NotificationFilter struct {
+	UserID     int64
+	Event      string
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+}
+
+type notificationRepo struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建通知仓储
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *notificationRepo) List(ctx context.Context, filter NotificationFilter) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", filter.UserID)
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	err := query.Order("id DESC").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *notificationRepo) ListAfter(ctx context.Context, userID, afterID int64, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND id > ?", userID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepo) LatestID(ctx context.Context, userID int64) (int64, error) {
+	var id int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+func (r *notificationRepo) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("user_id = ? AND id IN ? AND read_at IS NULL", userID, ids).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepo) MarkAllRead(ctx context.Context, userID int64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// ErrOAuthStateNotFound state 不存在、已过期或已被消费
+var ErrOAuthStateNotFound = errors.New("oauth state not found or expired")
+
+// ==================== 接口定义 ====================
+
+// OAuthStateRepository OAuth 授权状态仓储接口（Postgres 实现的 state store）
+type OAuthStateRepository interface {
+	// Save 保存授权状态，顺带清理已过期记录
+	Save(ctx context.Context, state *model.OAuthState) error
+	// Consume 一次性取出授权状态：取出即删除，并发回调只有一个成功
+	// 不存在、已过期或已被消费时返回 ErrOAuthStateNotFound
+	Consume(ctx context.Context, state string) (*model.OAuthState, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ==================== 仓储实现 ====================
+
+type oauthStateRepo struct {
+	db *gorm.DB
+}
+
+// NewOAuthStateRepository 创建 OAuth 授权状态仓储
+func NewOAuthStateRepository(db *gorm.DB) OAuthStateRepository {
+	return &oauthStateRepo{db: db}
+}
+
+func (r *oauthStateRepo) Save(ctx context.Context, state *model.OAuthState) error {
+	if _, err := r.DeleteExpired(ctx, time.Now()); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+func (r *oauthStateRepo) Consume(ctx context.Context, state string) (*model.OAuthState, error) {
+	var st model.OAuthState
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ?", state).First(&st).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOAuthStateNotFound
+			}
+			return err
+		}
+		// 以删除成功作为消费凭证，已被其他请求删除时影响行数为 0
+		result := tx.Unscoped().Where("id = ?", st.ID).Delete(&model.OAuthState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOAuthStateNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(st.ExpiresAt) {
+		return nil, ErrOAuthStateNotFound
+	}
+	return &st, nil
+}
+
+func (r *oauthStateRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&model.OAuthState{})
+	return result.RowsAffected, result.Error
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
@@ -17,6 +19,9 @@ type ProductRepository interface {
 	Create(ctx context.Context, product *model.Product) error
 	GetByID(ctx context.Context, id int64) (*model.Product, error)
 	GetByListingID(ctx context.Context, listingID int64) (*model.Product, error)
+	ListByListingIDs(ctx context.Context, listingIDs []int64) ([]model.Product, error)
+	// ListByLocalSKU 商品本身或任一变体的 LocalSKU 匹配的在售商品（跨店铺库存联动）
+	ListByLocalSKU(ctx context.Context, sku string) ([]model.Product, error)
 	Update(ctx context.Context, product *model.Product) error
 	UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error
 	Delete(ctx context.Context, id int64) error
@@ -38,6 +43,7 @@ type ProductRepository interface {
 	CreateVariant(ctx context.Context, variant *model.ProductVariant) error
 	BatchUpsertVariants(ctx context.Context, variants []model.ProductVariant) error
 	DeleteVariantsByProductID(ctx context.Context, productID int64) error
+	ReplaceVariants(ctx context.Context, productID int64, variants []model.ProductVariant) error
 
 	// 图片操作
 	CreateImage(ctx context.Context, image *model.ProductImage) error
@@ -107,6 +113,32 @@ func (r *productRepo) GetByListingID(ctx context.Context, listingID int64) (*mod
 	return &product, nil
 }
 
+func (r *productRepo) ListByListingIDs(ctx context.Context, listingIDs []int64) ([]model.Product, error) {
+	var products []model.Product
+	if len(listingIDs) == 0 {
+		return products, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("listing_id IN ?", listingIDs).
+		Find(&products).Error
+	return products, err
+}
+
+func (r *productRepo) ListByLocalSKU(ctx context.Context, sku string) ([]model.Product, error) {
+	var products []model.Product
+	variantProducts := r.db.Model(&model.ProductVariant{}).
+		Select("product_id").
+		Where("local_sku = ?", sku)
+	err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("local_sku = ? OR id IN (?)", sku, variantProducts).
+		Where("state != ?", model.ProductStateRemoved).
+		Order("id ASC").
+		Find(&products).Error
+	return products, err
+}
+
 func (r *productRepo) Update(ctx context.Context, product *model.Product) error {
 	return r.db.WithContext(ctx).Save(product).Error
 }
@@ -231,6 +263,7 @@ func (r *productRepo) BatchUpsert(ctx context.Context, products []model.Product)
 			"quantity", "tags", "materials", "styles",
 			"views", "num_favorers",
 			"etsy_last_modified_ts", "etsy_state_ts",
+			"taxonomy_id", "has_variations",
 			"sync_status", "updated_at",
 		}),
 	}).Create(&products).Error
@@ -270,6 +303,75 @@ func (r *productRepo) DeleteVariantsByProductID(ctx context.Context, productID i
 		Delete(&model.ProductVariant{}).Error
 }
 
+// ReplaceVariants 以 Etsy 库存覆盖商品变体
+// Etsy 每次更新库存都会重新生成 product_id，因此先按 etsy_product_id、再按属性组合匹配已有变体：
+// 匹配到的原地更新（保留变体 ID 与未下发的 LocalSKU，库存关联不受影响），新增的插入，已不存在的删除
+func (r *productRepo) ReplaceVariants(ctx context.Context, productID int64, variants []model.ProductVariant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []model.ProductVariant
+		if err := tx.Where("product_id = ?", productID).Find(&existing).Error; err != nil {
+			return err
+		}
+		byEtsyID := make(map[int64]int, len(existing))
+		byProps := make(map[string]int, len(existing))
+		for i, v := range existing {
+			if v.EtsyProductID > 0 {
+				byEtsyID[v.EtsyProductID] = i
+			}
+			byProps[variantPropsKey(v.PropertyValues)] = i
+		}
+
+		matched := make(map[int64]bool, len(existing))
+		for i := range variants {
+			v := &variants[i]
+			v.ID = 0
+			v.ProductID = productID
+
+			idx, ok := byEtsyID[v.EtsyProductID]
+			if !ok || matched[existing[idx].ID] {
+				idx, ok = byProps[variantPropsKey(v.PropertyValues)]
+			}
+			if !ok || matched[existing[idx].ID] {
+				if err := tx.Create(v).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			old := existing[idx]
+			matched[old.ID] = true
+			v.ID, v.CreatedAt, v.CreatedBy = old.ID, old.CreatedAt, old.CreatedBy
+			if v.LocalSKU == "" {
+				v.LocalSKU = old.LocalSKU
+			}
+			if err := tx.Save(v).Error; err != nil {
+				return err
+			}
+		}
+
+		var stale []int64
+		for _, v := range existing {
+			if !matched[v.ID] {
+				stale = append(stale, v.ID)
+			}
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+		return tx.Unscoped().Where("id IN ?", stale).Delete(&model.ProductVariant{}).Error
+	})
+}
+
+// variantPropsKey 属性组合的规范化 Key（JSONB 读回时格式可能变化，按解析后的值比较）
+func variantPropsKey(raw datatypes.JSON) string {
+	var props map[string]interface{}
+	if err := json.Unmarshal(raw, &props); err != nil || len(props) == 0 {
+		return "{}"
+	}
+	key, _ := json.Marshal(props)
+	return string(key)
+}
+
 func (r *productRepo) CreateImage(ctx context.Context, image *model.ProductImage) error {
 	return r.db.WithContext(ctx).Create(image).Error
 }
@@ -6,10 +6,21 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"etsy_dev_v1_202512/internal/model"
 )
 
+// DefaultMaxShopsPerSharedProxy 共享代理默认最多绑定的店铺数
+const DefaultMaxShopsPerSharedProxy = 2
+
+var (
+	// ErrProxyCapacityFull 独享代理已有店铺，或共享代理已达上限
+	ErrProxyCapacityFull = errors.New("proxy capacity full")
+	// ErrProxyUsedByOtherShop 代理曾被其他店铺使用，不能再分配给当前店铺
+	ErrProxyUsedByOtherShop = errors.New("proxy has been used by another shop")
+)
+
 // ==================== 接口定义 ====================
 
 // ProxyRepository 代理仓储接口
@@ -24,13 +35,37 @@ type ProxyRepository interface {
 
 	// 查询
 	FindByEndpoint(ctx context.Context, ip, port string) (*model.Proxy, error)
+	// FindDuplicate 按查重键查找已存在的代理，不存在时返回 nil
+	// 固定出口按 IP+Port；网关同一入口可开多个账号，按 IP+Port+Username 区分
+	FindDuplicate(ctx context.Context, proxy *model.Proxy) (*model.Proxy, error)
 	GetRandomProxy(ctx context.Context) (*model.Proxy, error)
-	FindSpareProxy(ctx context.Context, region string) (*model.Proxy, error)
+	// FindSpareProxy 为店铺查找同地区有空余容量、且未被其他店铺用过的代理，店铺自己用过的代理优先，其次评分高者优先
+	// shopID 为 0 表示尚未建店；maxShared 为共享代理的店铺上限
+	FindSpareProxy(ctx context.Context, region string, shopID int64, maxShared int) (*model.Proxy, error)
 	FindCheckList(ctx context.Context) ([]model.Proxy, error)
+	// FindSharedExitProxies 出口 IP 与其他代理相同的代理（按出口 IP 排序，含绑定店铺）
+	FindSharedExitProxies(ctx context.Context) ([]model.Proxy, error)
 
 	// 更新
 	UpdateLastCheckTime(ctx context.Context, proxyID int64) error
 	UpdateStatusAndCount(ctx context.Context, proxy *model.Proxy) error
+	UpdateFields(ctx context.Context, proxyID int64, fields map[string]interface{}) error
+
+	// 店铺绑定（同时维护 shops.proxy_id 与绑定历史）
+	// BindShop 在事务内校验容量与店铺亲和规则后绑定，店铺原有绑定以 binding.BindReason 关闭
+	// 违反规则时返回 ErrProxyCapacityFull / ErrProxyUsedByOtherShop
+	BindShop(ctx context.Context, binding *model.ProxyBinding, maxShared int) error
+	UnbindShop(ctx context.Context, shopID int64, reason string) error
+	ListBindings(ctx context.Context, shopID int64) ([]model.ProxyBinding, error)
+	// FindActiveBinding 店铺当前绑定，不存在时返回 nil
+	FindActiveBinding(ctx context.Context, shopID int64) (*model.ProxyBinding, error)
+	// ConfirmProxyErrorBindings 代理被判定故障后，将其上因请求失败解绑的记录改为 proxy_failed，此后计入亲和规则
+	ConfirmProxyErrorBindings(ctx context.Context, proxyID int64) error
+
+	// 评分历史
+	CreateScoreSnapshots(ctx context.Context, snapshots []model.ProxyScoreSnapshot) error
+	ListScoreSnapshots(ctx context.Context, proxyID int64, since time.Time) ([]model.ProxyScoreSnapshot, error)
+	DeleteScoreSnapshotsBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 // ==================== 过滤条件 ====================
@@ -41,8 +76,12 @@ type ProxyFilter struct {
 	Region   string
 	Status   int
 	Capacity int
-	Page     int
-	PageSize int
+	// GeoMismatch 只看出口国家与 Region 不符的代理
+	GeoMismatch bool
+	// SortByScore 按质量评分从高到低排序（默认按 ID 倒序）
+	SortByScore bool
+	Page        int
+	PageSize    int
 }
 
 // ==================== 仓储实现 ====================
@@ -106,6 +145,9 @@ func (r *proxyRepo) List(ctx context.Context, filter ProxyFilter) ([]model.Proxy
 	if filter.Capacity > 0 {
 		query = query.Where("capacity = ?", filter.Capacity)
 	}
+	if filter.GeoMismatch {
+		query = query.Where("geo_mismatch = ?", true)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -118,12 +160,17 @@ func (r *proxyRepo) List(ctx context.Context, filter ProxyFilter) ([]model.Proxy
 		filter.PageSize = 20
 	}
 
+	order := "id DESC"
+	if filter.SortByScore {
+		order = "score DESC, id DESC"
+	}
+
 	offset := (filter.Page - 1) * filter.PageSize
 	err := query.
 		Preload("Shops", func(db *gorm.DB) *gorm.DB {
 			return db.Select("id", "proxy_id", "shop_name", "etsy_shop_id", "token_status")
 		}).
-		Order("id DESC").
+		Order(order).
 		Limit(filter.PageSize).
 		Offset(offset).
 		Find(&list).Error
@@ -145,6 +192,25 @@ func (r *proxyRepo) FindByEndpoint(ctx context.Context, ip, port string) (*model
 	return &proxy, nil
 }
 
+func (r *proxyRepo) FindDuplicate(ctx context.Context, proxy *model.Proxy) (*model.Proxy, error) {
+	query := r.db.WithContext(ctx).Where("ip = ? AND port = ?", proxy.IP, proxy.Port)
+	if proxy.IsGateway() {
+		query = query.Where("type = ? AND username = ?", model.ProxyTypeGateway, proxy.Username)
+	} else {
+		query = query.Where("type <> ?", model.ProxyTypeGateway)
+	}
+
+	var existing model.Proxy
+	err := query.First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
 func (r *proxyRepo) GetRandomProxy(ctx context.Context) (*model.Proxy, error) {
 	var proxy model.Proxy
 	err := r.db.WithContext(ctx).
@@ -157,16 +223,26 @@ func (r *proxyRepo) GetRandomProxy(ctx context.Context) (*model.Proxy, error) {
 	return &proxy, nil
 }
 
-func (r *proxyRepo) FindSpareProxy(ctx context.Context, region string) (*model.Proxy, error) {
+func (r *proxyRepo) FindSpareProxy(ctx context.Context, region string, shopID int64, maxShared int) (*model.Proxy, error) {
 	var proxy model.Proxy
 	err := r.db.WithContext(ctx).
 		Table("proxies").
 		Select("proxies.*").
-		Joins("LEFT JOIN shops ON shops.proxy_id = proxies.id").
-		Where("proxies.region = ? AND proxies.status = ? AND proxies.Capacity = ?", region, model.PROXY_STATUS_ACTIVE, model.PROXY_SHARED).
+		Joins("LEFT JOIN shops ON shops.proxy_id = proxies.id AND shops.id <> ? AND shops.deleted_at IS NULL", shopID).
+		Where("proxies.region = ? AND proxies.status = ? AND proxies.deleted_at IS NULL", region, model.PROXY_STATUS_ACTIVE).
+		// 亲和规则：其他店铺用过（已解绑）的代理不再分配；网关按会话隔离出口，不受限制
+		// 单次请求失败解绑（proxy_error）的代理在被判定故障前仍可分配，避免一次超时就永久损失代理
+		Where("proxies.type = ? OR NOT EXISTS (SELECT 1 FROM proxy_bindings b WHERE b.proxy_id = proxies.id AND b.shop_id <> ? AND b.unbound_at IS NOT NULL AND b.unbind_reason <> ? AND b.deleted_at IS NULL)",
+			model.ProxyTypeGateway, shopID, model.ProxyBindReasonProxyError).
 		Group("proxies.id").
-		Having("COUNT(shops.id) < ?", 2).
-		Order("COUNT(shops.id) ASC").
+		// 独享代理只能空闲时分配，共享代理不超过上限，网关不限
+		Having("proxies.type = ? OR (proxies.capacity = ? AND COUNT(shops.id) = 0) OR (proxies.capacity = ? AND COUNT(shops.id) < ?)",
+			model.ProxyTypeGateway, model.PROXY_PRIVATE, model.PROXY_SHARED, maxShared).
+		// 店铺自己用过的代理优先，重新绑定时尽量回到原出口
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "EXISTS (SELECT 1 FROM proxy_bindings ob WHERE ob.proxy_id = proxies.id AND ob.shop_id = ? AND ob.deleted_at IS NULL) DESC, proxies.score DESC, COUNT(shops.id) ASC",
+			Vars: []interface{}{shopID},
+		}}).
 		First(&proxy).Error
 	if err != nil {
 		return nil, err
@@ -174,6 +250,136 @@ func (r *proxyRepo) FindSpareProxy(ctx context.Context, region string) (*model.P
 	return &proxy, nil
 }
 
+func (r *proxyRepo) BindShop(ctx context.Context, binding *model.ProxyBinding, maxShared int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// 先写代理行取得行锁，串行化同一代理的并发绑定，避免超卖
+		result := tx.Model(&model.Proxy{}).Where("id = ?", binding.ProxyID).UpdateColumn("updated_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		var proxy model.Proxy
+		if err := tx.First(&proxy, binding.ProxyID).Error; err != nil {
+			return err
+		}
+
+		if err := checkBindingRules(tx, &proxy, binding.ShopID, maxShared); err != nil {
+			return err
+		}
+
+		var shop model.Shop
+		if err := tx.Select("id", "proxy_id", "created_at").First(&shop, binding.ShopID).Error; err != nil {
+			return err
+		}
+		if shop.ProxyID == binding.ProxyID {
+			return nil
+		}
+		if err := closeBindings(tx, &shop, binding.BindReason, now); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Shop{}).Where("id = ?", shop.ID).UpdateColumn("proxy_id", binding.ProxyID).Error; err != nil {
+			return err
+		}
+		binding.BoundAt = now
+		return tx.Create(binding).Error
+	})
+}
+
+// checkBindingRules 容量与店铺亲和规则，网关代理不检查
+func checkBindingRules(tx *gorm.DB, proxy *model.Proxy, shopID int64, maxShared int) error {
+	if proxy.IsGateway() {
+		return nil
+	}
+
+	var tenants int64
+	if err := tx.Model(&model.Shop{}).
+		Where("proxy_id = ? AND id <> ?", proxy.ID, shopID).
+		Count(&tenants).Error; err != nil {
+		return err
+	}
+	if (proxy.Capacity == model.PROXY_PRIVATE && tenants > 0) || tenants >= int64(maxShared) {
+		return ErrProxyCapacityFull
+	}
+
+	var used int64
+	if err := tx.Model(&model.ProxyBinding{}).
+		Where("proxy_id = ? AND shop_id <> ? AND unbound_at IS NOT NULL AND unbind_reason <> ?", proxy.ID, shopID, model.ProxyBindReasonProxyError).
+		Count(&used).Error; err != nil {
+		return err
+	}
+	if used > 0 {
+		return ErrProxyUsedByOtherShop
+	}
+	return nil
+}
+
+func (r *proxyRepo) UnbindShop(ctx context.Context, shopID int64, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var shop model.Shop
+		if err := tx.Select("id", "proxy_id", "created_at").First(&shop, shopID).Error; err != nil {
+			return err
+		}
+		if err := closeBindings(tx, &shop, reason, time.Now()); err != nil {
+			return err
+		}
+		return tx.Model(&model.Shop{}).Where("id = ?", shopID).UpdateColumn("proxy_id", nil).Error
+	})
+}
+
+// closeBindings 关闭店铺当前绑定；绑定历史上线前已有的绑定没有记录，补录一条已解绑记录以保留亲和关系
+func closeBindings(tx *gorm.DB, shop *model.Shop, reason string, now time.Time) error {
+	result := tx.Model(&model.ProxyBinding{}).
+		Where("shop_id = ? AND unbound_at IS NULL", shop.ID).
+		Updates(map[string]interface{}{"unbound_at": now, "unbind_reason": reason})
+	if result.Error != nil || result.RowsAffected > 0 || shop.ProxyID == 0 {
+		return result.Error
+	}
+	return tx.Create(&model.ProxyBinding{
+		ShopID:       shop.ID,
+		ProxyID:      shop.ProxyID,
+		BoundAt:      shop.CreatedAt,
+		BindReason:   model.ProxyBindReasonLegacy,
+		UnboundAt:    &now,
+		UnbindReason: reason,
+	}).Error
+}
+
+func (r *proxyRepo) ListBindings(ctx context.Context, shopID int64) ([]model.ProxyBinding, error) {
+	var list []model.ProxyBinding
+	err := r.db.WithContext(ctx).
+		Preload("Proxy", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "ip", "port", "region", "exit_ip")
+		}).
+		Where("shop_id = ?", shopID).
+		Order("bound_at DESC, id DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *proxyRepo) FindActiveBinding(ctx context.Context, shopID int64) (*model.ProxyBinding, error) {
+	var binding model.ProxyBinding
+	err := r.db.WithContext(ctx).
+		Where("shop_id = ? AND unbound_at IS NULL", shopID).
+		Order("id DESC").
+		First(&binding).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+func (r *proxyRepo) ConfirmProxyErrorBindings(ctx context.Context, proxyID int64) error {
+	return r.db.WithContext(ctx).Model(&model.ProxyBinding{}).
+		Where("proxy_id = ? AND unbind_reason = ?", proxyID, model.ProxyBindReasonProxyError).
+		UpdateColumn("unbind_reason", model.ProxyBindReasonProxyFailed).Error
+}
+
 func (r *proxyRepo) FindCheckList(ctx context.Context) ([]model.Proxy, error) {
 	var list []model.Proxy
 	err := r.db.WithContext(ctx).
@@ -183,6 +389,24 @@ func (r *proxyRepo) FindCheckList(ctx context.Context) ([]model.Proxy, error) {
 	return list, err
 }
 
+func (r *proxyRepo) FindSharedExitProxies(ctx context.Context) ([]model.Proxy, error) {
+	shared := r.db.Model(&model.Proxy{}).
+		Select("exit_ip").
+		Where("exit_ip <> ''").
+		Group("exit_ip").
+		Having("COUNT(*) > 1")
+
+	var list []model.Proxy
+	err := r.db.WithContext(ctx).
+		Preload("Shops", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "proxy_id", "shop_name", "etsy_shop_id", "token_status")
+		}).
+		Where("exit_ip IN (?)", shared).
+		Order("exit_ip ASC, id ASC").
+		Find(&list).Error
+	return list, err
+}
+
 func (r *proxyRepo) UpdateLastCheckTime(ctx context.Context, proxyID int64) error {
 	return r.db.WithContext(ctx).
 		Model(&model.Proxy{}).
@@ -199,3 +423,34 @@ func (r *proxyRepo) UpdateStatusAndCount(ctx context.Context, proxy *model.Proxy
 			FailureCount: proxy.FailureCount,
 		}).Error
 }
+
+func (r *proxyRepo) UpdateFields(ctx context.Context, proxyID int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Proxy{}).
+		Where("id = ?", proxyID).
+		UpdateColumns(fields).Error
+}
+
+func (r *proxyRepo) CreateScoreSnapshots(ctx context.Context, snapshots []model.ProxyScoreSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(snapshots, 200).Error
+}
+
+func (r *proxyRepo) ListScoreSnapshots(ctx context.Context, proxyID int64, since time.Time) ([]model.ProxyScoreSnapshot, error) {
+	var list []model.ProxyScoreSnapshot
+	err := r.db.WithContext(ctx).
+		Where("proxy_id = ? AND created_at >= ?", proxyID, since).
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *proxyRepo) DeleteScoreSnapshotsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Where("created_at < ?", before).
+		Delete(&model.ProxyScoreSnapshot{})
+	return result.RowsAffected, result.Error
+}
@@ -6,6 +6,7 @@ import (
 
 	"etsy_dev_v1_202512/internal/model"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +14,7 @@ import (
 
 // ShipmentFilter 发货记录过滤条件
 type ShipmentFilter struct {
+	ShopIDs        []int64 // 按订单所属店铺筛选，nil 表示不筛选
 	OrderID        int64
 	CarrierCode    string
 	Status         string
@@ -39,7 +41,7 @@ type ShipmentRepository interface {
 	UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error
 	UpdateStatus(ctx context.Context, id int64, status string) error
 	UpdateTrackingInfo(ctx context.Context, id int64, status, location string, eventTime *time.Time) error
-	MarkEtsySynced(ctx context.Context, id int64) error
+	MarkEtsySynced(ctx context.Context, id int64, receiptShippingID int64, rawResp []byte) error
 	MarkEtsySyncFailed(ctx context.Context, id int64, errMsg string) error
 	Delete(ctx context.Context, id int64) error
 
@@ -117,6 +119,9 @@ func (r *shipmentRepository) List(ctx context.Context, filter ShipmentFilter) ([
 
 	db := r.db.WithContext(ctx).Model(&model.Shipment{})
 
+	if filter.ShopIDs != nil {
+		db = db.Where("order_id IN (?)", r.db.Model(&model.Order{}).Select("id").Where("shop_id IN ?", filter.ShopIDs))
+	}
 	if filter.OrderID > 0 {
 		db = db.Where("order_id = ?", filter.OrderID)
 	}
@@ -193,13 +198,18 @@ func (r *shipmentRepository) UpdateTrackingInfo(ctx context.Context, id int64, s
 	return r.db.WithContext(ctx).Model(&model.Shipment{}).Where("id = ?", id).Updates(updates).Error
 }
 
-func (r *shipmentRepository) MarkEtsySynced(ctx context.Context, id int64) error {
+func (r *shipmentRepository) MarkEtsySynced(ctx context.Context, id int64, receiptShippingID int64, rawResp []byte) error {
 	now := time.Now()
-	return r.db.WithContext(ctx).Model(&model.Shipment{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"etsy_synced":     true,
-		"etsy_synced_at":  &now,
-		"etsy_sync_error": "",
-	}).Error
+	updates := map[string]interface{}{
+		"etsy_synced":              true,
+		"etsy_synced_at":           &now,
+		"etsy_sync_error":          "",
+		"etsy_receipt_shipping_id": receiptShippingID,
+	}
+	if len(rawResp) > 0 {
+		updates["etsy_sync_response"] = datatypes.JSON(rawResp)
+	}
+	return r.db.WithContext(ctx).Model(&model.Shipment{}).Where("id = ?", id).Updates(updates).Error
 }
 
 func (r *shipmentRepository) MarkEtsySyncFailed(ctx context.Context, id int64, errMsg string) error {
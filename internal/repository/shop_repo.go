@@ -35,6 +35,7 @@ type ShopRepository interface {
 	FindExpiringShops(ctx context.Context) ([]model.Shop, error)
 	UpdateToken(ctx context.Context, id int64, accessToken, refreshToken string, expiresAt int64) error
 	// 开发者关联
+	ListByDeveloperID(ctx context.Context, developerID int64) ([]model.Shop, error)
 	GetDeveloperByShopID(ctx context.Context, shopID int64) (*model.Developer, error)
 }
 
@@ -42,6 +43,7 @@ type ShopRepository interface {
 
 // ShopFilter 店铺过滤条件
 type ShopFilter struct {
+	IDs         []int64 // nil 表示不筛选
 	UserID      int64
 	ShopName    string
 	Status      int   // -1 表示不筛选
@@ -104,7 +106,19 @@ func (r *shopRepo) GetByEtsyShopID(ctx context.Context, etsyShopID int64) (*mode
 }
 
 func (r *shopRepo) Update(ctx context.Context, shop *model.Shop) error {
-	return r.db.WithContext(ctx).Save(shop).Error
+	// 未绑定的代理/开发者保持 NULL，写入 0 会违反外键约束
+	var omits []string
+	if shop.ProxyID == 0 {
+		omits = append(omits, "proxy_id")
+	}
+	if shop.DeveloperID == 0 {
+		omits = append(omits, "developer_id")
+	}
+	tx := r.db.WithContext(ctx)
+	if len(omits) > 0 {
+		tx = tx.Omit(omits...)
+	}
+	return tx.Save(shop).Error
 }
 
 func (r *shopRepo) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
@@ -121,6 +135,9 @@ func (r *shopRepo) List(ctx context.Context, filter ShopFilter) ([]model.Shop, i
 
 	query := r.db.WithContext(ctx).Model(&model.Shop{})
 
+	if filter.IDs != nil {
+		query = query.Where("id IN ?", filter.IDs)
+	}
 	if filter.UserID > 0 {
 		query = query.Where("user_id = ?", filter.UserID)
 	}
@@ -186,6 +203,16 @@ func (r *shopRepo) GetByProxyID(ctx context.Context, proxyID int64) ([]model.Sho
 	return shops, err
 }
 
+// ListByDeveloperID 使用指定开发者 Key 的店铺
+func (r *shopRepo) ListByDeveloperID(ctx context.Context, developerID int64) ([]model.Shop, error) {
+	var shops []model.Shop
+	err := r.db.WithContext(ctx).
+		Model(&model.Shop{}).
+		Where("developer_id = ?", developerID).
+		Find(&shops).Error
+	return shops, err
+}
+
 // FindExpiringShops 查找即将过期的店铺（Token 有效但即将过期）
 func (r *shopRepo) FindExpiringShops(ctx context.Context) ([]model.Shop, error) {
 	var shops []model.Shop
@@ -266,7 +293,9 @@ type ShopMemberRepository interface {
 	GetByUserAndShop(ctx context.Context, userID, shopID int64) (*model.ShopMember, error)
 	ListByUser(ctx context.Context, userID int64) ([]model.ShopMember, error)
 	ListByShop(ctx context.Context, shopID int64) ([]model.ShopMember, error)
+	UpdateRole(ctx context.Context, userID, shopID int64, role string) error
 	Delete(ctx context.Context, userID, shopID int64) error
+	CountByRole(ctx context.Context, shopID int64, role string) (int64, error)
 	HasAccess(ctx context.Context, userID, shopID int64) (bool, error)
 	GetUserShopIDs(ctx context.Context, userID int64) ([]int64, error)
 }
@@ -317,13 +346,29 @@ func (r *shopMemberRepository) ListByShop(ctx context.Context, shopID int64) ([]
 	return members, err
 }
 
-// Delete 删除成员关联
+// UpdateRole 更新成员角色
+func (r *shopMemberRepository) UpdateRole(ctx context.Context, userID, shopID int64, role string) error {
+	return r.db.WithContext(ctx).Model(&model.ShopMember{}).
+		Where("user_id = ? AND shop_id = ?", userID, shopID).
+		Update("role", role).Error
+}
+
+// Delete 删除成员关联（物理删除，避免软删除记录占用唯一索引导致无法重新添加）
 func (r *shopMemberRepository) Delete(ctx context.Context, userID, shopID int64) error {
-	return r.db.WithContext(ctx).
+	return r.db.WithContext(ctx).Unscoped().
 		Where("user_id = ? AND shop_id = ?", userID, shopID).
 		Delete(&model.ShopMember{}).Error
 }
 
+// CountByRole 统计店铺中指定角色的成员数
+func (r *shopMemberRepository) CountByRole(ctx context.Context, shopID int64, role string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.ShopMember{}).
+		Where("shop_id = ? AND role = ?", shopID, role).
+		Count(&count).Error
+	return count, err
+}
+
 // HasAccess 检查用户是否有店铺访问权限
 func (r *shopMemberRepository) HasAccess(ctx context.Context, userID, shopID int64) (bool, error) {
 	var count int64
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+var (
+	// ErrStockVersionConflict 盘点时库存已被其他操作修改
+	ErrStockVersionConflict = errors.New("stock version conflict")
+	// ErrStockMovementExists 同一幂等键的流水已记录（重复扣减）
+	ErrStockMovementExists = errors.New("stock movement already recorded")
+)
+
+// ==================== 仓储接口 ====================
+
+// StockRepository 主库存仓储接口
+type StockRepository interface {
+	Create(ctx context.Context, item *model.StockItem) error
+	GetByID(ctx context.Context, id int64) (*model.StockItem, error)
+	// GetBySKU 按 SKU 获取主库存，不存在时返回 nil
+	GetBySKU(ctx context.Context, sku string) (*model.StockItem, error)
+	List(ctx context.Context, keyword string, page, pageSize int) ([]model.StockItem, int64, error)
+	UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error
+
+	// ApplyDelta 原子增减库存并记录流水（movement.Before/After 由仓储回填）
+	// 幂等键已存在时返回 ErrStockMovementExists，库存不变
+	ApplyDelta(ctx context.Context, movement *model.StockMovement) (*model.StockItem, error)
+	// SetOnHand 按版本号设置库存（盘点），版本不一致时返回 ErrStockVersionConflict
+	SetOnHand(ctx context.Context, id, expectedVersion int64, onHand int, movement *model.StockMovement) (*model.StockItem, error)
+
+	ListMovements(ctx context.Context, stockItemID int64, page, pageSize int) ([]model.StockMovement, int64, error)
+}
+
+// ==================== 仓储实现 ====================
+
+type stockRepo struct {
+	db *gorm.DB
+}
+
+// NewStockRepository 创建主库存仓储
+func NewStockRepository(db *gorm.DB) StockRepository {
+	return &stockRepo{db: db}
+}
+
+func (r *stockRepo) Create(ctx context.Context, item *model.StockItem) error {
+	return r.db.WithContext(ctx).Create(item).Error
+}
+
+func (r *stockRepo) GetByID(ctx context.Context, id int64) (*model.StockItem, error) {
+	var item model.StockItem
+	if err := r.db.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *stockRepo) GetBySKU(ctx context.Context, sku string) (*model.StockItem, error) {
+	var item model.StockItem
+	err := r.db.WithContext(ctx).Where("local_sku = ?", sku).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *stockRepo) List(ctx context.Context, keyword string, page, pageSize int) ([]model.StockItem, int64, error) {
+	var items []model.StockItem
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.StockItem{})
+	if keyword != "" {
+		query = query.Where("local_sku ILIKE ? OR name ILIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	err := query.Order("local_sku ASC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&items).Error
+	return items, total, err
+}
+
+func (r *stockRepo) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.StockItem{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *stockRepo) ApplyDelta(ctx context.Context, movement *model.StockMovement) (*model.StockItem, error) {
+	var item model.StockItem
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if movement.IdempotencyKey != nil {
+			var count int64
+			if err := tx.Model(&model.StockMovement{}).
+				Where("idempotency_key = ?", *movement.IdempotencyKey).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrStockMovementExists
+			}
+		}
+
+		// 单条 UPDATE 内自增，行锁保证并发扣减不丢失；并发的同键请求由唯一索引兜底回滚
+		result := tx.Model(&model.StockItem{}).
+			Where("id = ?", movement.StockItemID).
+			Updates(map[string]interface{}{
+				"on_hand": gorm.Expr("on_hand + ?", movement.Delta),
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.First(&item, movement.StockItemID).Error; err != nil {
+			return err
+		}
+		movement.LocalSKU = item.LocalSKU
+		movement.After = item.OnHand
+		movement.Before = item.OnHand - movement.Delta
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *stockRepo) SetOnHand(ctx context.Context, id, expectedVersion int64, onHand int, movement *model.StockMovement) (*model.StockItem, error) {
+	var item model.StockItem
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&item, id).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.StockItem{}).
+			Where("id = ? AND version = ?", id, expectedVersion).
+			Updates(map[string]interface{}{
+				"on_hand": onHand,
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStockVersionConflict
+		}
+
+		movement.StockItemID = id
+		movement.LocalSKU = item.LocalSKU
+		movement.Before = item.OnHand
+		movement.After = onHand
+		movement.Delta = onHand - item.OnHand
+		if err := tx.Create(movement).Error; err != nil {
+			return err
+		}
+		return tx.First(&item, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *stockRepo) ListMovements(ctx context.Context, stockItemID int64, page, pageSize int) ([]model.StockMovement, int64, error) {
+	var movements []model.StockMovement
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.StockMovement{}).Where("stock_item_id = ?", stockItemID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	err := query.Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&movements).Error
+	return movements, total, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// ==================== 接口定义 ====================
+
+// SyncStateRepository 增量同步检查点仓储接口
+type SyncStateRepository interface {
+	// GetOrCreate 获取店铺检查点，不存在时创建初始记录
+	GetOrCreate(ctx context.Context, shopID int64, resource string) (*model.SyncState, error)
+	UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error
+	// AdvanceCursor 推进高水位（只增不减）
+	AdvanceCursor(ctx context.Context, id int64, cursor int64) error
+	ResetCursor(ctx context.Context, id int64) error
+}
+
+// ==================== 仓储实现 ====================
+
+type syncStateRepo struct {
+	db *gorm.DB
+}
+
+// NewSyncStateRepository 创建增量同步检查点仓储
+func NewSyncStateRepository(db *gorm.DB) SyncStateRepository {
+	return &syncStateRepo{db: db}
+}
+
+func (r *syncStateRepo) GetOrCreate(ctx context.Context, shopID int64, resource string) (*model.SyncState, error) {
+	var state model.SyncState
+	err := r.db.WithContext(ctx).
+		Where("shop_id = ? AND resource = ?", shopID, resource).
+		First(&state).Error
+	if err == nil {
+		return &state, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	state = model.SyncState{
+		ShopID:   shopID,
+		Resource: resource,
+		Status:   model.SyncStateIdle,
+	}
+	if err := r.db.WithContext(ctx).Create(&state).Error; err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (r *syncStateRepo) UpdateFields(ctx context.Context, id int64, fields map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&model.SyncState{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *syncStateRepo) AdvanceCursor(ctx context.Context, id int64, cursor int64) error {
+	return r.db.WithContext(ctx).
+		Model(&model.SyncState{}).
+		Where("id = ? AND cursor < ?", id, cursor).
+		Update("cursor", cursor).Error
+}
+
+func (r *syncStateRepo) ResetCursor(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&model.SyncState{}).Where("id = ?", id).Update("cursor", 0).Error
+}
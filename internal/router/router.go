@@ -3,6 +3,7 @@ package router
 import (
 	"etsy_dev_v1_202512/internal/controller"
 	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -28,6 +29,14 @@ type Controllers struct {
 	Shipment     *controller.ShipmentController
 	Karrio       *controller.KarrioController
 	Sync         *controller.SyncController
+	Storage      *controller.StorageController
+	AIUsage      *controller.AIUsageController
+	AIBudget     *controller.AIBudgetController
+	Notification *controller.NotificationController
+	ShopMember   *controller.ShopMemberController
+	Encryption   *controller.EncryptionController
+	Stock        *controller.StockController
+	Domain       *controller.DomainController
 }
 
 // ==================== 主路由设置 ====================
@@ -48,24 +57,33 @@ func SetupRouter(ctrl *Controllers) *gin.Engine {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// 本地存储静态文件（签名在控制器内校验）
+	registerStorageRoutes(r, ctrl.Storage)
+
 	public := r.Group("/")
 	{
 		// 用户登录/注册
 		registerUserAuthRoutes(public, ctrl.User)
 		// Etsy OAuth 回调
 		registerAuthRoutes(public, ctrl.Auth)
+		registerDeveloperCallbackRoutes(public, ctrl.Auth)
 	}
 
 	// API 路由组
 	api := r.Group("/api")
 	api.Use(middleware.JWTAuth())
 	api.Use(middleware.AuditContext())
+	if ctrl.ShopMember != nil {
+		api.Use(middleware.ShopAuth(ctrl.ShopMember.AccessChecker()))
+	}
 	{
 		registerUserRoutes(api, ctrl.User)
 		registerProxyRoutes(api, ctrl.Proxy)
 		registerDeveloperRoutes(api, ctrl.Developer)
+		registerDomainRoutes(api, ctrl.Domain)
 		registerAuthRoutes(api, ctrl.Auth)
 		registerShopRoutes(api, ctrl.Shop, ctrl.Shipping, ctrl.ReturnPolicy)
+		registerShopMemberRoutes(api, ctrl.ShopMember)
 		registerShippingRoutes(api, ctrl.Shipping, ctrl.ReturnPolicy)
 		registerProductRoutes(api, ctrl.Product)
 		registerDraftRoutes(api, ctrl.Draft)
@@ -73,8 +91,16 @@ func SetupRouter(ctrl *Controllers) *gin.Engine {
 		registerShipmentRoutes(api, ctrl.Shipment)
 		registerKarrioRoutes(api, ctrl.Karrio)
 		registerSyncRoutes(api, ctrl.Sync)
+		registerAIUsageRoutes(api, ctrl.AIUsage)
+		registerAIBudgetRoutes(api, ctrl.AIBudget)
+		registerNotificationRoutes(api, ctrl.Notification)
+		registerEncryptionRoutes(api, ctrl.Encryption)
+		registerStockRoutes(api, ctrl.Stock)
 	}
 
+	// SSE 通知流（EventSource 无法设置请求头，使用一次性票据认证）
+	registerNotificationStreamRoutes(r, ctrl.Notification)
+
 	// Webhook 路由（独立于 API 组）
 	webhooks := r.Group("/api/webhooks")
 	{
@@ -86,6 +112,15 @@ func SetupRouter(ctrl *Controllers) *gin.Engine {
 
 // ==================== 模块路由注册 ====================
 
+// registerStorageRoutes 本地存储文件访问路由（公开，仅 local provider）
+func registerStorageRoutes(r *gin.Engine, ctl *controller.StorageController) {
+	if ctl == nil || !ctl.Enabled() {
+		return
+	}
+
+	r.GET(ctl.RoutePrefix()+"/*key", ctl.ServeFile)
+}
+
 // registerUserAuthRoutes 用户认证路由（公开）
 func registerUserAuthRoutes(api *gin.RouterGroup, ctl *controller.UserController) {
 	if ctl == nil {
@@ -130,9 +165,14 @@ func registerProxyRoutes(api *gin.RouterGroup, ctl *controller.ProxyController)
 	proxy := api.Group("/proxies")
 	{
 		proxy.GET("", ctl.GetList)
+		proxy.GET("/shared-exits", ctl.SharedExits)
+		proxy.GET("/shops/:shop_id/bindings", shopViewer(middleware.ShopResourceShop, "shop_id"), ctl.ShopBindings)
 		proxy.GET("/:id", ctl.GetDetail)
+		proxy.GET("/:id/scores", ctl.ScoreHistory)
 		proxy.POST("", ctl.Create)
+		proxy.POST("/import", ctl.Import)
 		proxy.PUT("", ctl.Update)
+		proxy.DELETE("/:id", ctl.Delete)
 		proxy.GET("/callback", ctl.Callback)
 	}
 }
@@ -148,14 +188,30 @@ func registerDeveloperRoutes(api *gin.RouterGroup, ctl *controller.DeveloperCont
 		developer.PATCH("/:id/status", ctl.UpdateStatus)
 		developer.DELETE("/:id", ctl.Delete)
 		developer.POST("/:id/ping", ctl.TestConnectivity)
+		developer.POST("/:id/callback/verify", ctl.VerifyCallback)
+		developer.POST("/:id/callback/regenerate", ctl.RegenerateCallback)
+	}
+}
+
+// registerDomainRoutes 回调域名池路由（仅管理员）
+func registerDomainRoutes(api *gin.RouterGroup, ctl *controller.DomainController) {
+	if ctl == nil {
+		return
+	}
+
+	domains := api.Group("/domains")
+	domains.Use(middleware.RequireRole("admin"))
+	{
+		domains.GET("", ctl.List)
+		domains.POST("", ctl.Create)
+		domains.PATCH("/:id", ctl.Update)
+		domains.DELETE("/:id", ctl.Delete)
+		domains.GET("/:id/proxy-config", ctl.ExportProxyConfig)
 	}
 }
 
 // registerAuthRoutes 鉴权模块路由
 func registerAuthRoutes(api *gin.RouterGroup, ctl *controller.AuthController) {
-	// todo 正式环境通用回调
-	//api.GET("/:path/oauth/callback", ctl.Callback)
-
 	auth := api.Group("/oauth")
 	{
 		auth.GET("/login", ctl.Login)
@@ -164,6 +220,17 @@ func registerAuthRoutes(api *gin.RouterGroup, ctl *controller.AuthController) {
 	}
 }
 
+// registerDeveloperCallbackRoutes 开发者专属 OAuth 回调（公开，Etsy 直接跳转）
+func registerDeveloperCallbackRoutes(public *gin.RouterGroup, ctl *controller.AuthController) {
+	if ctl == nil {
+		return
+	}
+
+	public.GET("/:path/oauth/callback", ctl.DeveloperCallback)
+	// 早期生成的回调地址格式
+	public.GET("/api/:path/auth/callback", ctl.DeveloperCallback)
+}
+
 // registerShopRoutes 店铺模块路由
 func registerShopRoutes(
 	api *gin.RouterGroup,
@@ -171,32 +238,52 @@ func registerShopRoutes(
 	shippingCtl *controller.ShippingProfileController,
 	returnPolicyCtl *controller.ReturnPolicyController,
 ) {
+	const shop = middleware.ShopResourceShop
+
 	shops := api.Group("/shops")
 	{
-		// 店铺基础操作
+		// 店铺基础操作（列表按成员关系过滤）
 		shops.GET("", shopCtl.GetShopList)
-		shops.GET("/:id", shopCtl.GetShopDetail)
-		shops.PUT("/:id", shopCtl.UpdateShopToEtsy)
-		shops.DELETE("/:id", shopCtl.DeleteShop)
-		shops.POST("/:id/stop", shopCtl.StopShop)
-		shops.POST("/:id/resume", shopCtl.ResumeShop)
-		shops.POST("/:id/sync", shopCtl.SyncShop)
+		shops.GET("/:id", shopViewer(shop, "id"), shopCtl.GetShopDetail)
+		shops.PUT("/:id", shopManager(shop, "id"), shopCtl.UpdateShopToEtsy)
+		shops.DELETE("/:id", shopOwner(shop, "id"), shopCtl.DeleteShop)
+		shops.POST("/:id/stop", shopManager(shop, "id"), shopCtl.StopShop)
+		shops.POST("/:id/resume", shopManager(shop, "id"), shopCtl.ResumeShop)
+		shops.POST("/:id/sync", shopManager(shop, "id"), shopCtl.SyncShop)
+		shops.PUT("/:id/ai-settings", shopManager(shop, "id"), shopCtl.UpdateAISettings)
 
 		// Section 管理
-		shops.POST("/:id/sections/sync", shopCtl.SyncSections)
-		shops.POST("/:id/sections", shopCtl.CreateSection)
-		shops.PUT("/sections/:sectionId", shopCtl.UpdateSection)
-		shops.DELETE("/sections/:sectionId", shopCtl.DeleteSection)
+		shops.POST("/:id/sections/sync", shopManager(shop, "id"), shopCtl.SyncSections)
+		shops.POST("/:id/sections", shopManager(shop, "id"), shopCtl.CreateSection)
+		shops.PUT("/sections/:sectionId", shopManager(middleware.ShopResourceSection, "sectionId"), shopCtl.UpdateSection)
+		shops.DELETE("/sections/:sectionId", shopManager(middleware.ShopResourceSection, "sectionId"), shopCtl.DeleteSection)
 
 		// 店铺下的运费模板
-		shops.GET("/:id/shipping-profiles", shippingCtl.GetProfileList)
-		shops.POST("/:id/shipping-profiles", shippingCtl.CreateProfile)
-		shops.POST("/:id/shipping-profiles/sync", shippingCtl.SyncProfiles)
+		shops.GET("/:id/shipping-profiles", shopViewer(shop, "id"), shippingCtl.GetProfileList)
+		shops.POST("/:id/shipping-profiles", shopManager(shop, "id"), shippingCtl.CreateProfile)
+		shops.POST("/:id/shipping-profiles/sync", shopManager(shop, "id"), shippingCtl.SyncProfiles)
 
 		// 店铺下的退货政策
-		shops.GET("/:id/return-policies", returnPolicyCtl.GetPolicyList)
-		shops.POST("/:id/return-policies", returnPolicyCtl.CreatePolicy)
-		shops.POST("/:id/return-policies/sync", returnPolicyCtl.SyncPolicies)
+		shops.GET("/:id/return-policies", shopViewer(shop, "id"), returnPolicyCtl.GetPolicyList)
+		shops.POST("/:id/return-policies", shopManager(shop, "id"), returnPolicyCtl.CreatePolicy)
+		shops.POST("/:id/return-policies/sync", shopManager(shop, "id"), returnPolicyCtl.SyncPolicies)
+	}
+}
+
+// registerShopMemberRoutes 店铺成员管理路由（查看需 viewer，变更需 owner）
+func registerShopMemberRoutes(api *gin.RouterGroup, ctl *controller.ShopMemberController) {
+	if ctl == nil {
+		return
+	}
+
+	const shop = middleware.ShopResourceShop
+
+	members := api.Group("/shops/:id/members")
+	{
+		members.GET("", shopViewer(shop, "id"), ctl.ListMembers)
+		members.POST("", shopOwner(shop, "id"), ctl.AddMember)
+		members.PUT("/:user_id", shopOwner(shop, "id"), ctl.UpdateMember)
+		members.DELETE("/:user_id", shopOwner(shop, "id"), ctl.RemoveMember)
 	}
 }
 
@@ -206,78 +293,105 @@ func registerShippingRoutes(
 	shippingCtl *controller.ShippingProfileController,
 	returnPolicyCtl *controller.ReturnPolicyController,
 ) {
+	const (
+		profile = middleware.ShopResourceShippingProfile
+		upgrade = middleware.ShopResourceShippingUpgrade
+		policy  = middleware.ShopResourceReturnPolicy
+	)
+
 	shipping := api.Group("/shipping")
 	{
 		// 运费模板
-		shipping.GET("/:id", shippingCtl.GetProfileDetail)
-		shipping.PUT("/:id", shippingCtl.UpdateProfile)
-		shipping.DELETE("/:id", shippingCtl.DeleteProfile)
+		shipping.GET("/:id", shopViewer(profile, "id"), shippingCtl.GetProfileDetail)
+		shipping.PUT("/:id", shopManager(profile, "id"), shippingCtl.UpdateProfile)
+		shipping.DELETE("/:id", shopManager(profile, "id"), shippingCtl.DeleteProfile)
 
 		// 目的地
-		shipping.POST("/:profileID/destinations", shippingCtl.CreateDestination)
+		shipping.POST("/:profileID/destinations", shopManager(profile, "profileID"), shippingCtl.CreateDestination)
 
 		// 升级选项
-		shipping.POST("/:profileID/upgrades", shippingCtl.CreateUpgrade)
-		shipping.PUT("/upgrades/:id", shippingCtl.UpdateUpgrade)
-		shipping.DELETE("/upgrades/:id", shippingCtl.DeleteUpgrade)
+		shipping.POST("/:profileID/upgrades", shopManager(profile, "profileID"), shippingCtl.CreateUpgrade)
+		shipping.PUT("/upgrades/:id", shopManager(upgrade, "id"), shippingCtl.UpdateUpgrade)
+		shipping.DELETE("/upgrades/:id", shopManager(upgrade, "id"), shippingCtl.DeleteUpgrade)
 
 		// 退货政策
-		shipping.GET("/return-policies/:id", returnPolicyCtl.GetPolicyDetail)
-		shipping.PUT("/return-policies/:id", returnPolicyCtl.UpdatePolicy)
-		shipping.DELETE("/return-policies/:id", returnPolicyCtl.DeletePolicy)
+		shipping.GET("/return-policies/:id", shopViewer(policy, "id"), returnPolicyCtl.GetPolicyDetail)
+		shipping.PUT("/return-policies/:id", shopManager(policy, "id"), returnPolicyCtl.UpdatePolicy)
+		shipping.DELETE("/return-policies/:id", shopManager(policy, "id"), returnPolicyCtl.DeletePolicy)
 	}
 }
 
 // registerProductRoutes 商品模块路由
 func registerProductRoutes(api *gin.RouterGroup, ctl *controller.ProductController) {
+	const (
+		shop    = middleware.ShopResourceShop
+		product = middleware.ShopResourceProduct
+	)
+
 	products := api.Group("/products")
 	{
-		// 查询
-		products.GET("", ctl.GetProducts)
-		products.GET("/stats", ctl.GetProductStats)
-		products.GET("/:id", ctl.GetProduct)
+		// 查询（shop_id 查询参数必填）
+		products.GET("", shopViewer(shop, "shop_id"), ctl.GetProducts)
+		products.GET("/stats", shopViewer(shop, "shop_id"), ctl.GetProductStats)
+		products.GET("/:id", shopViewer(product, "id"), ctl.GetProduct)
 
-		// CRUD
+		// CRUD（创建时在控制器内校验请求体中的 shop_id）
 		products.POST("", ctl.CreateProduct)
-		products.PATCH("/:id", ctl.UpdateProduct)
-		products.DELETE("/:id", ctl.DeleteProduct)
+		products.PATCH("/:id", shopManager(product, "id"), ctl.UpdateProduct)
+		products.DELETE("/:id", shopManager(product, "id"), ctl.DeleteProduct)
 
 		// 状态变更
-		products.POST("/:id/activate", ctl.ActivateProduct)
-		products.POST("/:id/deactivate", ctl.DeactivateProduct)
+		products.POST("/:id/activate", shopManager(product, "id"), ctl.ActivateProduct)
+		products.POST("/:id/deactivate", shopManager(product, "id"), ctl.DeactivateProduct)
 
 		// AI 草稿
 		products.POST("/ai/generate", ctl.GenerateAIDraft)
-		products.POST("/:id/approve", ctl.ApproveAIDraft)
+		products.POST("/:id/approve", shopManager(product, "id"), ctl.ApproveAIDraft)
 
 		// 同步 & 图片
-		products.POST("/sync", ctl.SyncProducts)
-		products.POST("/:id/images", ctl.UploadImage)
+		products.POST("/sync", shopManager(shop, "shop_id"), ctl.SyncProducts)
+		products.POST("/:id/images", shopManager(product, "id"), ctl.UploadImage)
+
+		// 库存 / 变体
+		products.GET("/:id/inventory", shopViewer(product, "id"), ctl.GetInventory)
+		products.PUT("/:id/inventory", shopManager(product, "id"), ctl.UpdateInventory)
+		products.POST("/:id/inventory/sync", shopManager(product, "id"), ctl.SyncInventory)
+		products.PATCH("/:id/variants/:variant_id", shopManager(product, "id"), ctl.UpdateVariant)
 	}
 }
 
 // registerDraftRoutes 草稿模块路由
 func registerDraftRoutes(api *gin.RouterGroup, ctl *controller.DraftController) {
+	const (
+		task    = middleware.ShopResourceDraftTask
+		product = middleware.ShopResourceDraftProduct
+	)
+
 	drafts := api.Group("/drafts")
 	{
-		// 任务列表与创建
+		// 任务列表与创建（列表按成员关系过滤，创建时在控制器内校验请求体中的 shop_ids）
 		drafts.GET("", ctl.ListDraftTasks)
 		drafts.POST("", ctl.CreateDraft)
 
 		// 支持的平台
 		drafts.GET("/platforms", ctl.GetSupportedPlatforms)
 
-		// 任务详情与操作
-		drafts.GET("/:task_id", ctl.GetDraftDetail)
-		drafts.GET("/:task_id/stream", ctl.StreamProgress)
-		drafts.POST("/:task_id/confirm-all", ctl.ConfirmAllDrafts)
-		drafts.POST("/:task_id/regenerate-images", ctl.RegenerateImages)
+		// 提交失败处理（按成员关系过滤）
+		drafts.GET("/submissions/failed", ctl.ListSubmitFailures)
+
+		// 任务详情与操作（需对任务涉及的全部店铺有权限）
+		drafts.GET("/:task_id", shopViewer(task, "task_id"), ctl.GetDraftDetail)
+		drafts.GET("/:task_id/stream", shopViewer(task, "task_id"), ctl.StreamProgress)
+		drafts.POST("/:task_id/confirm-all", shopManager(task, "task_id"), ctl.ConfirmAllDrafts)
+		drafts.POST("/:task_id/regenerate-images", shopManager(task, "task_id"), ctl.RegenerateImages)
 
 		// 草稿商品操作
 		draftProducts := drafts.Group("/products")
 		{
-			draftProducts.PATCH("/:product_id", ctl.UpdateDraftProduct)
-			draftProducts.POST("/:product_id/confirm", ctl.ConfirmDraftProduct)
+			draftProducts.PATCH("/:product_id", shopManager(product, "product_id"), ctl.UpdateDraftProduct)
+			draftProducts.POST("/:product_id/confirm", shopManager(product, "product_id"), ctl.ConfirmDraftProduct)
+			draftProducts.POST("/:product_id/retry-submit", shopManager(product, "product_id"), ctl.RetrySubmit)
+			draftProducts.POST("/:product_id/abandon-submit", shopManager(product, "product_id"), ctl.AbandonSubmit)
 		}
 	}
 }
@@ -288,36 +402,137 @@ func registerOrderRoutes(api *gin.RouterGroup, ctl *controller.OrderController)
 		return
 	}
 
+	const (
+		shop  = middleware.ShopResourceShop
+		order = middleware.ShopResourceOrder
+	)
+
 	orders := api.Group("/orders")
 	{
-		// 订单列表与详情
-		orders.GET("", ctl.List)
-		orders.GET("/:id", ctl.GetByID)
+		// 订单列表与详情（列表 shop_id 查询参数必填）
+		orders.GET("", shopViewer(shop, "shop_id"), ctl.List)
+		orders.GET("/:id", shopViewer(order, "id"), ctl.GetByID)
 
-		// 订单同步
+		// 订单同步（控制器内校验请求体中的 shop_id）
 		orders.POST("/sync", ctl.SyncOrders)
 
 		// 订单状态更新
-		orders.PATCH("/:id/status", ctl.UpdateStatus)
-		orders.PATCH("/:id/note", ctl.UpdateNote)
+		orders.PATCH("/:id/status", shopManager(order, "id"), ctl.UpdateStatus)
+		orders.PATCH("/:id/note", shopManager(order, "id"), ctl.UpdateNote)
 
 		// 订单统计
-		orders.GET("/stats", ctl.GetStats)
+		orders.GET("/stats", shopViewer(shop, "shop_id"), ctl.GetStats)
 
 		// 订单下的发货信息
-		orders.GET("/:id/shipment", ctl.GetShipment)
+		orders.GET("/:id/shipment", shopViewer(order, "id"), ctl.GetShipment)
+	}
+}
+
+// registerAIUsageRoutes AI 用量路由
+func registerAIUsageRoutes(api *gin.RouterGroup, ctl *controller.AIUsageController) {
+	if ctl == nil {
+		return
+	}
+
+	usage := api.Group("/ai/usage")
+	{
+		usage.GET("/shops/:id", shopViewer(middleware.ShopResourceShop, "id"), ctl.ShopUsage)
+		usage.GET("/tasks/:id", shopViewer(middleware.ShopResourceDraftTask, "id"), ctl.TaskUsage)
+		usage.GET("/daily", ctl.DailyUsage)
 	}
 }
 
+// registerAIBudgetRoutes AI 预算路由
+func registerAIBudgetRoutes(api *gin.RouterGroup, ctl *controller.AIBudgetController) {
+	if ctl == nil {
+		return
+	}
+
+	budgets := api.Group("/ai/budgets")
+	{
+		budgets.GET("", ctl.List)
+		budgets.GET("/:scope/:scope_id", ctl.GetStatus)
+	}
+
+	// 预算配置与放行（仅管理员）
+	admin := api.Group("/ai/budgets")
+	admin.Use(middleware.RequireRole("admin"))
+	{
+		admin.PUT("/:scope/:scope_id", ctl.Set)
+		admin.DELETE("/:scope/:scope_id", ctl.Delete)
+		admin.POST("/:scope/:scope_id/override", ctl.Override)
+	}
+}
+
+// registerStockRoutes 跨店铺主库存路由（涉及多个店铺，仅管理员）
+func registerStockRoutes(api *gin.RouterGroup, ctl *controller.StockController) {
+	if ctl == nil {
+		return
+	}
+
+	stock := api.Group("/stock")
+	stock.Use(middleware.RequireRole("admin"))
+	{
+		stock.GET("", ctl.List)
+		stock.POST("", ctl.Create)
+		stock.GET("/:id", ctl.Get)
+		stock.GET("/:id/movements", ctl.Movements)
+		stock.POST("/:id/adjust", ctl.Adjust)
+		stock.POST("/:id/count", ctl.Count)
+		stock.POST("/:id/push", ctl.Push)
+	}
+}
+
+// registerEncryptionRoutes 字段加密维护路由（仅管理员）
+func registerEncryptionRoutes(api *gin.RouterGroup, ctl *controller.EncryptionController) {
+	if ctl == nil {
+		return
+	}
+
+	encryption := api.Group("/admin/encryption")
+	encryption.Use(middleware.RequireRole("admin"))
+	{
+		encryption.GET("/status", ctl.Status)
+		encryption.POST("/reencrypt", ctl.Reencrypt)
+	}
+}
+
+// registerNotificationRoutes 站内通知路由（当前用户）
+func registerNotificationRoutes(api *gin.RouterGroup, ctl *controller.NotificationController) {
+	if ctl == nil {
+		return
+	}
+
+	notifications := api.Group("/notifications")
+	{
+		notifications.GET("", ctl.List)
+		notifications.POST("/stream-ticket", ctl.StreamTicket)
+		notifications.GET("/unread-count", ctl.UnreadCount)
+		notifications.POST("/read", ctl.MarkRead)
+		notifications.POST("/read-all", ctl.MarkAllRead)
+	}
+}
+
+// registerNotificationStreamRoutes SSE 通知流路由（票据认证，不经 JWTAuth）
+func registerNotificationStreamRoutes(r *gin.Engine, ctl *controller.NotificationController) {
+	if ctl == nil {
+		return
+	}
+
+	r.GET("/api/notifications/stream", middleware.StreamTicketAuth(), middleware.AuditContext(), ctl.Stream)
+}
+
 // registerShipmentRoutes 发货模块路由
 func registerShipmentRoutes(api *gin.RouterGroup, ctl *controller.ShipmentController) {
 	if ctl == nil {
 		return
 	}
 
+	const shipment = middleware.ShopResourceShipment
+
 	shipments := api.Group("/shipments")
 	{
-		// 发货列表与创建
+		// 发货列表与创建（列表按成员关系过滤，创建时在控制器内校验订单所属店铺）
 		shipments.GET("", ctl.List)
 		shipments.POST("", ctl.Create)
 		shipments.POST("/with-label", ctl.CreateWithLabel)
@@ -326,9 +541,9 @@ func registerShipmentRoutes(api *gin.RouterGroup, ctl *controller.ShipmentContro
 		shipments.GET("/carriers", ctl.GetCarriers)
 
 		// 发货详情与操作
-		shipments.GET("/:id", ctl.GetByID)
-		shipments.POST("/:id/refresh-tracking", ctl.RefreshTracking)
-		shipments.POST("/:id/sync-etsy", ctl.SyncToEtsy)
+		shipments.GET("/:id", shopViewer(shipment, "id"), ctl.GetByID)
+		shipments.POST("/:id/refresh-tracking", shopManager(shipment, "id"), ctl.RefreshTracking)
+		shipments.POST("/:id/sync-etsy", shopManager(shipment, "id"), ctl.SyncToEtsy)
 	}
 }
 
@@ -386,12 +601,14 @@ func registerSyncRoutes(r *gin.RouterGroup, ctrl *controller.SyncController) {
 
 		// 同步单个店铺（限流：5 分钟）
 		sync.POST("/shops/:id",
+			shopManager(middleware.ShopResourceShop, "id"),
 			middleware.SyncRateLimit(middleware.SyncTypeShop, 0),
 			ctrl.SyncShop,
 		)
 
-		// 同步所有店铺（全局限流：5 分钟）
+		// 同步所有店铺（仅管理员，全局限流：5 分钟）
 		sync.POST("/shops",
+			middleware.RequireRole("admin"),
 			middleware.GlobalSyncRateLimit(middleware.SyncTypeShop, 0),
 			ctrl.SyncAllShops,
 		)
@@ -400,12 +617,14 @@ func registerSyncRoutes(r *gin.RouterGroup, ctrl *controller.SyncController) {
 
 		// 同步单个店铺商品（限流：5 分钟）
 		sync.POST("/products/:shop_id",
+			shopManager(middleware.ShopResourceShop, "shop_id"),
 			middleware.SyncRateLimit(middleware.SyncTypeProduct, 0),
 			ctrl.SyncProducts,
 		)
 
-		// 同步所有商品（全局限流：5 分钟）
+		// 同步所有商品（仅管理员，全局限流：5 分钟）
 		sync.POST("/products",
+			middleware.RequireRole("admin"),
 			middleware.GlobalSyncRateLimit(middleware.SyncTypeProduct, 0),
 			ctrl.SyncAllProducts,
 		)
@@ -414,20 +633,23 @@ func registerSyncRoutes(r *gin.RouterGroup, ctrl *controller.SyncController) {
 
 		// 同步单个店铺订单（限流：3 分钟）
 		sync.POST("/orders/:shop_id",
+			shopManager(middleware.ShopResourceShop, "shop_id"),
 			middleware.SyncRateLimit(middleware.SyncTypeOrder, 0),
 			ctrl.SyncOrders,
 		)
 
-		// 同步所有订单（全局限流：3 分钟）
+		// 同步所有订单（仅管理员，全局限流：3 分钟）
 		sync.POST("/orders",
+			middleware.RequireRole("admin"),
 			middleware.GlobalSyncRateLimit(middleware.SyncTypeOrder, 0),
 			ctrl.SyncAllOrders,
 		)
 
 		// ==================== 物流同步 ====================
 
-		// 刷新物流跟踪（全局限流：2 分钟）
+		// 刷新物流跟踪（仅管理员，全局限流：2 分钟）
 		sync.POST("/tracking/refresh",
+			middleware.RequireRole("admin"),
 			middleware.GlobalSyncRateLimit(middleware.SyncTypeTracking, 0),
 			ctrl.RefreshTracking,
 		)
@@ -453,3 +675,20 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// ==================== 店铺级权限简写 ====================
+
+// shopViewer 要求对资源所属店铺至少有 viewer 角色
+func shopViewer(resource, param string) gin.HandlerFunc {
+	return middleware.RequireShopRole(model.ShopMemberRoleViewer, resource, param)
+}
+
+// shopManager 要求对资源所属店铺至少有 manager 角色
+func shopManager(resource, param string) gin.HandlerFunc {
+	return middleware.RequireShopRole(model.ShopMemberRoleManager, resource, param)
+}
+
+// shopOwner 要求对资源所属店铺有 owner 角色
+func shopOwner(resource, param string) gin.HandlerFunc {
+	return middleware.RequireShopRole(model.ShopMemberRoleOwner, resource, param)
+}
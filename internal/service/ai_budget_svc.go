@@ -0,0 +1,394 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ==================== 错误定义 ====================
+
+// ErrAIBudgetExhausted AI 预算已用尽
+var ErrAIBudgetExhausted = errors.New("AI 预算已用尽")
+
+// ErrInvalidBudgetScope 预算范围无效
+var ErrInvalidBudgetScope = errors.New("无效的预算范围")
+
+// AIBudgetExceededError 预算超限详情
+type AIBudgetExceededError struct {
+	Scope    string
+	ScopeID  int64
+	Period   string
+	SpentUSD float64
+	LimitUSD float64
+}
+
+func (e *AIBudgetExceededError) Error() string {
+	return fmt.Sprintf("%v: %s %d %s已花费 $%.4f / 预算 $%.2f",
+		ErrAIBudgetExhausted, budgetScopeName(e.Scope), e.ScopeID, budgetPeriodName(e.Period), e.SpentUSD, e.LimitUSD)
+}
+
+func (e *AIBudgetExceededError) Is(target error) bool {
+	return target == ErrAIBudgetExhausted
+}
+
+// ==================== 告警 ====================
+
+// AIBudgetWarning 预算告警
+type AIBudgetWarning struct {
+	Scope    string  `json:"scope"`
+	ScopeID  int64   `json:"scope_id"`
+	Period   string  `json:"period"`
+	Percent  int     `json:"percent"`
+	SpentUSD float64 `json:"spent_usd"`
+	LimitUSD float64 `json:"limit_usd"`
+}
+
+// AIBudgetWarningHandler 告警回调
+type AIBudgetWarningHandler func(ctx context.Context, warning AIBudgetWarning)
+
+// defaultWarnPercents 未配置阈值时的默认告警点
+var defaultWarnPercents = []int{80, 95}
+
+// ==================== 服务 ====================
+
+// AIBudgetService AI 花费预算服务
+// 预算按 ai_call_logs 的成本累计计算；店铺预算与用户预算同时生效
+type AIBudgetService struct {
+	budgetRepo  repository.AIBudgetRepository
+	callLogRepo repository.AICallLogRepository
+
+	warnHandler AIBudgetWarningHandler
+
+	// 告警去重：同一周期同一阈值只告警一次
+	warnedMu sync.Mutex
+	warned   map[string]struct{}
+	warnDay  string
+}
+
+// NewAIBudgetService 创建 AI 预算服务
+func NewAIBudgetService(budgetRepo repository.AIBudgetRepository, callLogRepo repository.AICallLogRepository) *AIBudgetService {
+	return &AIBudgetService{
+		budgetRepo:  budgetRepo,
+		callLogRepo: callLogRepo,
+		warned:      make(map[string]struct{}),
+	}
+}
+
+// SetWarningHandler 设置告警回调（默认仅打日志）
+func (s *AIBudgetService) SetWarningHandler(handler AIBudgetWarningHandler) {
+	s.warnHandler = handler
+}
+
+// CheckBudget 调用前检查店铺/用户预算，超限返回 ErrAIBudgetExhausted
+func (s *AIBudgetService) CheckBudget(ctx context.Context, shopID, userID int64) error {
+	if shopID > 0 {
+		if err := s.checkScope(ctx, model.AIBudgetScopeShop, shopID); err != nil {
+			return err
+		}
+	}
+	if userID > 0 {
+		if err := s.checkScope(ctx, model.AIBudgetScopeUser, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AIBudgetStatus 预算使用情况
+type AIBudgetStatus struct {
+	Budget          *model.AIBudget `json:"budget"`
+	Inherited       bool            `json:"inherited"` // 是否使用默认预算
+	DailySpentUSD   float64         `json:"daily_spent_usd"`
+	MonthlySpentUSD float64         `json:"monthly_spent_usd"`
+	Overridden      bool            `json:"overridden"`
+	Exhausted       bool            `json:"exhausted"`
+}
+
+// GetStatus 查询预算使用情况
+func (s *AIBudgetService) GetStatus(ctx context.Context, scope string, scopeID int64) (*AIBudgetStatus, error) {
+	if err := validateBudgetScope(scope); err != nil {
+		return nil, err
+	}
+
+	budget, err := s.effectiveBudget(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status := &AIBudgetStatus{Budget: budget}
+	if status.DailySpentUSD, err = s.spent(ctx, scope, scopeID, periodStart(model.AIBudgetPeriodDaily, now)); err != nil {
+		return nil, err
+	}
+	if status.MonthlySpentUSD, err = s.spent(ctx, scope, scopeID, periodStart(model.AIBudgetPeriodMonthly, now)); err != nil {
+		return nil, err
+	}
+
+	if budget != nil {
+		status.Inherited = budget.ScopeID != scopeID
+		status.Overridden = budget.IsOverridden(now)
+		status.Exhausted = budget.Enabled && !status.Overridden &&
+			(overLimit(status.DailySpentUSD, budget.DailyLimitUSD) || overLimit(status.MonthlySpentUSD, budget.MonthlyLimitUSD))
+	}
+	return status, nil
+}
+
+// ==================== 管理接口 ====================
+
+// ListBudgets 预算列表
+func (s *AIBudgetService) ListBudgets(ctx context.Context, filter repository.AIBudgetFilter) ([]model.AIBudget, error) {
+	return s.budgetRepo.List(ctx, filter)
+}
+
+// SetBudget 设置预算（不存在则创建）
+func (s *AIBudgetService) SetBudget(ctx context.Context, budget *model.AIBudget) (*model.AIBudget, error) {
+	if err := validateBudgetScope(budget.Scope); err != nil {
+		return nil, err
+	}
+	if budget.DailyLimitUSD < 0 || budget.MonthlyLimitUSD < 0 {
+		return nil, fmt.Errorf("预算金额不能为负数")
+	}
+	for _, p := range budget.WarnPercents {
+		if p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("告警阈值必须在 1-99 之间")
+		}
+	}
+
+	if err := s.budgetRepo.Upsert(ctx, budget); err != nil {
+		return nil, err
+	}
+	return s.budgetRepo.GetByScope(ctx, budget.Scope, budget.ScopeID)
+}
+
+// DeleteBudget 删除预算
+func (s *AIBudgetService) DeleteBudget(ctx context.Context, scope string, scopeID int64) error {
+	if err := validateBudgetScope(scope); err != nil {
+		return err
+	}
+	return s.budgetRepo.Delete(ctx, scope, scopeID)
+}
+
+// Override 管理员临时放行（until 为零值时取消放行）
+// 仅继承默认预算时，复制默认限额创建专属预算承载放行，不影响其他店铺/用户
+func (s *AIBudgetService) Override(ctx context.Context, scope string, scopeID int64, until time.Time, reason string, operatorID int64) (*model.AIBudget, error) {
+	if err := validateBudgetScope(scope); err != nil {
+		return nil, err
+	}
+
+	budget, err := s.effectiveBudget(ctx, scope, scopeID)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, fmt.Errorf("%s %d 未配置预算", budgetScopeName(scope), scopeID)
+	}
+
+	if budget.ScopeID != scopeID {
+		if until.IsZero() {
+			// 没有专属预算，也就没有需要取消的放行
+			return budget, nil
+		}
+		if err := s.budgetRepo.CreateIfAbsent(ctx, &model.AIBudget{
+			Scope:           scope,
+			ScopeID:         scopeID,
+			DailyLimitUSD:   budget.DailyLimitUSD,
+			MonthlyLimitUSD: budget.MonthlyLimitUSD,
+			WarnPercents:    budget.WarnPercents,
+			Enabled:         budget.Enabled,
+		}); err != nil {
+			return nil, err
+		}
+		if budget, err = s.budgetRepo.GetByScope(ctx, scope, scopeID); err != nil {
+			return nil, err
+		}
+		if budget == nil {
+			return nil, fmt.Errorf("%s %d 创建专属预算失败", budgetScopeName(scope), scopeID)
+		}
+	}
+
+	fields := map[string]interface{}{
+		"override_until":  nil,
+		"override_reason": "",
+		"override_by":     operatorID,
+	}
+	if !until.IsZero() {
+		if !until.After(time.Now()) {
+			return nil, fmt.Errorf("放行截止时间必须晚于当前时间")
+		}
+		fields["override_until"] = until
+		fields["override_reason"] = reason
+	}
+
+	if err := s.budgetRepo.UpdateFields(ctx, budget.ID, fields); err != nil {
+		return nil, err
+	}
+	return s.budgetRepo.GetByScope(ctx, scope, scopeID)
+}
+
+// ==================== 内部方法 ====================
+
+// checkScope 检查单个范围的预算
+func (s *AIBudgetService) checkScope(ctx context.Context, scope string, scopeID int64) error {
+	budget, err := s.effectiveBudget(ctx, scope, scopeID)
+	if err != nil {
+		// 预算查询失败不阻断业务
+		log.Printf("[AIBudget] 查询预算失败 %s=%d: %v", scope, scopeID, err)
+		return nil
+	}
+	if budget == nil || !budget.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	limits := []struct {
+		period string
+		limit  float64
+	}{
+		{model.AIBudgetPeriodDaily, budget.DailyLimitUSD},
+		{model.AIBudgetPeriodMonthly, budget.MonthlyLimitUSD},
+	}
+
+	for _, l := range limits {
+		if l.limit <= 0 {
+			continue
+		}
+
+		spent, err := s.spent(ctx, scope, scopeID, periodStart(l.period, now))
+		if err != nil {
+			log.Printf("[AIBudget] 统计花费失败 %s=%d: %v", scope, scopeID, err)
+			continue
+		}
+
+		s.maybeWarn(ctx, budget, scope, scopeID, l.period, spent, l.limit, now)
+
+		if overLimit(spent, l.limit) && !budget.IsOverridden(now) {
+			return &AIBudgetExceededError{
+				Scope:    scope,
+				ScopeID:  scopeID,
+				Period:   l.period,
+				SpentUSD: spent,
+				LimitUSD: l.limit,
+			}
+		}
+	}
+	return nil
+}
+
+// effectiveBudget 优先使用专属预算，其次使用该范围的默认预算
+func (s *AIBudgetService) effectiveBudget(ctx context.Context, scope string, scopeID int64) (*model.AIBudget, error) {
+	budget, err := s.budgetRepo.GetByScope(ctx, scope, scopeID)
+	if err != nil || budget != nil || scopeID == 0 {
+		return budget, err
+	}
+	return s.budgetRepo.GetByScope(ctx, scope, 0)
+}
+
+func (s *AIBudgetService) spent(ctx context.Context, scope string, scopeID int64, since time.Time) (float64, error) {
+	filter := repository.AICostFilter{StartTime: since}
+	if scope == model.AIBudgetScopeShop {
+		filter.ShopID = scopeID
+	} else {
+		filter.UserID = scopeID
+	}
+	return s.callLogRepo.GetCost(ctx, filter)
+}
+
+// maybeWarn 触发跨过的最高告警阈值（同周期内去重）
+func (s *AIBudgetService) maybeWarn(ctx context.Context, budget *model.AIBudget, scope string, scopeID int64, period string, spent, limit float64, now time.Time) {
+	percents := append([]int(nil), budget.WarnPercents...)
+	if len(percents) == 0 {
+		percents = append(percents, defaultWarnPercents...)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(percents)))
+
+	reached := 0
+	for _, p := range percents {
+		if spent >= limit*float64(p)/100 {
+			reached = p
+			break
+		}
+	}
+	if overLimit(spent, limit) {
+		reached = 100
+	}
+	if reached == 0 {
+		return
+	}
+
+	periodKey := now.Format("2006-01-02")
+	if period == model.AIBudgetPeriodMonthly {
+		periodKey = now.Format("2006-01")
+	}
+	key := fmt.Sprintf("%s:%d:%s:%s:%d", scope, scopeID, period, periodKey, reached)
+
+	s.warnedMu.Lock()
+	if day := now.Format("2006-01-02"); day != s.warnDay {
+		// 跨天清理（月度 key 在新的一天会重新告警一次，可接受）
+		s.warned = make(map[string]struct{})
+		s.warnDay = day
+	}
+	_, done := s.warned[key]
+	s.warned[key] = struct{}{}
+	s.warnedMu.Unlock()
+
+	if done {
+		return
+	}
+
+	warning := AIBudgetWarning{
+		Scope:    scope,
+		ScopeID:  scopeID,
+		Period:   period,
+		Percent:  reached,
+		SpentUSD: spent,
+		LimitUSD: limit,
+	}
+	log.Printf("[AIBudget] 预算告警: %s %d %s已花费 $%.4f / $%.2f (%d%%)",
+		budgetScopeName(scope), scopeID, budgetPeriodName(period), spent, limit, reached)
+
+	if s.warnHandler != nil {
+		s.warnHandler(ctx, warning)
+	}
+}
+
+// ==================== 工具函数 ====================
+
+func overLimit(spent, limit float64) bool {
+	return limit > 0 && spent >= limit
+}
+
+func periodStart(period string, now time.Time) time.Time {
+	y, m, d := now.Date()
+	if period == model.AIBudgetPeriodMonthly {
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+func validateBudgetScope(scope string) error {
+	if scope != model.AIBudgetScopeShop && scope != model.AIBudgetScopeUser {
+		return fmt.Errorf("%w: %s", ErrInvalidBudgetScope, scope)
+	}
+	return nil
+}
+
+func budgetScopeName(scope string) string {
+	if scope == model.AIBudgetScopeShop {
+		return "店铺"
+	}
+	return "用户"
+}
+
+func budgetPeriodName(period string) string {
+	if period == model.AIBudgetPeriodMonthly {
+		return "本月"
+	}
+	return "今日"
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// ==================== Fake Provider (本地/测试) ====================
+
+// FakeAIProvider 确定性 Fake Provider
+// 相同输入始终得到相同输出，不发起网络请求、不产生费用，用于本地开发与测试
+type FakeAIProvider struct{}
+
+// NewFakeAIProvider 创建 Fake Provider
+func NewFakeAIProvider() *FakeAIProvider {
+	return &FakeAIProvider{}
+}
+
+func (p *FakeAIProvider) Name() string              { return AIProviderFake }
+func (p *FakeAIProvider) DefaultTextModel() string  { return "fake-text" }
+func (p *FakeAIProvider) DefaultImageModel() string { return "fake-image" }
+
+// GenerateText 返回由 prompt 哈希决定的商品文案（JSON）
+func (p *FakeAIProvider) GenerateText(ctx context.Context, req *LLMTextRequest) (*LLMTextResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hash := fakeHash(req.Prompt)
+	product := fakePromptField(req.Prompt, "Product:")
+	if product == "" {
+		product = "Handmade Item"
+	}
+	style := fakePromptField(req.Prompt, "Style Hint:")
+
+	title := fmt.Sprintf("%s %s", product, hash[:6])
+	if len(title) > 140 {
+		title = title[:140]
+	}
+
+	tags := make([]string, 0, 13)
+	for i := 0; i < 13; i++ {
+		tags = append(tags, fmt.Sprintf("tag %s%d", hash[i:i+2], i))
+	}
+
+	text, _ := json.Marshal(TextGenerateResult{
+		Title:       title,
+		Description: fmt.Sprintf("%s. Style: %s. Reference: %s.", product, style, hash[:12]),
+		Tags:        tags,
+	})
+
+	return &LLMTextResponse{
+		Text:  string(text),
+		Usage: AIUsage{InputTokens: len(req.Prompt) / 4, OutputTokens: len(text) / 4},
+	}, nil
+}
+
+// GenerateImage 返回由 prompt 哈希决定颜色的纯色 PNG
+func (p *FakeAIProvider) GenerateImage(ctx context.Context, req *ImageGenRequest) (*ImageGenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	count := req.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	result := &ImageGenResponse{Usage: AIUsage{InputTokens: len(req.Prompt) / 4}}
+	for i := 0; i < count; i++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", req.Prompt, i)))
+		data, err := fakePNG(color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255})
+		if err != nil {
+			return result, err
+		}
+		result.Images = append(result.Images, base64.StdEncoding.EncodeToString(data))
+	}
+	return result, nil
+}
+
+func fakeHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// fakePromptField 提取 prompt 中 "Key: value" 行的值
+func fakePromptField(prompt, key string) string {
+	for _, line := range strings.Split(prompt, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, key) {
+			return strings.TrimSpace(strings.TrimPrefix(line, key))
+		}
+	}
+	return ""
+}
+
+func fakePNG(c color.RGBA) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// ==================== Gemini / Imagen Provider ====================
+
+const geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/models"
+
+// GeminiProvider Google Gemini（文本 + 图片），imagen-* 模型走 Imagen predict 接口
+type GeminiProvider struct {
+	apiKey     string
+	textModel  string
+	imageModel string
+}
+
+// NewGeminiProvider 创建 Gemini Provider
+func NewGeminiProvider(apiKey, textModel, imageModel string) *GeminiProvider {
+	if textModel == "" {
+		textModel = "gemini-3-flash"
+	}
+	if imageModel == "" {
+		imageModel = "gemini-3-pro-image-preview-2k"
+	}
+	return &GeminiProvider{
+		apiKey:     apiKey,
+		textModel:  textModel,
+		imageModel: imageModel,
+	}
+}
+
+func (p *GeminiProvider) Name() string              { return AIProviderGemini }
+func (p *GeminiProvider) DefaultTextModel() string  { return p.textModel }
+func (p *GeminiProvider) DefaultImageModel() string { return p.imageModel }
+
+// geminiUsage Gemini 响应中的 usageMetadata
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// toAIUsage 思考 token 按输出计费
+func (u *geminiUsage) toAIUsage() AIUsage {
+	if u == nil {
+		return AIUsage{}
+	}
+	return AIUsage{
+		InputTokens:  u.PromptTokenCount,
+		OutputTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+	}
+}
+
+// GenerateText 调用 generateContent 生成文本
+func (p *GeminiProvider) GenerateText(ctx context.Context, req *LLMTextRequest) (*LLMTextResponse, error) {
+	reqBody := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"parts": []map[string]interface{}{{"text": req.Prompt}}},
+		},
+	}
+	if req.JSONMode {
+		reqBody["generationConfig"] = map[string]interface{}{
+			"responseMimeType": "application/json",
+		}
+	}
+
+	respBody, usage, err := p.generateContent(ctx, req.Model, reqBody, 60*time.Second)
+	result := &LLMTextResponse{Usage: usage.toAIUsage()}
+	if err != nil {
+		return result, err
+	}
+
+	var geminiResp struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+
+	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
+		return result, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	if len(geminiResp.Candidates) == 0 {
+		return result, fmt.Errorf("无生成结果")
+	}
+
+	for _, candidate := range geminiResp.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if part.Text != "" {
+				result.Text = part.Text
+				return result, nil
+			}
+		}
+	}
+
+	return result, fmt.Errorf("无生成结果")
+}
+
+// GenerateImage 生成图片
+// Gemini 多模态模型单次返回一张图片；imagen-* 模型支持一次生成多张
+func (p *GeminiProvider) GenerateImage(ctx context.Context, req *ImageGenRequest) (*ImageGenResponse, error) {
+	if strings.HasPrefix(req.Model, "imagen") {
+		return p.generateImagen(ctx, req)
+	}
+
+	// 构建请求体
+	parts := []map[string]interface{}{
+		{"text": req.Prompt},
+	}
+
+	// 如果有参考图片，添加到请求中
+	if len(req.ReferenceImage) > 0 {
+		parts = append(parts, map[string]interface{}{
+			"inline_data": map[string]interface{}{
+				"mime_type": req.ReferenceMimeType,
+				"data":      base64.StdEncoding.EncodeToString(req.ReferenceImage),
+			},
+		})
+	}
+
+	reqBody := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"parts": parts},
+		},
+		"generationConfig": map[string]interface{}{
+			"responseModalities": []string{"TEXT", "IMAGE"},
+		},
+	}
+
+	respBody, usage, err := p.generateContent(ctx, req.Model, reqBody, 60*time.Second)
+	result := &ImageGenResponse{Usage: usage.toAIUsage()}
+	if err != nil {
+		return result, err
+	}
+
+	// 解析响应，提取生成的图片
+	var geminiResp struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text       string `json:"text,omitempty"`
+					InlineData *struct {
+						MimeType string `json:"mimeType"`
+						Data     string `json:"data"`
+					} `json:"inlineData,omitempty"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
+		return result, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	if geminiResp.Error != nil {
+		return result, fmt.Errorf("API错误: %s", geminiResp.Error.Message)
+	}
+
+	// 查找图片数据
+	for _, candidate := range geminiResp.Candidates {
+		for _, part := range candidate.Content.Parts {
+			if part.InlineData != nil && part.InlineData.Data != "" {
+				result.Images = append(result.Images, part.InlineData.Data)
+			}
+		}
+	}
+
+	if len(result.Images) == 0 {
+		return result, fmt.Errorf("响应中未找到图片数据")
+	}
+	return result, nil
+}
+
+// generateImagen 调用 Imagen predict 接口
+func (p *GeminiProvider) generateImagen(ctx context.Context, req *ImageGenRequest) (*ImageGenResponse, error) {
+	count := req.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	reqBody := map[string]interface{}{
+		"instances": []map[string]interface{}{
+			{"prompt": req.Prompt},
+		},
+		"parameters": map[string]interface{}{
+			"sampleCount": count,
+			"aspectRatio": "1:1",
+		},
+	}
+
+	url := fmt.Sprintf("%s/%s:predict?key=%s", geminiBaseURL, req.Model, p.apiKey)
+	respBody, err := postAIJSON(ctx, url, nil, reqBody, 120*time.Second)
+	result := &ImageGenResponse{}
+	if err != nil {
+		return result, err
+	}
+
+	var imagenResp struct {
+		Predictions []struct {
+			BytesBase64Encoded string `json:"bytesBase64Encoded"`
+			MimeType           string `json:"mimeType"`
+		} `json:"predictions"`
+	}
+
+	if err := json.Unmarshal(respBody, &imagenResp); err != nil {
+		return result, fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	for _, pred := range imagenResp.Predictions {
+		if pred.BytesBase64Encoded != "" {
+			result.Images = append(result.Images, pred.BytesBase64Encoded)
+		}
+	}
+
+	return result, nil
+}
+
+// generateContent 调用 generateContent 接口，返回原始响应与用量
+func (p *GeminiProvider) generateContent(ctx context.Context, modelName string, reqBody interface{}, timeout time.Duration) ([]byte, *geminiUsage, error) {
+	url := fmt.Sprintf("%s/%s:generateContent?key=%s", geminiBaseURL, modelName, p.apiKey)
+
+	respBody, err := postAIJSON(ctx, url, nil, reqBody, timeout)
+
+	// 错误响应中也可能带有 usageMetadata（已计费）
+	var meta struct {
+		UsageMetadata *geminiUsage `json:"usageMetadata"`
+	}
+	if len(respBody) > 0 {
+		_ = json.Unmarshal(respBody, &meta)
+	}
+
+	return respBody, meta.UsageMetadata, err
+}
+
+// ==================== HTTP 工具 ====================
+
+// postAIJSON 发送 JSON 请求，非 200 时返回错误（同时返回响应体）
+func postAIJSON(ctx context.Context, url string, headers map[string]string, reqBody interface{}, timeout time.Duration) ([]byte, error) {
+	bodyBytes, _ := json.Marshal(reqBody)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		// url.Error 会带上含 API Key 的完整 URL，去掉后再返回（错误信息会落库）
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return respBody, fmt.Errorf("AI API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
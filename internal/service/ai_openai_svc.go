@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==================== OpenAI 兼容 Provider ====================
+
+// OpenAIConfig OpenAI 兼容接口配置（OpenAI / Azure 网关 / vLLM / OneAPI 等）
+type OpenAIConfig struct {
+	BaseURL    string // 默认 https://api.openai.com/v1
+	ApiKey     string
+	TextModel  string
+	ImageModel string
+}
+
+// OpenAIProvider OpenAI 兼容 Provider
+// 文本走 /chat/completions，图片走 /images/generations（不支持参考图）
+type OpenAIProvider struct {
+	baseURL    string
+	apiKey     string
+	textModel  string
+	imageModel string
+}
+
+// NewOpenAIProvider 创建 OpenAI 兼容 Provider
+func NewOpenAIProvider(cfg OpenAIConfig) *OpenAIProvider {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	textModel := cfg.TextModel
+	if textModel == "" {
+		textModel = "gpt-4o-mini"
+	}
+	imageModel := cfg.ImageModel
+	if imageModel == "" {
+		imageModel = "gpt-image-1"
+	}
+	return &OpenAIProvider{
+		baseURL:    baseURL,
+		apiKey:     cfg.ApiKey,
+		textModel:  textModel,
+		imageModel: imageModel,
+	}
+}
+
+func (p *OpenAIProvider) Name() string              { return AIProviderOpenAI }
+func (p *OpenAIProvider) DefaultTextModel() string  { return p.textModel }
+func (p *OpenAIProvider) DefaultImageModel() string { return p.imageModel }
+
+// GenerateText 调用 /chat/completions
+func (p *OpenAIProvider) GenerateText(ctx context.Context, req *LLMTextRequest) (*LLMTextResponse, error) {
+	reqBody := map[string]interface{}{
+		"model": req.Model,
+		"messages": []map[string]string{
+			{"role": "user", "content": req.Prompt},
+		},
+	}
+	if req.JSONMode {
+		reqBody["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	respBody, err := postAIJSON(ctx, p.baseURL+"/chat/completions", p.headers(), reqBody, 60*time.Second)
+	result := &LLMTextResponse{}
+
+	var chatResp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if len(respBody) > 0 {
+		if jsonErr := json.Unmarshal(respBody, &chatResp); jsonErr != nil && err == nil {
+			return result, fmt.Errorf("解析响应失败: %v", jsonErr)
+		}
+	}
+	if chatResp.Usage != nil {
+		result.Usage = AIUsage{
+			InputTokens:  chatResp.Usage.PromptTokens,
+			OutputTokens: chatResp.Usage.CompletionTokens,
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
+		return result, fmt.Errorf("无生成结果")
+	}
+
+	result.Text = chatResp.Choices[0].Message.Content
+	return result, nil
+}
+
+// GenerateImage 调用 /images/generations
+func (p *OpenAIProvider) GenerateImage(ctx context.Context, req *ImageGenRequest) (*ImageGenResponse, error) {
+	count := req.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	reqBody := map[string]interface{}{
+		"model":  req.Model,
+		"prompt": req.Prompt,
+		"n":      count,
+		"size":   "1024x1024",
+	}
+	// dall-e 系列默认返回 URL，需显式要求 base64；gpt-image-* 只返回 base64
+	if strings.HasPrefix(req.Model, "dall-e") {
+		reqBody["response_format"] = "b64_json"
+	}
+
+	respBody, err := postAIJSON(ctx, p.baseURL+"/images/generations", p.headers(), reqBody, 120*time.Second)
+	result := &ImageGenResponse{}
+
+	var imgResp struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+		Usage *struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if len(respBody) > 0 {
+		if jsonErr := json.Unmarshal(respBody, &imgResp); jsonErr != nil && err == nil {
+			return result, fmt.Errorf("解析响应失败: %v", jsonErr)
+		}
+	}
+	if imgResp.Usage != nil {
+		result.Usage = AIUsage{
+			InputTokens:  imgResp.Usage.InputTokens,
+			OutputTokens: imgResp.Usage.OutputTokens,
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	for _, d := range imgResp.Data {
+		if d.B64JSON != "" {
+			result.Images = append(result.Images, d.B64JSON)
+		}
+	}
+	if len(result.Images) == 0 {
+		return result, fmt.Errorf("响应中未找到图片数据")
+	}
+	return result, nil
+}
+
+func (p *OpenAIProvider) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + p.apiKey}
+}
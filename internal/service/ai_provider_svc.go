@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// ==================== Provider 名称 ====================
+
+const (
+	AIProviderGemini = "gemini"
+	AIProviderOpenAI = "openai"
+	AIProviderFake   = "fake"
+)
+
+// IsValidAIProvider 是否为支持的 Provider
+func IsValidAIProvider(name string) bool {
+	switch name {
+	case AIProviderGemini, AIProviderOpenAI, AIProviderFake:
+		return true
+	}
+	return false
+}
+
+// ==================== Provider 接口 ====================
+
+// AIUsage 单次调用用量
+type AIUsage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// LLMTextRequest 文本生成请求
+type LLMTextRequest struct {
+	Model    string
+	Prompt   string
+	JSONMode bool // 要求输出 JSON
+}
+
+// LLMTextResponse 文本生成结果（出错时也可能携带已计费的用量）
+type LLMTextResponse struct {
+	Text  string
+	Usage AIUsage
+}
+
+// LLMProvider 文本生成 Provider
+type LLMProvider interface {
+	Name() string
+	DefaultTextModel() string
+	GenerateText(ctx context.Context, req *LLMTextRequest) (*LLMTextResponse, error)
+}
+
+// ImageGenRequest 图片生成请求
+type ImageGenRequest struct {
+	Model             string
+	Prompt            string
+	ReferenceImage    []byte // 参考图（Provider 不支持时忽略）
+	ReferenceMimeType string
+	Count             int
+}
+
+// ImageGenResponse 图片生成结果（Base64）
+type ImageGenResponse struct {
+	Images []string
+	Usage  AIUsage
+}
+
+// ImageProvider 图片生成 Provider
+type ImageProvider interface {
+	Name() string
+	DefaultImageModel() string
+	GenerateImage(ctx context.Context, req *ImageGenRequest) (*ImageGenResponse, error)
+}
+
+// ==================== Provider / 模型选择 ====================
+
+type aiModelSelectionKey struct{}
+
+// AIModelSelection Provider 与模型选择（空字段使用默认配置）
+type AIModelSelection struct {
+	Provider   string
+	TextModel  string
+	ImageModel string
+}
+
+// Merge 以 override 覆盖当前选择；Provider 不同时不沿用原模型
+func (s AIModelSelection) Merge(override AIModelSelection) AIModelSelection {
+	if override.Provider != "" && override.Provider != s.Provider {
+		return override
+	}
+	if override.TextModel != "" {
+		s.TextModel = override.TextModel
+	}
+	if override.ImageModel != "" {
+		s.ImageModel = override.ImageModel
+	}
+	return s
+}
+
+// WithAIModelSelection 注入 Provider / 模型选择到 context
+func WithAIModelSelection(ctx context.Context, sel AIModelSelection) context.Context {
+	return context.WithValue(ctx, aiModelSelectionKey{}, sel)
+}
+
+// AIModelSelectionFromContext 从 context 获取 Provider / 模型选择
+func AIModelSelectionFromContext(ctx context.Context) AIModelSelection {
+	sel, _ := ctx.Value(aiModelSelectionKey{}).(AIModelSelection)
+	return sel
+}
+
+// ==================== 解析 ====================
+
+// resolveTextProvider 按 ctx 选择文本 Provider 与模型
+func (s *AIService) resolveTextProvider(ctx context.Context) (LLMProvider, string, error) {
+	sel := AIModelSelectionFromContext(ctx)
+	name := s.providerName(sel)
+
+	provider, ok := s.textProviders[name]
+	if !ok {
+		return nil, "", fmt.Errorf("AI Provider %s 未配置", name)
+	}
+
+	modelName := sel.TextModel
+	if modelName == "" {
+		modelName = provider.DefaultTextModel()
+	}
+	return provider, modelName, nil
+}
+
+// resolveImageProvider 按 ctx 选择图片 Provider 与模型
+func (s *AIService) resolveImageProvider(ctx context.Context) (ImageProvider, string, error) {
+	sel := AIModelSelectionFromContext(ctx)
+	name := s.providerName(sel)
+
+	provider, ok := s.imageProviders[name]
+	if !ok {
+		return nil, "", fmt.Errorf("AI Provider %s 未配置", name)
+	}
+
+	modelName := sel.ImageModel
+	if modelName == "" {
+		modelName = provider.DefaultImageModel()
+	}
+	return provider, modelName, nil
+}
+
+func (s *AIService) providerName(sel AIModelSelection) string {
+	if sel.Provider != "" {
+		return sel.Provider
+	}
+	return s.Config.Provider
+}
@@ -1,10 +1,9 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
+	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"fmt"
 	"io"
@@ -16,34 +15,100 @@ import (
 
 // AIConfig AI 服务配置
 type AIConfig struct {
+	// Provider 默认 Provider: gemini | openai | fake（店铺/草稿任务可单独指定）
+	Provider string
+
+	// Gemini
 	ApiKey     string
 	TextModel  string
 	ImageModel string
+
+	// OpenAI 兼容接口
+	OpenAI OpenAIConfig
+
+	// Prices 模型单价表（覆盖默认值，key 为模型名或模型名前缀）
+	Prices map[string]AIModelPrice
 }
 
 // ==================== 服务 ====================
 
+// AIService AI 服务
+// 负责 Provider 选择、预算检查与调用日志，具体调用由 LLMProvider / ImageProvider 实现
 type AIService struct {
 	Config      *AIConfig
 	Storage     *StorageService
 	callLogRepo repository.AICallLogRepository
+	budget      AIBudgetChecker
+
+	textProviders  map[string]LLMProvider
+	imageProviders map[string]ImageProvider
+}
+
+// AIBudgetChecker 调用前预算检查
+type AIBudgetChecker interface {
+	CheckBudget(ctx context.Context, shopID, userID int64) error
 }
 
 // NewAIService 创建 AI 服务
+// 仅注册已配置密钥的 Provider；fake 始终可用
 func NewAIService(cfg *AIConfig, storage *StorageService, callLogRepo repository.AICallLogRepository) *AIService {
-	// 固定模型配置
-	if cfg.TextModel == "" {
-		cfg.TextModel = "gemini-3-flash"
+	if cfg.Provider == "" {
+		cfg.Provider = AIProviderGemini
 	}
-	if cfg.ImageModel == "" {
-		cfg.ImageModel = "gemini-3-pro-image-preview-2k"
+
+	prices := DefaultAIModelPrices()
+	for name, price := range cfg.Prices {
+		prices[name] = price
 	}
+	cfg.Prices = prices
 
-	return &AIService{
-		Config:      cfg,
-		Storage:     storage,
-		callLogRepo: callLogRepo,
+	s := &AIService{
+		Config:         cfg,
+		Storage:        storage,
+		callLogRepo:    callLogRepo,
+		textProviders:  make(map[string]LLMProvider),
+		imageProviders: make(map[string]ImageProvider),
 	}
+
+	if cfg.ApiKey != "" {
+		gemini := NewGeminiProvider(cfg.ApiKey, cfg.TextModel, cfg.ImageModel)
+		s.RegisterTextProvider(gemini)
+		s.RegisterImageProvider(gemini)
+	}
+	if cfg.OpenAI.ApiKey != "" {
+		openai := NewOpenAIProvider(cfg.OpenAI)
+		s.RegisterTextProvider(openai)
+		s.RegisterImageProvider(openai)
+	}
+	fake := NewFakeAIProvider()
+	s.RegisterTextProvider(fake)
+	s.RegisterImageProvider(fake)
+
+	return s
+}
+
+// RegisterTextProvider 注册文本 Provider（同名覆盖）
+func (s *AIService) RegisterTextProvider(p LLMProvider) {
+	s.textProviders[p.Name()] = p
+}
+
+// RegisterImageProvider 注册图片 Provider（同名覆盖）
+func (s *AIService) RegisterImageProvider(p ImageProvider) {
+	s.imageProviders[p.Name()] = p
+}
+
+// SetBudgetChecker 设置预算检查（可选注入）
+func (s *AIService) SetBudgetChecker(budget AIBudgetChecker) {
+	s.budget = budget
+}
+
+// checkBudget 按 ctx 中的调用归属检查预算
+func (s *AIService) checkBudget(ctx context.Context) error {
+	if s.budget == nil {
+		return nil
+	}
+	scope := AICallScopeFromContext(ctx)
+	return s.budget.CheckBudget(ctx, scope.ShopID, scope.UserID)
 }
 
 // ==================== 文案生成 ====================
@@ -56,9 +121,14 @@ type TextGenerateResult struct {
 }
 
 // GenerateProductContent 根据商品标题生成 Etsy 文案
+// Provider/模型由 WithAIModelSelection 指定，调用记录归属由 WithAICallScope 指定
 func (s *AIService) GenerateProductContent(ctx context.Context, productTitle, styleHint string) (*TextGenerateResult, error) {
-	if s.Config.ApiKey == "" {
-		return nil, fmt.Errorf("Gemini API Key 未配置")
+	provider, modelName, err := s.resolveTextProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(ctx); err != nil {
+		return nil, err
 	}
 
 	prompt := fmt.Sprintf(`You are an Etsy SEO expert. Generate optimized listing content for:
@@ -78,98 +148,35 @@ Output Format (JSON only, no markdown):
   "tags": ["tag1", "tag2", "tag3", "tag4", "tag5", "tag6", "tag7", "tag8", "tag9", "tag10", "tag11", "tag12", "tag13"]
 }`, productTitle, styleHint)
 
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s",
-		s.Config.TextModel, s.Config.ApiKey)
-
-	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{"parts": []map[string]interface{}{{"text": prompt}}},
-		},
-		"generationConfig": map[string]interface{}{
-			"responseMimeType": "application/json",
-		},
-	}
-
-	bodyBytes, _ := json.Marshal(reqBody)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
+	resp, err := s.generateText(ctx, provider, &LLMTextRequest{
+		Model:    modelName,
+		Prompt:   prompt,
+		JSONMode: true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Gemini API 错误 [%d]: %s", resp.StatusCode, string(respBody))
-	}
-
-	// 解析响应
-	var geminiResp struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-	}
-
-	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
-	}
-
-	if len(geminiResp.Candidates) == 0 {
-		return nil, fmt.Errorf("无生成结果")
-	}
-
-	// 提取 JSON 文本
-	var jsonText string
-	for _, candidate := range geminiResp.Candidates {
-		for _, part := range candidate.Content.Parts {
-			if part.Text != "" {
-				jsonText = part.Text
-				break
-			}
-		}
-	}
 
 	// 解析生成结果
 	var result TextGenerateResult
-	if err := json.Unmarshal([]byte(jsonText), &result); err != nil {
-		return nil, fmt.Errorf("解析生成结果失败: %v, raw: %s", err, jsonText)
+	if err := json.Unmarshal([]byte(resp.Text), &result); err != nil {
+		return nil, fmt.Errorf("解析生成结果失败: %v, raw: %s", err, resp.Text)
 	}
 
-	//startTime := time.Now()
-	//
-	//// 记录调用日志
-	//s.callLogRepo.Create(ctx, &model.AICallLog{
-	//	ShopID:     shopID,  // 从 ctx 或参数传入
-	//	TaskID:     taskID,
-	//	CallType:   model.AICallTypeImage,
-	//	ModelName:  s.Config.ImageModel,
-	//	ImageCount: len(images),
-	//	DurationMs: time.Since(startTime).Milliseconds(),
-	//	CostUSD:    calculateImageCost(len(images)),
-	//	Status:     model.AICallStatusSuccess,
-	//})
-
 	return &result, nil
 }
 
 // ==================== 图片生成 ====================
 
-// GenerateImages 调用 Gemini 多模态能力生成图片
-// 返回 Base64 编码的图片数据
+// GenerateImages 生成商品图片
+// 逐张调用 Provider（每张单独记录日志、检查预算），返回 Base64 编码的图片数据
 func (s *AIService) GenerateImages(ctx context.Context, prompt, referenceImageURL string, count int) ([]string, error) {
-	if s.Config.ApiKey == "" {
-		return nil, fmt.Errorf("Gemini API Key 未配置")
+	provider, modelName, err := s.resolveImageProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBudget(ctx); err != nil {
+		return nil, err
 	}
 
 	// 下载参考图片
@@ -197,19 +204,35 @@ Requirements:
 - High resolution, suitable for e-commerce
 - Focus on product details and quality`, prompt)
 
-	// 调用 Gemini API 生成图片
 	images := make([]string, 0, count)
 
 	for i := 0; i < count; i++ {
-		imageData, err := s.callGeminiImageGeneration(ctx, fullPrompt, referenceImageData, referenceImageMimeType)
+		// 逐张检查预算，超限后停止继续生成
+		if i > 0 {
+			if err := s.checkBudget(ctx); err != nil {
+				if len(images) == 0 {
+					return nil, err
+				}
+				fmt.Printf("预算已用尽，停止生成剩余 %d 张图片: %v\n", count-i, err)
+				break
+			}
+		}
+
+		resp, err := s.generateImage(ctx, provider, &ImageGenRequest{
+			Model:             modelName,
+			Prompt:            fullPrompt,
+			ReferenceImage:    referenceImageData,
+			ReferenceMimeType: referenceImageMimeType,
+			Count:             1,
+		})
 		if err != nil {
 			fmt.Printf("生成第 %d 张图片失败: %v\n", i+1, err)
 			continue
 		}
-		images = append(images, imageData)
+		images = append(images, resp.Images[0])
 
 		// 避免请求过快
-		if i < count-1 {
+		if i < count-1 && provider.Name() != AIProviderFake {
 			time.Sleep(500 * time.Millisecond)
 		}
 	}
@@ -221,150 +244,71 @@ Requirements:
 	return images, nil
 }
 
-// callGeminiImageGeneration 调用 Gemini 图片生成API
-func (s *AIService) callGeminiImageGeneration(ctx context.Context, prompt string, referenceImage []byte, mimeType string) (string, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s",
-		s.Config.ImageModel, s.Config.ApiKey)
-
-	// 构建请求体
-	parts := []map[string]interface{}{
-		{"text": prompt},
-	}
-
-	// 如果有参考图片，添加到请求中
-	if len(referenceImage) > 0 {
-		parts = append(parts, map[string]interface{}{
-			"inline_data": map[string]interface{}{
-				"mime_type": mimeType,
-				"data":      base64.StdEncoding.EncodeToString(referenceImage),
-			},
-		})
-	}
+// ==================== Imagen API (备选方案) ====================
 
-	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{"parts": parts},
-		},
-		"generationConfig": map[string]interface{}{
-			"responseModalities": []string{"TEXT", "IMAGE"},
-		},
+// GenerateImagesWithImagen 使用 Imagen 生成图片（固定走 Gemini Provider）
+func (s *AIService) GenerateImagesWithImagen(ctx context.Context, prompt string, count int) ([]string, error) {
+	provider, ok := s.imageProviders[AIProviderGemini]
+	if !ok {
+		return nil, fmt.Errorf("AI Provider %s 未配置", AIProviderGemini)
 	}
-
-	bodyBytes, _ := json.Marshal(reqBody)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return "", err
+	if err := s.checkBudget(ctx); err != nil {
+		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Do(httpReq)
+	resp, err := s.generateImage(ctx, provider, &ImageGenRequest{
+		Model:  "imagen-3.0-generate-002",
+		Prompt: prompt,
+		Count:  count,
+	})
 	if err != nil {
-		return "", fmt.Errorf("请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Gemini API 错误 [%d]: %s", resp.StatusCode, string(respBody))
-	}
-
-	// 解析响应，提取生成的图片
-	var geminiResp struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text       string `json:"text,omitempty"`
-					InlineData *struct {
-						MimeType string `json:"mimeType"`
-						Data     string `json:"data"`
-					} `json:"inlineData,omitempty"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-		Error *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-
-	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %v", err)
-	}
-
-	if geminiResp.Error != nil {
-		return "", fmt.Errorf("API错误: %s", geminiResp.Error.Message)
-	}
-
-	// 查找图片数据
-	for _, candidate := range geminiResp.Candidates {
-		for _, part := range candidate.Content.Parts {
-			if part.InlineData != nil && part.InlineData.Data != "" {
-				return part.InlineData.Data, nil
-			}
-		}
+		return nil, err
 	}
-
-	return "", fmt.Errorf("响应中未找到图片数据")
+	return resp.Images, nil
 }
 
-// ==================== Imagen API (备选方案) ====================
+// ==================== 调用封装（日志） ====================
 
-// GenerateImagesWithImagen 使用 Imagen 生成图片
-func (s *AIService) GenerateImagesWithImagen(ctx context.Context, prompt string, count int) ([]string, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/imagen-3.0-generate-002:predict?key=%s",
-		s.Config.ApiKey)
-
-	reqBody := map[string]interface{}{
-		"instances": []map[string]interface{}{
-			{"prompt": prompt},
-		},
-		"parameters": map[string]interface{}{
-			"sampleCount": count,
-			"aspectRatio": "1:1",
-		},
-	}
+// generateText 调用文本 Provider 并记录日志
+func (s *AIService) generateText(ctx context.Context, provider LLMProvider, req *LLMTextRequest) (*LLMTextResponse, error) {
+	startTime := time.Now()
+	resp, err := provider.GenerateText(ctx, req)
 
-	bodyBytes, _ := json.Marshal(reqBody)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, err
+	rec := &aiCallRecord{
+		CallType:  model.AICallTypeText,
+		ModelName: req.Model,
+		StartTime: startTime,
+		Err:       err,
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 120 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("请求失败: %v", err)
+	if resp != nil {
+		rec.Usage = resp.Usage
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
+	s.recordCall(ctx, rec)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Imagen API 错误 [%d]: %s", resp.StatusCode, string(respBody))
-	}
+	return resp, err
+}
 
-	var imagenResp struct {
-		Predictions []struct {
-			BytesBase64Encoded string `json:"bytesBase64Encoded"`
-			MimeType           string `json:"mimeType"`
-		} `json:"predictions"`
+// generateImage 调用图片 Provider 并记录日志
+func (s *AIService) generateImage(ctx context.Context, provider ImageProvider, req *ImageGenRequest) (*ImageGenResponse, error) {
+	startTime := time.Now()
+	resp, err := provider.GenerateImage(ctx, req)
+	if err == nil && (resp == nil || len(resp.Images) == 0) {
+		err = fmt.Errorf("响应中未找到图片数据")
 	}
 
-	if err := json.Unmarshal(respBody, &imagenResp); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+	rec := &aiCallRecord{
+		CallType:  model.AICallTypeImage,
+		ModelName: req.Model,
+		StartTime: startTime,
+		Err:       err,
 	}
-
-	images := make([]string, 0, len(imagenResp.Predictions))
-	for _, pred := range imagenResp.Predictions {
-		if pred.BytesBase64Encoded != "" {
-			images = append(images, pred.BytesBase64Encoded)
-		}
+	if resp != nil {
+		rec.Usage = resp.Usage
+		rec.ImageCount = len(resp.Images)
 	}
+	s.recordCall(ctx, rec)
 
-	return images, nil
+	return resp, err
 }
 
 // ==================== 图片上传辅助 ====================
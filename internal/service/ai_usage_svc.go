@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ==================== 调用上下文 ====================
+
+type aiCallScopeKey struct{}
+
+// AICallScope AI 调用归属（写入 ai_call_logs 的 shop_id / task_id / user_id）
+type AICallScope struct {
+	ShopID int64
+	TaskID int64
+	UserID int64
+}
+
+// WithAICallScope 注入调用归属到 context
+func WithAICallScope(ctx context.Context, shopID, taskID, userID int64) context.Context {
+	return context.WithValue(ctx, aiCallScopeKey{}, AICallScope{ShopID: shopID, TaskID: taskID, UserID: userID})
+}
+
+// AICallScopeFromContext 从 context 获取调用归属
+// 未指定用户时取审计上下文中的登录用户
+func AICallScopeFromContext(ctx context.Context) AICallScope {
+	scope, _ := ctx.Value(aiCallScopeKey{}).(AICallScope)
+	if scope.UserID == 0 {
+		scope.UserID = middleware.GetAuditUserID(ctx)
+	}
+	return scope
+}
+
+// ==================== 价格表 ====================
+
+// AIModelPrice 模型单价（美元）
+type AIModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`  // 每百万输入 token
+	OutputPerMillion float64 `json:"output_per_million"` // 每百万输出 token
+	PerImage         float64 `json:"per_image"`          // 每张生成图片
+}
+
+// DefaultAIModelPrices 默认价格表
+// Gemini 图片模型按张计费，不再重复按输出 token 计费；gpt-image-* 按 token 计费
+// 未收录的模型（如 fake）成本记为 0
+func DefaultAIModelPrices() map[string]AIModelPrice {
+	return map[string]AIModelPrice{
+		"gemini-3-flash":             {InputPerMillion: 0.50, OutputPerMillion: 3.00},
+		"gemini-3-pro":               {InputPerMillion: 2.00, OutputPerMillion: 12.00},
+		"gemini-3-pro-image-preview": {InputPerMillion: 2.00, PerImage: 0.134},
+		"gemini-2.5-flash-image":     {InputPerMillion: 0.30, PerImage: 0.039},
+		"imagen-3.0-generate-002":    {PerImage: 0.03},
+		"gpt-4o-mini":                {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+		"gpt-4o":                     {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+		"gpt-image-1":                {InputPerMillion: 5.00, OutputPerMillion: 40.00},
+		"dall-e-3":                   {PerImage: 0.04},
+	}
+}
+
+// lookupPrice 查找模型单价：精确匹配优先，其次最长前缀匹配
+func lookupPrice(prices map[string]AIModelPrice, modelName string) (AIModelPrice, bool) {
+	if price, ok := prices[modelName]; ok {
+		return price, true
+	}
+
+	var (
+		best    AIModelPrice
+		bestLen int
+	)
+	for name, price := range prices {
+		if strings.HasPrefix(modelName, name) && len(name) > bestLen {
+			best, bestLen = price, len(name)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// CalculateAICost 计算单次调用成本（美元）
+func CalculateAICost(prices map[string]AIModelPrice, modelName string, inputTokens, outputTokens, imageCount int) float64 {
+	price, ok := lookupPrice(prices, modelName)
+	if !ok {
+		return 0
+	}
+	return float64(inputTokens)*price.InputPerMillion/1e6 +
+		float64(outputTokens)*price.OutputPerMillion/1e6 +
+		float64(imageCount)*price.PerImage
+}
+
+// ==================== 调用记录 ====================
+
+// aiCallRecord 单次调用记录
+type aiCallRecord struct {
+	CallType   string
+	ModelName  string
+	Usage      AIUsage
+	ImageCount int
+	StartTime  time.Time
+	Err        error
+}
+
+// recordCall 写入调用日志（失败只打日志，不影响业务）
+func (s *AIService) recordCall(ctx context.Context, rec *aiCallRecord) {
+	if s.callLogRepo == nil {
+		return
+	}
+
+	scope := AICallScopeFromContext(ctx)
+	callLog := &model.AICallLog{
+		ShopID:     scope.ShopID,
+		TaskID:     scope.TaskID,
+		UserID:     scope.UserID,
+		CallType:   rec.CallType,
+		ModelName:  rec.ModelName,
+		ImageCount: rec.ImageCount,
+		DurationMs: time.Since(rec.StartTime).Milliseconds(),
+		Status:     model.AICallStatusSuccess,
+	}
+
+	callLog.InputTokens = rec.Usage.InputTokens
+	callLog.OutputTokens = rec.Usage.OutputTokens
+	callLog.CostUSD = CalculateAICost(s.Config.Prices, rec.ModelName,
+		callLog.InputTokens, callLog.OutputTokens, callLog.ImageCount)
+
+	if rec.Err != nil {
+		callLog.Status = model.AICallStatusFailed
+		callLog.ErrorMsg = truncateString(rec.Err.Error(), 1024)
+	}
+
+	// 调用方 ctx 可能已取消（超时），日志仍需落库
+	if err := s.callLogRepo.Create(context.WithoutCancel(ctx), callLog); err != nil {
+		log.Printf("[AIService] 记录调用日志失败: %v", err)
+	}
+}
+
+// truncateString 按字节截断（保证 UTF-8 完整）
+func truncateString(str string, maxBytes int) string {
+	if len(str) <= maxBytes {
+		return str
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(str[cut]) {
+		cut--
+	}
+	return str[:cut]
+}
+
+// ==================== 用量查询 ====================
+
+// ErrInvalidDateRange 查询日期范围无效
+var ErrInvalidDateRange = errors.New("结束日期不能早于开始日期")
+
+// AIUsageService AI 用量统计服务
+type AIUsageService struct {
+	callLogRepo repository.AICallLogRepository
+}
+
+// NewAIUsageService 创建 AI 用量统计服务
+func NewAIUsageService(callLogRepo repository.AICallLogRepository) *AIUsageService {
+	return &AIUsageService{callLogRepo: callLogRepo}
+}
+
+// GetShopUsage 店铺用量
+func (s *AIUsageService) GetShopUsage(ctx context.Context, shopID int64, startTime, endTime time.Time) (*repository.AIUsageStats, error) {
+	return s.callLogRepo.GetUsageByShop(ctx, shopID, startTime, endTime)
+}
+
+// GetTaskUsage 草稿任务用量
+func (s *AIUsageService) GetTaskUsage(ctx context.Context, taskID int64) (*repository.AIUsageStats, error) {
+	return s.callLogRepo.GetUsageByTask(ctx, taskID)
+}
+
+// GetDailyUsage 每日用量，shopIDs 为可见店铺范围（nil 表示不限制）
+func (s *AIUsageService) GetDailyUsage(ctx context.Context, startDate, endDate time.Time, shopIDs []int64) ([]repository.DailyUsageStats, error) {
+	if endDate.Before(startDate) {
+		return nil, ErrInvalidDateRange
+	}
+	stats, err := s.callLogRepo.GetDailyUsage(ctx, startDate, endDate, shopIDs)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = []repository.DailyUsageStats{}
+	}
+	return stats, nil
+}
+
+// GetTotalCost 时间范围内总成本，shopIDs 为可见店铺范围（nil 表示不限制）
+func (s *AIUsageService) GetTotalCost(ctx context.Context, startTime, endTime time.Time, shopIDs []int64) (float64, error) {
+	return s.callLogRepo.GetTotalCost(ctx, startTime, endTime, shopIDs)
+}
@@ -3,14 +3,18 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"etsy_dev_v1_202512/internal/middleware"
 	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/pkg/etsy"
 	"etsy_dev_v1_202512/pkg/net"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"etsy_dev_v1_202512/pkg/utils"
@@ -21,13 +25,37 @@ const (
 	// CallbackURL 必须与 Etsy 后台填写的完全一致
 	// CallbackURL = "http://localhost:8080/api/auth/callback"
 	// 测试用 URL
-	CallbackURL  = "https://elizabet-avian-glenna.ngrok-free.dev/api/oauth/callback"
-	EtsyTokenURL = "https://api.etsy.com/v3/public/oauth/token"
+	CallbackURL    = "https://elizabet-avian-glenna.ngrok-free.dev/api/oauth/callback"
+	EtsyTokenURL   = "https://api.etsy.com/v3/public/oauth/token"
+	EtsyConnectURL = "https://www.etsy.com/oauth/connect"
+
+	// OAuthStateTTL 授权链接有效期，足够完成授权流程
+	OAuthStateTTL = 10 * time.Minute
 )
 
+// ErrOAuthCallbackMismatch 回调路径与发起授权的开发者不一致
+var ErrOAuthCallbackMismatch = errors.New("oauth callback path mismatch")
+
+// OAuthStateStore OAuth 授权状态存储
+// 多实例部署时必须使用共享存储（repository.OAuthStateRepository），否则回调可能落到没有 state 的实例
+type OAuthStateStore interface {
+	Save(ctx context.Context, state *model.OAuthState) error
+	// Consume 一次性取出，不存在 / 过期 / 已消费时返回 repository.ErrOAuthStateNotFound
+	Consume(ctx context.Context, state string) (*model.OAuthState, error)
+}
+
 type AuthService struct {
 	ShopService *ShopService
 	dispatcher  net.Dispatcher
+	notifier    ShopNotifier
+	memberRepo  repository.ShopMemberRepository
+	stateStore  OAuthStateStore
+	// Token 接口不经 etsy.Client 发送，鉴权结果需单独上报
+	authReporter etsy.AuthReporter
+
+	// OAuth 地址（本地模拟环境可替换）
+	connectURL string
+	tokenURL   string
 }
 
 // NewAuthService 工厂方法
@@ -35,12 +63,45 @@ func NewAuthService(shopService *ShopService, dispatcher net.Dispatcher) *AuthSe
 	return &AuthService{
 		ShopService: shopService,
 		dispatcher:  dispatcher,
+		stateStore:  newMemoryStateStore(),
+		connectURL:  EtsyConnectURL,
+		tokenURL:    EtsyTokenURL,
+	}
+}
+
+// SetOAuthEndpoints 替换 OAuth 授权页与 Token 地址，空值保持默认
+func (s *AuthService) SetOAuthEndpoints(connectURL, tokenURL string) {
+	if connectURL != "" {
+		s.connectURL = connectURL
 	}
+	if tokenURL != "" {
+		s.tokenURL = tokenURL
+	}
+}
+
+// SetNotifier 设置授权失效通知
+func (s *AuthService) SetNotifier(notifier ShopNotifier) {
+	s.notifier = notifier
+}
+
+// SetMemberRepo 设置店铺成员仓库（新建店铺时将发起人设为 owner）
+func (s *AuthService) SetMemberRepo(memberRepo repository.ShopMemberRepository) {
+	s.memberRepo = memberRepo
+}
+
+// SetAuthReporter 设置 Token 接口的鉴权结果上报（识别被吊销的 API Key）
+func (s *AuthService) SetAuthReporter(reporter etsy.AuthReporter) {
+	s.authReporter = reporter
+}
+
+// SetStateStore 设置 OAuth 授权状态存储（默认进程内存，仅适用于单实例）
+func (s *AuthService) SetStateStore(store OAuthStateStore) {
+	s.stateStore = store
 }
 
 // GenerateLoginURL 生成授权链接
-// 初次授权 将新建店铺，绑定相同 region 下 且 <2 个 shop的 developer
-func (s *AuthService) GenerateLoginURL(ctx context.Context, shopID int64, region string) (string, error) {
+// 初次授权 将新建店铺，绑定相同 region 下 且 <2 个 shop的 developer；operatorID > 0 时发起人成为店铺 owner
+func (s *AuthService) GenerateLoginURL(ctx context.Context, shopID int64, region string, operatorID int64) (string, error) {
 	// 1. 查店铺
 	var shop model.Shop
 	var err error
@@ -51,6 +112,16 @@ func (s *AuthService) GenerateLoginURL(ctx context.Context, shopID int64, region
 			return "", err
 		}
 		shop = *newShop
+
+		if operatorID > 0 && s.memberRepo != nil {
+			if err := s.memberRepo.Create(ctx, &model.ShopMember{
+				UserID: operatorID,
+				ShopID: shop.ID,
+				Role:   model.ShopMemberRoleOwner,
+			}); err != nil {
+				return "", fmt.Errorf("绑定店铺 owner 失败: %w", err)
+			}
+		}
 	} else {
 		existingShop, err := s.ShopService.shopRepo.GetByID(ctx, shopID)
 		if err != nil {
@@ -76,9 +147,20 @@ func (s *AuthService) GenerateLoginURL(ctx context.Context, shopID int64, region
 	challenge := utils.GenerateCodeChallenge(verifier)
 	state, _ := utils.GenerateRandomString(16)
 
-	// 4. 缓存 Verifier (格式为 key=state, value="verifier:shop_id")
-	cacheValue := fmt.Sprintf("%s:%d", verifier, shop.ID)
-	utils.SetCache(state, cacheValue)
+	// 4. 持久化授权状态，回调时一次性消费
+	redirectURI := oauthRedirectURI(shop.Developer)
+	if err := s.stateStore.Save(ctx, &model.OAuthState{
+		State:        state,
+		CodeVerifier: verifier,
+		ShopID:       shop.ID,
+		DeveloperID:  shop.Developer.ID,
+		Region:       shop.Region,
+		RedirectURI:  redirectURI,
+		UserID:       operatorID,
+		ExpiresAt:    time.Now().Add(OAuthStateTTL),
+	}); err != nil {
+		return "", fmt.Errorf("保存授权状态失败: %w", err)
+	}
 
 	// 5. 拼接 Etsy 官方授权 URL 获取所有权限
 	scopes := "address_r address_w billing_r cart_r cart_w email_r favorites_r favorites_w feedback_r listings_r listings_w listings_d profile_r profile_w recommend_r recommend_w shops_r shops_w transactions_r transactions_w"
@@ -92,55 +174,89 @@ func (s *AuthService) GenerateLoginURL(ctx context.Context, shopID int64, region
 		     &code_challenge=DSWlW2Abh-cf8CeLL8-g3hQ2WQyYdKyiu83u_s7nRhI
 		     &code_challenge_method=S256
 	*/
-	// todo 正式环境需要更新 callback url 需要更新为 shop.Developer.CallbackURL
 	authURL := fmt.Sprintf(
-		"https://www.etsy.com/oauth/connect?response_type=code&client_id=%s&redirect_uri=%s&scope=%s&state=%s&code_challenge=%s&code_challenge_method=S256",
-		shop.Developer.ApiKey, CallbackURL, scopes, state, challenge,
+		"%s?response_type=code&client_id=%s&redirect_uri=%s&scope=%s&state=%s&code_challenge=%s&code_challenge_method=S256",
+		s.connectURL, shop.Developer.ApiKey, url.QueryEscape(redirectURI), url.PathEscape(scopes), state, challenge,
 	)
 	return authURL, err
 }
 
 // HandleCallback 处理 Etsy 回调 -> 换 Token
 func (s *AuthService) HandleCallback(ctx context.Context, code, state string) (*model.Shop, error) {
-	var shop *model.Shop
-	// 1. 校验 State 取缓存
-	cachedVal, exists := utils.GetCache(state)
-	if !exists {
-		return shop, fmt.Errorf("授权超时或 State 无效，请重新发起")
+	st, err := s.consumeState(ctx, state)
+	if err != nil {
+		return nil, err
 	}
+	return s.exchangeCode(ctx, st, code)
+}
 
-	// 2. 解析缓存 "verifier:shop_id"
-	parts := strings.Split(cachedVal, ":")
-	if len(parts) != 2 {
-		return shop, fmt.Errorf("缓存数据格式错误，预期 'verifier:shopID'，实际: %s", cachedVal)
+// HandleDeveloperCallback 处理开发者专属回调地址（/{callback_path}/oauth/callback）
+// state 必须由该开发者发起，防止串用其他开发者的授权
+func (s *AuthService) HandleDeveloperCallback(ctx context.Context, callbackPath, code, state string) (*model.Shop, error) {
+	dev, err := s.ShopService.developerRepo.FindByCallbackPath(ctx, callbackPath)
+	if err != nil {
+		return nil, err
+	}
+	if dev == nil {
+		return nil, ErrOAuthCallbackMismatch
 	}
-	verifier := parts[0]
-	shopID, err := strconv.ParseInt(parts[1], 10, 64)
+	st, err := s.consumeState(ctx, state)
 	if err != nil {
-		return shop, fmt.Errorf("缓存中的 ShopID 无效: %v", err)
+		return nil, err
 	}
+	if st.DeveloperID != dev.ID {
+		log.Printf("[Auth] 回调路径 %s 属于开发者 %d，但 state 由开发者 %d 发起", callbackPath, dev.ID, st.DeveloperID)
+		return nil, ErrOAuthCallbackMismatch
+	}
+	return s.exchangeCode(ctx, st, code)
+}
+
+// CallbackPathExists 回调路径是否属于某个开发者（回调连通性校验）
+func (s *AuthService) CallbackPathExists(ctx context.Context, callbackPath string) (bool, error) {
+	dev, err := s.ShopService.developerRepo.FindByCallbackPath(ctx, callbackPath)
+	return dev != nil, err
+}
 
-	// 3. 查出 Shop 配置
-	shop, err = s.ShopService.shopRepo.GetByID(ctx, shopID)
+// consumeState 一次性取出授权状态
+func (s *AuthService) consumeState(ctx context.Context, state string) (*model.OAuthState, error) {
+	st, err := s.stateStore.Consume(ctx, state)
+	if errors.Is(err, repository.ErrOAuthStateNotFound) {
+		return nil, fmt.Errorf("授权超时或 State 无效，请重新发起")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取授权状态失败: %w", err)
+	}
+	return st, nil
+}
+
+// exchangeCode 用授权码换取 Token 并更新店铺
+func (s *AuthService) exchangeCode(ctx context.Context, st *model.OAuthState, code string) (*model.Shop, error) {
+	// 回调无登录态，以发起人作为审计用户
+	if middleware.GetAuditUserID(ctx) == 0 && st.UserID > 0 {
+		ctx = middleware.WithAuditInfo(ctx, st.UserID, "")
+	}
+	shopID := st.ShopID
+
+	// 1. 查出 Shop 配置
+	shop, err := s.ShopService.shopRepo.GetByID(ctx, shopID)
 	if err != nil {
 		log.Printf("get shop ID : %d err %v", shopID, err)
 		return shop, err
 	}
-	// 4. 组装请求
+	// 2. 组装请求
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("client_id", shop.Developer.ApiKey)
-	// todo 正式环境修改为 data.Set("redirect_uri", shop.Developer.CallbackURL)
-	data.Set("redirect_uri", CallbackURL)
+	data.Set("redirect_uri", st.RedirectURI)
 	data.Set("code", code)
-	data.Set("code_verifier", verifier)
+	data.Set("code_verifier", st.CodeVerifier)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", EtsyTokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return shop, err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	// 5. 通过 dispatcher 发送换取 Token
+	// 3. 通过 dispatcher 发送换取 Token
 	tokenResp, err := s.dispatcher.Send(ctx, shopID, req)
 	if err != nil {
 		//s.updateTokenStatus(&shop, model.ShopTokenStatusInvalid)
@@ -148,16 +264,16 @@ func (s *AuthService) HandleCallback(ctx context.Context, code, state string) (*
 	}
 	defer tokenResp.Body.Close()
 
-	// 6. 解析响应
-	if tokenResp.StatusCode != 200 {
-		return shop, fmt.Errorf("ETSY refused token exchange: status %d", tokenResp.StatusCode)
+	// 4. 解析响应
+	if etsyErr := s.observeTokenAuth(ctx, shop, tokenResp); etsyErr != nil {
+		return shop, fmt.Errorf("ETSY refused token exchange: %w", etsyErr)
 	}
 
 	var etsyResp etsyTokenResp
 	if err = json.NewDecoder(tokenResp.Body).Decode(&etsyResp); err != nil {
 		return shop, fmt.Errorf("ETSY json decode failed: %v", err)
 	}
-	// 8. 更新数据
+	// 5. 更新数据
 	shop.AccessToken = etsyResp.AccessToken
 	shop.RefreshToken = etsyResp.RefreshToken
 	shop.TokenExpiresAt = time.Now().Add(time.Duration(etsyResp.ExpiresIn) * time.Second)
@@ -171,6 +287,14 @@ func (s *AuthService) HandleCallback(ctx context.Context, code, state string) (*
 	return shop, nil
 }
 
+// oauthRedirectURI 开发者已生成专属回调地址时使用专属地址，否则使用通用回调
+func oauthRedirectURI(dev *model.Developer) string {
+	if dev != nil && dev.CallbackURL != "" {
+		return dev.CallbackURL
+	}
+	return CallbackURL
+}
+
 // 辅助结构体：Token 响应
 type etsyTokenResp struct {
 	AccessToken  string `json:"access_token"`
@@ -187,7 +311,7 @@ func (s *AuthService) RefreshAccessToken(ctx context.Context, shop *model.Shop)
 	data.Set("client_id", shop.Developer.ApiKey)
 	data.Set("refresh_token", shop.RefreshToken)
 
-	req, _ := http.NewRequestWithContext(ctx, "POST", EtsyTokenURL, strings.NewReader(data.Encode()))
+	req, _ := http.NewRequestWithContext(ctx, "POST", s.tokenURL, strings.NewReader(data.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// 2. 托管发送
@@ -201,10 +325,11 @@ func (s *AuthService) RefreshAccessToken(ctx context.Context, shop *model.Shop)
 	defer resp.Body.Close()
 
 	// B. 业务层错误 (Etsy 明确拒绝)
-	if resp.StatusCode != 200 {
+	if etsyErr := s.observeTokenAuth(ctx, shop, resp); etsyErr != nil {
 		// 只有明确收到 400/401 才标记为失效
 		err = s.ShopService.shopRepo.UpdateFields(ctx, shop.ID, map[string]interface{}{"token_status": model.ShopTokenStatusInvalid})
-		return fmt.Errorf("refresh denied by ETSY: %d, err: %v", resp.StatusCode, err)
+		s.notifyTokenExpired(ctx, shop, resp.StatusCode)
+		return fmt.Errorf("refresh denied by ETSY: %w, err: %v", etsyErr, err)
 	}
 
 	// C. 成功处理
@@ -220,3 +345,72 @@ func (s *AuthService) RefreshAccessToken(ctx context.Context, shop *model.Shop)
 
 	return s.ShopService.shopRepo.Update(ctx, shop)
 }
+
+// observeTokenAuth 非 200 时解析 Etsy 错误，并上报 Key / Token 是否被拒绝
+func (s *AuthService) observeTokenAuth(ctx context.Context, shop *model.Shop, resp *http.Response) *etsy.EtsyError {
+	var apiKey string
+	if shop.Developer != nil {
+		apiKey = shop.Developer.ApiKey
+	}
+	if resp.StatusCode == http.StatusOK {
+		if s.authReporter != nil && apiKey != "" {
+			s.authReporter.ReportAuthSuccess(ctx, apiKey)
+		}
+		return nil
+	}
+
+	etsyErr := etsy.ParseErrorResponse(resp)
+	if failure := etsyErr.AuthFailure(); s.authReporter != nil && apiKey != "" && failure != etsy.AuthFailureNone {
+		s.authReporter.ReportAuthFailure(ctx, etsy.Credentials{
+			ShopID:     shop.ID,
+			EtsyShopID: shop.EtsyShopID,
+			APIKey:     apiKey,
+		}, failure, etsyErr)
+	}
+	return etsyErr
+}
+
+// notifyTokenExpired 通知店铺成员重新授权
+func (s *AuthService) notifyTokenExpired(ctx context.Context, shop *model.Shop, statusCode int) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.NotifyShop(ctx, shop.ID, model.NotificationEventTokenExpired, map[string]interface{}{
+		"shop_name":   shop.ShopName,
+		"status_code": statusCode,
+	})
+	if err != nil {
+		log.Printf("[Auth] 店铺 %d 授权失效通知发送失败: %v", shop.ID, err)
+	}
+}
+
+// ==================== 进程内 state store ====================
+
+// memoryStateStore 进程内授权状态存储，仅用于单实例部署与测试
+type memoryStateStore struct {
+	states sync.Map // state -> *model.OAuthState
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{}
+}
+
+func (m *memoryStateStore) Save(_ context.Context, state *model.OAuthState) error {
+	now := time.Now()
+	m.states.Range(func(key, value any) bool {
+		if now.After(value.(*model.OAuthState).ExpiresAt) {
+			m.states.Delete(key)
+		}
+		return true
+	})
+	m.states.Store(state.State, state)
+	return nil
+}
+
+func (m *memoryStateStore) Consume(_ context.Context, state string) (*model.OAuthState, error) {
+	value, ok := m.states.LoadAndDelete(state)
+	if !ok || time.Now().After(value.(*model.OAuthState).ExpiresAt) {
+		return nil, repository.ErrOAuthStateNotFound
+	}
+	return value.(*model.OAuthState), nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+const (
+	// defaultKeyFailureThreshold 连续多少次 API Key 被拒绝后自动封禁
+	defaultKeyFailureThreshold = 3
+	// defaultKeyFailureWindow 连续失败需发生在该时间窗口内，超出后重新计数
+	defaultKeyFailureWindow = 30 * time.Minute
+)
+
+// DeveloperBanNotifier 封禁通知：店铺成员重新授权 + 管理员告警（NotificationService 实现）
+type DeveloperBanNotifier interface {
+	ShopNotifier
+	NotifyUser(userID int64, event string, data interface{}) error
+}
+
+// keyFailures 单个 API Key 的连续失败计数
+type keyFailures struct {
+	count   int
+	first   time.Time
+	banning bool
+}
+
+// DeveloperHealthService 开发者 Key 健康检测（实现 etsy.AuthReporter）
+// Etsy 连续拒绝同一 API Key 时自动封禁该开发者，并把店铺迁移到同地区的健康开发者下等待重新授权；
+// 店铺 Token 失效只影响单个店铺，由 Token 刷新流程处理，不计入 Key 失败
+type DeveloperHealthService struct {
+	developerRepo repository.DeveloperRepository
+	shopRepo      repository.ShopRepository
+	userRepo      repository.UserRepository
+	notifier      DeveloperBanNotifier
+
+	threshold int
+	window    time.Duration
+
+	mu       sync.Mutex
+	failures map[string]*keyFailures // apiKey -> 连续失败
+}
+
+// NewDeveloperHealthService 创建开发者健康检测服务
+func NewDeveloperHealthService(
+	developerRepo repository.DeveloperRepository,
+	shopRepo repository.ShopRepository,
+	userRepo repository.UserRepository,
+) *DeveloperHealthService {
+	return &DeveloperHealthService{
+		developerRepo: developerRepo,
+		shopRepo:      shopRepo,
+		userRepo:      userRepo,
+		threshold:     defaultKeyFailureThreshold,
+		window:        defaultKeyFailureWindow,
+		failures:      make(map[string]*keyFailures),
+	}
+}
+
+var _ etsy.AuthReporter = (*DeveloperHealthService)(nil)
+
+// SetNotifier 设置封禁通知
+func (s *DeveloperHealthService) SetNotifier(notifier DeveloperBanNotifier) {
+	s.notifier = notifier
+}
+
+// SetFailureThreshold 设置自动封禁的连续失败次数与时间窗口
+func (s *DeveloperHealthService) SetFailureThreshold(threshold int, window time.Duration) {
+	if threshold > 0 {
+		s.threshold = threshold
+	}
+	if window > 0 {
+		s.window = window
+	}
+}
+
+// ReportAuthSuccess Etsy 接受了该 Key，清零连续失败
+func (s *DeveloperHealthService) ReportAuthSuccess(_ context.Context, apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f := s.failures[apiKey]; f != nil && !f.banning {
+		delete(s.failures, apiKey)
+	}
+}
+
+// ReportAuthFailure 记录鉴权失败，API Key 连续失败达到阈值时封禁开发者
+func (s *DeveloperHealthService) ReportAuthFailure(ctx context.Context, cred etsy.Credentials, failure etsy.AuthFailure, etsyErr *etsy.EtsyError) {
+	if failure != etsy.AuthFailureAPIKey {
+		log.Printf("[DeveloperHealth] 店铺 %d Token 被拒绝: %v", cred.ShopID, etsyErr)
+		return
+	}
+
+	if !s.recordFailure(cred.APIKey) {
+		return
+	}
+	defer s.finishBan(cred.APIKey)
+
+	// 请求上下文可能已结束，使用独立超时
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if err := s.banDeveloper(ctx, cred.APIKey, etsyErr.Error()); err != nil {
+		log.Printf("[DeveloperHealth] 自动封禁开发者失败: %v", err)
+	}
+}
+
+// recordFailure 累加失败次数，达到阈值且无其他封禁流程进行时返回 true
+func (s *DeveloperHealthService) recordFailure(apiKey string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	f := s.failures[apiKey]
+	if f == nil || (!f.banning && now.Sub(f.first) > s.window) {
+		f = &keyFailures{first: now}
+		s.failures[apiKey] = f
+	}
+	if f.banning {
+		return false
+	}
+	f.count++
+	if f.count < s.threshold {
+		return false
+	}
+	f.banning = true
+	return true
+}
+
+func (s *DeveloperHealthService) finishBan(apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, apiKey)
+}
+
+// banDeveloper 封禁开发者并迁移其店铺
+func (s *DeveloperHealthService) banDeveloper(ctx context.Context, apiKey, reason string) error {
+	dev, err := s.developerRepo.FindByApiKey(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	if dev == nil || dev.Status == model.DeveloperStatusBanned {
+		return nil
+	}
+
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	now := time.Now()
+	dev.Status = model.DeveloperStatusBanned
+	dev.BannedAt = &now
+	dev.BanReason = reason
+	if err := s.developerRepo.Update(ctx, dev); err != nil {
+		return fmt.Errorf("更新开发者状态失败: %w", err)
+	}
+	log.Printf("[DeveloperHealth] 开发者 %d (%s) API Key 连续被拒绝，已自动封禁: %s", dev.ID, dev.Name, reason)
+
+	migrated, err := s.MigrateShops(ctx, dev)
+	s.notifyAdmins(ctx, dev, reason, migrated)
+	return err
+}
+
+// ShopMigration 店铺迁移结果
+type ShopMigration struct {
+	ShopID         int64  `json:"shop_id"`
+	ShopName       string `json:"shop_name"`
+	Region         string `json:"region"`
+	NewDeveloperID int64  `json:"new_developer_id"` // 0 表示同地区没有可用开发者
+}
+
+// MigrateShops 将封禁开发者下的店铺迁移到同地区的健康开发者，并标记需要重新授权
+// 旧 Token 由被封的 Key 签发，无法继续使用；没有可用开发者的店铺解绑，等待人工分配
+func (s *DeveloperHealthService) MigrateShops(ctx context.Context, dev *model.Developer) ([]ShopMigration, error) {
+	shops, err := s.shopRepo.ListByDeveloperID(ctx, dev.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询开发者店铺失败: %w", err)
+	}
+	if len(shops) == 0 {
+		return nil, nil
+	}
+
+	log.Printf("[DeveloperHealth] 迁移开发者 %d 的 %d 个店铺...", dev.ID, len(shops))
+	migrations := make([]ShopMigration, 0, len(shops))
+	var firstErr error
+	for _, shop := range shops {
+		m := ShopMigration{ShopID: shop.ID, ShopName: shop.ShopName, Region: shop.Region}
+		best, err := s.developerRepo.FindBestDevForRegion(ctx, shop.Region, dev.ID)
+		if err == nil {
+			m.NewDeveloperID = best.ID
+		} else {
+			log.Printf("[CRITICAL] 店铺 %d (Region %s) 没有可用的开发者!", shop.ID, shop.Region)
+		}
+
+		err = s.shopRepo.UpdateFields(ctx, shop.ID, map[string]interface{}{
+			"developer_id": m.NewDeveloperID,
+			"token_status": model.ShopTokenStatusInvalid,
+		})
+		if err != nil {
+			log.Printf("[DeveloperHealth] 迁移店铺 %d 失败: %v", shop.ID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		migrations = append(migrations, m)
+		s.notifyShop(ctx, &shop, m.NewDeveloperID)
+	}
+	return migrations, firstErr
+}
+
+// notifyShop 通知店铺成员重新授权
+func (s *DeveloperHealthService) notifyShop(ctx context.Context, shop *model.Shop, newDeveloperID int64) {
+	if s.notifier == nil {
+		return
+	}
+	err := s.notifier.NotifyShop(ctx, shop.ID, model.NotificationEventTokenExpired, map[string]interface{}{
+		"shop_name":        shop.ShopName,
+		"reason":           "developer_banned",
+		"resource":         "developer_banned",
+		"new_developer_id": newDeveloperID,
+	})
+	if err != nil {
+		log.Printf("[DeveloperHealth] 通知店铺 %d 失败: %v", shop.ID, err)
+	}
+}
+
+// notifyAdmins 通知全部启用的管理员
+func (s *DeveloperHealthService) notifyAdmins(ctx context.Context, dev *model.Developer, reason string, migrated []ShopMigration) {
+	if s.notifier == nil || s.userRepo == nil {
+		return
+	}
+	status := int(model.UserStatusActive)
+	admins, _, err := s.userRepo.List(ctx, repository.UserFilter{
+		Role:     string(model.UserRoleAdmin),
+		Status:   &status,
+		PageSize: 100,
+	})
+	if err != nil {
+		log.Printf("[DeveloperHealth] 查询管理员失败: %v", err)
+		return
+	}
+	data := map[string]interface{}{
+		"developer_id":   dev.ID,
+		"developer_name": dev.Name,
+		"reason":         reason,
+		"shops":          migrated,
+	}
+	for _, admin := range admins {
+		if err := s.notifier.NotifyUser(admin.ID, model.NotificationEventDeveloperBanned, data); err != nil {
+			log.Printf("[DeveloperHealth] 通知管理员 %d 失败: %v", admin.ID, err)
+		}
+	}
+}
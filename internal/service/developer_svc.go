@@ -2,36 +2,52 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
-	"etsy_dev_v1_202512/pkg/net"
+	"etsy_dev_v1_202512/pkg/etsy"
 	"etsy_dev_v1_202512/pkg/utils"
 	"fmt"
 	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
-)
 
-const (
-	ETSYPing = "https://api.etsy.com/v3/application/openapi-ping"
+	"gorm.io/gorm"
 )
 
+// ErrCallbackNotVerified 回调地址未通过连通性校验，不能启用
+var ErrCallbackNotVerified = errors.New("developer callback not verified")
+
+// 分配回调地址时随机串冲突的重试次数
+const callbackAssignAttempts = 5
+
 type DeveloperService struct {
 	DeveloperRepo repository.DeveloperRepository
 	ShopRepo      repository.ShopRepository
-	Dispatcher    net.Dispatcher
+	EtsyClient    *etsy.Client
+
+	// 回调连通性校验直接访问自有域名，不走 Etsy 代理
+	httpClient *http.Client
 }
 
-func NewDeveloperService(developerRepo repository.DeveloperRepository, shopRepo repository.ShopRepository, dispatcher net.Dispatcher) *DeveloperService {
+func NewDeveloperService(developerRepo repository.DeveloperRepository, shopRepo repository.ShopRepository, etsyClient *etsy.Client) *DeveloperService {
 	return &DeveloperService{
 		DeveloperRepo: developerRepo,
 		ShopRepo:      shopRepo,
-		Dispatcher:    dispatcher,
+		EtsyClient:    etsyClient,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
+// SetHTTPClient 替换回调校验使用的 HTTP 客户端
+func (s *DeveloperService) SetHTTPClient(client *http.Client) {
+	s.httpClient = client
+}
+
 func (s *DeveloperService) CreateDeveloper(ctx context.Context, req dto.CreateDeveloperReq) (string, error) {
 	// 1. 查重逻辑：防止 apiKey 重复
 	existDev, err := s.DeveloperRepo.FindByApiKey(ctx, req.ApiKey)
@@ -57,21 +73,137 @@ func (s *DeveloperService) CreateDeveloper(ctx context.Context, req dto.CreateDe
 
 // InitDeveloper 初始化 developer 生成防关联 callbackURL
 func (s *DeveloperService) InitDeveloper(ctx context.Context, developer *model.Developer) error {
-	// 1. 找一个随机域名
+	if err := s.assignCallback(ctx, developer); err != nil {
+		return err
+	}
+	// 初始化状态为 pending 未配置，回调校验通过后启用
+	developer.Status = model.DeveloperStatusPending
+	return s.DeveloperRepo.Create(ctx, developer)
+}
+
+// assignCallback 分配域名与唯一的子域名、回调路径
+// 格式： https://{subdomain}.{host}/{path}/oauth/callback
+func (s *DeveloperService) assignCallback(ctx context.Context, developer *model.Developer) error {
+	// 1. 找一个启用且未满的随机域名
 	domain, err := s.DeveloperRepo.GetRandomActiveDomain(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("没有可用的回调域名（均已停用或达到开发者上限）")
+	}
 	if err != nil {
-		return fmt.Errorf("no active domains available err: %s", err)
-	}
-	// 2. 生成随机字符串
-	developer.SubDomain, _ = utils.GenerateRandomString(rand.IntN(5) + 8)
-	developer.CallbackPath, _ = utils.GenerateRandomString(rand.IntN(3) + 6)
-	developer.DomainPoolID = domain.ID
-	// 3. 拼接 url，格式： https://{subdomain}.{host}/api/{path}/auth/callback
-	developer.CallbackURL = fmt.Sprintf("https://%s.%s/api/%s/auth/callback",
-		developer.SubDomain, domain.Host, developer.CallbackPath)
-	// 4. 初始化状态为 pending 未配置
-	developer.Status = 0
-	return s.DeveloperRepo.Create(ctx, developer)
+		return fmt.Errorf("查询回调域名失败: %w", err)
+	}
+
+	// 2. 生成随机字符串，与历史开发者（含已删除）均不重复
+	for i := 0; i < callbackAssignAttempts; i++ {
+		subDomain, _ := utils.GenerateRandomString(rand.IntN(5) + 8)
+		subDomain = strings.ToLower(subDomain) // DNS 不区分大小写
+		callbackPath, _ := utils.GenerateRandomString(rand.IntN(3) + 6)
+
+		taken, err := s.DeveloperRepo.CallbackTaken(ctx, domain.ID, subDomain, callbackPath)
+		if err != nil {
+			return err
+		}
+		if taken {
+			continue
+		}
+
+		developer.DomainPoolID = domain.ID
+		developer.SubDomain = subDomain
+		developer.CallbackPath = callbackPath
+		developer.CallbackURL = fmt.Sprintf("https://%s.%s/%s/oauth/callback", subDomain, domain.Host, callbackPath)
+		return nil
+	}
+	return errors.New("生成回调地址失败，请重试")
+}
+
+// RegenerateCallback 重新分配回调地址（域名停用 / 疑似关联时使用）
+// 新地址需要重新填写到 Etsy 后台并校验，未封禁的开发者回到 pending
+func (s *DeveloperService) RegenerateCallback(ctx context.Context, id int64) (string, error) {
+	dev, err := s.DeveloperRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if err := s.assignCallback(ctx, dev); err != nil {
+		return "", err
+	}
+	dev.CallbackVerifiedAt = nil
+	dev.CallbackCheckError = ""
+	if dev.Status != model.DeveloperStatusBanned {
+		dev.Status = model.DeveloperStatusPending
+	}
+	if err := s.DeveloperRepo.Update(ctx, dev); err != nil {
+		return "", err
+	}
+	return dev.CallbackURL, nil
+}
+
+// VerifyCallback 校验回调地址可达且路由到本服务
+// 带随机 verify 参数访问回调地址，回调接口原样返回即视为通过；pending 的开发者通过后自动启用
+func (s *DeveloperService) VerifyCallback(ctx context.Context, id int64) (*dto.DeveloperResp, error) {
+	dev, err := s.DeveloperRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	checkErr := s.probeCallback(ctx, dev.CallbackURL)
+	if checkErr != nil {
+		dev.CallbackCheckError = checkErr.Error()
+		if len(dev.CallbackCheckError) > 255 {
+			dev.CallbackCheckError = dev.CallbackCheckError[:255]
+		}
+	} else {
+		now := time.Now()
+		dev.CallbackVerifiedAt = &now
+		dev.CallbackCheckError = ""
+		if dev.Status == model.DeveloperStatusPending {
+			dev.Status = model.DeveloperStatusActive
+		}
+	}
+	if err := s.DeveloperRepo.Update(ctx, dev); err != nil {
+		return nil, err
+	}
+	if checkErr != nil {
+		return nil, fmt.Errorf("回调校验失败: %w", checkErr)
+	}
+
+	resp := s.convertToResp(dev)
+	return &resp, nil
+}
+
+// probeCallback 访问 {callbackURL}?verify={nonce}
+func (s *DeveloperService) probeCallback(ctx context.Context, callbackURL string) error {
+	if callbackURL == "" {
+		return errors.New("尚未分配回调地址")
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("回调地址无效: %v", err)
+	}
+	nonce, _ := utils.GenerateRandomString(16)
+	q := u.Query()
+	q.Set("verify", nonce)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("回调地址返回状态码 %d", resp.StatusCode)
+	}
+	var body struct {
+		Verify string `json:"verify"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Verify != nonce {
+		return errors.New("回调地址未路由到本服务")
+	}
+	return nil
 }
 
 // GetDeveloperList 分页列表查询
@@ -127,6 +259,16 @@ func (s *DeveloperService) UpdateStatus(ctx context.Context, id int64, status in
 	if status < 0 || status > 2 {
 		return errors.New("invalid status value")
 	}
+	// 启用前回调地址必须通过校验
+	if status == model.DeveloperStatusActive {
+		dev, err := s.DeveloperRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if dev.CallbackVerifiedAt == nil {
+			return ErrCallbackNotVerified
+		}
+	}
 	return s.DeveloperRepo.UpdateStatus(ctx, id, status)
 }
 
@@ -150,34 +292,23 @@ func (s *DeveloperService) TestConnectivity(ctx context.Context, id int64) (bool
 		return false, 0, err
 	}
 
-	// 2. 构造请求
-	req, err := http.NewRequestWithContext(ctx, "GET", ETSYPing, nil)
-	if err != nil {
-		return false, 0, err
-	}
-	req.Header.Set("x-api-key", dev.ApiKey)
-
-	// 3. 设置超时
+	// 2. 设置超时
 	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	req = req.WithContext(pingCtx)
 
-	// 4. 通过 Dispatcher 发送（使用随机代理）
+	// 3. 通过 Dispatcher 发送（使用随机代理）
 	startTime := time.Now()
-	resp, err := s.Dispatcher.Ping(pingCtx, req)
+	_, err = s.EtsyClient.Ping(pingCtx, dev.ApiKey)
 	elapsed := time.Since(startTime).Milliseconds()
 
 	if err != nil {
-		return false, elapsed, fmt.Errorf("请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// 5. 判断结果
-	if resp.StatusCode == 200 {
-		return true, elapsed, nil
+		if etsyErr, ok := etsy.AsEtsyError(err); ok {
+			return false, elapsed, fmt.Errorf("ETSY 返回状态码: %d", etsyErr.StatusCode)
+		}
+		return false, elapsed, err
 	}
 
-	return false, elapsed, fmt.Errorf("ETSY 返回状态码: %d", resp.StatusCode)
+	return true, elapsed, nil
 }
 
 // convertToResp Model 转 DTO
@@ -192,7 +323,17 @@ func (s *DeveloperService) convertToResp(dev *model.Developer) dto.DeveloperResp
 		CallbackURL:  dev.CallbackURL,
 		Status:       dev.Status,
 		StatusText:   s.getStatusText(dev.Status),
+		Quota: dto.DeveloperQuotaResp{
+			LimitPerSecond: dev.QuotaPerSecond,
+			LimitPerDay:    dev.QuotaPerDay,
+			RemainingToday: dev.QuotaRemainingToday,
+			UpdatedAt:      dev.QuotaUpdatedAt,
+		},
 	}
+	resp.CallbackVerifiedAt = dev.CallbackVerifiedAt
+	resp.CallbackCheckError = dev.CallbackCheckError
+	resp.BannedAt = dev.BannedAt
+	resp.BanReason = dev.BanReason
 	return resp
 }
 
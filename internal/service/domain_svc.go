@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 反向代理配置导出格式
+const (
+	ProxyConfigNginx = "nginx"
+	ProxyConfigCaddy = "caddy"
+)
+
+// 新增域名默认可分配的开发者数量
+const defaultMaxDevelopers = 10
+
+// DomainService 回调域名池服务
+type DomainService struct {
+	domainRepo repository.DomainPoolRepository
+}
+
+// NewDomainService 创建回调域名池服务
+func NewDomainService(domainRepo repository.DomainPoolRepository) *DomainService {
+	return &DomainService{domainRepo: domainRepo}
+}
+
+// ListDomains 域名列表（含已分配开发者数量）
+func (s *DomainService) ListDomains(ctx context.Context) ([]dto.DomainResp, error) {
+	list, err := s.domainRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(list))
+	for _, d := range list {
+		ids = append(ids, d.ID)
+	}
+	counts, err := s.domainRepo.CountDevelopers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.DomainResp, 0, len(list))
+	for i := range list {
+		resp = append(resp, toDomainResp(&list[i], counts[list[i].ID]))
+	}
+	return resp, nil
+}
+
+// CreateDomain 新增回调域名
+func (s *DomainService) CreateDomain(ctx context.Context, req *dto.CreateDomainReq) (*dto.DomainResp, error) {
+	host := normalizeHost(req.Host)
+	existing, err := s.domainRepo.GetByHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("域名 %s 已存在", host)
+	}
+
+	isActive, maxDevelopers := true, defaultMaxDevelopers
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	if req.MaxDevelopers != nil {
+		maxDevelopers = *req.MaxDevelopers
+	}
+	domain := &model.DomainPool{
+		Host:          host,
+		IsActive:      isActive,
+		MaxDevelopers: maxDevelopers,
+		Remark:        req.Remark,
+	}
+	if err := s.domainRepo.Create(ctx, domain); err != nil {
+		return nil, err
+	}
+	// 零值字段会被 GORM 替换为数据库默认值，显式停用 / 不限上限需单独更新
+	zeroFields := map[string]interface{}{}
+	if !isActive {
+		zeroFields["is_active"] = false
+	}
+	if maxDevelopers == 0 {
+		zeroFields["max_developers"] = 0
+	}
+	if len(zeroFields) > 0 {
+		if err := s.domainRepo.UpdateFields(ctx, domain.ID, zeroFields); err != nil {
+			return nil, err
+		}
+		domain.IsActive, domain.MaxDevelopers = isActive, maxDevelopers
+	}
+
+	resp := toDomainResp(domain, 0)
+	return &resp, nil
+}
+
+// UpdateDomain 更新启用状态 / 开发者上限 / 备注
+// 停用或调低上限只影响后续分配，已分配的开发者不变
+func (s *DomainService) UpdateDomain(ctx context.Context, id int64, req *dto.UpdateDomainReq) (*dto.DomainResp, error) {
+	fields := map[string]interface{}{}
+	if req.IsActive != nil {
+		fields["is_active"] = *req.IsActive
+	}
+	if req.MaxDevelopers != nil {
+		fields["max_developers"] = *req.MaxDevelopers
+	}
+	if req.Remark != nil {
+		fields["remark"] = *req.Remark
+	}
+	if len(fields) > 0 {
+		if err := s.domainRepo.UpdateFields(ctx, id, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	domain, err := s.domainRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.domainRepo.CountDevelopers(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	resp := toDomainResp(domain, counts[id])
+	return &resp, nil
+}
+
+// DeleteDomain 删除域名，仍有开发者使用时拒绝
+func (s *DomainService) DeleteDomain(ctx context.Context, id int64) error {
+	counts, err := s.domainRepo.CountDevelopers(ctx, []int64{id})
+	if err != nil {
+		return err
+	}
+	if counts[id] > 0 {
+		return fmt.Errorf("域名下仍有 %d 个开发者，请先重新分配回调地址", counts[id])
+	}
+	return s.domainRepo.Delete(ctx, id)
+}
+
+// ExportProxyConfig 导出域名下所有开发者回调的反向代理配置
+// 每个开发者一个虚拟主机，只放行其回调路径；upstream 为本服务地址
+func (s *DomainService) ExportProxyConfig(ctx context.Context, id int64, format, upstream string) (string, error) {
+	domain, err := s.domainRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if u, err := url.Parse(upstream); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("upstream 必须是 http(s) 地址")
+	}
+	developers, err := s.domainRepo.ListDevelopers(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %d developers, wildcard DNS *.%s -> this proxy\n", domain.Host, len(developers), domain.Host)
+	for _, dev := range developers {
+		callback, err := url.Parse(dev.CallbackURL)
+		if err != nil || callback.Host == "" {
+			fmt.Fprintf(&b, "\n# developer %d: invalid callback url %q, skipped\n", dev.ID, dev.CallbackURL)
+			continue
+		}
+		switch format {
+		case ProxyConfigCaddy:
+			writeCaddySite(&b, &dev, callback, upstream)
+		case ProxyConfigNginx, "":
+			writeNginxServer(&b, &dev, callback, domain.Host, upstream)
+		default:
+			return "", fmt.Errorf("不支持的格式: %s", format)
+		}
+	}
+	return b.String(), nil
+}
+
+func writeNginxServer(b *strings.Builder, dev *model.Developer, callback *url.URL, host, upstream string) {
+	fmt.Fprintf(b, `
+# developer %d %s
+server {
+    listen 443 ssl;
+    server_name %s;
+    ssl_certificate     /etc/ssl/%s/fullchain.pem;
+    ssl_certificate_key /etc/ssl/%s/privkey.pem;
+
+    location = %s {
+        proxy_pass %s;
+        proxy_set_header Host $host;
+        proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+        proxy_set_header X-Forwarded-Proto $scheme;
+    }
+    location / {
+        return 404;
+    }
+}
+`, dev.ID, dev.Name, callback.Hostname(), host, host, callback.Path, upstream)
+}
+
+func writeCaddySite(b *strings.Builder, dev *model.Developer, callback *url.URL, upstream string) {
+	fmt.Fprintf(b, `
+# developer %d %s
+%s {
+    handle %s {
+        reverse_proxy %s
+    }
+    respond 404
+}
+`, dev.ID, dev.Name, callback.Hostname(), callback.Path, upstream)
+}
+
+// normalizeHost 统一小写，去掉泛域名前缀与末尾的点
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimPrefix(host, "*.")
+	return strings.TrimSuffix(host, ".")
+}
+
+func toDomainResp(d *model.DomainPool, developerCount int64) dto.DomainResp {
+	return dto.DomainResp{
+		ID:             d.ID,
+		CreatedAt:      d.CreatedAt,
+		Host:           d.Host,
+		IsActive:       d.IsActive,
+		MaxDevelopers:  d.MaxDevelopers,
+		DeveloperCount: developerCount,
+		Remark:         d.Remark,
+	}
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -45,6 +47,11 @@ type DraftService struct {
 	scraper  OneBoundServiceInterface
 	ai       AIServiceInterface
 	storage  StorageServiceInterface
+	budget   AIBudgetChecker
+
+	// 队列
+	jobMaxAttempts int
+	jobReady       chan struct{}
 
 	// 进度订阅管理
 	subscribers     map[int64][]chan dto.ProgressEvent
@@ -66,9 +73,17 @@ func NewDraftService(
 		ai:          ai,
 		storage:     storage,
 		subscribers: make(map[int64][]chan dto.ProgressEvent),
+
+		jobMaxAttempts: defaultDraftJobMaxAttempts,
+		jobReady:       make(chan struct{}, 1),
 	}
 }
 
+// SetBudgetChecker 设置 AI 预算检查（可选注入）
+func (s *DraftService) SetBudgetChecker(budget AIBudgetChecker) {
+	s.budget = budget
+}
+
 // ==================== 进度订阅 ====================
 
 // Subscribe 订阅任务进度
@@ -100,8 +115,22 @@ func (s *DraftService) Unsubscribe(taskID int64, ch chan dto.ProgressEvent) {
 	}
 }
 
-// notifyProgress 通知进度
-func (s *DraftService) notifyProgress(taskID int64, event dto.ProgressEvent) {
+// notifyProgress 持久化进度事件并推送给当前实例的订阅者
+func (s *DraftService) notifyProgress(ctx context.Context, taskID int64, event dto.ProgressEvent) {
+	record := &model.DraftTaskEvent{
+		TaskID:   taskID,
+		Stage:    event.Stage,
+		Progress: event.Progress,
+		Message:  truncateString(event.Message, 1024),
+	}
+	if event.Data != nil {
+		record.Data, _ = json.Marshal(event.Data)
+	}
+	// 事件落库不随任务取消而丢失；失败时仍推送实时事件（无 EventID，不可重放）
+	if err := s.uow.Events.Create(context.WithoutCancel(ctx), record); err == nil {
+		event.EventID = record.ID
+	}
+
 	s.subscriberMutex.RLock()
 	defer s.subscriberMutex.RUnlock()
 
@@ -109,11 +138,34 @@ func (s *DraftService) notifyProgress(taskID int64, event dto.ProgressEvent) {
 		select {
 		case ch <- event:
 		default:
-			// channel 已满，跳过
+			// channel 已满，跳过（订阅方可通过重放补齐）
 		}
 	}
 }
 
+// ReplayProgress 返回 afterID 之后的已持久化进度事件
+func (s *DraftService) ReplayProgress(ctx context.Context, taskID, afterID int64) ([]dto.ProgressEvent, error) {
+	records, err := s.uow.Events.ListAfter(ctx, taskID, afterID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]dto.ProgressEvent, len(records))
+	for i, r := range records {
+		events[i] = dto.ProgressEvent{
+			EventID:  r.ID,
+			TaskID:   r.TaskID,
+			Stage:    r.Stage,
+			Progress: r.Progress,
+			Message:  r.Message,
+		}
+		if len(r.Data) > 0 {
+			events[i].Data = json.RawMessage(r.Data)
+		}
+	}
+	return events, nil
+}
+
 // ==================== 创建草稿 ====================
 
 // CreateDraft 创建草稿任务
@@ -131,6 +183,15 @@ func (s *DraftService) CreateDraft(ctx context.Context, req *dto.CreateDraftRequ
 		}
 	}
 
+	// 预算检查：任一店铺或当前用户预算用尽则拒绝创建
+	if s.budget != nil {
+		for _, shopID := range req.ShopIDs {
+			if err := s.budget.CheckBudget(ctx, shopID, req.UserID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// 设置默认值
 	imageCount := req.ImageCount
 	if imageCount <= 0 || imageCount > 20 {
@@ -151,16 +212,39 @@ func (s *DraftService) CreateDraft(ctx context.Context, req *dto.CreateDraftRequ
 		ImageCount:     imageCount,
 		StyleHint:      req.StyleHint,
 		ExtraPrompt:    req.ExtraPrompt,
+		AIProvider:     req.AIProvider,
+		AITextModel:    req.AITextModel,
+		AIImageModel:   req.AIImageModel,
 		Status:         model.TaskStatusPending,
 		AIStatus:       model.AIStatusPending,
 	}
 
-	if err := s.uow.Tasks.Create(ctx, task); err != nil {
+	// 任务与队列记录同事务写入，由 DraftJobWorker 领取执行
+	err = s.uow.Transaction(ctx, func(uow *repository.DraftUnitOfWork) error {
+		if err := uow.Tasks.Create(ctx, task); err != nil {
+			return err
+		}
+		return uow.Jobs.Create(ctx, &model.DraftJob{
+			TaskID:      task.ID,
+			ShopIDs:     datatypes.JSONSlice[int64](req.ShopIDs),
+			Quantity:    quantity,
+			Stage:       model.DraftJobStageFetching,
+			Status:      model.DraftJobStatusQueued,
+			MaxAttempts: s.jobMaxAttempts,
+			NextRunAt:   time.Now(),
+		})
+	})
+	if err != nil {
 		return nil, fmt.Errorf("创建任务失败: %v", err)
 	}
 
-	// 异步处理
-	go s.processTask(task.ID, req.ShopIDs, quantity)
+	s.notifyProgress(ctx, task.ID, dto.ProgressEvent{
+		TaskID:   task.ID,
+		Stage:    "queued",
+		Progress: 0,
+		Message:  "任务已排队",
+	})
+	s.wakeJobWorker()
 
 	return &dto.CreateDraftResult{
 		TaskID:    task.ID,
@@ -180,23 +264,190 @@ type shopDraftResult struct {
 	Error        error
 }
 
-// processTask 异步处理任务
-func (s *DraftService) processTask(taskID int64, shopIDs []int64, quantity int) {
-	ctx := context.Background()
+// ==================== 队列执行 ====================
 
-	// 更新状态为处理中
-	s.uow.Tasks.UpdateStatus(ctx, taskID, model.TaskStatusProcessing, model.AIStatusProcessing)
+// defaultDraftJobMaxAttempts 草稿任务默认最大执行次数
+const defaultDraftJobMaxAttempts = 3
+
+// permanentDraftError 不可重试的错误（任务不存在、预算用尽等）
+type permanentDraftError struct {
+	err error
+}
+
+func (e *permanentDraftError) Error() string { return e.err.Error() }
+func (e *permanentDraftError) Unwrap() error { return e.err }
+
+// IsPermanentDraftError 是否为不可重试的草稿处理错误
+func IsPermanentDraftError(err error) bool {
+	var pe *permanentDraftError
+	return errors.As(err, &pe) || errors.Is(err, ErrAIBudgetExhausted)
+}
+
+// SetJobMaxAttempts 设置新任务的最大执行次数
+func (s *DraftService) SetJobMaxAttempts(n int) {
+	if n > 0 {
+		s.jobMaxAttempts = n
+	}
+}
+
+// JobReady 新任务入队通知（Worker 据此提前拉取，避免等待轮询周期）
+func (s *DraftService) JobReady() <-chan struct{} {
+	return s.jobReady
+}
+
+func (s *DraftService) wakeJobWorker() {
+	select {
+	case s.jobReady <- struct{}{}:
+	default:
+	}
+}
+
+// ProcessJob 执行一次草稿任务（由 DraftJobWorker 在持有租约时调用）
+// 按阶段断点续跑：已保存的源数据不重新抓取，已生成草稿的店铺不重复生成
+func (s *DraftService) ProcessJob(ctx context.Context, job *model.DraftJob) error {
+	taskID := job.TaskID
 
-	// 获取任务
 	task, err := s.uow.Tasks.GetByID(ctx, taskID)
 	if err != nil {
-		s.failTask(ctx, taskID, "获取任务失败: "+err.Error())
-		return
+		return &permanentDraftError{fmt.Errorf("获取任务失败: %v", err)}
+	}
+	if task.Status != model.TaskStatusPending && task.Status != model.TaskStatusProcessing {
+		// 任务已被其他途径结束（过期清理等），队列记录直接完成
+		return nil
+	}
+
+	// 更新状态为处理中
+	if err := s.uow.Tasks.UpdateStatus(ctx, taskID, model.TaskStatusProcessing, model.AIStatusProcessing); err != nil {
+		return err
 	}
 
 	// 1. 抓取商品数据（共享）
-	s.notifyProgress(taskID, dto.ProgressEvent{
+	source, err := s.fetchSource(ctx, job, task)
+	if err != nil {
+		return err
+	}
+
+	// 2. 为尚未生成的店铺并发生成 AI 内容，每个店铺完成即落库
+	if err := s.uow.Jobs.UpdateStage(ctx, job.ID, job.LeaseOwner, model.DraftJobStageGenerating); err != nil {
+		return err
+	}
+
+	existing, err := s.uow.Products.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	done := make(map[int64]bool, len(existing))
+	for _, p := range existing {
+		done[p.ShopID] = true
+	}
+
+	var pending []int64
+	for _, shopID := range job.ShopIDs {
+		if !done[shopID] {
+			pending = append(pending, shopID)
+		}
+	}
+
+	var results []shopDraftResult
+	if len(pending) > 0 {
+		message := fmt.Sprintf("正在为 %d 个店铺生成内容...", len(pending))
+		if len(done) > 0 {
+			message = fmt.Sprintf("继续生成：已完成 %d 个店铺，剩余 %d 个", len(done), len(pending))
+		}
+		s.notifyProgress(ctx, taskID, dto.ProgressEvent{
+			TaskID:   taskID,
+			Stage:    "generating",
+			Progress: 20,
+			Message:  message,
+		})
+
+		taskSel := AIModelSelection{
+			Provider:   task.AIProvider,
+			TextModel:  task.AITextModel,
+			ImageModel: task.AIImageModel,
+		}
+		results = s.generateForShops(ctx, taskID, task.UserID, taskSel, pending, source.Title, task.StyleHint, task.ExtraPrompt, source.RefImageURL, task.ImageCount, job.Quantity)
+	}
+
+	// 租约丢失或服务关闭时不汇总，交由下一次执行续跑
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 3. 汇总结果
+	if err := s.uow.Jobs.UpdateStage(ctx, job.ID, job.LeaseOwner, model.DraftJobStageSaving); err != nil {
+		return err
+	}
+
+	s.notifyProgress(ctx, taskID, dto.ProgressEvent{
+		TaskID:   taskID,
+		Stage:    "saving",
+		Progress: 80,
+		Message:  "正在保存草稿商品...",
+	})
+
+	successCount := len(done)
+	var lastErr error
+	budgetOnly := true
+	for _, result := range results {
+		if result.Error != nil {
+			lastErr = result.Error
+			if !errors.Is(result.Error, ErrAIBudgetExhausted) {
+				budgetOnly = false
+			}
+			continue
+		}
+		successCount++
+	}
+
+	// 4. 更新任务状态
+	if successCount == 0 {
+		err := fmt.Errorf("所有店铺生成均失败: %w", lastErr)
+		if lastErr != nil && budgetOnly {
+			return &permanentDraftError{err}
+		}
+		return err
+	}
+
+	// 部分成功也算完成
+	if err := s.uow.Tasks.UpdateStatus(ctx, taskID, model.TaskStatusDraft, model.AIStatusDone); err != nil {
+		return err
+	}
+
+	s.notifyProgress(ctx, taskID, dto.ProgressEvent{
 		TaskID:   taskID,
+		Stage:    "done",
+		Progress: 100,
+		Message:  fmt.Sprintf("处理完成，成功生成 %d/%d 个店铺草稿", successCount, len(job.ShopIDs)),
+	})
+	return nil
+}
+
+// draftSource 生成所需的源商品信息
+type draftSource struct {
+	Title       string
+	RefImageURL string
+}
+
+// fetchSource 抓取源商品；已保存过源数据时直接复用
+func (s *DraftService) fetchSource(ctx context.Context, job *model.DraftJob, task *model.DraftTask) (*draftSource, error) {
+	if len(task.SourceData) > 0 {
+		var sourceMap map[string]interface{}
+		if err := json.Unmarshal(task.SourceData, &sourceMap); err == nil {
+			source := &draftSource{Title: getMapString(sourceMap, "title")}
+			if images, ok := sourceMap["images"].([]interface{}); ok && len(images) > 0 {
+				source.RefImageURL, _ = images[0].(string)
+			}
+			return source, nil
+		}
+	}
+
+	if err := s.uow.Jobs.UpdateStage(ctx, job.ID, job.LeaseOwner, model.DraftJobStageFetching); err != nil {
+		return nil, err
+	}
+
+	s.notifyProgress(ctx, task.ID, dto.ProgressEvent{
+		TaskID:   task.ID,
 		Stage:    "fetching",
 		Progress: 10,
 		Message:  "正在抓取商品信息...",
@@ -204,8 +455,7 @@ func (s *DraftService) processTask(taskID int64, shopIDs []int64, quantity int)
 
 	product, err := s.scraper.FetchProduct(ctx, task.SourcePlatform, task.SourceItemID)
 	if err != nil {
-		s.failTask(ctx, taskID, "抓取商品失败: "+err.Error())
-		return
+		return nil, fmt.Errorf("抓取商品失败: %w", err)
 	}
 
 	// 保存抓取数据（使用 datatypes.JSON）
@@ -220,43 +470,23 @@ func (s *DraftService) processTask(taskID int64, shopIDs []int64, quantity int)
 		"attributes":  product.Attributes,
 	}
 	sourceDataBytes, _ := json.Marshal(sourceData)
-	s.uow.Tasks.UpdateFields(ctx, taskID, map[string]interface{}{
+	if err := s.uow.Tasks.UpdateFields(ctx, task.ID, map[string]interface{}{
 		"source_data": datatypes.JSON(sourceDataBytes),
-	})
+	}); err != nil {
+		return nil, err
+	}
 
 	// 获取参考图片
-	var refImageURL string
+	source := &draftSource{Title: product.Title}
 	if len(product.Images) > 0 {
-		refImageURL = product.Images[0]
+		source.RefImageURL = product.Images[0]
 	}
+	return source, nil
+}
 
-	// 2. 为每个店铺并发生成 AI 内容
-	s.notifyProgress(taskID, dto.ProgressEvent{
-		TaskID:   taskID,
-		Stage:    "generating",
-		Progress: 20,
-		Message:  fmt.Sprintf("正在为 %d 个店铺生成内容...", len(shopIDs)),
-	})
-
-	results := s.generateForShops(ctx, taskID, shopIDs, product.Title, task.StyleHint, task.ExtraPrompt, refImageURL, task.ImageCount)
-
-	// 3. 处理结果，创建草稿商品
-	s.notifyProgress(taskID, dto.ProgressEvent{
-		TaskID:   taskID,
-		Stage:    "saving",
-		Progress: 80,
-		Message:  "正在保存草稿商品...",
-	})
-
-	var successCount int
-	var lastError string
-
-	for _, result := range results {
-		if result.Error != nil {
-			lastError = result.Error.Error()
-			continue
-		}
-
+// saveShopDraft 保存单个店铺的图片与草稿商品（同一事务，避免续跑时重复写图片）
+func (s *DraftService) saveShopDraft(ctx context.Context, taskID int64, quantity int, result *shopDraftResult) error {
+	return s.uow.Transaction(ctx, func(uow *repository.DraftUnitOfWork) error {
 		// 保存图片到 DraftImage
 		var draftImages []model.DraftImage
 		for i, url := range result.ImageURLs {
@@ -269,11 +499,13 @@ func (s *DraftService) processTask(taskID int64, shopIDs []int64, quantity int)
 			})
 		}
 		if len(draftImages) > 0 {
-			s.uow.Images.CreateBatch(ctx, draftImages)
+			if err := uow.Images.CreateBatch(ctx, draftImages); err != nil {
+				return err
+			}
 		}
 
 		// 创建草稿商品（使用 datatypes.JSONSlice）
-		draftProduct := model.DraftProduct{
+		return uow.Products.Create(ctx, &model.DraftProduct{
 			TaskID:         taskID,
 			ShopID:         result.ShopID,
 			Title:          result.Title,
@@ -284,40 +516,71 @@ func (s *DraftService) processTask(taskID int64, shopIDs []int64, quantity int)
 			Quantity:       quantity,
 			Status:         model.DraftStatusDraft,
 			SyncStatus:     model.DraftSyncStatusNone,
-		}
+		})
+	})
+}
 
-		if err := s.uow.Products.Create(ctx, &draftProduct); err != nil {
-			lastError = err.Error()
-			continue
-		}
+// MarkJobRetrying 记录本次失败并通知将要重试
+func (s *DraftService) MarkJobRetrying(ctx context.Context, job *model.DraftJob, errMsg string, nextRunAt time.Time) {
+	s.uow.Tasks.UpdateFields(ctx, job.TaskID, map[string]interface{}{
+		"ai_error_message": truncateString(errMsg, 1024),
+	})
 
-		successCount++
+	s.notifyProgress(ctx, job.TaskID, dto.ProgressEvent{
+		TaskID:   job.TaskID,
+		Stage:    "retrying",
+		Progress: 0,
+		Message: fmt.Sprintf("第 %d/%d 次执行失败，将于 %s 重试: %s",
+			job.Attempts, job.MaxAttempts, nextRunAt.Format(time.RFC3339), errMsg),
+	})
+}
+
+// RecoverOrphans 启动时恢复孤儿任务
+// 租约过期的队列记录按 RecoverExpiredJobs 处理；队列上线前遗留的处理中任务缺少店铺参数，无法续跑，标记失败
+func (s *DraftService) RecoverOrphans(ctx context.Context) error {
+	if err := s.RecoverExpiredJobs(ctx); err != nil {
+		return err
 	}
 
-	// 4. 更新任务状态
-	if successCount == 0 {
-		s.failTask(ctx, taskID, "所有店铺生成均失败: "+lastError)
-		return
+	orphans, err := s.uow.Tasks.FindOrphaned(ctx)
+	if err != nil {
+		return err
+	}
+	for _, task := range orphans {
+		s.FailTask(ctx, task.ID, "服务重启导致任务中断，请重新创建")
 	}
 
-	// 部分成功也算完成
-	s.uow.Tasks.UpdateStatus(ctx, taskID, model.TaskStatusDraft, model.AIStatusDone)
+	if len(orphans) > 0 {
+		log.Printf("[DraftService] 孤儿任务恢复: 标记失败 %d 个", len(orphans))
+	}
+	return nil
+}
 
-	s.notifyProgress(taskID, dto.ProgressEvent{
-		TaskID:   taskID,
-		Stage:    "done",
-		Progress: 100,
-		Message:  fmt.Sprintf("处理完成，成功生成 %d/%d 个店铺草稿", successCount, len(shopIDs)),
-	})
+// RecoverExpiredJobs 租约过期的队列记录：未达最大执行次数的重新排队，已达上限的放弃并将任务标记失败
+func (s *DraftService) RecoverExpiredJobs(ctx context.Context) error {
+	requeued, dead, err := s.uow.Jobs.RecoverExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range dead {
+		s.FailTask(ctx, job.TaskID, fmt.Sprintf("%v (第 %d/%d 次)", repository.ErrDraftJobLeaseExhausted, job.Attempts, job.MaxAttempts))
+	}
+
+	if requeued > 0 || len(dead) > 0 {
+		log.Printf("[DraftService] 租约过期任务: 重新排队 %d 个, 放弃 %d 个", requeued, len(dead))
+	}
+	return nil
 }
 
 // generateForShops 并发为多个店铺生成内容
 func (s *DraftService) generateForShops(
 	ctx context.Context,
-	taskID int64,
+	taskID, userID int64,
+	taskSel AIModelSelection,
 	shopIDs []int64,
 	sourceTitle, styleHint, extraPrompt, refImageURL string,
-	imageCount int,
+	imageCount, quantity int,
 ) []shopDraftResult {
 	results := make([]shopDraftResult, len(shopIDs))
 
@@ -360,10 +623,21 @@ func (s *DraftService) generateForShops(
 				combinedStyle = combinedStyle + ". " + extraPrompt
 			}
 
+			// AI 调用日志与预算归属到任务 + 店铺 + 用户
+			aiCtx := WithAICallScope(ctx, sid, taskID, userID)
+
+			// Provider / 模型：任务配置优先，其次店铺配置
+			shopSel := AIModelSelection{
+				Provider:   shop.AIProvider,
+				TextModel:  shop.AITextModel,
+				ImageModel: shop.AIImageModel,
+			}
+			aiCtx = WithAIModelSelection(aiCtx, shopSel.Merge(taskSel))
+
 			// 生成文案
-			textResult, err := s.ai.GenerateProductContent(ctx, sourceTitle, combinedStyle)
+			textResult, err := s.ai.GenerateProductContent(aiCtx, sourceTitle, combinedStyle)
 			if err != nil {
-				result.Error = fmt.Errorf("生成文案失败: %v", err)
+				result.Error = fmt.Errorf("生成文案失败: %w", err)
 				results[idx] = result
 				return
 			}
@@ -374,9 +648,9 @@ func (s *DraftService) generateForShops(
 			// 生成图片
 			imagePrompt := fmt.Sprintf("Product photo of %s, %s, professional e-commerce photography",
 				textResult.Title, variantStyle)
-			base64Images, err := s.ai.GenerateImages(ctx, imagePrompt, refImageURL, imageCount)
+			base64Images, err := s.ai.GenerateImages(aiCtx, imagePrompt, refImageURL, imageCount)
 			if err != nil {
-				result.Error = fmt.Errorf("生成图片失败: %v", err)
+				result.Error = fmt.Errorf("生成图片失败: %w", err)
 				results[idx] = result
 				return
 			}
@@ -399,6 +673,11 @@ func (s *DraftService) generateForShops(
 			}
 
 			result.ImageURLs = imageURLs
+
+			// 立即落库，中断后续跑时跳过该店铺
+			if err := s.saveShopDraft(ctx, taskID, quantity, &result); err != nil {
+				result.Error = fmt.Errorf("保存草稿失败: %v", err)
+			}
 			results[idx] = result
 		}(i, shopID)
 	}
@@ -407,15 +686,15 @@ func (s *DraftService) generateForShops(
 	return results
 }
 
-// failTask 标记任务失败
-func (s *DraftService) failTask(ctx context.Context, taskID int64, errMsg string) {
+// FailTask 标记任务失败
+func (s *DraftService) FailTask(ctx context.Context, taskID int64, errMsg string) {
 	s.uow.Tasks.UpdateFields(ctx, taskID, map[string]interface{}{
 		"status":           model.TaskStatusFailed,
 		"ai_status":        model.AIStatusFailed,
-		"ai_error_message": errMsg,
+		"ai_error_message": truncateString(errMsg, 1024),
 	})
 
-	s.notifyProgress(taskID, dto.ProgressEvent{
+	s.notifyProgress(ctx, taskID, dto.ProgressEvent{
 		TaskID:   taskID,
 		Stage:    "failed",
 		Progress: 0,
@@ -432,6 +711,7 @@ func (s *DraftService) ListTasks(ctx context.Context, req *dto.ListDraftTasksReq
 		Status:   req.Status,
 		Page:     req.Page,
 		PageSize: req.PageSize,
+		ShopIDs:  req.ShopIDs,
 	})
 	if err != nil {
 		return nil, 0, err
@@ -509,25 +789,8 @@ func (s *DraftService) GetTaskDetail(ctx context.Context, taskID int64) (*dto.Dr
 			shopName = shop.ShopName
 		}
 
-		productVOs[i] = dto.DraftProductVO{
-			ID:                p.ID,
-			ShopID:            p.ShopID,
-			ShopName:          shopName,
-			Status:            p.Status,
-			SyncStatus:        p.SyncStatus,
-			Title:             p.Title,
-			Description:       p.Description,
-			Tags:              []string(p.Tags),
-			Price:             p.GetPrice(),
-			CurrencyCode:      p.CurrencyCode,
-			Quantity:          p.Quantity,
-			TaxonomyID:        p.TaxonomyID,
-			ShippingProfileID: p.ShippingProfileID,
-			ReturnPolicyID:    p.ReturnPolicyID,
-			SelectedImages:    []string(p.SelectedImages),
-			ListingID:         p.ListingID,
-			SyncError:         p.SyncError,
-		}
+		productVOs[i] = toDraftProductVO(&p, shopName)
+		productVOs[i].ImageUploads = s.imageUploadVOs(ctx, &p)
 	}
 
 	return &dto.DraftDetailResponse{
@@ -547,8 +810,9 @@ func (s *DraftService) UpdateDraftProduct(ctx context.Context, productID int64,
 		return fmt.Errorf("草稿商品不存在")
 	}
 
-	if product.Status != model.DraftStatusDraft {
-		return fmt.Errorf("只能修改草稿状态的商品")
+	// 提交失败的草稿允许修正后重试
+	if product.Status != model.DraftStatusDraft && !product.CanRetrySubmit() {
+		return fmt.Errorf("只能修改草稿状态或提交失败的商品")
 	}
 
 	updates := make(map[string]interface{})
@@ -608,6 +872,67 @@ func (s *DraftService) ConfirmAllDrafts(ctx context.Context, taskID int64) (int6
 	return s.uow.Products.ConfirmAll(ctx, taskID)
 }
 
+// ==================== 提交失败处理 ====================
+
+// ListSubmitFailures 查询提交失败 / 死信的草稿
+func (s *DraftService) ListSubmitFailures(ctx context.Context, req *dto.ListSubmitFailuresRequest) ([]dto.DraftProductVO, int64, error) {
+	products, total, err := s.uow.Products.FindSubmitFailed(ctx, repository.SubmitFailedFilter{
+		ShopID:     req.ShopID,
+		SyncStatus: req.SyncStatus,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		ShopIDs:    req.ShopIDs,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	shopNames := make(map[int64]string)
+	result := make([]dto.DraftProductVO, len(products))
+	for i, p := range products {
+		name, ok := shopNames[p.ShopID]
+		if !ok {
+			if shop, err := s.shopRepo.GetByID(ctx, p.ShopID); err == nil {
+				name = shop.ShopName
+			}
+			shopNames[p.ShopID] = name
+		}
+		result[i] = toDraftProductVO(&p, name)
+		result[i].ImageUploads = s.imageUploadVOs(ctx, &p)
+	}
+	return result, total, nil
+}
+
+// RetrySubmit 将失败 / 死信 / 已放弃的草稿重新加入提交队列（尝试次数清零）
+func (s *DraftService) RetrySubmit(ctx context.Context, productID int64) error {
+	product, err := s.uow.Products.GetByID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("草稿商品不存在")
+	}
+
+	if !product.CanRetrySubmit() {
+		return fmt.Errorf("当前状态不允许重试提交")
+	}
+
+	return s.uow.Products.ResetSubmit(ctx, productID)
+}
+
+// AbandonSubmit 放弃提交失败的草稿
+func (s *DraftService) AbandonSubmit(ctx context.Context, productID int64) error {
+	product, err := s.uow.Products.GetByID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("草稿商品不存在")
+	}
+
+	switch product.SyncStatus {
+	case model.DraftSyncStatusFailed, model.DraftSyncStatusDead:
+	default:
+		return fmt.Errorf("只能放弃提交失败的草稿")
+	}
+
+	return s.uow.Products.UpdateSyncStatus(ctx, productID, model.DraftSyncStatusAbandoned)
+}
+
 // ==================== 平台信息 ====================
 
 // GetSupportedPlatforms 获取支持的平台
@@ -626,6 +951,58 @@ func (s *DraftService) GetSupportedPlatforms() *dto.SupportedPlatformsResponse {
 
 // ==================== 辅助函数 ====================
 
+// imageUploadVOs 已开始提交（有 Listing）的草稿返回逐张图片上传状态
+func (s *DraftService) imageUploadVOs(ctx context.Context, p *model.DraftProduct) []dto.DraftImageUploadVO {
+	if p.ListingID == 0 {
+		return nil
+	}
+
+	uploads, err := s.uow.Uploads.GetByDraftProductID(ctx, p.ID)
+	if err != nil || len(uploads) == 0 {
+		return nil
+	}
+
+	vos := make([]dto.DraftImageUploadVO, len(uploads))
+	for i, up := range uploads {
+		vos[i] = dto.DraftImageUploadVO{
+			Rank:         up.Rank,
+			SourceURL:    up.SourceURL,
+			Status:       up.Status,
+			Attempts:     up.Attempts,
+			EtsyImageID:  up.EtsyImageID,
+			ErrorMessage: up.ErrorMessage,
+		}
+	}
+	return vos
+}
+
+func toDraftProductVO(p *model.DraftProduct, shopName string) dto.DraftProductVO {
+	vo := dto.DraftProductVO{
+		ID:                p.ID,
+		ShopID:            p.ShopID,
+		ShopName:          shopName,
+		Status:            p.Status,
+		SyncStatus:        p.SyncStatus,
+		Title:             p.Title,
+		Description:       p.Description,
+		Tags:              []string(p.Tags),
+		Price:             p.GetPrice(),
+		CurrencyCode:      p.CurrencyCode,
+		Quantity:          p.Quantity,
+		TaxonomyID:        p.TaxonomyID,
+		ShippingProfileID: p.ShippingProfileID,
+		ReturnPolicyID:    p.ReturnPolicyID,
+		SelectedImages:    []string(p.SelectedImages),
+		ListingID:         p.ListingID,
+		SyncError:         p.SyncError,
+		SubmitAttempts:    p.SubmitAttempts,
+	}
+	if p.NextSubmitAt != nil && p.SyncStatus == model.DraftSyncStatusPending {
+		vo.NextSubmitAt = p.NextSubmitAt.Format(time.RFC3339)
+	}
+	return vo
+}
+
 func getMapString(m map[string]interface{}, key string) string {
 	if v, ok := m[key]; ok {
 		if s, ok := v.(string); ok {
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/pkg/utils"
+)
+
+// defaultReencryptBatchSize 每批处理的行数
+const defaultReencryptBatchSize = 200
+
+// encryptedModels 含加密字段的模型（新增加密字段的模型需加入此列表才会参与密钥轮换）
+var encryptedModels = []interface{}{
+	&model.Developer{},
+	&model.Shop{},
+	&model.ShopAccount{},
+	&model.Proxy{},
+}
+
+// EncryptionStatus 加密状态
+type EncryptionStatus struct {
+	PrimaryVersion uint32                  `json:"primary_version"`
+	Versions       []uint32                `json:"versions"`
+	Columns        []EncryptedColumnStatus `json:"columns"`
+}
+
+// EncryptedColumnStatus 加密列状态
+type EncryptedColumnStatus struct {
+	Table   string `json:"table"`
+	Column  string `json:"column"`
+	Pending int64  `json:"pending"` // 明文或旧版本密钥加密的行数
+}
+
+// ReencryptReport 重新加密结果
+type ReencryptReport struct {
+	PrimaryVersion uint32 `json:"primary_version"`
+	Scanned        int64  `json:"scanned"` // 扫描行数
+	Updated        int64  `json:"updated"` // 重新加密的字段数
+	Skipped        int64  `json:"skipped"` // 处理期间被并发修改的字段（新写入已使用主密钥）
+	Failed         int64  `json:"failed"`  // 无法解密的字段（缺少旧版本密钥等）
+}
+
+// EncryptionService 字段加密维护（状态查询 / 密钥轮换后重新加密存量数据）
+type EncryptionService struct {
+	repo      repository.EncryptionRepository
+	ring      *utils.KeyRing
+	batchSize int
+}
+
+// NewEncryptionService 创建加密维护服务
+func NewEncryptionService(repo repository.EncryptionRepository, ring *utils.KeyRing) *EncryptionService {
+	return &EncryptionService{
+		repo:      repo,
+		ring:      ring,
+		batchSize: defaultReencryptBatchSize,
+	}
+}
+
+// Status 各加密列待迁移数量
+func (s *EncryptionService) Status(ctx context.Context) (*EncryptionStatus, error) {
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &EncryptionStatus{
+		PrimaryVersion: s.ring.PrimaryVersion(),
+		Versions:       s.ring.Versions(),
+		Columns:        []EncryptedColumnStatus{},
+	}
+	prefix := fmt.Sprintf("enc:v%d:", s.ring.PrimaryVersion())
+	for _, t := range tables {
+		for _, col := range t.Columns {
+			pending, err := s.repo.CountPending(ctx, t.Table, col, prefix)
+			if err != nil {
+				return nil, fmt.Errorf("统计 %s.%s 失败: %w", t.Table, col, err)
+			}
+			status.Columns = append(status.Columns, EncryptedColumnStatus{
+				Table:   t.Table,
+				Column:  col,
+				Pending: pending,
+			})
+		}
+	}
+	return status, nil
+}
+
+// Reencrypt 将明文和旧版本密文迁移到当前主密钥
+// 旧密文只重新包装数据密钥，不重新加密数据；可重复执行
+func (s *EncryptionService) Reencrypt(ctx context.Context) (*ReencryptReport, error) {
+	tables, err := s.tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReencryptReport{PrimaryVersion: s.ring.PrimaryVersion()}
+	for _, t := range tables {
+		if err := s.reencryptTable(ctx, t, report); err != nil {
+			return report, fmt.Errorf("重新加密 %s 失败: %w", t.Table, err)
+		}
+	}
+
+	log.Printf("[Encryption] 重新加密完成: 主密钥 v%d, 扫描 %d, 更新 %d, 跳过 %d, 失败 %d",
+		report.PrimaryVersion, report.Scanned, report.Updated, report.Skipped, report.Failed)
+	return report, nil
+}
+
+func (s *EncryptionService) reencryptTable(ctx context.Context, t repository.EncryptedTable, report *ReencryptReport) error {
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rows, err := s.repo.ListRawBatch(ctx, t, afterID, s.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			afterID = row.ID
+			report.Scanned++
+
+			for col, value := range row.Values {
+				if !s.ring.NeedsRewrap(value) {
+					continue
+				}
+
+				rewrapped, changed, err := s.ring.Rewrap(value)
+				if err != nil {
+					log.Printf("[Encryption] %s.%s id=%d 重新加密失败: %v", t.Table, col, row.ID, err)
+					report.Failed++
+					continue
+				}
+				if !changed {
+					continue
+				}
+
+				updated, err := s.repo.UpdateRawIfUnchanged(ctx, t.Table, col, row.ID, value, rewrapped)
+				if err != nil {
+					return err
+				}
+				if updated {
+					report.Updated++
+				} else {
+					report.Skipped++
+				}
+			}
+		}
+	}
+}
+
+// tables 已存在的加密表
+func (s *EncryptionService) tables(ctx context.Context) ([]repository.EncryptedTable, error) {
+	all, err := s.repo.EncryptedTables(encryptedModels...)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]repository.EncryptedTable, 0, len(all))
+	for _, t := range all {
+		if s.repo.HasTable(ctx, t.Table) {
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/pkg/etsy"
+
+	"gorm.io/gorm"
+)
+
+// 确保 EtsyShipmentService 实现 EtsyShipmentSyncer 接口
+var _ EtsyShipmentSyncer = (*EtsyShipmentService)(nil)
+
+// EtsyShipmentService Etsy 订单发货同步（createReceiptShipment）
+// 使用店铺绑定的开发者凭证，通过 Dispatcher 走店铺绑定的代理
+type EtsyShipmentService struct {
+	shopRepo   repository.ShopRepository
+	etsyClient *etsy.Client
+
+	// 内部物流商代码 -> Etsy carrier_name
+	etsyCarriers map[string]string
+}
+
+// NewEtsyShipmentService 创建 Etsy 发货同步服务
+func NewEtsyShipmentService(shopRepo repository.ShopRepository, etsyClient *etsy.Client) *EtsyShipmentService {
+	return &EtsyShipmentService{
+		shopRepo:   shopRepo,
+		etsyClient: etsyClient,
+		etsyCarriers: map[string]string{
+			model.CarrierYanwen:  "yanwen",
+			model.CarrierWanbang: "other",
+			"cainiao":            "cainiao",
+			"yto":                "yto-express",
+			"sto":                "sto-express",
+			"zto":                "zto-express",
+			"sf":                 "sf-express",
+			"dhl":                "dhl",
+			"fedex":              "fedex",
+			"ups":                "ups",
+			"usps":               "usps",
+			"royal_mail":         "royal-mail",
+		},
+	}
+}
+
+// CreateReceiptShipment 提交订单物流信息到 Etsy
+// shopID 为本地店铺 ID，receiptID 为 Etsy Receipt ID
+func (s *EtsyShipmentService) CreateReceiptShipment(ctx context.Context, shopID int64, receiptID int64, trackingCode, carrierCode string) (*EtsyShipmentResult, error) {
+	shop, err := s.shopRepo.GetByID(ctx, shopID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("店铺不存在")
+		}
+		return nil, err
+	}
+	if shop.Developer == nil || shop.DeveloperID == 0 {
+		return nil, errors.New("店铺未绑定开发者账号")
+	}
+	if shop.TokenStatus != model.ShopTokenStatusValid {
+		return nil, errors.New("店铺 Token 无效，需重新授权")
+	}
+
+	receipt, err := s.etsyClient.CreateReceiptShipment(ctx, EtsyCredentials(shop, nil), receiptID, etsy.EtsyReceiptShipmentCreateReq{
+		TrackingCode: trackingCode,
+		CarrierName:  s.mapEtsyCarrier(carrierCode),
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(receipt)
+
+	result := &EtsyShipmentResult{
+		EtsyStatus:  receipt.Status,
+		RawResponse: raw,
+	}
+	// 响应中的 shipments 包含历史记录，取与本次跟踪号一致的最后一条
+	for _, sh := range receipt.Shipments {
+		if sh.TrackingCode == trackingCode {
+			result.ReceiptShippingID = sh.ReceiptShippingID
+		}
+	}
+
+	return result, nil
+}
+
+// mapEtsyCarrier 内部物流商代码转换为 Etsy carrier_name
+func (s *EtsyShipmentService) mapEtsyCarrier(code string) string {
+	if name, ok := s.etsyCarriers[code]; ok {
+		return name
+	}
+	if code == "" {
+		return "other"
+	}
+	return strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+)
+
+// ShopNotifier 店铺维度通知（投递给店铺全部成员）
+type ShopNotifier interface {
+	NotifyShop(ctx context.Context, shopID int64, event string, data map[string]interface{}) error
+}
+
+// notificationTitles 事件默认标题
+var notificationTitles = map[string]string{
+	model.NotificationEventDraftSubmitted:    "草稿已发布到 Etsy",
+	model.NotificationEventDraftSubmitFailed: "草稿提交失败",
+	model.NotificationEventDraftSubmitDead:   "草稿提交多次失败，已停止重试",
+	model.NotificationEventSyncFailed:        "店铺同步失败",
+	model.NotificationEventTokenExpired:      "店铺授权已失效，请重新授权",
+	model.NotificationEventShipmentException: "物流异常",
+	model.NotificationEventAIBudgetWarning:   "AI 预算告警",
+	model.NotificationEventDeveloperBanned:   "开发者账号已被自动封禁",
+}
+
+// throttledEvents 定时任务反复触发的事件，同一店铺在去重窗口内只通知一次
+var throttledEvents = map[string]bool{
+	model.NotificationEventSyncFailed:   true,
+	model.NotificationEventTokenExpired: true,
+}
+
+// ==================== 服务 ====================
+
+// NotificationService 站内通知中心
+// 通知先落库再推送给本实例的在线订阅者；其他实例的订阅者通过轮询 ListAfter 获取
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	memberRepo       repository.ShopMemberRepository
+
+	// 在线订阅者（按用户）
+	subscribers     map[int64][]chan dto.NotificationVO
+	subscriberMutex sync.RWMutex
+
+	// 店铺事件去重
+	dedupeWindow time.Duration
+	recent       map[string]time.Time
+	recentMutex  sync.Mutex
+}
+
+// NewNotificationService 创建通知服务
+func NewNotificationService(notificationRepo repository.NotificationRepository, memberRepo repository.ShopMemberRepository) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		memberRepo:       memberRepo,
+		subscribers:      make(map[int64][]chan dto.NotificationVO),
+		dedupeWindow:     time.Hour,
+		recent:           make(map[string]time.Time),
+	}
+}
+
+// SetDedupeWindow 设置店铺事件去重窗口（0 表示不去重）
+func (s *NotificationService) SetDedupeWindow(d time.Duration) {
+	s.dedupeWindow = d
+}
+
+// ==================== 发布 ====================
+
+// NotifyUser 通知单个用户（实现 task.Notifier）
+func (s *NotificationService) NotifyUser(userID int64, event string, data interface{}) error {
+	if userID <= 0 {
+		return nil
+	}
+
+	var shopID int64
+	if m, ok := data.(map[string]interface{}); ok {
+		if id, ok := m["shop_id"].(int64); ok {
+			shopID = id
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.publish(ctx, userID, shopID, event, data)
+}
+
+// NotifyShop 通知店铺全部成员
+func (s *NotificationService) NotifyShop(ctx context.Context, shopID int64, event string, data map[string]interface{}) error {
+	if throttledEvents[event] && s.throttled(fmt.Sprintf("%d:%s:%v", shopID, event, data["resource"])) {
+		return nil
+	}
+
+	members, err := s.memberRepo.ListByShop(ctx, shopID)
+	if err != nil {
+		return fmt.Errorf("查询店铺成员失败: %w", err)
+	}
+	if len(members) == 0 {
+		log.Printf("[Notification] 店铺 %d 无成员，事件 %s 未投递", shopID, event)
+		return nil
+	}
+
+	// 复制一份再补充 shop_id，不修改调用方的 map
+	payload := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		payload[k] = v
+	}
+	payload["shop_id"] = shopID
+
+	var firstErr error
+	for _, member := range members {
+		if err := s.publish(ctx, member.UserID, shopID, event, payload); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// publish 落库并推送给在线订阅者
+func (s *NotificationService) publish(ctx context.Context, userID, shopID int64, event string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("序列化通知数据失败: %w", err)
+	}
+
+	title := notificationTitles[event]
+	if title == "" {
+		title = event
+	}
+
+	notification := &model.Notification{
+		UserID: userID,
+		ShopID: shopID,
+		Event:  event,
+		Title:  title,
+		Data:   raw,
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		log.Printf("[Notification] 保存通知失败 user=%d event=%s: %v", userID, event, err)
+		return err
+	}
+
+	vo := toNotificationVO(notification)
+
+	s.subscriberMutex.RLock()
+	defer s.subscriberMutex.RUnlock()
+	for _, ch := range s.subscribers[userID] {
+		select {
+		case ch <- vo:
+		default:
+			// 订阅者处理不过来时丢弃，客户端可通过轮询 / Last-Event-ID 补齐
+		}
+	}
+	return nil
+}
+
+// throttled 去重窗口内已通知过返回 true，否则记录本次通知时间
+func (s *NotificationService) throttled(key string) bool {
+	if s.dedupeWindow <= 0 {
+		return false
+	}
+
+	s.recentMutex.Lock()
+	defer s.recentMutex.Unlock()
+
+	now := time.Now()
+	if last, ok := s.recent[key]; ok && now.Sub(last) < s.dedupeWindow {
+		return true
+	}
+	for k, t := range s.recent {
+		if now.Sub(t) >= s.dedupeWindow {
+			delete(s.recent, k)
+		}
+	}
+	s.recent[key] = now
+	return false
+}
+
+// ==================== 订阅 ====================
+
+// Subscribe 订阅用户通知
+func (s *NotificationService) Subscribe(userID int64) chan dto.NotificationVO {
+	s.subscriberMutex.Lock()
+	defer s.subscriberMutex.Unlock()
+
+	ch := make(chan dto.NotificationVO, 100)
+	s.subscribers[userID] = append(s.subscribers[userID], ch)
+	return ch
+}
+
+// Unsubscribe 取消订阅
+func (s *NotificationService) Unsubscribe(userID int64, ch chan dto.NotificationVO) {
+	s.subscriberMutex.Lock()
+	defer s.subscriberMutex.Unlock()
+
+	subs := s.subscribers[userID]
+	for i, sub := range subs {
+		if sub == ch {
+			s.subscribers[userID] = append(subs[:i], subs[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+
+	if len(s.subscribers[userID]) == 0 {
+		delete(s.subscribers, userID)
+	}
+}
+
+// Replay 返回 afterID 之后的通知（断线重连 / 跨实例补齐）
+func (s *NotificationService) Replay(ctx context.Context, userID, afterID int64) ([]dto.NotificationVO, error) {
+	notifications, err := s.notificationRepo.ListAfter(ctx, userID, afterID, 200)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.NotificationVO, 0, len(notifications))
+	for i := range notifications {
+		result = append(result, toNotificationVO(&notifications[i]))
+	}
+	return result, nil
+}
+
+// LatestID 用户最新通知 ID，作为首次订阅的起点
+func (s *NotificationService) LatestID(ctx context.Context, userID int64) (int64, error) {
+	return s.notificationRepo.LatestID(ctx, userID)
+}
+
+// ==================== 查询 / 已读 ====================
+
+// List 通知列表
+func (s *NotificationService) List(ctx context.Context, userID int64, req *dto.ListNotificationsRequest) (*dto.NotificationListResponse, error) {
+	notifications, total, err := s.notificationRepo.List(ctx, repository.NotificationFilter{
+		UserID:     userID,
+		Event:      req.Event,
+		UnreadOnly: req.UnreadOnly,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.NotificationVO, 0, len(notifications))
+	for i := range notifications {
+		list = append(list, toNotificationVO(&notifications[i]))
+	}
+
+	return &dto.NotificationListResponse{
+		List:     list,
+		Total:    total,
+		Unread:   unread,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// UnreadCount 未读数量
+func (s *NotificationService) UnreadCount(ctx context.Context, userID int64) (int64, error) {
+	return s.notificationRepo.CountUnread(ctx, userID)
+}
+
+// MarkRead 标记指定通知为已读（只处理属于该用户的通知）
+func (s *NotificationService) MarkRead(ctx context.Context, userID int64, ids []int64) (int64, error) {
+	return s.notificationRepo.MarkRead(ctx, userID, ids)
+}
+
+// MarkAllRead 全部标记为已读
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int64) (int64, error) {
+	return s.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+// ==================== 事件适配 ====================
+
+// HandleAIBudgetWarning AI 预算告警转为通知（用于 AIBudgetService.SetWarningHandler）
+// 店铺预算通知店铺成员，用户预算通知该用户；默认预算（ScopeID=0）无明确接收人，仅记录日志
+func (s *NotificationService) HandleAIBudgetWarning(ctx context.Context, warning AIBudgetWarning) {
+	data := map[string]interface{}{
+		"scope":     warning.Scope,
+		"scope_id":  warning.ScopeID,
+		"period":    warning.Period,
+		"percent":   warning.Percent,
+		"spent_usd": warning.SpentUSD,
+		"limit_usd": warning.LimitUSD,
+	}
+
+	var err error
+	switch {
+	case warning.ScopeID == 0:
+		return
+	case warning.Scope == model.AIBudgetScopeShop:
+		err = s.NotifyShop(ctx, warning.ScopeID, model.NotificationEventAIBudgetWarning, data)
+	default:
+		err = s.publish(ctx, warning.ScopeID, 0, model.NotificationEventAIBudgetWarning, data)
+	}
+	if err != nil {
+		log.Printf("[Notification] AI 预算告警通知失败: %v", err)
+	}
+}
+
+func toNotificationVO(n *model.Notification) dto.NotificationVO {
+	vo := dto.NotificationVO{
+		ID:        n.ID,
+		ShopID:    n.ShopID,
+		Event:     n.Event,
+		Title:     n.Title,
+		Data:      json.RawMessage(n.Data),
+		Read:      n.IsRead(),
+		CreatedAt: n.CreatedAt.Format(time.RFC3339),
+	}
+	if n.ReadAt != nil {
+		vo.ReadAt = n.ReadAt.Format(time.RFC3339)
+	}
+	return vo
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"log"
+	"strconv"
 	"time"
 
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
-	"etsy_dev_v1_202512/pkg/net"
+	"etsy_dev_v1_202512/pkg/etsy"
 )
 
 // ==================== 数据传输对象 ====================
@@ -23,13 +24,20 @@ type OrderDetail struct {
 
 // ==================== Service 实现 ====================
 
+// OrderStockConsumer 新订单项入库后扣减库存
+type OrderStockConsumer interface {
+	ConsumeOrderItem(ctx context.Context, shopID int64, item *model.OrderItem) error
+}
+
 // OrderService 订单服务
 type OrderService struct {
-	orderRepo    repository.OrderRepository
-	itemRepo     repository.OrderItemRepository
-	shipmentRepo repository.ShipmentRepository
-	shopRepo     repository.ShopRepository
-	dispatcher   net.Dispatcher
+	orderRepo     repository.OrderRepository
+	itemRepo      repository.OrderItemRepository
+	shipmentRepo  repository.ShipmentRepository
+	shopRepo      repository.ShopRepository
+	syncStateRepo repository.SyncStateRepository
+	etsyClient    *etsy.Client
+	stock         OrderStockConsumer
 }
 
 // NewOrderService 创建订单服务
@@ -38,17 +46,24 @@ func NewOrderService(
 	itemRepo repository.OrderItemRepository,
 	shipmentRepo repository.ShipmentRepository,
 	shopRepo repository.ShopRepository,
-	dispatcher net.Dispatcher,
+	syncStateRepo repository.SyncStateRepository,
+	etsyClient *etsy.Client,
 ) *OrderService {
 	return &OrderService{
-		orderRepo:    orderRepo,
-		itemRepo:     itemRepo,
-		shipmentRepo: shipmentRepo,
-		shopRepo:     shopRepo,
-		dispatcher:   dispatcher,
+		orderRepo:     orderRepo,
+		itemRepo:      itemRepo,
+		shipmentRepo:  shipmentRepo,
+		shopRepo:      shopRepo,
+		syncStateRepo: syncStateRepo,
+		etsyClient:    etsyClient,
 	}
 }
 
+// SetStockConsumer 设置库存扣减（跨店铺 SKU 库存联动）
+func (s *OrderService) SetStockConsumer(stock OrderStockConsumer) {
+	s.stock = stock
+}
+
 // ==================== 查询 ====================
 
 // List 订单列表
@@ -80,83 +95,284 @@ func (s *OrderService) GetByEtsyReceiptID(ctx context.Context, shopID, receiptID
 
 // ==================== 同步 ====================
 
+const (
+	orderSyncPageSize  = 100                    // Etsy receipts 单页上限
+	orderSyncPageDelay = 300 * time.Millisecond // 翻页间隔，避免触发 Etsy 限流
+)
+
 // SyncOrders 从 Etsy 同步订单（供 Task 调用）
+// 指定 MinCreated/MaxCreated 时按创建时间窗口拉取，不影响检查点；
+// 否则从店铺检查点增量同步，FullResync 时忽略检查点全量拉取
+// FullResync 与 ForceSync 相互独立：全量拉取不会隐含覆盖本地较新的订单
 func (s *OrderService) SyncOrders(ctx context.Context, req *dto.SyncOrdersRequest) (*dto.SyncOrdersResponse, error) {
-	return s.SyncFromEtsy(ctx, req.ShopID, req.MinCreated, req.MaxCreated, req.ForceSync)
+	if req.MinCreated != "" || req.MaxCreated != "" {
+		return s.SyncFromEtsy(ctx, req.ShopID, req.MinCreated, req.MaxCreated, req.ForceSync)
+	}
+	return s.SyncIncremental(ctx, req.ShopID, req.FullResync, req.ForceSync)
 }
 
-// SyncFromEtsy 从 Etsy 同步订单
+// SyncFromEtsy 按创建时间窗口从 Etsy 同步订单（分页拉取全部）
 func (s *OrderService) SyncFromEtsy(ctx context.Context, shopID int64, minCreated, maxCreated string, forceSync bool) (*dto.SyncOrdersResponse, error) {
+	shop, err := s.getSyncableShop(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := etsy.EtsyReceiptsQuery{SortOn: "created", SortOrder: "asc"}
+	if query.MinCreated, err = parseUnixParam("min_created", minCreated); err != nil {
+		return nil, err
+	}
+	if query.MaxCreated, err = parseUnixParam("max_created", maxCreated); err != nil {
+		return nil, err
+	}
+
 	result := &dto.SyncOrdersResponse{}
+	err = s.pageReceipts(ctx, shop, query, func(receipts []etsy.EtsyReceiptResp) error {
+		for i := range receipts {
+			s.syncReceipt(ctx, shop, &receipts[i], forceSync, result)
+		}
+		return nil
+	})
+	return result, err
+}
 
-	// 获取店铺
-	shop, err := s.shopRepo.GetByID(ctx, shopID)
+// SyncIncremental 基于检查点增量同步订单
+// 按 last_modified 窗口翻页（见 pageReceiptsByModified），每页处理完成后推进高水位；
+// 中途失败时检查点停在已完整处理的位置，下次从该位置续传
+// 进入 running 后的任何错误都会把状态落为 failed，不会卡在 running
+func (s *OrderService) SyncIncremental(ctx context.Context, shopID int64, fullResync, forceSync bool) (*dto.SyncOrdersResponse, error) {
+	shop, err := s.getSyncableShop(ctx, shopID)
 	if err != nil {
-		return nil, fmt.Errorf("店铺不存在: %v", err)
+		return nil, err
+	}
+
+	state, err := s.syncStateRepo.GetOrCreate(ctx, shopID, model.SyncResourceOrders)
+	if err != nil {
+		return nil, fmt.Errorf("获取同步检查点失败: %v", err)
+	}
+
+	startedAt := time.Now()
+	_ = s.syncStateRepo.UpdateFields(ctx, state.ID, map[string]interface{}{
+		"status":          model.SyncStateRunning,
+		"last_started_at": &startedAt,
+	})
+
+	result := &dto.SyncOrdersResponse{}
+	blocked := false // 出现失败订单后不再推进检查点
+
+	cursor := state.Cursor
+	var syncErr error
+	if fullResync {
+		cursor = 0
+		if err := s.syncStateRepo.ResetCursor(ctx, state.ID); err != nil {
+			syncErr = fmt.Errorf("重置同步检查点失败: %v", err)
+		}
 	}
 
-	// 构建 Etsy API 请求
-	params := map[string]string{
-		"limit": "100",
+	// min_last_modified 为闭区间，边界上的订单会被重复拉取，upsert 保证幂等
+	query := etsy.EtsyReceiptsQuery{MinLastModified: cursor, SortOn: "updated", SortOrder: "asc"}
+
+	if syncErr == nil {
+		syncErr = s.pageReceiptsByModified(ctx, shop, query, func(receipts []etsy.EtsyReceiptResp) error {
+			pageHigh := int64(0)
+			for i := range receipts {
+				receipt := &receipts[i]
+				if !s.syncReceipt(ctx, shop, receipt, forceSync, result) && !blocked {
+					// 停在失败订单的时间点，下次从这里重新拉取
+					blocked = true
+					if receipt.UpdatedTimestamp > 0 {
+						_ = s.syncStateRepo.AdvanceCursor(ctx, state.ID, receipt.UpdatedTimestamp)
+					}
+				}
+				if !blocked && receipt.UpdatedTimestamp > pageHigh {
+					pageHigh = receipt.UpdatedTimestamp
+				}
+			}
+			if !blocked && pageHigh > 0 {
+				if err := s.syncStateRepo.AdvanceCursor(ctx, state.ID, pageHigh); err != nil {
+					return fmt.Errorf("保存同步检查点失败: %v", err)
+				}
+				result.Checkpoint = pageHigh
+			}
+			return nil
+		})
 	}
-	if minCreated != "" {
-		params["min_created"] = minCreated
+
+	finishedAt := time.Now()
+	fields := map[string]interface{}{
+		"status":           model.SyncStateIdle,
+		"last_error":       "",
+		"last_fetched":     result.TotalFetched,
+		"last_finished_at": &finishedAt,
 	}
-	if maxCreated != "" {
-		params["max_created"] = maxCreated
+	if syncErr != nil {
+		fields["status"] = model.SyncStateFailed
+		fields["last_error"] = syncErr.Error()
+	} else if blocked {
+		fields["status"] = model.SyncStateFailed
+		fields["last_error"] = fmt.Sprintf("%d 个订单处理失败", len(result.Errors))
 	}
-	url := fmt.Sprintf("%s/shops/%d/receipts?%s", EtsyAPIBaseURL, shop.EtsyShopID, params)
-	req, err := net.BuildEtsyRequest(ctx, http.MethodGet, url, nil, shop.Developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
+	if fullResync && syncErr == nil {
+		fields["last_full_sync_at"] = &finishedAt
 	}
+	// 请求取消或超时也要落下最终状态
+	_ = s.syncStateRepo.UpdateFields(context.WithoutCancel(ctx), state.ID, fields)
 
-	// 调用 Etsy API
-	resp, err := s.dispatcher.Send(ctx, shopID, req)
-	if err != nil {
-		return nil, fmt.Errorf("调用 Etsy API 失败: %v", err)
+	if result.Checkpoint == 0 {
+		result.Checkpoint = cursor
 	}
+	return result, syncErr
+}
+
+// GetSyncState 获取店铺订单同步检查点
+func (s *OrderService) GetSyncState(ctx context.Context, shopID int64) (*model.SyncState, error) {
+	return s.syncStateRepo.GetOrCreate(ctx, shopID, model.SyncResourceOrders)
+}
 
-	// 解析响应
-	var etsyResp struct {
-		Count   int                   `json:"count"`
-		Results []dto.EtsyReceiptData `json:"results"`
+// pageReceipts 分页拉取 Receipts，每页回调一次
+func (s *OrderService) pageReceipts(ctx context.Context, shop *model.Shop, query etsy.EtsyReceiptsQuery, handle func([]etsy.EtsyReceiptResp) error) error {
+	pager := s.etsyClient.ShopReceipts(EtsyCredentials(shop, nil), query).
+		WithLimit(orderSyncPageSize).
+		WithDelay(orderSyncPageDelay)
+
+	for pager.Next(ctx) {
+		if err := handle(pager.Page()); err != nil {
+			return err
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&etsyResp); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+	if err := pager.Err(); err != nil {
+		return fmt.Errorf("拉取订单失败 (offset=%d): %w", pager.Offset(), err)
 	}
+	return nil
+}
 
-	result.TotalFetched = len(etsyResp.Results)
+// pageReceiptsByModified 按 last_modified 窗口翻页，每页回调一次
+// 每页以上一页最大的 last_modified 作为下一页的 min_last_modified，翻页期间被修改的订单只会后移，
+// 不会像 offset 翻页那样使后续订单前移而被跳过，因此每页处理完即可推进检查点；
+// 整页 last_modified 相同时才在窗口内按 offset 前进。窗口边界上的订单会重复返回，回调前去重
+func (s *OrderService) pageReceiptsByModified(ctx context.Context, shop *model.Shop, query etsy.EtsyReceiptsQuery, handle func([]etsy.EtsyReceiptResp) error) error {
+	cred := EtsyCredentials(shop, nil)
+	seen := make(map[int64]int64) // receipt_id -> 已处理的 last_modified
+	window, offset := query.MinLastModified, 0
+
+	for first := true; ; first = false {
+		if !first {
+			timer := time.NewTimer(orderSyncPageDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
 
-	// 处理每个订单
-	for _, receipt := range etsyResp.Results {
-		// 检查是否是新订单
-		existing, _ := s.orderRepo.GetByEtsyReceiptID(ctx, shopID, receipt.ReceiptID)
-		isNew := existing == nil
+		query.MinLastModified = window
+		pager := s.etsyClient.ShopReceipts(cred, query).WithLimit(orderSyncPageSize).WithOffset(offset)
+		if !pager.Next(ctx) {
+			if err := pager.Err(); err != nil {
+				return fmt.Errorf("拉取订单失败 (min_last_modified=%d, offset=%d): %w", window, offset, err)
+			}
+			return nil
+		}
+		page := pager.Page()
 
-		if err := s.processReceipt(ctx, shopID, &receipt, existing, forceSync); err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("订单 %d: %v", receipt.ReceiptID, err))
-			continue
+		pageHigh := window
+		fresh := make([]etsy.EtsyReceiptResp, 0, len(page))
+		for _, receipt := range page {
+			if receipt.UpdatedTimestamp > pageHigh {
+				pageHigh = receipt.UpdatedTimestamp
+			}
+			if ts, ok := seen[receipt.ReceiptID]; ok && ts == receipt.UpdatedTimestamp {
+				continue
+			}
+			seen[receipt.ReceiptID] = receipt.UpdatedTimestamp
+			fresh = append(fresh, receipt)
+		}
+		if len(fresh) > 0 {
+			if err := handle(fresh); err != nil {
+				return err
+			}
 		}
 
-		if isNew {
-			result.NewOrders++
+		if len(page) < orderSyncPageSize {
+			return nil
+		}
+		if pageHigh > window {
+			window, offset = pageHigh, 0
 		} else {
-			result.UpdatedOrders++
+			offset += len(page)
 		}
 	}
+}
 
-	return result, nil
+// parseUnixParam 解析 Unix 时间戳参数，空值返回 0
+func parseUnixParam(name, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	ts, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ts < 0 {
+		return 0, fmt.Errorf("%s 须为 Unix 时间戳: %s", name, value)
+	}
+	return ts, nil
+}
+
+// syncReceipt 同步单个 Receipt 并累计统计，返回是否成功
+func (s *OrderService) syncReceipt(ctx context.Context, shop *model.Shop, receipt *etsy.EtsyReceiptResp, forceSync bool, result *dto.SyncOrdersResponse) bool {
+	result.TotalFetched++
+
+	// Receipt 列表未带交易明细时单独拉取
+	if len(receipt.Transactions) == 0 {
+		txs, err := s.etsyClient.ListReceiptTransactions(ctx, EtsyCredentials(shop, nil), receipt.ReceiptID)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("订单 %d: 拉取交易明细失败: %v", receipt.ReceiptID, err))
+			return false
+		}
+		receipt.Transactions = txs
+	}
+
+	existing, _ := s.orderRepo.GetByEtsyReceiptID(ctx, shop.ID, receipt.ReceiptID)
+	if err := s.processReceipt(ctx, shop.ID, receipt, existing, forceSync); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("订单 %d: %v", receipt.ReceiptID, err))
+		return false
+	}
+
+	if existing == nil {
+		result.NewOrders++
+	} else {
+		result.UpdatedOrders++
+	}
+	return true
+}
+
+// getSyncableShop 获取可同步的店铺（含开发者凭证）
+func (s *OrderService) getSyncableShop(ctx context.Context, shopID int64) (*model.Shop, error) {
+	shop, err := s.shopRepo.GetByID(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("店铺不存在: %v", err)
+	}
+	if shop.Developer == nil {
+		return nil, fmt.Errorf("店铺未绑定开发者账号")
+	}
+	if shop.TokenStatus == model.ShopTokenStatusInvalid {
+		return nil, fmt.Errorf("授权已失效")
+	}
+	return shop, nil
 }
 
 // processReceipt 处理单个 Etsy Receipt
-func (s *OrderService) processReceipt(ctx context.Context, shopID int64, receipt *dto.EtsyReceiptData, existing *model.Order, forceSync bool) error {
+func (s *OrderService) processReceipt(ctx context.Context, shopID int64, receipt *etsy.EtsyReceiptResp, existing *model.Order, forceSync bool) error {
 	// 构建订单模型
 	order := s.buildOrderFromReceipt(shopID, receipt)
 
 	if existing != nil {
 		// 更新
 		order.ID = existing.ID
+		order.CreatedAt = existing.CreatedAt // 分区键，不可变
+		// 本地流转状态（打单/发货）领先于 Etsy 时保留本地状态
+		if order.Status == model.OrderStatusPending {
+			order.Status = existing.Status
+		}
 		if !forceSync && existing.EtsyUpdatedAt != nil && order.EtsyUpdatedAt != nil {
 			if existing.EtsyUpdatedAt.After(*order.EtsyUpdatedAt) {
 				return nil // 本地更新时间更新，跳过
@@ -172,20 +388,26 @@ func (s *OrderService) processReceipt(ctx context.Context, shopID int64, receipt
 		}
 	}
 
-	// 处理订单项
+	// 处理订单项（按 Etsy Transaction ID 幂等写入）
 	for _, tx := range receipt.Transactions {
 		item := s.buildOrderItemFromTransaction(order.ID, &tx)
-		if existing != nil {
-			// 更新或创建订单项
-			existingItem, _ := s.itemRepo.GetByEtsyTransactionID(ctx, tx.TransactionID)
-			if existingItem != nil {
-				item.ID = existingItem.ID
-				_ = s.itemRepo.Update(ctx, item)
-			} else {
-				_ = s.itemRepo.Create(ctx, item)
+		existingItem, _ := s.itemRepo.GetByEtsyTransactionID(ctx, tx.TransactionID)
+		if existingItem != nil {
+			item.ID = existingItem.ID
+			item.CreatedAt = existingItem.CreatedAt
+			if err := s.itemRepo.Update(ctx, item); err != nil {
+				return fmt.Errorf("更新订单项 %d 失败: %v", tx.TransactionID, err)
+			}
+			continue
+		}
+		if err := s.itemRepo.Create(ctx, item); err != nil {
+			return fmt.Errorf("创建订单项 %d 失败: %v", tx.TransactionID, err)
+		}
+		// 库存扣减失败不影响订单入库，只记录日志
+		if s.stock != nil {
+			if err := s.stock.ConsumeOrderItem(ctx, shopID, item); err != nil {
+				log.Printf("[OrderService] 订单项 %d 扣减库存失败: %v", tx.TransactionID, err)
 			}
-		} else {
-			_ = s.itemRepo.Create(ctx, item)
 		}
 	}
 
@@ -193,7 +415,7 @@ func (s *OrderService) processReceipt(ctx context.Context, shopID int64, receipt
 }
 
 // buildOrderFromReceipt 从 Etsy Receipt 构建订单
-func (s *OrderService) buildOrderFromReceipt(shopID int64, receipt *dto.EtsyReceiptData) *model.Order {
+func (s *OrderService) buildOrderFromReceipt(shopID int64, receipt *etsy.EtsyReceiptResp) *model.Order {
 	// 构建收货地址 JSON
 	shippingAddr := map[string]interface{}{
 		"name":        receipt.Name,
@@ -265,7 +487,7 @@ func (s *OrderService) buildOrderFromReceipt(shopID int64, receipt *dto.EtsyRece
 }
 
 // etsyMoneyToCents 将 Etsy Money 对象转换为分（int64）
-func (s *OrderService) etsyMoneyToCents(m dto.EtsyMoney) int64 {
+func (s *OrderService) etsyMoneyToCents(m etsy.EtsyMoney) int64 {
 	if m.Divisor == 0 {
 		return 0
 	}
@@ -275,7 +497,7 @@ func (s *OrderService) etsyMoneyToCents(m dto.EtsyMoney) int64 {
 }
 
 // buildOrderItemFromTransaction 从 Etsy Transaction 构建订单项
-func (s *OrderService) buildOrderItemFromTransaction(orderID int64, tx *dto.EtsyTransactionData) *model.OrderItem {
+func (s *OrderService) buildOrderItemFromTransaction(orderID int64, tx *etsy.EtsyTransactionResp) *model.OrderItem {
 	// 构建变体信息
 	var variations map[string]interface{}
 	if len(tx.Variations) > 0 {
@@ -319,7 +541,7 @@ func (s *OrderService) buildOrderItemFromTransaction(orderID int64, tx *dto.Etsy
 }
 
 // mapEtsyStatus 映射 Etsy 状态到内部状态
-func (s *OrderService) mapEtsyStatus(receipt *dto.EtsyReceiptData) string {
+func (s *OrderService) mapEtsyStatus(receipt *etsy.EtsyReceiptResp) string {
 	if receipt.IsShipped {
 		return model.OrderStatusShipped
 	}
@@ -0,0 +1,591 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/pkg/etsy"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// ErrInvalidInventory 变体矩阵不满足 Etsy 约束
+var ErrInvalidInventory = errors.New("变体库存不合法")
+
+// Etsy 库存约束
+const (
+	etsyCustomPropertyPrimary   int64 = 513 // 自定义变体属性 1
+	etsyCustomPropertySecondary int64 = 514 // 自定义变体属性 2
+
+	maxVariationProperties = 2   // 每个商品最多 2 个变体属性
+	maxInventoryVariants   = 400 // 每个商品最多的属性组合数
+	minOfferingPrice       = 0.20
+	maxOfferingPrice       = 50000.0
+)
+
+// ==================== 拉取 ====================
+
+// SyncListingInventory 从 Etsy 拉取单个商品的库存与变体
+func (s *ProductService) SyncListingInventory(ctx context.Context, productID int64) (*model.Product, error) {
+	product, err := s.ProductRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %v", err)
+	}
+	if product.ListingID == 0 {
+		return nil, fmt.Errorf("商品尚未上传到 Etsy")
+	}
+	shop, err := s.ShopRepo.GetByID(ctx, product.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.pullInventory(ctx, shop, product); err != nil {
+		return nil, err
+	}
+	return s.ProductRepo.GetByID(ctx, productID)
+}
+
+// syncShopInventories 全量同步后拉取库存：仅处理新增、Etsy 侧有修改或本地尚无变体的商品
+// previous 为同步前本地各 listing 的 EtsyLastModifiedTS
+func (s *ProductService) syncShopInventories(ctx context.Context, shop *model.Shop, listings []model.Product, previous map[int64]int64) error {
+	listingIDs := make([]int64, 0, len(listings))
+	for _, l := range listings {
+		listingIDs = append(listingIDs, l.ListingID)
+	}
+	products, err := s.ProductRepo.ListByListingIDs(ctx, listingIDs)
+	if err != nil {
+		return err
+	}
+
+	synced, failed := 0, 0
+	for i := range products {
+		p := &products[i]
+		if ts, ok := previous[p.ListingID]; ok && ts == p.EtsyLastModifiedTS && len(p.Variants) > 0 {
+			continue
+		}
+		if err := s.pullInventory(ctx, shop, p); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			log.Printf("[ProductService] 同步库存失败: Shop=%d, Listing=%d, Err=%v", shop.ID, p.ListingID, err)
+			continue
+		}
+		synced++
+	}
+	if synced+failed > 0 {
+		log.Printf("[ProductService] 店铺 %d 库存同步完成: 成功 %d, 失败 %d", shop.ID, synced, failed)
+	}
+	return nil
+}
+
+// pullInventory 拉取 Etsy 库存并覆盖本地变体
+func (s *ProductService) pullInventory(ctx context.Context, shop *model.Shop, product *model.Product) error {
+	inv, err := s.EtsyClient.GetListingInventory(ctx, EtsyCredentials(shop, nil), product.ListingID)
+	if err != nil {
+		return fmt.Errorf("获取库存失败: %w", err)
+	}
+	return s.saveInventory(ctx, product, inv)
+}
+
+// ==================== 推送 ====================
+
+// UpdateProductInventory 全量更新商品变体矩阵（先推 Etsy，再以 Etsy 返回结果覆盖本地）
+func (s *ProductService) UpdateProductInventory(ctx context.Context, productID int64, req *dto.UpdateProductInventoryReq) (*model.Product, error) {
+	product, err := s.ProductRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %v", err)
+	}
+	if product.ListingID == 0 {
+		return nil, fmt.Errorf("商品尚未上传到 Etsy，请先创建 Etsy 草稿")
+	}
+	shop, err := s.ShopRepo.GetByID(ctx, product.ShopID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateInventory(ctx, shop, product, req); err != nil {
+		return nil, err
+	}
+
+	inv, err := s.EtsyClient.UpdateListingInventory(ctx, EtsyCredentials(shop, nil), product.ListingID, toEtsyInventoryReq(req))
+	if err != nil {
+		_ = s.ProductRepo.UpdateFields(ctx, product.ID, map[string]interface{}{
+			"sync_status": int(model.ProductSyncStatusFailed),
+			"sync_error":  err.Error(),
+		})
+		return nil, fmt.Errorf("ETSY 更新库存失败: %w", err)
+	}
+
+	// Etsy 返回的 product_id 全部重新生成，本地 SKU 按属性组合带回
+	product.Variants = make([]model.ProductVariant, 0, len(req.Variants))
+	for _, v := range req.Variants {
+		product.Variants = append(product.Variants, model.ProductVariant{
+			LocalSKU:     v.LocalSKU,
+			EtsyRawProps: mustJSON(toEtsyPropValues(v.PropertyValues)),
+		})
+	}
+
+	if err := s.saveInventory(ctx, product, inv); err != nil {
+		return nil, err
+	}
+	return s.ProductRepo.GetByID(ctx, productID)
+}
+
+// UpdateProductVariant 修改单个变体，基于本地变体矩阵重建完整库存后推送
+func (s *ProductService) UpdateProductVariant(ctx context.Context, productID, variantID int64, req *dto.UpdateProductVariantReq) (*model.Product, error) {
+	product, err := s.ProductRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %v", err)
+	}
+
+	found := false
+	inventoryReq := &dto.UpdateProductInventoryReq{
+		PriceOnProperty:    product.PriceOnProperty,
+		QuantityOnProperty: product.QuantityOnProperty,
+		SkuOnProperty:      product.SkuOnProperty,
+	}
+	for _, v := range product.Variants {
+		item := toVariantReq(&v)
+		if v.ID == variantID {
+			found = true
+			if req.Price != nil {
+				item.Price = *req.Price
+			}
+			if req.Quantity != nil {
+				item.Quantity = *req.Quantity
+			}
+			if req.SKU != nil {
+				item.SKU = *req.SKU
+			}
+			if req.LocalSKU != nil {
+				item.LocalSKU = *req.LocalSKU
+			}
+			if req.IsEnabled != nil {
+				item.IsEnabled = req.IsEnabled
+			}
+		}
+		inventoryReq.Variants = append(inventoryReq.Variants, item)
+	}
+	if !found {
+		return nil, fmt.Errorf("变体不存在")
+	}
+
+	return s.UpdateProductInventory(ctx, productID, inventoryReq)
+}
+
+// ==================== 校验 ====================
+
+// validateInventory 按 Etsy 规则校验变体矩阵，并为非自定义属性补全 property_name
+//   - 最多 2 个变体属性，所有变体的属性集合一致，属性组合不可重复
+//   - 非自定义属性须为商品类目下 supports_variations 的属性，带度量单位的属性须指定 scale_id
+//   - *_on_property 只能引用已使用的属性；未声明的维度上价格 / 库存 / SKU 必须一致
+//   - 价格 0.20~50000，至少一个启用的变体库存大于 0
+func (s *ProductService) validateInventory(ctx context.Context, shop *model.Shop, product *model.Product, req *dto.UpdateProductInventoryReq) error {
+	if len(req.Variants) == 0 {
+		return fmt.Errorf("%w: 至少需要一个变体", ErrInvalidInventory)
+	}
+	if len(req.Variants) > maxInventoryVariants {
+		return fmt.Errorf("%w: 变体数量不能超过 %d", ErrInvalidInventory, maxInventoryVariants)
+	}
+
+	// 1. 属性集合
+	propIDs := variantPropertyIDs(req.Variants[0].PropertyValues)
+	if len(propIDs) > maxVariationProperties {
+		return fmt.Errorf("%w: 最多 %d 个变体属性", ErrInvalidInventory, maxVariationProperties)
+	}
+	if len(propIDs) == 0 && len(req.Variants) > 1 {
+		return fmt.Errorf("%w: 多个变体必须指定属性值", ErrInvalidInventory)
+	}
+	seen := make(map[string]bool, len(req.Variants))
+	enabledStock := false
+	for i, v := range req.Variants {
+		ids := variantPropertyIDs(v.PropertyValues)
+		if len(ids) != len(v.PropertyValues) {
+			return fmt.Errorf("%w: variants[%d] 属性重复", ErrInvalidInventory, i)
+		}
+		if !sameIDs(ids, propIDs) {
+			return fmt.Errorf("%w: variants[%d] 的属性与其他变体不一致", ErrInvalidInventory, i)
+		}
+		key := inventoryPropKey(toEtsyPropValues(v.PropertyValues))
+		if seen[key] {
+			return fmt.Errorf("%w: variants[%d] 属性组合重复", ErrInvalidInventory, i)
+		}
+		seen[key] = true
+
+		if v.Price < minOfferingPrice || v.Price > maxOfferingPrice {
+			return fmt.Errorf("%w: variants[%d] 价格须在 %.2f~%.0f 之间", ErrInvalidInventory, i, minOfferingPrice, maxOfferingPrice)
+		}
+		if v.Quantity < 0 || v.Quantity > 999 {
+			return fmt.Errorf("%w: variants[%d] 库存须在 0~999 之间", ErrInvalidInventory, i)
+		}
+		if variantEnabled(v) && v.Quantity > 0 {
+			enabledStock = true
+		}
+	}
+	if !enabledStock {
+		return fmt.Errorf("%w: 至少一个启用的变体库存大于 0", ErrInvalidInventory)
+	}
+
+	// 2. 属性须被类目支持
+	if err := s.checkVariationProperties(ctx, shop, product, req, propIDs); err != nil {
+		return err
+	}
+
+	// 3. *_on_property 与取值一致性
+	checks := []struct {
+		name  string
+		on    []int64
+		value func(v dto.ProductVariantReq) string
+	}{
+		{"price_on_property", req.PriceOnProperty, func(v dto.ProductVariantReq) string { return fmt.Sprintf("%.2f", v.Price) }},
+		{"quantity_on_property", req.QuantityOnProperty, func(v dto.ProductVariantReq) string { return fmt.Sprint(v.Quantity) }},
+		{"sku_on_property", req.SkuOnProperty, func(v dto.ProductVariantReq) string { return v.SKU }},
+	}
+	for _, c := range checks {
+		for _, id := range c.on {
+			if !containsID(propIDs, id) {
+				return fmt.Errorf("%w: %s 引用了未使用的属性 %d", ErrInvalidInventory, c.name, id)
+			}
+		}
+		if hasDuplicateIDs(c.on) {
+			return fmt.Errorf("%w: %s 存在重复属性", ErrInvalidInventory, c.name)
+		}
+		// 投影到 on_property 维度后相同的变体，取值必须一致
+		values := make(map[string]string)
+		for i, v := range req.Variants {
+			key := projectedKey(v.PropertyValues, c.on)
+			if prev, ok := values[key]; ok && prev != c.value(v) {
+				return fmt.Errorf("%w: variants[%d] 的取值随未声明在 %s 中的属性变化", ErrInvalidInventory, i, c.name)
+			}
+			values[key] = c.value(v)
+		}
+	}
+	return nil
+}
+
+// checkVariationProperties 自定义属性须带名称；其他属性须为类目下可用于变体的属性
+func (s *ProductService) checkVariationProperties(ctx context.Context, shop *model.Shop, product *model.Product, req *dto.UpdateProductInventoryReq, propIDs []int64) error {
+	needTaxonomy := false
+	for _, id := range propIDs {
+		if id != etsyCustomPropertyPrimary && id != etsyCustomPropertySecondary {
+			needTaxonomy = true
+		}
+	}
+
+	taxonomyProps := make(map[int64]etsy.EtsyTaxonomyPropertyResp)
+	if needTaxonomy {
+		if product.TaxonomyID == 0 {
+			return fmt.Errorf("%w: 商品未设置类目，只能使用自定义属性 (%d/%d)", ErrInvalidInventory, etsyCustomPropertyPrimary, etsyCustomPropertySecondary)
+		}
+		props, err := s.EtsyClient.GetTaxonomyProperties(ctx, EtsyCredentials(shop, nil), product.TaxonomyID)
+		if err != nil {
+			return fmt.Errorf("获取类目属性失败: %w", err)
+		}
+		for _, p := range props {
+			taxonomyProps[p.PropertyID] = p
+		}
+	}
+
+	for i := range req.Variants {
+		for j := range req.Variants[i].PropertyValues {
+			pv := &req.Variants[i].PropertyValues[j]
+			if pv.PropertyID == etsyCustomPropertyPrimary || pv.PropertyID == etsyCustomPropertySecondary {
+				if strings.TrimSpace(pv.PropertyName) == "" {
+					return fmt.Errorf("%w: 自定义属性 %d 须指定 property_name", ErrInvalidInventory, pv.PropertyID)
+				}
+				continue
+			}
+			prop, ok := taxonomyProps[pv.PropertyID]
+			if !ok || !prop.SupportsVariations {
+				return fmt.Errorf("%w: 属性 %d 不能用于类目 %d 的变体", ErrInvalidInventory, pv.PropertyID, product.TaxonomyID)
+			}
+			if len(prop.Scales) > 0 && pv.ScaleID == nil {
+				return fmt.Errorf("%w: 属性 %s 须指定 scale_id", ErrInvalidInventory, prop.Name)
+			}
+			if pv.PropertyName == "" {
+				pv.PropertyName = defaultString(prop.DisplayName, prop.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// ==================== 本地落库 ====================
+
+// saveInventory 以 Etsy 库存覆盖本地变体，并回写商品的变体控制字段、价格（启用变体最低价）与库存（启用变体之和）
+// product.Variants 中的 LocalSKU 按属性组合保留
+func (s *ProductService) saveInventory(ctx context.Context, product *model.Product, inv *etsy.EtsyListingInventoryResp) error {
+	localSKUs := make(map[string]string, len(product.Variants))
+	for _, v := range product.Variants {
+		var props []etsy.EtsyInventoryPropValue
+		_ = json.Unmarshal(v.EtsyRawProps, &props)
+		localSKUs[inventoryPropKey(props)] = v.LocalSKU
+	}
+
+	variants := make([]model.ProductVariant, 0, len(inv.Products))
+	quantity, minPrice := 0, int64(math.MaxInt64)
+	divisor, currency := product.PriceDivisor, product.CurrencyCode
+	for _, p := range inv.Products {
+		if p.IsDeleted {
+			continue
+		}
+		offering, ok := activeOffering(p.Offerings)
+		if !ok {
+			continue
+		}
+		variants = append(variants, model.ProductVariant{
+			ShopID:         product.ShopID,
+			EtsyProductID:  p.ProductID,
+			EtsyOfferingID: offering.OfferingID,
+			PropertyValues: mustJSON(propertyValueMap(p.PropertyValues)),
+			EtsyRawProps:   mustJSON(p.PropertyValues),
+			PriceAmount:    offering.Price.Amount,
+			PriceDivisor:   offering.Price.Divisor,
+			CurrencyCode:   offering.Price.CurrencyCode,
+			Quantity:       offering.Quantity,
+			IsEnabled:      offering.IsEnabled,
+			LocalSKU:       localSKUs[inventoryPropKey(p.PropertyValues)],
+			EtsySKU:        p.SKU,
+		})
+		if offering.IsEnabled {
+			quantity += offering.Quantity
+			// 统一按分比较，避免 divisor 不同导致的误差
+			if amount := offering.Price.Amount * 100 / max(offering.Price.Divisor, 1); amount < minPrice {
+				minPrice = amount
+				divisor, currency = 100, offering.Price.CurrencyCode
+			}
+		}
+	}
+
+	if err := s.ProductRepo.ReplaceVariants(ctx, product.ID, variants); err != nil {
+		return fmt.Errorf("保存变体失败: %v", err)
+	}
+
+	fields := map[string]interface{}{
+		"has_variations":       len(variants) > 1,
+		"price_on_property":    datatypes.JSONSlice[int64](nonNilIDs(inv.PriceOnProperty)),
+		"quantity_on_property": datatypes.JSONSlice[int64](nonNilIDs(inv.QuantityOnProperty)),
+		"sku_on_property":      datatypes.JSONSlice[int64](nonNilIDs(inv.SkuOnProperty)),
+		"quantity":             quantity,
+		"sync_status":          int(model.ProductSyncStatusSynced),
+		"sync_error":           "",
+	}
+	if minPrice != math.MaxInt64 {
+		fields["price_amount"] = minPrice
+		fields["price_divisor"] = divisor
+		fields["currency_code"] = currency
+	}
+	return s.ProductRepo.UpdateFields(ctx, product.ID, fields)
+}
+
+// ==================== DTO 转换 ====================
+
+// ToProductInventoryResp 商品变体矩阵 Model -> DTO
+func (s *ProductService) ToProductInventoryResp(p *model.Product) dto.ProductInventoryResp {
+	resp := dto.ProductInventoryResp{
+		ProductID:          p.ID,
+		ListingID:          p.ListingID,
+		HasVariations:      p.HasVariations,
+		PriceOnProperty:    nonNilIDs(p.PriceOnProperty),
+		QuantityOnProperty: nonNilIDs(p.QuantityOnProperty),
+		SkuOnProperty:      nonNilIDs(p.SkuOnProperty),
+		Variants:           make([]dto.ProductVariantResp, 0, len(p.Variants)),
+	}
+	for _, v := range p.Variants {
+		resp.Variants = append(resp.Variants, s.toProductVariantResp(&v))
+	}
+	return resp
+}
+
+// toEtsyInventoryReq 变体矩阵 DTO -> Etsy 请求
+func toEtsyInventoryReq(req *dto.UpdateProductInventoryReq) etsy.EtsyListingInventoryUpdateReq {
+	out := etsy.EtsyListingInventoryUpdateReq{
+		Products:           make([]etsy.EtsyInventoryProductReq, 0, len(req.Variants)),
+		PriceOnProperty:    nonNilIDs(req.PriceOnProperty),
+		QuantityOnProperty: nonNilIDs(req.QuantityOnProperty),
+		SkuOnProperty:      nonNilIDs(req.SkuOnProperty),
+	}
+	for _, v := range req.Variants {
+		out.Products = append(out.Products, etsy.EtsyInventoryProductReq{
+			SKU:            v.SKU,
+			PropertyValues: toEtsyPropValues(v.PropertyValues),
+			Offerings: []etsy.EtsyInventoryOfferingReq{{
+				Price:     v.Price,
+				Quantity:  v.Quantity,
+				IsEnabled: variantEnabled(v),
+			}},
+		})
+	}
+	return out
+}
+
+// toEtsyPropValues 请求属性值 -> Etsy 属性值（每个属性一个取值）
+func toEtsyPropValues(values []dto.VariantPropertyReq) []etsy.EtsyInventoryPropValue {
+	props := make([]etsy.EtsyInventoryPropValue, 0, len(values))
+	for _, pv := range values {
+		props = append(props, etsy.EtsyInventoryPropValue{
+			PropertyID:   pv.PropertyID,
+			PropertyName: pv.PropertyName,
+			ScaleID:      pv.ScaleID,
+			ValueIDs:     nonNilIDs(pv.ValueIDs),
+			Values:       []string{pv.Value},
+		})
+	}
+	return props
+}
+
+// toVariantReq 本地变体 -> 变体请求（用于单变体修改时重建完整矩阵）
+func toVariantReq(v *model.ProductVariant) dto.ProductVariantReq {
+	var props []etsy.EtsyInventoryPropValue
+	_ = json.Unmarshal(v.EtsyRawProps, &props)
+
+	enabled := v.IsEnabled
+	item := dto.ProductVariantReq{
+		Price:     float64(v.PriceAmount) / float64(max(v.PriceDivisor, 1)),
+		Quantity:  v.Quantity,
+		SKU:       v.EtsySKU,
+		LocalSKU:  v.LocalSKU,
+		IsEnabled: &enabled,
+	}
+	for _, p := range props {
+		item.PropertyValues = append(item.PropertyValues, dto.VariantPropertyReq{
+			PropertyID:   p.PropertyID,
+			PropertyName: p.PropertyName,
+			ScaleID:      p.ScaleID,
+			ValueIDs:     p.ValueIDs,
+			Value:        strings.Join(p.Values, ", "),
+		})
+	}
+	return item
+}
+
+// toVariantPropertyResps Etsy 原始属性 -> DTO
+func toVariantPropertyResps(raw datatypes.JSON) []dto.VariantPropertyResp {
+	var props []etsy.EtsyInventoryPropValue
+	if raw == nil || json.Unmarshal(raw, &props) != nil {
+		return nil
+	}
+	out := make([]dto.VariantPropertyResp, 0, len(props))
+	for _, p := range props {
+		out = append(out, dto.VariantPropertyResp{
+			PropertyID:   p.PropertyID,
+			PropertyName: p.PropertyName,
+			ScaleID:      p.ScaleID,
+			ValueIDs:     nonNilIDs(p.ValueIDs),
+			Value:        strings.Join(p.Values, ", "),
+		})
+	}
+	return out
+}
+
+// ==================== 辅助函数 ====================
+
+// activeOffering 变体下第一个未删除的报价
+func activeOffering(offerings []etsy.EtsyInventoryOffering) (etsy.EtsyInventoryOffering, bool) {
+	for _, o := range offerings {
+		if !o.IsDeleted {
+			return o, true
+		}
+	}
+	return etsy.EtsyInventoryOffering{}, false
+}
+
+// propertyValueMap 属性名 -> 属性值，用于展示
+func propertyValueMap(values []etsy.EtsyInventoryPropValue) map[string]string {
+	out := make(map[string]string, len(values))
+	for _, v := range values {
+		out[v.PropertyName] = strings.Join(v.Values, ", ")
+	}
+	return out
+}
+
+// inventoryPropKey Etsy 属性组合的唯一键（属性ID + 小写取值，与顺序无关）
+func inventoryPropKey(values []etsy.EtsyInventoryPropValue) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprintf("%d=%s", v.PropertyID, strings.ToLower(strings.Join(v.Values, ", "))))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// projectedKey 属性组合在指定属性上的投影键，格式与 inventoryPropKey 一致
+func projectedKey(values []dto.VariantPropertyReq, ids []int64) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if !containsID(ids, v.PropertyID) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%d=%s", v.PropertyID, strings.ToLower(v.Value)))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// variantPropertyIDs 去重后的属性ID（升序）
+func variantPropertyIDs(values []dto.VariantPropertyReq) []int64 {
+	ids := make([]int64, 0, len(values))
+	for _, v := range values {
+		if !containsID(ids, v.PropertyID) {
+			ids = append(ids, v.PropertyID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func variantEnabled(v dto.ProductVariantReq) bool {
+	return v.IsEnabled == nil || *v.IsEnabled
+}
+
+func sameIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasDuplicateIDs(ids []int64) bool {
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func nonNilIDs(ids []int64) []int64 {
+	if ids == nil {
+		return []int64{}
+	}
+	return ids
+}
+
+func mustJSON(v interface{}) datatypes.JSON {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -1,17 +1,15 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
-	"etsy_dev_v1_202512/pkg/net"
+	"etsy_dev_v1_202512/pkg/etsy"
 	"fmt"
-	"io"
+	"log"
 	"math"
-	"net/http"
 	"time"
 )
 
@@ -20,7 +18,7 @@ type ProductService struct {
 	ShopRepo    repository.ShopRepository
 	AIService   *AIService
 	Storage     *StorageService
-	Dispatcher  net.Dispatcher
+	EtsyClient  *etsy.Client
 }
 
 func NewProductService(
@@ -28,14 +26,14 @@ func NewProductService(
 	shopRepo repository.ShopRepository,
 	ai *AIService,
 	storage *StorageService,
-	dispatcher net.Dispatcher,
+	etsyClient *etsy.Client,
 ) *ProductService {
 	return &ProductService{
 		ProductRepo: productRepo,
 		ShopRepo:    shopRepo,
 		AIService:   ai,
 		Storage:     storage,
-		Dispatcher:  dispatcher,
+		EtsyClient:  etsyClient,
 	}
 }
 
@@ -89,9 +87,15 @@ func (s *ProductService) GenerateAIDraft(ctx context.Context, req *dto.AIGenerat
 	}
 
 	// 2. 调用 AI 服务生成内容
-	aiResult, err := s.AIService.GenerateProductContent(ctx, req.SourceMaterial, req.StyleHint)
+	aiCtx := WithAICallScope(ctx, shop.ID, 0, 0)
+	aiCtx = WithAIModelSelection(aiCtx, AIModelSelection{
+		Provider:   shop.AIProvider,
+		TextModel:  shop.AITextModel,
+		ImageModel: shop.AIImageModel,
+	})
+	aiResult, err := s.AIService.GenerateProductContent(aiCtx, req.SourceMaterial, req.StyleHint)
 	if err != nil {
-		return nil, fmt.Errorf("AI 生成失败: %v", err)
+		return nil, fmt.Errorf("AI 生成失败: %w", err)
 	}
 
 	// 3. 构建本地草稿
@@ -158,88 +162,49 @@ func (s *ProductService) CreateDraftListing(ctx context.Context, req *dto.Create
 		currency = "USD"
 	}
 
-	payload := map[string]interface{}{
-		"quantity":    req.Quantity,
-		"title":       req.Title,
-		"description": req.Description,
-		"price": map[string]interface{}{
-			"amount":        priceAmount,
-			"divisor":       100,
-			"currency_code": currency,
+	etsyReq := etsy.EtsyListingCreateReq{
+		Quantity:    req.Quantity,
+		Title:       req.Title,
+		Description: req.Description,
+		Price: etsy.PriceDTO{
+			Amount:       priceAmount,
+			Divisor:      100,
+			CurrencyCode: currency,
 		},
-		"taxonomy_id":         req.TaxonomyID,
-		"shipping_profile_id": req.ShippingProfileID,
-		"who_made":            defaultString(req.WhoMade, "i_did"),
-		"when_made":           defaultString(req.WhenMade, "made_to_order"),
-		"is_supply":           req.IsSupply,
-	}
+		TaxonomyID:        req.TaxonomyID,
+		ShippingProfileID: req.ShippingProfileID,
+		WhoMade:           defaultString(req.WhoMade, "i_did"),
+		WhenMade:          defaultString(req.WhenMade, "made_to_order"),
+		IsSupply:          req.IsSupply,
 
-	// 可选字段
-	if req.ReturnPolicyID > 0 {
-		payload["return_policy_id"] = req.ReturnPolicyID
-	}
-	if len(req.Tags) > 0 {
-		payload["tags"] = req.Tags
-	}
-	if len(req.Materials) > 0 {
-		payload["materials"] = req.Materials
-	}
-	if len(req.Styles) > 0 {
-		payload["styles"] = req.Styles
-	}
-	if len(req.ImageIDs) > 0 {
-		payload["image_ids"] = req.ImageIDs
-	}
-	if req.ShopSectionID > 0 {
-		payload["shop_section_id"] = req.ShopSectionID
+		// 可选字段（零值不提交）
+		ReturnPolicyID: req.ReturnPolicyID,
+		ShopSectionID:  req.ShopSectionID,
+		Tags:           req.Tags,
+		Materials:      req.Materials,
+		Styles:         req.Styles,
+		ImageIDs:       req.ImageIDs,
 	}
 
 	// 物理属性
 	if req.ItemWeight > 0 {
-		payload["item_weight"] = req.ItemWeight
-		payload["item_weight_unit"] = defaultString(req.ItemWeightUnit, "oz")
+		etsyReq.ItemWeight = req.ItemWeight
+		etsyReq.ItemWeightUnit = defaultString(req.ItemWeightUnit, "oz")
 	}
 	if req.ItemLength > 0 || req.ItemWidth > 0 || req.ItemHeight > 0 {
-		payload["item_length"] = req.ItemLength
-		payload["item_width"] = req.ItemWidth
-		payload["item_height"] = req.ItemHeight
-		payload["item_dimensions_unit"] = defaultString(req.ItemDimensionsUnit, "in")
+		etsyReq.ItemLength = req.ItemLength
+		etsyReq.ItemWidth = req.ItemWidth
+		etsyReq.ItemHeight = req.ItemHeight
+		etsyReq.ItemDimensionsUnit = defaultString(req.ItemDimensionsUnit, "in")
 	}
 
-	// 3. 构建 HTTP 请求
-	url := fmt.Sprintf("https://api.etsy.com/v3/application/shops/%d/listings", shop.EtsyShopID)
-	bodyBytes, _ := json.Marshal(payload)
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-	httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-	// 4. 通过 Dispatcher 发送 (自动处理代理)
-	resp, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
+	// 3. 通过 Etsy 客户端创建草稿 (Dispatcher 自动处理代理)
+	result, err := s.EtsyClient.CreateDraftListing(ctx, EtsyCredentials(shop, nil), etsyReq)
 	if err != nil {
-		return nil, fmt.Errorf("网络请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 201 {
-		return nil, fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
-	}
-
-	// 5. 解析响应
-	var result struct {
-		ListingID int64 `json:"listing_id"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 
-	// 6. 本地入库
+	// 4. 本地入库
 	product := &model.Product{
 		ShopID:            shop.ID,
 		ListingID:         result.ListingID,
@@ -287,60 +252,41 @@ func (s *ProductService) UpdateListing(ctx context.Context, req *dto.UpdateProdu
 		return err
 	}
 
-	// 3. 构建更新 payload
-	payload := make(map[string]interface{})
+	// 3. 构建更新请求
+	var etsyReq etsy.EtsyListingUpdateReq
 	if req.Title != nil {
-		payload["title"] = *req.Title
+		etsyReq.Title = req.Title
 		product.Title = *req.Title
 	}
 	if req.Description != nil {
-		payload["description"] = *req.Description
+		etsyReq.Description = req.Description
 		product.Description = *req.Description
 	}
 	if req.Price != nil {
 		priceAmount := int64(math.Round(*req.Price * 100))
-		payload["price"] = map[string]interface{}{
-			"amount":        priceAmount,
-			"divisor":       100,
-			"currency_code": product.CurrencyCode,
+		etsyReq.Price = &etsy.PriceDTO{
+			Amount:       priceAmount,
+			Divisor:      100,
+			CurrencyCode: product.CurrencyCode,
 		}
 		product.PriceAmount = priceAmount
 	}
 	if req.Quantity != nil {
-		payload["quantity"] = *req.Quantity
+		etsyReq.Quantity = req.Quantity
 		product.Quantity = *req.Quantity
 	}
 	if len(req.Tags) > 0 {
-		payload["tags"] = req.Tags
+		etsyReq.Tags = req.Tags
 		product.Tags = req.Tags
 	}
 
 	// 4. 如果商品已上传 Etsy，则先推送
-	if product.ListingID > 0 && len(payload) > 0 {
-		url := fmt.Sprintf("https://api.etsy.com/v3/application/shops/%d/listings/%d",
-			shop.EtsyShopID, product.ListingID)
-
-		bodyBytes, _ := json.Marshal(payload)
-		httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(bodyBytes))
-		httpReq.Header.Set("Content-Type", "application/json")
-		httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-		httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-		resp, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
-		if err != nil {
+	if product.ListingID > 0 && !etsyReq.Empty() {
+		if _, err := s.EtsyClient.UpdateListing(ctx, EtsyCredentials(shop, nil), product.ListingID, etsyReq); err != nil {
 			product.SyncStatus = int(model.DraftSyncStatusFailed)
 			product.SyncError = err.Error()
 			_ = s.ProductRepo.Update(ctx, product)
-			return fmt.Errorf("ETSY 更新失败: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != 200 {
-			respBody, _ := io.ReadAll(resp.Body)
-			product.SyncStatus = int(model.DraftSyncStatusFailed)
-			product.SyncError = string(respBody)
-			_ = s.ProductRepo.Update(ctx, product)
-			return fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+			return fmt.Errorf("ETSY 更新失败: %w", err)
 		}
 
 		product.SyncStatus = int(model.ProductSyncStatusSynced)
@@ -366,24 +312,8 @@ func (s *ProductService) ActivateListing(ctx context.Context, productID int64) e
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.etsy.com/v3/application/shops/%d/listings/%d",
-		shop.EtsyShopID, product.ListingID)
-
-	bodyBytes, _ := json.Marshal(map[string]interface{}{"state": "active"})
-	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(bodyBytes))
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-	httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-	resp, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
-	if err != nil {
-		return fmt.Errorf("上架失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+	if _, err := s.EtsyClient.UpdateListing(ctx, EtsyCredentials(shop, nil), product.ListingID, etsy.EtsyListingUpdateReq{State: "active"}); err != nil {
+		return fmt.Errorf("上架失败: %w", err)
 	}
 
 	product.State = model.ProductStateActive
@@ -406,24 +336,8 @@ func (s *ProductService) DeactivateListing(ctx context.Context, productID int64)
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.etsy.com/v3/application/shops/%d/listings/%d",
-		shop.EtsyShopID, product.ListingID)
-
-	bodyBytes, _ := json.Marshal(map[string]interface{}{"state": "inactive"})
-	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(bodyBytes))
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-	httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-	resp, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
-	if err != nil {
-		return fmt.Errorf("下架失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+	if _, err := s.EtsyClient.UpdateListing(ctx, EtsyCredentials(shop, nil), product.ListingID, etsy.EtsyListingUpdateReq{State: "inactive"}); err != nil {
+		return fmt.Errorf("下架失败: %w", err)
 	}
 
 	product.State = model.ProductStateInactive
@@ -444,21 +358,9 @@ func (s *ProductService) DeleteListing(ctx context.Context, productID int64) err
 			return err
 		}
 
-		url := fmt.Sprintf("https://api.etsy.com/v3/application/listings/%d", product.ListingID)
-		httpReq, _ := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
-		httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-		httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-		resp, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
-		if err != nil {
-			return fmt.Errorf("删除远程失败: %v", err)
-		}
-		defer resp.Body.Close()
-
 		// 404 也视为删除成功
-		if resp.StatusCode != 204 && resp.StatusCode != 404 {
-			respBody, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+		if err := s.EtsyClient.DeleteListing(ctx, EtsyCredentials(shop, nil), product.ListingID); err != nil && !etsy.IsNotFound(err) {
+			return fmt.Errorf("删除远程失败: %w", err)
 		}
 	}
 
@@ -479,54 +381,42 @@ func (s *ProductService) SyncListingsFromEtsy(ctx context.Context, shopID int64)
 	}
 
 	var allProducts []model.Product
-	limit := 100
-	offset := 0
-
-	for {
-		url := fmt.Sprintf("https://api.etsy.com/v3/application/shops/%d/listings?limit=%d&offset=%d",
-			shop.EtsyShopID, limit, offset)
-
-		httpReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-		httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-		httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-		resp, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
-		if err != nil {
-			return fmt.Errorf("同步失败: %v", err)
-		}
-
-		respBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		if resp.StatusCode != 200 {
-			return fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+	pager := s.EtsyClient.ShopListings(EtsyCredentials(shop, nil), "").
+		WithDelay(500 * time.Millisecond)
+	for pager.Next(ctx) {
+		page := pager.Page()
+		for i := range page {
+			allProducts = append(allProducts, *s.mapEtsyListingToProduct(shop.ID, &page[i]))
 		}
-
-		var result struct {
-			Count   int                      `json:"count"`
-			Results []map[string]interface{} `json:"results"`
-		}
-		if err := json.Unmarshal(respBody, &result); err != nil {
-			return fmt.Errorf("解析响应失败: %v", err)
-		}
-
-		for _, item := range result.Results {
-			product := s.mapEtsyListingToProduct(shop.ID, item)
-			allProducts = append(allProducts, *product)
-		}
-
-		if len(result.Results) < limit {
-			break
-		}
-		offset += limit
-		time.Sleep(500 * time.Millisecond)
+	}
+	if err := pager.Err(); err != nil {
+		return fmt.Errorf("同步失败: %w", err)
 	}
 
 	if len(allProducts) == 0 {
 		return nil
 	}
 
-	return s.ProductRepo.BatchUpsert(ctx, allProducts)
+	// 记录同步前的修改时间，用于判断哪些商品需要重新拉取库存
+	listingIDs := make([]int64, 0, len(allProducts))
+	for _, p := range allProducts {
+		listingIDs = append(listingIDs, p.ListingID)
+	}
+	existing, err := s.ProductRepo.ListByListingIDs(ctx, listingIDs)
+	if err != nil {
+		return err
+	}
+	previous := make(map[int64]int64, len(existing))
+	for _, p := range existing {
+		previous[p.ListingID] = p.EtsyLastModifiedTS
+	}
+
+	if err := s.ProductRepo.BatchUpsert(ctx, allProducts); err != nil {
+		return err
+	}
+
+	// 拉取库存与变体
+	return s.syncShopInventories(ctx, shop, allProducts, previous)
 }
 
 // ==================== 图片操作 ====================
@@ -548,55 +438,21 @@ func (s *ProductService) UploadListingImage(ctx context.Context, productID int64
 		return nil, fmt.Errorf("店铺不存在: %v", err)
 	}
 
-	// 3. 构建 multipart 请求
-	url := fmt.Sprintf("https://api.etsy.com/v3/application/shops/%d/listings/%d/images",
-		shop.EtsyShopID, product.ListingID)
-
-	resp, err := s.Dispatcher.SendMultipart(ctx, shop.ID, &net.MultipartRequest{
-		URL: url,
-		Headers: map[string]string{
-			"x-api-key":     shop.Developer.ApiKey,
-			"Authorization": "Bearer " + shop.AccessToken,
-		},
-		Files: map[string]net.FileData{
-			"image": {Data: imageData, Filename: filename},
-		},
-		Fields: map[string]string{
-			"rank": fmt.Sprintf("%d", rank),
-		},
+	// 3. 上传到 Etsy
+	result, err := s.EtsyClient.UploadListingImage(ctx, EtsyCredentials(shop, nil), product.ListingID, etsy.EtsyListingImageUploadReq{
+		Data:     imageData,
+		Filename: filename,
+		Rank:     rank,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("上传请求失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 201 {
-		return nil, fmt.Errorf("ETSY API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("上传图片失败: %w", err)
 	}
 
-	// 4. 解析响应
-	var result struct {
-		ListingImageID int64  `json:"listing_image_id"`
-		ListingID      int64  `json:"listing_id"`
-		Rank           int    `json:"rank"`
-		UrlFullxfull   string `json:"url_fullxfull"`
-		Url570xN       string `json:"url_570xN"`
-		UrlThumb       string `json:"url_75x75"`
-		FullHeight     int    `json:"full_height"`
-		FullWidth      int    `json:"full_width"`
-		HexCode        string `json:"hex_code"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
-	}
-
-	// 5. 本地入库
+	// 4. 本地入库
 	image := &model.ProductImage{
 		ProductID:   productID,
 		EtsyImageID: result.ListingImageID,
-		EtsyUrl:     result.UrlFullxfull,
+		EtsyUrl:     result.URLFullxFull,
 		Rank:        result.Rank,
 		Height:      result.FullHeight,
 		Width:       result.FullWidth,
@@ -614,82 +470,36 @@ func (s *ProductService) UploadListingImage(ctx context.Context, productID int64
 // ==================== 私有方法 ====================
 
 func (s *ProductService) deleteEtsyListingInternal(ctx context.Context, shop *model.Shop, listingID int64) {
-	url := fmt.Sprintf("https://api.etsy.com/v3/application/listings/%d", listingID)
-	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
-	httpReq.Header.Set("x-api-key", shop.Developer.ApiKey)
-	httpReq.Header.Set("Authorization", "Bearer "+shop.AccessToken)
-
-	_, err := s.Dispatcher.Send(ctx, shop.ID, httpReq)
-	if err != nil {
-		fmt.Printf("[CRITICAL] 回滚失败: Shop=%d, Listing=%d, Err=%v\n", shop.ID, listingID, err)
+	if err := s.EtsyClient.DeleteListing(ctx, EtsyCredentials(shop, nil), listingID); err != nil {
+		log.Printf("[CRITICAL] 回滚失败: Shop=%d, Listing=%d, Err=%v", shop.ID, listingID, err)
 	}
 }
 
-func (s *ProductService) mapEtsyListingToProduct(shopID int64, data map[string]interface{}) *model.Product {
-	product := &model.Product{
+func (s *ProductService) mapEtsyListingToProduct(shopID int64, listing *etsy.ProductListingDTO) *model.Product {
+	return &model.Product{
 		ShopID:     shopID,
 		SyncStatus: int(model.ProductSyncStatusSynced),
-	}
 
-	if v, ok := data["listing_id"].(float64); ok {
-		product.ListingID = int64(v)
-	}
-	if v, ok := data["user_id"].(float64); ok {
-		product.UserID = int64(v)
-	}
-	if v, ok := data["title"].(string); ok {
-		product.Title = v
-	}
-	if v, ok := data["description"].(string); ok {
-		product.Description = v
-	}
-	if v, ok := data["state"].(string); ok {
-		product.State = model.ProductState(v)
-	}
-	if v, ok := data["url"].(string); ok {
-		product.Url = v
-	}
-	if v, ok := data["quantity"].(float64); ok {
-		product.Quantity = int(v)
-	}
-	if v, ok := data["views"].(float64); ok {
-		product.Views = int(v)
-	}
-	if v, ok := data["num_favorers"].(float64); ok {
-		product.NumFavorers = int(v)
+		ListingID:    listing.ListingID,
+		UserID:       listing.UserID,
+		Title:        listing.Title,
+		Description:  listing.Description,
+		State:        model.ProductState(listing.State),
+		Url:          listing.URL,
+		Quantity:     listing.Quantity,
+		Views:        listing.Views,
+		NumFavorers:  listing.NumFavorers,
+		PriceAmount:  listing.Price.Amount,
+		PriceDivisor: listing.Price.Divisor,
+		CurrencyCode: listing.Price.CurrencyCode,
+		Tags:         listing.Tags,
+
+		TaxonomyID:    listing.TaxonomyID,
+		HasVariations: listing.HasVariations,
+
+		EtsyCreationTS:     listing.CreationTimestamp,
+		EtsyLastModifiedTS: listing.LastModifiedTimestamp,
 	}
-
-	// 价格
-	if price, ok := data["price"].(map[string]interface{}); ok {
-		if v, ok := price["amount"].(float64); ok {
-			product.PriceAmount = int64(v)
-		}
-		if v, ok := price["divisor"].(float64); ok {
-			product.PriceDivisor = int64(v)
-		}
-		if v, ok := price["currency_code"].(string); ok {
-			product.CurrencyCode = v
-		}
-	}
-
-	// 标签
-	if tags, ok := data["tags"].([]interface{}); ok {
-		for _, t := range tags {
-			if str, ok := t.(string); ok {
-				product.Tags = append(product.Tags, str)
-			}
-		}
-	}
-
-	// 时间戳
-	if v, ok := data["creation_timestamp"].(float64); ok {
-		product.EtsyCreationTS = int64(v)
-	}
-	if v, ok := data["last_modified_timestamp"].(float64); ok {
-		product.EtsyLastModifiedTS = int64(v)
-	}
-
-	return product
 }
 
 func defaultString(s, def string) string {
@@ -793,6 +603,7 @@ func (s *ProductService) toProductVariantResp(v *model.ProductVariant) dto.Produ
 		LocalSKU:       v.LocalSKU,
 		EtsySKU:        v.EtsySKU,
 		IsEnabled:      v.IsEnabled,
+		Properties:     toVariantPropertyResps(v.EtsyRawProps),
 	}
 }
 
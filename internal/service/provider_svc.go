@@ -2,10 +2,11 @@ package service
 
 import (
 	"context"
+	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/pkg/net"
 	"fmt"
 	"log"
-	"net/url"
 	"time"
 )
 
@@ -23,15 +24,14 @@ func NewNetworkProvider(shopRepo repository.ShopRepository, proxyService *ProxyS
 }
 
 // GetProxy 实现接口：根据 key (ShopID) 获取可用代理
-func (n *NetworkProvider) GetProxy(ctx context.Context, shopID int64) (*url.URL, error) {
+func (n *NetworkProvider) GetProxy(ctx context.Context, shopID int64) (*net.ProxyEndpoint, error) {
 	// 无 ID 随机生成 proxy, 可用于连通性测试等场景
 	if shopID == 0 {
 		randomProxy, err := n.ProxyService.PickRandomProxy(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("no random proxy available: %v", err)
 		}
-		proxyURL, err := randomProxy.ProxyToURL()
-		return proxyURL, err
+		return proxyEndpoint(randomProxy, proxyCheckSession)
 	}
 	// 1. 查库获取 Shop 信息
 	shop, err := n.ShopRepo.GetByID(ctx, shopID)
@@ -40,24 +40,39 @@ func (n *NetworkProvider) GetProxy(ctx context.Context, shopID int64) (*url.URL,
 	}
 
 	// 2. 惰性绑定逻辑 (Lazy Binding)
-	// 如果当前没有代理，或者代理字段为空，调用 ProxyService 分配
+	// 如果当前没有代理，或者代理字段为空，调用 ProxyService 分配（校验容量与亲和规则并记录绑定历史）
 	if shop.ProxyID == 0 || shop.Proxy == nil {
-		// 调用 ProxyService 的 PickBestProxy
-		newProxy, err := n.ProxyService.PickBestProxy(ctx, shop.Region)
-		if err != nil {
+		if _, err := n.ProxyService.BindBestProxy(ctx, shop, model.ProxyBindReasonLazy); err != nil {
 			return nil, fmt.Errorf("no proxy available: %v", err)
 		}
+	}
 
-		// 绑定并更新
-		err = n.ShopRepo.UpdateFields(ctx, shop.ID, map[string]interface{}{"proxy_id": newProxy.ID})
+	// 3. 网关代理取当前绑定的会话，保证同一店铺始终走同一出口
+	session := ""
+	if shop.Proxy.IsGateway() {
+		binding, err := n.ProxyService.ProxyRepo.FindActiveBinding(ctx, shop.ID)
 		if err != nil {
 			return nil, err
 		}
-		shop.Proxy = newProxy
+		if binding != nil && binding.Session != "" {
+			session = binding.Session
+		} else {
+			// 历史绑定没有会话，按店铺 ID 兜底，同样保持粘性
+			session = fmt.Sprintf("shop%d", shop.ID)
+		}
 	}
 
-	// 3. 返回标准 URL 对象给 Dispatcher
-	return shop.Proxy.ProxyToURL()
+	// 4. 返回拨号配置给 Dispatcher
+	return proxyEndpoint(shop.Proxy, session)
+}
+
+// proxyEndpoint 代理转换为 Dispatcher 拨号配置
+func proxyEndpoint(proxy *model.Proxy, session string) (*net.ProxyEndpoint, error) {
+	proxyURL, err := proxy.SessionURL(session)
+	if err != nil {
+		return nil, err
+	}
+	return &net.ProxyEndpoint{URL: proxyURL, InsecureSkipVerify: proxy.TLSInsecure, ProxyID: proxy.ID}, nil
 }
 
 // ReportError 实现接口：处理故障
@@ -77,7 +92,7 @@ func (n *NetworkProvider) ReportError(ctx context.Context, shopID int64) {
 	badProxy := shop.Proxy
 	log.Printf("[Network] 收到故障上报 ShopID=%d, Proxy=%s, 正在解绑...", shopID, badProxy.IP)
 	// 2. 解绑店铺 (业务动作)
-	if err = n.ShopRepo.UpdateFields(ctx, shopID, map[string]interface{}{"proxy_id": 0}); err != nil {
+	if err = n.ProxyService.UnbindShop(ctx, shopID, model.ProxyBindReasonProxyError); err != nil {
 		log.Printf("[Network] 解绑失败: %v", err)
 	}
 
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+)
+
+// bindRetries 并发抢占同一代理时重新挑选的次数
+const bindRetries = 3
+
+// SetMaxShopsPerSharedProxy 设置共享代理最多绑定的店铺数，非正数保持默认
+func (s *ProxyService) SetMaxShopsPerSharedProxy(n int) {
+	if n > 0 {
+		s.maxSharedShops = n
+	}
+}
+
+// BindBestProxy 为店铺挑选并绑定最佳代理
+// 规则：独享代理只服务一个店铺；共享代理不超过上限；其他店铺用过的代理不再分配。
+// 挑选与绑定之间代理可能被其他请求占用，此时重新挑选
+func (s *ProxyService) BindBestProxy(ctx context.Context, shop *model.Shop, reason string) (*model.Proxy, error) {
+	var lastErr error
+	for i := 0; i < bindRetries; i++ {
+		proxy, err := s.PickBestProxy(ctx, shop.Region, shop.ID)
+		if err != nil {
+			return nil, err
+		}
+		binding := &model.ProxyBinding{
+			ShopID:     shop.ID,
+			ProxyID:    proxy.ID,
+			BindReason: reason,
+		}
+		if proxy.IsGateway() {
+			// 每次绑定生成新会话：同一店铺粘在一个出口上，换绑后换出口
+			binding.Session = newGatewaySession()
+		}
+		err = s.ProxyRepo.BindShop(ctx, binding, s.maxSharedShops)
+		if err == nil {
+			shop.ProxyID = proxy.ID
+			shop.Proxy = proxy
+			return proxy, nil
+		}
+		if !errors.Is(err, repository.ErrProxyCapacityFull) && !errors.Is(err, repository.ErrProxyUsedByOtherShop) {
+			return nil, err
+		}
+		log.Printf("[ProxyBinding] 代理 %d 已被占用，为店铺 %d 重新挑选: %v", proxy.ID, shop.ID, err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// newGatewaySession 随机会话 ID，写入网关用户名模板的 {session}
+func newGatewaySession() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// UnbindShop 解绑店铺当前代理并记录原因
+func (s *ProxyService) UnbindShop(ctx context.Context, shopID int64, reason string) error {
+	return s.ProxyRepo.UnbindShop(ctx, shopID, reason)
+}
+
+// GetShopBindings 店铺的代理绑定历史（最新在前）
+func (s *ProxyService) GetShopBindings(ctx context.Context, shopID int64) ([]dto.ProxyBindingResp, error) {
+	list, err := s.ProxyRepo.ListBindings(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.ProxyBindingResp, 0, len(list))
+	for _, b := range list {
+		item := dto.ProxyBindingResp{
+			ID:           b.ID,
+			ProxyID:      b.ProxyID,
+			BoundAt:      b.BoundAt,
+			BindReason:   b.BindReason,
+			UnboundAt:    b.UnboundAt,
+			UnbindReason: b.UnbindReason,
+			Active:       b.UnboundAt == nil,
+			Session:      b.Session,
+		}
+		if b.Proxy != nil {
+			item.IP, item.Port, item.Region, item.ExitIP = b.Proxy.IP, b.Proxy.Port, b.Proxy.Region, b.Proxy.ExitIP
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	gonet "net"
+	"net/http"
+	"strings"
+	"time"
+
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/pkg/geoip"
+)
+
+// DefaultProxyIPEchoURL 出口 IP 回显接口，返回纯文本 IP 或 {"ip": "..."}
+const DefaultProxyIPEchoURL = "https://api.ipify.org"
+
+// ProxyGeoLocator 按 IP 查询国家与 ASN（geoip.Locator 实现）
+type ProxyGeoLocator interface {
+	Locate(ip gonet.IP) (geoip.Location, error)
+}
+
+// regionAlpha3 Region 使用三位国家码时对应的 ISO alpha-2（如店铺默认地区 IDN）
+var regionAlpha3 = map[string]string{
+	"USA": "US", "IDN": "ID", "GBR": "GB", "DEU": "DE", "FRA": "FR", "CAN": "CA",
+	"AUS": "AU", "JPN": "JP", "CHN": "CN", "HKG": "HK", "SGP": "SG", "MYS": "MY",
+	"THA": "TH", "VNM": "VN", "PHL": "PH", "IND": "IN", "NLD": "NL", "ITA": "IT",
+	"ESP": "ES", "BRA": "BR", "MEX": "MX", "KOR": "KR", "TWN": "TW",
+}
+
+// regionCountry 代理 Region 对应的 ISO alpha-2 国家码，无法识别（如 "EU"）时返回空，不参与比对
+func regionCountry(region string) string {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	switch len(region) {
+	case 2:
+		if region == "UK" {
+			return "GB"
+		}
+		if region == "EU" {
+			return ""
+		}
+		return region
+	case 3:
+		return regionAlpha3[region]
+	}
+	return ""
+}
+
+// SetIPEchoURL 设置出口 IP 回显接口，空值关闭出口检测
+func (s *ProxyService) SetIPEchoURL(echoURL string) {
+	s.ipEchoURL = echoURL
+}
+
+// SetGeoLocator 设置本地 GeoIP 库，未设置时只记录出口 IP
+func (s *ProxyService) SetGeoLocator(locator ProxyGeoLocator) {
+	s.geo = locator
+}
+
+// probeExit 经代理访问回显接口获取出口 IP，并查询国家与 ASN
+func (s *ProxyService) probeExit(client *http.Client, result *ProxyCheckResult) {
+	if s.ipEchoURL == "" {
+		return
+	}
+	resp, err := client.Get(s.ipEchoURL)
+	if err != nil {
+		log.Printf("[ProxyGeo] 获取出口 IP 失败: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+	ip := parseEchoIP(body)
+	if ip == nil {
+		return
+	}
+	result.ExitIP = ip.String()
+
+	if s.geo == nil {
+		return
+	}
+	loc, err := s.geo.Locate(ip)
+	if err != nil {
+		log.Printf("[ProxyGeo] 查询出口 IP %s 失败: %v", result.ExitIP, err)
+		return
+	}
+	result.Exit = &loc
+}
+
+// parseEchoIP 兼容纯文本与 JSON 两种回显格式
+func parseEchoIP(body []byte) gonet.IP {
+	text := strings.TrimSpace(string(body))
+	if strings.HasPrefix(text, "{") {
+		var payload struct {
+			IP     string `json:"ip"`
+			Origin string `json:"origin"` // httpbin 格式
+		}
+		if json.Unmarshal(body, &payload) != nil {
+			return nil
+		}
+		text = payload.IP
+		if text == "" {
+			text, _, _ = strings.Cut(payload.Origin, ",")
+		}
+	}
+	return gonet.ParseIP(strings.TrimSpace(text))
+}
+
+// applyExitGeo 把检测到的出口信息写到代理上，返回需更新的字段；未拿到出口 IP 时保留上次结果
+func applyExitGeo(proxy *model.Proxy, result ProxyCheckResult, now time.Time) map[string]interface{} {
+	if result.ExitIP == "" {
+		return nil
+	}
+	proxy.ExitIP = result.ExitIP
+	if result.Exit != nil {
+		proxy.ExitCountry = result.Exit.Country
+		proxy.ExitASN = int64(result.Exit.ASN)
+		proxy.ExitASOrg = result.Exit.ASOrg
+	}
+	expected := regionCountry(proxy.Region)
+	proxy.GeoMismatch = proxy.ExitCountry != "" && expected != "" && proxy.ExitCountry != expected
+	proxy.GeoCheckedAt = &now
+
+	return map[string]interface{}{
+		"exit_ip":        proxy.ExitIP,
+		"exit_country":   proxy.ExitCountry,
+		"exit_asn":       proxy.ExitASN,
+		"exit_as_org":    proxy.ExitASOrg,
+		"geo_mismatch":   proxy.GeoMismatch,
+		"geo_checked_at": now,
+	}
+}
+
+// recordExitGeo 巡检后落库出口信息，出口国家与 Region 不符时告警
+func (s *ProxyService) recordExitGeo(ctx context.Context, proxy *model.Proxy, result ProxyCheckResult) {
+	wasMismatch := proxy.GeoMismatch
+	fields := applyExitGeo(proxy, result, time.Now())
+	if fields == nil {
+		return
+	}
+	if err := s.ProxyRepo.UpdateFields(ctx, proxy.ID, fields); err != nil {
+		log.Printf("[ProxyGeo] 更新代理 %d 出口信息失败: %v", proxy.ID, err)
+		return
+	}
+	if proxy.GeoMismatch && !wasMismatch {
+		log.Printf("[ProxyGeo] 代理 %d (%s:%s) 标记地区 %s，实际出口 %s 位于 %s (AS%d %s)，存在关联风险",
+			proxy.ID, proxy.IP, proxy.Port, proxy.Region, proxy.ExitIP, proxy.ExitCountry, proxy.ExitASN, proxy.ExitASOrg)
+	}
+}
+
+// DetectSharedExitIPs 找出共用同一出口 IP 的代理
+// 这些代理下的店铺在 Etsy 看来来自同一网络，可能被关联；有店铺受影响时记录告警
+func (s *ProxyService) DetectSharedExitIPs(ctx context.Context) ([]dto.SharedExitGroup, error) {
+	proxies, err := s.ProxyRepo.FindSharedExitProxies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]dto.SharedExitGroup, 0)
+	for _, p := range proxies {
+		if len(groups) == 0 || groups[len(groups)-1].ExitIP != p.ExitIP {
+			groups = append(groups, dto.SharedExitGroup{
+				ExitIP:  p.ExitIP,
+				Country: p.ExitCountry,
+				ASN:     p.ExitASN,
+				Proxies: []dto.SharedExitProxy{},
+				Shops:   []dto.BoundShopItem{},
+			})
+		}
+		g := &groups[len(groups)-1]
+		g.Proxies = append(g.Proxies, dto.SharedExitProxy{ID: p.ID, IP: p.IP, Port: p.Port, Region: p.Region})
+		for _, shop := range p.Shops {
+			g.Shops = append(g.Shops, dto.BoundShopItem{
+				ShopID:     shop.ID,
+				ShopName:   shop.ShopName,
+				EtsyShopID: shop.EtsyShopID,
+				Status:     shop.TokenStatus,
+			})
+		}
+	}
+
+	for _, g := range groups {
+		if len(g.Shops) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(g.Shops))
+		for _, shop := range g.Shops {
+			names = append(names, shop.ShopName)
+		}
+		log.Printf("[ProxyGeo] 出口 IP %s 被 %d 个代理共用，店铺 [%s] 可能被 Etsy 关联",
+			g.ExitIP, len(g.Proxies), strings.Join(names, ", "))
+	}
+	return groups, nil
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+)
+
+// 批量导入单行结果
+const (
+	ProxyImportCreated     = "created"
+	ProxyImportDuplicate   = "duplicate"   // 库中或本批次已有相同 IP+Port
+	ProxyImportInvalid     = "invalid"     // 格式无法识别
+	ProxyImportUnreachable = "unreachable" // 连通性测试失败，未入库
+)
+
+// defaultImportConcurrency 导入时连通性测试的默认并发数
+const defaultImportConcurrency = 10
+
+// supportedProxyProtocols 与 dto.CreateProxyReq 的协议校验保持一致
+var supportedProxyProtocols = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// ParseProxyLine 解析供应商提供的单行代理
+// 支持 ip:port、ip:port:user:pass、user:pass@ip:port 与 scheme://user:pass@ip:port，未写协议时使用 defaultProtocol
+func ParseProxyLine(line, defaultProtocol string) (*model.Proxy, error) {
+	line = strings.TrimSpace(line)
+	if defaultProtocol == "" {
+		defaultProtocol = "http"
+	}
+
+	proxy := &model.Proxy{Protocol: defaultProtocol}
+	switch {
+	case strings.Contains(line, "://") || strings.Contains(line, "@"):
+		// 网关用户名模板中的 {session} / {region} 不是合法的 userinfo 字符，先转义
+		raw := strings.NewReplacer("{", "%7B", "}", "%7D").Replace(line)
+		if !strings.Contains(raw, "://") {
+			raw = defaultProtocol + "://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析代理地址: %v", err)
+		}
+		proxy.Protocol = strings.ToLower(u.Scheme)
+		proxy.IP = u.Hostname()
+		proxy.Port = u.Port()
+		if u.User != nil {
+			proxy.Username = u.User.Username()
+			proxy.Password, _ = u.User.Password()
+		}
+	default:
+		// 密码中可能含冒号，最多切 4 段
+		parts := strings.SplitN(line, ":", 4)
+		switch len(parts) {
+		case 2:
+			proxy.IP, proxy.Port = parts[0], parts[1]
+		case 4:
+			proxy.IP, proxy.Port, proxy.Username, proxy.Password = parts[0], parts[1], parts[2], parts[3]
+		default:
+			return nil, errors.New("无法识别的格式，应为 ip:port、ip:port:user:pass、user:pass@ip:port 或 scheme://user:pass@ip:port")
+		}
+	}
+
+	if !supportedProxyProtocols[proxy.Protocol] {
+		return nil, fmt.Errorf("不支持的协议: %s", proxy.Protocol)
+	}
+	if proxy.IP == "" || strings.ContainsAny(proxy.IP, " \t/") {
+		return nil, fmt.Errorf("主机地址无效: %q", proxy.IP)
+	}
+	if port, err := strconv.Atoi(proxy.Port); err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("端口无效: %q", proxy.Port)
+	}
+	return proxy, nil
+}
+
+// ImportProxies 批量导入代理
+// 逐行解析 -> 按 IP+Port 去重（库中与本批次）-> 可选并发连通性测试 -> 入库，返回逐行结果
+func (s *ProxyService) ImportProxies(ctx context.Context, req dto.ImportProxiesReq, operatorID int64) (*dto.ImportProxiesResp, error) {
+	region := strings.ToUpper(strings.TrimSpace(req.Region))
+	if region == "" {
+		return nil, errors.New("region 不能为空")
+	}
+	capacity := model.PROXY_SHARED
+	if req.Capacity != nil {
+		capacity = *req.Capacity
+	}
+	proxyType := req.Type
+	if proxyType == "" {
+		proxyType = model.ProxyTypeStatic
+	}
+
+	resp := &dto.ImportProxiesResp{Results: []dto.ImportProxyLineResult{}}
+	var pending []int // 待入库的结果下标
+	proxies := make(map[int]*model.Proxy)
+	seen := make(map[string]bool)
+
+	lines := strings.Split(strings.ReplaceAll(req.Content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		result := dto.ImportProxyLineResult{Line: i + 1}
+		proxy, err := ParseProxyLine(line, req.Protocol)
+		if err != nil {
+			result.Status, result.Error = ProxyImportInvalid, err.Error()
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+		result.IP, result.Port, result.Protocol = proxy.IP, proxy.Port, proxy.Protocol
+		proxy.Type, proxy.TLSInsecure, proxy.Region = proxyType, req.TLSInsecure, region
+		if err := validateProxyType(proxy); err != nil {
+			result.Status, result.Error = ProxyImportInvalid, err.Error()
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+
+		key := proxyDedupeKey(proxy)
+		existing, err := s.ProxyRepo.FindDuplicate(ctx, proxy)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case existing != nil:
+			result.Status, result.ProxyID, result.Error = ProxyImportDuplicate, existing.ID, "代理已存在"
+		case seen[key]:
+			result.Status, result.Error = ProxyImportDuplicate, "本批次重复"
+		default:
+			seen[key] = true
+			proxies[len(resp.Results)] = proxy
+			pending = append(pending, len(resp.Results))
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	var checks map[int]ProxyCheckResult
+	if req.Check {
+		checks = s.checkImportConnectivity(proxies, req.Concurrency)
+	}
+
+	now := time.Now()
+	for _, idx := range pending {
+		result := &resp.Results[idx]
+		if req.Check && !checks[idx].Alive {
+			result.Status, result.Error = ProxyImportUnreachable, "连通性测试失败"
+			continue
+		}
+
+		proxy := proxies[idx]
+		proxy.Capacity = capacity
+		proxy.Status = model.PROXY_STATUS_ACTIVE
+		proxy.IsActive = true
+		proxy.CreatedBy = operatorID
+		proxy.UpdatedBy = operatorID
+		if req.Check {
+			proxy.LastCheckTime = now
+			applyExitGeo(proxy, checks[idx], now)
+		}
+		if err := s.createImported(ctx, proxy, capacity); err != nil {
+			result.Status, result.Error = ProxyImportInvalid, fmt.Sprintf("入库失败: %v", err)
+			continue
+		}
+		result.Status, result.ProxyID = ProxyImportCreated, proxy.ID
+	}
+
+	for _, result := range resp.Results {
+		switch result.Status {
+		case ProxyImportCreated:
+			resp.Created++
+		case ProxyImportDuplicate:
+			resp.Duplicate++
+		case ProxyImportInvalid:
+			resp.Invalid++
+		case ProxyImportUnreachable:
+			resp.Unreachable++
+		}
+	}
+	resp.Total = len(resp.Results)
+	log.Printf("[ProxyImport] 地区 %s 导入 %d 行: 新增 %d, 重复 %d, 无效 %d, 不可达 %d",
+		region, resp.Total, resp.Created, resp.Duplicate, resp.Invalid, resp.Unreachable)
+	return resp, nil
+}
+
+// createImported 入库；容量字段带数据库默认值，独享（0）需入库后回写
+func (s *ProxyService) createImported(ctx context.Context, proxy *model.Proxy, capacity int) error {
+	if err := s.ProxyRepo.Create(ctx, proxy); err != nil {
+		return err
+	}
+	s.metrics.forgetEndpoint(proxy.IP, proxy.Port)
+	if capacity == model.PROXY_PRIVATE {
+		proxy.Capacity = capacity
+		return s.ProxyRepo.Update(ctx, proxy)
+	}
+	return nil
+}
+
+// checkImportConnectivity 并发测试连通性（含出口检测），返回各结果下标的测试结果
+func (s *ProxyService) checkImportConnectivity(proxies map[int]*model.Proxy, concurrency int) map[int]ProxyCheckResult {
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		checks = make(map[int]ProxyCheckResult, len(proxies))
+		sem    = make(chan struct{}, concurrency)
+	)
+	for idx, proxy := range proxies {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, proxy *model.Proxy) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := s.CheckConnectivity(proxy)
+			mu.Lock()
+			checks[idx] = result
+			mu.Unlock()
+		}(idx, proxy)
+	}
+	wg.Wait()
+	return checks
+}
+
+// proxyDedupeKey 批次内查重键，与 ProxyRepository.FindDuplicate 一致
+func proxyDedupeKey(proxy *model.Proxy) string {
+	if proxy.IsGateway() {
+		return model.ProxyTypeGateway + "|" + proxy.IP + ":" + proxy.Port + "|" + proxy.Username
+	}
+	return proxy.IP + ":" + proxy.Port
+}
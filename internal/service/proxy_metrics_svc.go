@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/pkg/net"
+)
+
+const (
+	// proxyMetricsWindow 评分只统计最近一段时间的样本
+	proxyMetricsWindow = time.Hour
+	// proxyMetricsMaxSamples 单个代理最多保留的样本数
+	proxyMetricsMaxSamples = 200
+	// proxyScoreRetention 评分历史保留时长
+	proxyScoreRetention = 30 * 24 * time.Hour
+	// proxyEndpointMissTTL 地址反查未命中的缓存时长，避免未入库代理的每个请求都查库
+	proxyEndpointMissTTL = time.Minute
+)
+
+var _ net.ProxyMetricsReporter = (*ProxyService)(nil)
+
+// proxySample 单次请求 / 巡检结果
+type proxySample struct {
+	at      time.Time
+	latency time.Duration
+	ok      bool
+	status  int
+}
+
+// proxyMetrics 进程内各代理的滚动样本
+// 多实例部署时各实例按自己的样本计算，巡检时覆盖写入，评分反映最近一次巡检实例的视角
+type proxyMetrics struct {
+	mu      sync.Mutex
+	samples map[int64][]proxySample // proxyID -> 样本（按时间递增）
+
+	endpoints sync.Map // "ip:port" -> endpointEntry
+}
+
+// endpointEntry 地址反查结果，proxyID 为 0 表示未入库，missUntil 后重新查询
+type endpointEntry struct {
+	proxyID   int64
+	missUntil time.Time
+}
+
+// forgetEndpoint 代理地址变更或删除时清除反查缓存
+func (m *proxyMetrics) forgetEndpoint(ip, port string) {
+	m.endpoints.Delete(ip + ":" + port)
+}
+
+func newProxyMetrics() *proxyMetrics {
+	return &proxyMetrics{samples: make(map[int64][]proxySample)}
+}
+
+func (m *proxyMetrics) record(proxyID int64, latency time.Duration, ok bool, status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := append(m.samples[proxyID], proxySample{at: time.Now(), latency: latency, ok: ok, status: status})
+	if len(list) > proxyMetricsMaxSamples {
+		list = list[len(list)-proxyMetricsMaxSamples:]
+	}
+	m.samples[proxyID] = list
+}
+
+// snapshot 裁掉过期样本后返回各代理的样本副本
+func (m *proxyMetrics) snapshot(now time.Time) map[int64][]proxySample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[int64][]proxySample, len(m.samples))
+	for id, list := range m.samples {
+		i := sort.Search(len(list), func(i int) bool { return now.Sub(list[i].at) <= proxyMetricsWindow })
+		list = list[i:]
+		if len(list) == 0 {
+			delete(m.samples, id)
+			continue
+		}
+		m.samples[id] = list
+		result[id] = append([]proxySample(nil), list...)
+	}
+	return result
+}
+
+// ProxyStats 代理质量统计
+type ProxyStats struct {
+	SampleCount  int
+	SuccessRate  float64
+	LatencyP50Ms int
+	LatencyP95Ms int
+	Recent403    int
+	Recent429    int
+	Score        float64
+}
+
+// computeProxyStats 计算成功率、延迟分位数与评分
+// 评分 = 100 × 成功率 − 延迟扣分（P95 每 100ms 扣 1 分，最多 30）− 403 扣分（每次 5 分，最多 30）− 429 扣分（每次 2 分，最多 20）
+func computeProxyStats(samples []proxySample) ProxyStats {
+	stats := ProxyStats{SampleCount: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	var latencies []time.Duration
+	success := 0
+	for _, sample := range samples {
+		switch sample.status {
+		case http.StatusForbidden:
+			stats.Recent403++
+		case http.StatusTooManyRequests:
+			stats.Recent429++
+		}
+		if sample.ok {
+			success++
+			latencies = append(latencies, sample.latency)
+		}
+	}
+	stats.SuccessRate = float64(success) / float64(len(samples))
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	stats.LatencyP50Ms = int(percentile(latencies, 0.50).Milliseconds())
+	stats.LatencyP95Ms = int(percentile(latencies, 0.95).Milliseconds())
+
+	score := 100 * stats.SuccessRate
+	score -= math.Min(30, float64(stats.LatencyP95Ms)/100)
+	score -= math.Min(30, float64(stats.Recent403*5))
+	score -= math.Min(20, float64(stats.Recent429*2))
+	stats.Score = math.Round(math.Max(0, score)*10) / 10
+	return stats
+}
+
+// percentile 已排序延迟的分位数
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
+
+// ReportProxySample 记录 Dispatcher 经代理发出的请求结果（实现 net.ProxyMetricsReporter）
+// 网络错误、5xx 与 407 视为代理失败；Etsy 403 / 429 单独计数并扣分
+// 优先按拨号配置携带的代理 ID 归属，未携带时才按地址反查（共用入口的网关无法区分）
+func (s *ProxyService) ReportProxySample(ctx context.Context, sample net.ProxySample) {
+	proxyID := sample.ProxyID
+	if proxyID == 0 && sample.ProxyURL != nil {
+		proxyID = s.proxyIDByEndpoint(ctx, sample.ProxyURL.Hostname(), sample.ProxyURL.Port())
+	}
+	if proxyID == 0 {
+		return
+	}
+	ok := sample.Err == nil && sample.StatusCode > 0 &&
+		sample.StatusCode < http.StatusInternalServerError && sample.StatusCode != http.StatusProxyAuthRequired
+	s.metrics.record(proxyID, sample.Latency, ok, sample.StatusCode)
+}
+
+// proxyIDByEndpoint 按 IP+Port 查代理 ID（缓存），未入库返回 0
+// 未命中的结果缓存 proxyEndpointMissTTL；查询出错不缓存
+func (s *ProxyService) proxyIDByEndpoint(ctx context.Context, ip, port string) int64 {
+	key := ip + ":" + port
+	if v, ok := s.metrics.endpoints.Load(key); ok {
+		entry := v.(endpointEntry)
+		if entry.proxyID > 0 || time.Now().Before(entry.missUntil) {
+			return entry.proxyID
+		}
+	}
+	proxy, err := s.ProxyRepo.FindByEndpoint(ctx, ip, port)
+	if err != nil {
+		return 0
+	}
+	if proxy == nil {
+		s.metrics.endpoints.Store(key, endpointEntry{missUntil: time.Now().Add(proxyEndpointMissTTL)})
+		return 0
+	}
+	s.metrics.endpoints.Store(key, endpointEntry{proxyID: proxy.ID})
+	return proxy.ID
+}
+
+// LiveStats 代理当前窗口内的实时统计（未落库）
+func (s *ProxyService) LiveStats(proxyID int64) (ProxyStats, bool) {
+	samples, ok := s.metrics.snapshot(time.Now())[proxyID]
+	if !ok {
+		return ProxyStats{}, false
+	}
+	return computeProxyStats(samples), true
+}
+
+// FlushMetrics 计算窗口内有样本的代理评分，写入代理表并记录评分历史（巡检结束时调用）
+func (s *ProxyService) FlushMetrics(ctx context.Context) error {
+	now := time.Now()
+	snapshots := make([]model.ProxyScoreSnapshot, 0)
+	for proxyID, samples := range s.metrics.snapshot(now) {
+		stats := computeProxyStats(samples)
+		err := s.ProxyRepo.UpdateFields(ctx, proxyID, map[string]interface{}{
+			"score":            stats.Score,
+			"success_rate":     stats.SuccessRate,
+			"latency_p50_ms":   stats.LatencyP50Ms,
+			"latency_p95_ms":   stats.LatencyP95Ms,
+			"recent403":        stats.Recent403,
+			"recent429":        stats.Recent429,
+			"sample_count":     stats.SampleCount,
+			"score_updated_at": now,
+		})
+		if err != nil {
+			log.Printf("[ProxyMetrics] 更新代理 %d 评分失败: %v", proxyID, err)
+			continue
+		}
+		snapshots = append(snapshots, model.ProxyScoreSnapshot{
+			ProxyID:      proxyID,
+			Score:        stats.Score,
+			SuccessRate:  stats.SuccessRate,
+			LatencyP50Ms: stats.LatencyP50Ms,
+			LatencyP95Ms: stats.LatencyP95Ms,
+			Recent403:    stats.Recent403,
+			Recent429:    stats.Recent429,
+			SampleCount:  stats.SampleCount,
+		})
+	}
+
+	if err := s.ProxyRepo.CreateScoreSnapshots(ctx, snapshots); err != nil {
+		return err
+	}
+	if _, err := s.ProxyRepo.DeleteScoreSnapshotsBefore(ctx, now.Add(-proxyScoreRetention)); err != nil {
+		log.Printf("[ProxyMetrics] 清理评分历史失败: %v", err)
+	}
+	return nil
+}
+
+// GetScoreHistory 代理评分历史
+func (s *ProxyService) GetScoreHistory(ctx context.Context, proxyID int64, since time.Time) ([]model.ProxyScoreSnapshot, error) {
+	return s.ProxyRepo.ListScoreSnapshots(ctx, proxyID, since)
+}
@@ -6,10 +6,11 @@ import (
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
-	"fmt"
+	"etsy_dev_v1_202512/pkg/geoip"
+	"etsy_dev_v1_202512/pkg/net"
 	"log"
 	"net/http"
-	"net/url"
+	"strings"
 	"time"
 )
 
@@ -17,14 +18,40 @@ type ProxyService struct {
 	ProxyRepo repository.ProxyRepository
 	ShopRepo  repository.ShopRepository
 
-	maxFailCount int // 最大失败次数，超过判定 IP死亡
+	maxFailCount int    // 最大失败次数，超过判定 IP死亡
+	checkURL     string // 连通性测试访问的地址
+
+	// 质量评分滚动窗口（巡检 + Dispatcher 请求）
+	metrics *proxyMetrics
+
+	// 出口 IP 检测与本地 GeoIP 库
+	ipEchoURL string
+	geo       ProxyGeoLocator
+
+	// 共享代理最多绑定的店铺数
+	maxSharedShops int
 }
 
+// DefaultProxyCheckURL 连通性测试默认访问 Etsy 静态资源
+const DefaultProxyCheckURL = "https://www.etsy.com/robots.txt"
+
 func NewProxyService(proxyRepo repository.ProxyRepository, shopRepo repository.ShopRepository) *ProxyService {
-	return &ProxyService{
+	s := &ProxyService{
 		ProxyRepo:    proxyRepo,
 		ShopRepo:     shopRepo,
 		maxFailCount: 10,
+		checkURL:     DefaultProxyCheckURL,
+		metrics:      newProxyMetrics(),
+		ipEchoURL:    DefaultProxyIPEchoURL,
+	}
+	s.maxSharedShops = repository.DefaultMaxShopsPerSharedProxy
+	return s
+}
+
+// SetCheckURL 替换连通性测试地址，空值保持默认
+func (s *ProxyService) SetCheckURL(checkURL string) {
+	if checkURL != "" {
+		s.checkURL = checkURL
 	}
 }
 
@@ -32,16 +59,7 @@ func NewProxyService(proxyRepo repository.ProxyRepository, shopRepo repository.S
 
 // CreateProxy 创建代理
 func (s *ProxyService) CreateProxy(ctx context.Context, req dto.CreateProxyReq, operatorID int64) error {
-	// 1. 查重逻辑：防止 IP+Port 重复
-	existProxy, err := s.ProxyRepo.FindByEndpoint(ctx, req.IP, req.Port)
-	if err != nil {
-		return err
-	}
-	if existProxy != nil {
-		return errors.New("proxy already exists (IP:Port conflict)")
-	}
-
-	// 2. DTO -> Model 转换
+	// 1. DTO -> Model 转换
 	proxy := &model.Proxy{
 		IP:       req.IP,
 		Port:     req.Port,
@@ -52,13 +70,33 @@ func (s *ProxyService) CreateProxy(ctx context.Context, req dto.CreateProxyReq,
 		Status:   1,    // 默认正常
 		IsActive: true, // 默认启用
 	}
+	proxy.Type, proxy.TLSInsecure = req.Type, req.TLSInsecure
+	if proxy.Type == "" {
+		proxy.Type = model.ProxyTypeStatic
+	}
+	if err := validateProxyType(proxy); err != nil {
+		return err
+	}
+
+	// 2. 查重逻辑：固定出口防止 IP+Port 重复，网关同一入口按账号区分
+	existProxy, err := s.ProxyRepo.FindDuplicate(ctx, proxy)
+	if err != nil {
+		return err
+	}
+	if existProxy != nil {
+		return errors.New("proxy already exists (IP:Port conflict)")
+	}
 
 	// 3. 审计字段填充
 	proxy.CreatedBy = operatorID
 	proxy.UpdatedBy = operatorID
 
 	// 4. 落库
-	return s.ProxyRepo.Create(ctx, proxy)
+	if err := s.ProxyRepo.Create(ctx, proxy); err != nil {
+		return err
+	}
+	s.metrics.forgetEndpoint(proxy.IP, proxy.Port)
+	return nil
 }
 
 // UpdateProxy 更新代理
@@ -72,6 +110,8 @@ func (s *ProxyService) UpdateProxy(ctx context.Context, req dto.UpdateProxyReq,
 		return errors.New("proxy not found")
 	}
 
+	oldIP, oldPort := proxy.IP, proxy.Port
+
 	// 2. 更新字段 (只更新允许修改的)
 	if req.IP != "" {
 		proxy.IP = req.IP
@@ -91,11 +131,53 @@ func (s *ProxyService) UpdateProxy(ctx context.Context, req dto.UpdateProxyReq,
 	if req.Status > 0 {
 		proxy.Status = req.Status
 	}
+	if req.Type != "" {
+		proxy.Type = req.Type
+	}
+	if req.TLSInsecure != nil {
+		proxy.TLSInsecure = *req.TLSInsecure
+	}
+	if err := validateProxyType(proxy); err != nil {
+		return err
+	}
 
 	// 3. 更新审计
 	proxy.UpdatedBy = operatorID
 
-	return s.ProxyRepo.Update(ctx, proxy)
+	if err := s.ProxyRepo.Update(ctx, proxy); err != nil {
+		return err
+	}
+	// 地址变更后旧地址不再属于该代理，新地址可能有未命中缓存
+	s.metrics.forgetEndpoint(oldIP, oldPort)
+	s.metrics.forgetEndpoint(proxy.IP, proxy.Port)
+	return nil
+}
+
+// ErrProxyHasShops 代理仍绑定店铺，删除前需先迁移
+var ErrProxyHasShops = errors.New("代理仍绑定店铺，请先迁移")
+
+// DeleteProxy 删除代理（软删除），仍绑定店铺时拒绝
+func (s *ProxyService) DeleteProxy(ctx context.Context, id int64) error {
+	proxy, err := s.ProxyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if proxy.ID == 0 {
+		return errors.New("proxy not found")
+	}
+	shops, err := s.ShopRepo.GetByProxyID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(shops) > 0 {
+		return ErrProxyHasShops
+	}
+
+	if err := s.ProxyRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.metrics.forgetEndpoint(proxy.IP, proxy.Port)
+	return nil
 }
 
 // 2. 读取逻辑 (List / Get) - 重点在于 DTO 组装
@@ -150,6 +232,28 @@ func (s *ProxyService) convertToResp(p *model.Proxy, withDetails bool) dto.Proxy
 		IsActive:      p.IsActive,
 		CreatedAt:     p.CreatedAt.Unix(), // 转时间戳
 
+		Type:        p.Type,
+		TLSInsecure: p.TLSInsecure,
+
+		Quality: dto.ProxyQualityResp{
+			Score:          p.Score,
+			SuccessRate:    p.SuccessRate,
+			LatencyP50Ms:   p.LatencyP50Ms,
+			LatencyP95Ms:   p.LatencyP95Ms,
+			Recent403:      p.Recent403,
+			Recent429:      p.Recent429,
+			SampleCount:    p.SampleCount,
+			ScoreUpdatedAt: p.ScoreUpdatedAt,
+		},
+		Exit: dto.ProxyExitResp{
+			ExitIP:      p.ExitIP,
+			Country:     p.ExitCountry,
+			ASN:         p.ExitASN,
+			ASOrg:       p.ExitASOrg,
+			GeoMismatch: p.GeoMismatch,
+			CheckedAt:   p.GeoCheckedAt,
+		},
+
 		CreatedBy: p.CreatedBy,
 		// TODO: 如果需要 CreatedByName，这里需要调用 UserService 查名字，或者在 Repo 层 Join 查出来
 
@@ -180,10 +284,11 @@ func (s *ProxyService) convertToResp(p *model.Proxy, withDetails bool) dto.Proxy
 // 作用：对指定 Proxy 进行一次体检。如果发现死亡，立即触发迁移。
 // 场景：Cron 巡检循环调用它；Dispatcher 发现请求失败时单点调用它
 func (s *ProxyService) VerifyAndHeal(ctx context.Context, proxy *model.Proxy) error {
-	// A. 探测连通性
-	isAlive := s.TestConnectivity(proxy)
+	// A. 探测连通性（同时获取出口 IP 与地理位置）
+	result := s.CheckConnectivity(proxy)
 	var err error
-	if isAlive {
+	if result.Alive {
+		s.recordExitGeo(ctx, proxy, result)
 		if proxy.FailureCount > 0 || proxy.Status != model.PROXY_STATUS_ACTIVE {
 			proxy.FailureCount = 0
 			proxy.Status = model.PROXY_STATUS_ACTIVE
@@ -215,13 +320,17 @@ func (s *ProxyService) VerifyAndHeal(ctx context.Context, proxy *model.Proxy) er
 	}
 
 	_ = s.ProxyRepo.UpdateLastCheckTime(ctx, proxy.ID)
+	// 代理确认故障：此前因请求失败解绑的记录开始计入亲和规则
+	if err = s.ProxyRepo.ConfirmProxyErrorBindings(ctx, proxy.ID); err != nil {
+		log.Printf("[ProxyMonitor] Failed to confirm proxy_error bindings: %v\n", err)
+	}
 	// 触发迁移：只有当状态变为不可用时，才需要把店移走
 	// 如果它已经是 Status=2 且店都被移走了，这里其实查出来是空列表，不耗性能
 	return s.MigrateShops(ctx, proxy)
 }
 
 // MigrateShops 迁移绑定在故障代理上的所有店铺
-// 作用：将指定坏代理下的所有店铺，自动迁移到同地区的可用代理上
+// 作用：将指定坏代理下的所有店铺，自动迁移到同地区评分最高的可用代理上
 func (s *ProxyService) MigrateShops(ctx context.Context, deadProxy *model.Proxy) error {
 	// 1. 查找所有绑定在这个坏代理上的店铺
 	shops, err := s.ShopRepo.GetByProxyID(ctx, deadProxy.ID)
@@ -236,55 +345,93 @@ func (s *ProxyService) MigrateShops(ctx context.Context, deadProxy *model.Proxy)
 
 	log.Printf("Migrating %d shops from Proxy %d...", len(shops), deadProxy.ID)
 
-	// 2. 逐个迁移
+	// 2. 逐个迁移（绑定时校验容量与亲和规则，并记录绑定历史）
 	for _, shop := range shops {
-		bestProxy, err := s.ProxyRepo.FindSpareProxy(ctx, deadProxy.Region)
+		bestProxy, err := s.BindBestProxy(ctx, &shop, model.ProxyBindReasonMigrate)
 		if err != nil {
-			log.Printf("[CRITICAL] No spare proxy for Shop %d (Region %s)!", shop.ID, deadProxy.Region)
+			log.Printf("[CRITICAL] No spare proxy for Shop %d (Region %s)!", shop.ID, shop.Region)
 			continue
 		}
-
-		// 更新绑定
-		if err := s.ShopRepo.UpdateFields(ctx, shop.ID, map[string]interface{}{"proxy_id": bestProxy.ID}); err == nil {
-			log.Printf("Shop %d migrated to Proxy %d", shop.ID, bestProxy.ID)
-		}
+		log.Printf("Shop %d migrated to Proxy %d", shop.ID, bestProxy.ID)
 	}
 	return err
 }
 
+// ProxyCheckResult 一次连通性测试结果
+type ProxyCheckResult struct {
+	Alive      bool
+	Latency    time.Duration
+	StatusCode int // 网络错误时为 0
+	Err        error
+
+	// 出口 IP 与地理位置，未配置回显接口或获取失败时为空
+	ExitIP string
+	Exit   *geoip.Location
+}
+
+// proxyCheckSession 巡检网关代理时使用的固定会话，不占用店铺会话
+const proxyCheckSession = "check"
+
+// ErrGatewaySessionTemplate 网关代理的用户名缺少会话占位符，无法为店铺派生粘性会话
+var ErrGatewaySessionTemplate = errors.New("gateway proxy username must contain " + model.ProxySessionPlaceholder)
+
+// validateProxyType 网关代理必须带会话模板
+func validateProxyType(proxy *model.Proxy) error {
+	if proxy.IsGateway() && !strings.Contains(proxy.Username, model.ProxySessionPlaceholder) {
+		return ErrGatewaySessionTemplate
+	}
+	return nil
+}
+
 // TestConnectivity 真实的连通性测试
 // 作用：执行一次物理连接测试
 func (s *ProxyService) TestConnectivity(proxy *model.Proxy) bool {
-	proxyURL, err := url.Parse(fmt.Sprintf("%s://%s:%s", proxy.Protocol, proxy.IP, proxy.Port))
+	return s.CheckConnectivity(proxy).Alive
+}
+
+// CheckConnectivity 连通性测试并返回延迟与状态码，已入库的代理同时计入质量评分
+func (s *ProxyService) CheckConnectivity(proxy *model.Proxy) ProxyCheckResult {
+	proxyURL, err := proxy.SessionURL(proxyCheckSession)
 	if err != nil {
 		log.Printf("[ProxyMonitor] Failed to parse proxy URL: %v\n", err)
-		return false
+		return ProxyCheckResult{Err: err}
 	}
-	if proxy.Username != "" {
-		proxyURL.User = url.UserPassword(proxy.Username, proxy.Password)
+	// 与 Dispatcher 使用同一套拨号逻辑，SOCKS5 与证书校验策略保持一致
+	transport, err := net.NewProxyTransport(&net.ProxyEndpoint{URL: proxyURL, InsecureSkipVerify: proxy.TLSInsecure})
+	if err != nil {
+		log.Printf("[ProxyMonitor] Failed to build proxy transport: %v\n", err)
+		return ProxyCheckResult{Err: err}
 	}
+	defer transport.CloseIdleConnections()
 
 	client := &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		},
-		Timeout: 10 * time.Second, // 5秒超时
+		Transport: transport,
+		Timeout:   10 * time.Second, // 5秒超时
 	}
 
 	// 访问 Etsy 静态资源
-	resp, err := client.Get("https://www.etsy.com/robots.txt")
-	if err != nil {
-		return false
+	start := time.Now()
+	resp, err := client.Get(s.checkURL)
+	result := ProxyCheckResult{Latency: time.Since(start), Err: err}
+	if err == nil {
+		resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+		result.Alive = resp.StatusCode == 200
 	}
-	defer resp.Body.Close()
-
-	return resp.StatusCode == 200
+	if result.Alive {
+		s.probeExit(client, &result)
+	}
+	if proxy.ID > 0 {
+		s.metrics.record(proxy.ID, result.Latency, result.Alive, result.StatusCode)
+	}
+	return result
 }
 
 // PickBestProxy
-// 作用：为指定 Shop 挑选一个最佳（最空闲/同地区）的可用代理
-func (s *ProxyService) PickBestProxy(ctx context.Context, region string) (*model.Proxy, error) {
-	proxy, err := s.ProxyRepo.FindSpareProxy(ctx, region)
+// 作用：为指定 Shop 挑选一个最佳（同地区、有空余容量、未被其他店铺用过、评分最高）的可用代理
+// shopID 为 0 表示尚未建店；只挑选不绑定，绑定走 BindBestProxy
+func (s *ProxyService) PickBestProxy(ctx context.Context, region string, shopID int64) (*model.Proxy, error) {
+	proxy, err := s.ProxyRepo.FindSpareProxy(ctx, region, shopID, s.maxSharedShops)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/pkg/net"
+)
+
+const (
+	// defaultLowQuotaRatio 每日剩余配额低于该比例时推迟定时同步
+	defaultLowQuotaRatio = 0.1
+	// quotaSnapshotTTL 快照超过该时长视为过期（Etsy 每日配额为滚动 24 小时）
+	quotaSnapshotTTL = 24 * time.Hour
+)
+
+// QuotaService Etsy API 配额快照（实现 net.QuotaReporter）
+type QuotaService struct {
+	developerRepo repository.DeveloperRepository
+	lowRatio      float64
+}
+
+// NewQuotaService 创建配额服务
+func NewQuotaService(developerRepo repository.DeveloperRepository) *QuotaService {
+	return &QuotaService{
+		developerRepo: developerRepo,
+		lowRatio:      defaultLowQuotaRatio,
+	}
+}
+
+var _ net.QuotaReporter = (*QuotaService)(nil)
+
+// SetLowQuotaRatio 设置推迟同步的剩余配额比例
+func (s *QuotaService) SetLowQuotaRatio(ratio float64) {
+	s.lowRatio = ratio
+}
+
+// ReportQuota 持久化 Dispatcher 观测到的配额快照
+func (s *QuotaService) ReportQuota(ctx context.Context, snapshot net.QuotaSnapshot) {
+	fields := map[string]interface{}{
+		"quota_updated_at": snapshot.UpdatedAt,
+	}
+	if snapshot.LimitPerSecond > 0 {
+		fields["quota_per_second"] = snapshot.LimitPerSecond
+	}
+	if snapshot.LimitPerDay > 0 {
+		fields["quota_per_day"] = snapshot.LimitPerDay
+	}
+	if snapshot.RemainingToday >= 0 {
+		fields["quota_remaining_today"] = snapshot.RemainingToday
+	}
+
+	// 请求上下文可能已结束，使用独立超时
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.developerRepo.UpdateQuotaByApiKey(ctx, snapshot.APIKey, fields); err != nil {
+		log.Printf("[Quota] 保存配额快照失败: %v", err)
+	}
+	if snapshot.LowDaily(s.lowRatio) {
+		log.Printf("[Quota] API Key 每日配额不足: 剩余 %d / %d", snapshot.RemainingToday, snapshot.LimitPerDay)
+	}
+}
+
+// LowQuotaDeveloperIDs 每日剩余配额偏低的开发者（定时同步据此推迟）
+func (s *QuotaService) LowQuotaDeveloperIDs(ctx context.Context) (map[int64]bool, error) {
+	ids, err := s.developerRepo.ListLowQuotaIDs(ctx, s.lowRatio, time.Now().Add(-quotaSnapshotTTL))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
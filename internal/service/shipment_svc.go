@@ -7,6 +7,7 @@ import (
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"fmt"
+	"log"
 	"time"
 
 	"gorm.io/datatypes"
@@ -22,7 +23,14 @@ var _ interface {
 
 // EtsyShipmentSyncer Etsy 发货同步接口
 type EtsyShipmentSyncer interface {
-	CreateReceipt(ctx context.Context, shopID int64, receiptID int64, trackingCode, carrierName string) error
+	CreateReceiptShipment(ctx context.Context, shopID int64, receiptID int64, trackingCode, carrierCode string) (*EtsyShipmentResult, error)
+}
+
+// EtsyShipmentResult Etsy 发货同步结果
+type EtsyShipmentResult struct {
+	ReceiptShippingID int64  // Etsy receipt_shipping_id
+	EtsyStatus        string // 同步后的 Etsy 订单状态
+	RawResponse       []byte // Etsy 原始响应
 }
 
 // ==================== Service 实现 ====================
@@ -35,6 +43,7 @@ type ShipmentService struct {
 	shopRepo     repository.ShopRepository
 	karrio       *KarrioClient // 使用同包的 KarrioClient（定义于 karrio_svc.go）
 	etsySyncer   EtsyShipmentSyncer
+	notifier     ShopNotifier
 
 	// 物流商映射
 	carrierNames map[string]string
@@ -73,6 +82,11 @@ func NewShipmentService(
 	}
 }
 
+// SetNotifier 设置物流异常通知
+func (s *ShipmentService) SetNotifier(notifier ShopNotifier) {
+	s.notifier = notifier
+}
+
 // ==================== 发货管理 ====================
 
 // CreateShipment 创建发货记录
@@ -270,6 +284,7 @@ func (s *ShipmentService) RefreshTracking(ctx context.Context, shipmentID int64)
 	newStatus := s.mapKarrioStatus(tracker.Delivered)
 	if newStatus != shipment.Status {
 		s.shipmentRepo.UpdateStatus(ctx, shipmentID, newStatus)
+		s.notifyStatusChange(ctx, shipment, newStatus)
 	}
 
 	// 保存事件
@@ -297,6 +312,7 @@ func (s *ShipmentService) HandleWebhook(ctx context.Context, trackerID string, s
 	newStatus := s.mapKarrioStatus(status)
 	if newStatus != shipment.Status {
 		s.shipmentRepo.UpdateStatus(ctx, shipment.ID, newStatus)
+		s.notifyStatusChange(ctx, shipment, newStatus)
 	}
 
 	// 保存事件
@@ -312,6 +328,30 @@ func (s *ShipmentService) HandleWebhook(ctx context.Context, trackerID string, s
 	return nil
 }
 
+// notifyStatusChange 物流状态变为异常时通知店铺成员
+func (s *ShipmentService) notifyStatusChange(ctx context.Context, shipment *model.Shipment, newStatus string) {
+	if s.notifier == nil || newStatus != model.ShipmentStatusException {
+		return
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, shipment.OrderID)
+	if err != nil {
+		log.Printf("[Shipment] 发货 %d 异常通知：订单查询失败: %v", shipment.ID, err)
+		return
+	}
+
+	err = s.notifier.NotifyShop(ctx, order.ShopID, model.NotificationEventShipmentException, map[string]interface{}{
+		"shipment_id":     shipment.ID,
+		"order_id":        order.ID,
+		"etsy_receipt_id": order.EtsyReceiptID,
+		"carrier_code":    shipment.CarrierCode,
+		"tracking_number": shipment.TrackingNumber,
+	})
+	if err != nil {
+		log.Printf("[Shipment] 发货 %d 异常通知发送失败: %v", shipment.ID, err)
+	}
+}
+
 // saveTrackingEvents 保存跟踪事件
 func (s *ShipmentService) saveTrackingEvents(ctx context.Context, shipmentID int64, events []dto.TrackingEvent) {
 	if len(events) == 0 {
@@ -370,18 +410,33 @@ func (s *ShipmentService) SyncToEtsy(ctx context.Context, shipmentID int64) erro
 		return fmt.Errorf("Etsy 同步器未配置")
 	}
 
-	// 同步到 Etsy
-	// todo. 验证需要同步到 etsy 的 TrackingNumber & CarrierName
-	err = s.etsySyncer.CreateReceipt(ctx, order.ShopID, order.EtsyReceiptID, shipment.TrackingNumber, shipment.CarrierName)
+	// 同步到 Etsy（物流商代码由同步器映射为 Etsy carrier_name）
+	result, err := s.etsySyncer.CreateReceiptShipment(ctx, order.ShopID, order.EtsyReceiptID, shipment.TrackingNumber, shipment.CarrierCode)
 	if err != nil {
 		s.shipmentRepo.MarkEtsySyncFailed(ctx, shipmentID, err.Error())
 		return fmt.Errorf("Etsy 同步失败: %v", err)
 	}
 
-	// 标记已同步
-	s.shipmentRepo.MarkEtsySynced(ctx, shipmentID)
+	// 标记已同步并记录 Etsy 响应
+	if err := s.shipmentRepo.MarkEtsySynced(ctx, shipmentID, result.ReceiptShippingID, result.RawResponse); err != nil {
+		return fmt.Errorf("更新发货记录失败: %v", err)
+	}
 
-	return nil
+	// 订单推进到已发货
+	fields := map[string]interface{}{
+		"is_shipped": true,
+	}
+	if result.EtsyStatus != "" {
+		fields["etsy_status"] = result.EtsyStatus
+	}
+	if order.Status == model.OrderStatusPending || order.Status == model.OrderStatusProcessing {
+		fields["status"] = model.OrderStatusShipped
+	}
+	if order.ShippedAt == nil {
+		now := time.Now()
+		fields["shipped_at"] = &now
+	}
+	return s.orderRepo.UpdateFields(ctx, order.ID, fields)
 }
 
 // ==================== 辅助方法 ====================
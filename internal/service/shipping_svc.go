@@ -1,18 +1,13 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/pkg/etsy"
-	"etsy_dev_v1_202512/pkg/net"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,7 +19,7 @@ type ShippingProfileService struct {
 	upgradeRepo     repository.ShippingUpgradeRepository
 	shopRepo        repository.ShopRepository
 	developerRepo   repository.DeveloperRepository
-	dispatcher      net.Dispatcher
+	etsyClient      *etsy.Client
 }
 
 func NewShippingProfileService(
@@ -33,7 +28,7 @@ func NewShippingProfileService(
 	upgradeRepo repository.ShippingUpgradeRepository,
 	shopRepo repository.ShopRepository,
 	developerRepo repository.DeveloperRepository,
-	dispatcher net.Dispatcher,
+	etsyClient *etsy.Client,
 ) *ShippingProfileService {
 	return &ShippingProfileService{
 		profileRepo:     profileRepo,
@@ -41,7 +36,7 @@ func NewShippingProfileService(
 		upgradeRepo:     upgradeRepo,
 		shopRepo:        shopRepo,
 		developerRepo:   developerRepo,
-		dispatcher:      dispatcher,
+		etsyClient:      etsyClient,
 	}
 }
 
@@ -105,30 +100,14 @@ func (s *ShippingProfileService) SyncProfilesFromEtsy(ctx context.Context, shopI
 		return errors.New("店铺已停用，无法同步")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles", EtsyAPIBaseURL, shop.EtsyShopID)
-	req, err := net.BuildEtsyRequest(ctx, http.MethodGet, url, nil, developer.ApiKey, shop.AccessToken)
+	etsyProfiles, err := s.etsyClient.ListShippingProfiles(ctx, EtsyCredentials(shop, developer))
 	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, shopID, req)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
-	}
-
-	var etsyResp etsy.EtsyShippingProfilesResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyResp); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
+		return err
 	}
 
 	now := time.Now()
 
-	for _, item := range etsyResp.Results {
+	for _, item := range etsyProfiles {
 		// 1. Upsert 运费模板
 		profile := model.ShippingProfile{
 			ShopID:            shopID,
@@ -220,31 +199,9 @@ func (s *ShippingProfileService) CreateProfileToEtsy(ctx context.Context, req dt
 		MinProcessingDays: req.ProcessingDaysMin,
 		MaxProcessingDays: req.ProcessingDaysMax,
 	}
-	body, err := json.Marshal(etsyReq)
+	etsyProfile, err := s.etsyClient.CreateShippingProfile(ctx, EtsyCredentials(shop, developer), etsyReq)
 	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles", EtsyAPIBaseURL, shop.EtsyShopID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPost, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, req.ShopID, httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, s.parseEtsyError(resp)
-	}
-
-	var etsyProfile etsy.EtsyShippingProfileResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyProfile); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 
 	now := time.Now()
@@ -293,26 +250,8 @@ func (s *ShippingProfileService) UpdateProfileToEtsy(ctx context.Context, id int
 		MinProcessingDays: req.ProcessingDaysMin,
 		MaxProcessingDays: req.ProcessingDaysMax,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d", EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPut, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if _, err := s.etsyClient.UpdateShippingProfile(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, etsyReq); err != nil {
+		return err
 	}
 
 	now := time.Now()
@@ -357,20 +296,8 @@ func (s *ShippingProfileService) DeleteProfileFromEtsy(ctx context.Context, id i
 		return errors.New("仅正常状态的店铺可以删除运费模板")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d", EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodDelete, url, nil, developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if err := s.etsyClient.DeleteShippingProfile(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID); err != nil {
+		return err
 	}
 
 	// 删除关联数据
@@ -416,31 +343,9 @@ func (s *ShippingProfileService) CreateDestinationToEtsy(ctx context.Context, re
 		MinDeliveryDays:       req.DeliveryDaysMin,
 		MaxDeliveryDays:       req.DeliveryDaysMax,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d/destinations", EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPost, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
+	etsyDest, err := s.etsyClient.CreateShippingDestination(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, etsyReq)
 	if err != nil {
-		return nil, fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, s.parseEtsyError(resp)
-	}
-
-	var etsyDest etsy.EtsyShippingDestinationResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyDest); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 
 	dest := &model.ShippingDestination{
@@ -499,27 +404,8 @@ func (s *ShippingProfileService) UpdateDestinationToEtsy(ctx context.Context, id
 		MinDeliveryDays:       req.DeliveryDaysMin,
 		MaxDeliveryDays:       req.DeliveryDaysMax,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d/destinations/%d",
-		EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID, dest.EtsyDestinationID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPut, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if _, err := s.etsyClient.UpdateShippingDestination(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, dest.EtsyDestinationID, etsyReq); err != nil {
+		return err
 	}
 
 	fields := map[string]interface{}{}
@@ -566,21 +452,8 @@ func (s *ShippingProfileService) DeleteDestinationFromEtsy(ctx context.Context,
 		return errors.New("仅正常状态的店铺可以删除运费目的地")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d/destinations/%d",
-		EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID, dest.EtsyDestinationID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodDelete, url, nil, developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if err := s.etsyClient.DeleteShippingDestination(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, dest.EtsyDestinationID); err != nil {
+		return err
 	}
 
 	return s.destinationRepo.Delete(ctx, id)
@@ -617,31 +490,9 @@ func (s *ShippingProfileService) CreateUpgradeToEtsy(ctx context.Context, req dt
 		MinDeliveryDays:   req.DeliveryDaysMin,
 		MaxDeliveryDays:   req.DeliveryDaysMax,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d/upgrades", EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPost, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
+	etsyUpgrade, err := s.etsyClient.CreateShippingUpgrade(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, etsyReq)
 	if err != nil {
-		return nil, fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, s.parseEtsyError(resp)
-	}
-
-	var etsyUpgrade etsy.EtsyShippingUpgradeResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyUpgrade); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 
 	upgrade := &model.ShippingUpgrade{
@@ -700,27 +551,8 @@ func (s *ShippingProfileService) UpdateUpgradeToEtsy(ctx context.Context, id int
 		MinDeliveryDays:   req.DeliveryDaysMin,
 		MaxDeliveryDays:   req.DeliveryDaysMax,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d/upgrades/%d",
-		EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID, upgrade.EtsyUpgradeID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPut, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if _, err := s.etsyClient.UpdateShippingUpgrade(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, upgrade.EtsyUpgradeID, etsyReq); err != nil {
+		return err
 	}
 
 	fields := map[string]interface{}{}
@@ -765,21 +597,8 @@ func (s *ShippingProfileService) DeleteUpgradeFromEtsy(ctx context.Context, id i
 		return errors.New("仅正常状态的店铺可以删除加急配送选项")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/shipping-profiles/%d/upgrades/%d",
-		EtsyAPIBaseURL, shop.EtsyShopID, profile.EtsyProfileID, upgrade.EtsyUpgradeID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodDelete, url, nil, developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, profile.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if err := s.etsyClient.DeleteShippingUpgrade(ctx, EtsyCredentials(shop, developer), profile.EtsyProfileID, upgrade.EtsyUpgradeID); err != nil {
+		return err
 	}
 
 	return s.upgradeRepo.Delete(ctx, id)
@@ -808,27 +627,6 @@ func (s *ShippingProfileService) getShopWithDeveloper(ctx context.Context, shopI
 	return shop, developer, nil
 }
 
-func (s *ShippingProfileService) parseEtsyError(resp *http.Response) error {
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("Etsy API 错误 (状态码: %d)", resp.StatusCode)
-	}
-
-	var etsyErr etsy.EtsyErrorResp
-	if err := json.Unmarshal(body, &etsyErr); err != nil {
-		return fmt.Errorf("Etsy API 错误 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	if etsyErr.ErrorDescription != "" {
-		return fmt.Errorf("Etsy API 错误: %s", etsyErr.ErrorDescription)
-	}
-	if etsyErr.Error != "" {
-		return fmt.Errorf("Etsy API 错误: %s", etsyErr.Error)
-	}
-
-	return fmt.Errorf("Etsy API 错误 (状态码: %d)", resp.StatusCode)
-}
-
 // ==================== DTO 转换方法 ====================
 
 func (s *ShippingProfileService) convertProfileToResp(profile *model.ShippingProfile) dto.ShippingProfileResp {
@@ -897,20 +695,20 @@ type ReturnPolicyService struct {
 	policyRepo    repository.ReturnPolicyRepository
 	shopRepo      repository.ShopRepository
 	developerRepo repository.DeveloperRepository
-	dispatcher    net.Dispatcher
+	etsyClient    *etsy.Client
 }
 
 func NewReturnPolicyService(
 	policyRepo repository.ReturnPolicyRepository,
 	shopRepo repository.ShopRepository,
 	developerRepo repository.DeveloperRepository,
-	dispatcher net.Dispatcher,
+	etsyClient *etsy.Client,
 ) *ReturnPolicyService {
 	return &ReturnPolicyService{
 		policyRepo:    policyRepo,
 		shopRepo:      shopRepo,
 		developerRepo: developerRepo,
-		dispatcher:    dispatcher,
+		etsyClient:    etsyClient,
 	}
 }
 
@@ -961,30 +759,14 @@ func (s *ReturnPolicyService) SyncPoliciesFromEtsy(ctx context.Context, shopID i
 		return errors.New("店铺已停用，无法同步")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/policies/return", EtsyAPIBaseURL, shop.EtsyShopID)
-	req, err := net.BuildEtsyRequest(ctx, http.MethodGet, url, nil, developer.ApiKey, shop.AccessToken)
+	etsyPolicies, err := s.etsyClient.ListReturnPolicies(ctx, EtsyCredentials(shop, developer))
 	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, shopID, req)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
-	}
-
-	var etsyResp etsy.EtsyReturnPoliciesResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyResp); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
+		return err
 	}
 
 	now := time.Now()
-	policies := make([]model.ReturnPolicy, 0, len(etsyResp.Results))
-	for _, item := range etsyResp.Results {
+	policies := make([]model.ReturnPolicy, 0, len(etsyPolicies))
+	for _, item := range etsyPolicies {
 		policies = append(policies, model.ReturnPolicy{
 			ShopID:           shopID,
 			EtsyPolicyID:     item.ReturnPolicyID,
@@ -1014,31 +796,9 @@ func (s *ReturnPolicyService) CreatePolicyToEtsy(ctx context.Context, req dto.Re
 		AcceptsExchanges: req.AcceptsExchanges,
 		ReturnDeadline:   req.ReturnDeadline,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/policies/return", EtsyAPIBaseURL, shop.EtsyShopID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPost, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, req.ShopID, httpReq)
+	etsyPolicy, err := s.etsyClient.CreateReturnPolicy(ctx, EtsyCredentials(shop, developer), etsyReq)
 	if err != nil {
-		return nil, fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, s.parseEtsyError(resp)
-	}
-
-	var etsyPolicy etsy.EtsyReturnPolicyResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyPolicy); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 
 	now := time.Now()
@@ -1083,26 +843,8 @@ func (s *ReturnPolicyService) UpdatePolicyToEtsy(ctx context.Context, id int64,
 		AcceptsExchanges: req.AcceptsExchanges,
 		ReturnDeadline:   req.ReturnDeadline,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/policies/return/%d", EtsyAPIBaseURL, shop.EtsyShopID, policy.EtsyPolicyID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPut, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, policy.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if _, err := s.etsyClient.UpdateReturnPolicy(ctx, EtsyCredentials(shop, developer), policy.EtsyPolicyID, etsyReq); err != nil {
+		return err
 	}
 
 	now := time.Now()
@@ -1135,20 +877,8 @@ func (s *ReturnPolicyService) DeletePolicyFromEtsy(ctx context.Context, id int64
 		return errors.New("仅正常状态的店铺可以删除退货政策")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/policies/return/%d", EtsyAPIBaseURL, shop.EtsyShopID, policy.EtsyPolicyID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodDelete, url, nil, developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, policy.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if err := s.etsyClient.DeleteReturnPolicy(ctx, EtsyCredentials(shop, developer), policy.EtsyPolicyID); err != nil {
+		return err
 	}
 
 	return s.policyRepo.Delete(ctx, id)
@@ -1177,27 +907,6 @@ func (s *ReturnPolicyService) getShopWithDeveloper(ctx context.Context, shopID i
 	return shop, developer, nil
 }
 
-func (s *ReturnPolicyService) parseEtsyError(resp *http.Response) error {
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("Etsy API 错误 (状态码: %d)", resp.StatusCode)
-	}
-
-	var etsyErr etsy.EtsyErrorResp
-	if err := json.Unmarshal(body, &etsyErr); err != nil {
-		return fmt.Errorf("Etsy API 错误 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	if etsyErr.ErrorDescription != "" {
-		return fmt.Errorf("Etsy API 错误: %s", etsyErr.ErrorDescription)
-	}
-	if etsyErr.Error != "" {
-		return fmt.Errorf("Etsy API 错误: %s", etsyErr.Error)
-	}
-
-	return fmt.Errorf("Etsy API 错误 (状态码: %d)", resp.StatusCode)
-}
-
 func (s *ReturnPolicyService) convertToResp(policy *model.ReturnPolicy) dto.ReturnPolicyResp {
 	return dto.ReturnPolicyResp{
 		ID:               int64(policy.ID),
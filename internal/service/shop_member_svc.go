@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/middleware"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+)
+
+var (
+	ErrShopMemberExists    = errors.New("该用户已是店铺成员")
+	ErrShopMemberNotFound  = errors.New("店铺成员不存在")
+	ErrShopLastOwner       = errors.New("店铺至少需要保留一名 owner")
+	ErrUnknownShopResource = errors.New("未知的店铺资源类型")
+)
+
+// 确保 ShopMemberService 可作为店铺权限数据源
+var _ middleware.ShopAccessChecker = (*ShopMemberService)(nil)
+
+// ShopMemberService 店铺成员与店铺级权限服务
+type ShopMemberService struct {
+	memberRepo   repository.ShopMemberRepository
+	userRepo     repository.UserRepository
+	shopRepo     repository.ShopRepository
+	productRepo  repository.ProductRepository
+	orderRepo    repository.OrderRepository
+	shipmentRepo repository.ShipmentRepository
+	sectionRepo  repository.ShopSectionRepository
+	profileRepo  repository.ShippingProfileRepository
+	upgradeRepo  repository.ShippingUpgradeRepository
+	policyRepo   repository.ReturnPolicyRepository
+
+	draftTaskRepo    repository.DraftTaskRepository
+	draftProductRepo repository.DraftProductRepository
+	draftJobRepo     repository.DraftJobRepository
+}
+
+// ShopMemberRepos 店铺成员服务依赖（用于解析各类资源所属店铺）
+type ShopMemberRepos struct {
+	Member          repository.ShopMemberRepository
+	User            repository.UserRepository
+	Shop            repository.ShopRepository
+	Product         repository.ProductRepository
+	Order           repository.OrderRepository
+	Shipment        repository.ShipmentRepository
+	Section         repository.ShopSectionRepository
+	ShippingProfile repository.ShippingProfileRepository
+	ShippingUpgrade repository.ShippingUpgradeRepository
+	ReturnPolicy    repository.ReturnPolicyRepository
+	DraftTask       repository.DraftTaskRepository
+	DraftProduct    repository.DraftProductRepository
+	DraftJob        repository.DraftJobRepository
+}
+
+// NewShopMemberService 创建店铺成员服务
+func NewShopMemberService(repos ShopMemberRepos) *ShopMemberService {
+	return &ShopMemberService{
+		memberRepo:   repos.Member,
+		userRepo:     repos.User,
+		shopRepo:     repos.Shop,
+		productRepo:  repos.Product,
+		orderRepo:    repos.Order,
+		shipmentRepo: repos.Shipment,
+		sectionRepo:  repos.Section,
+		profileRepo:  repos.ShippingProfile,
+		upgradeRepo:  repos.ShippingUpgrade,
+		policyRepo:   repos.ReturnPolicy,
+
+		draftTaskRepo:    repos.DraftTask,
+		draftProductRepo: repos.DraftProduct,
+		draftJobRepo:     repos.DraftJob,
+	}
+}
+
+// ==================== 权限数据源 ====================
+
+// ShopRole 用户在店铺中的角色，非成员返回空字符串
+func (s *ShopMemberService) ShopRole(ctx context.Context, userID, shopID int64) (string, error) {
+	member, err := s.memberRepo.GetByUserAndShop(ctx, userID, shopID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// UserShopIDs 用户所属的全部店铺
+func (s *ShopMemberService) UserShopIDs(ctx context.Context, userID int64) ([]int64, error) {
+	return s.memberRepo.GetUserShopIDs(ctx, userID)
+}
+
+// ResolveShopIDs 解析资源所属店铺，草稿任务返回目标店铺与已生成草稿的店铺
+func (s *ShopMemberService) ResolveShopIDs(ctx context.Context, resource string, id int64) ([]int64, error) {
+	if resource == middleware.ShopResourceDraftTask {
+		return s.draftTaskShopIDs(ctx, id)
+	}
+	shopID, err := s.ResolveShopID(ctx, resource, id)
+	if err != nil {
+		return nil, err
+	}
+	return []int64{shopID}, nil
+}
+
+// draftTaskShopIDs 草稿任务涉及的店铺（队列记录中的目标店铺 + 已生成的草稿）
+func (s *ShopMemberService) draftTaskShopIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	if s.draftTaskRepo == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownShopResource, middleware.ShopResourceDraftTask)
+	}
+	if _, err := s.draftTaskRepo.GetByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool)
+	var shopIDs []int64
+	add := func(id int64) {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			shopIDs = append(shopIDs, id)
+		}
+	}
+
+	if s.draftJobRepo != nil {
+		job, err := s.draftJobRepo.GetByTaskID(ctx, taskID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if job != nil {
+			for _, id := range job.ShopIDs {
+				add(id)
+			}
+		}
+	}
+	products, err := s.draftProductRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		add(p.ShopID)
+	}
+	return shopIDs, nil
+}
+
+// ResolveShopID 解析资源所属店铺
+func (s *ShopMemberService) ResolveShopID(ctx context.Context, resource string, id int64) (int64, error) {
+	switch resource {
+	case middleware.ShopResourceShop:
+		shop, err := s.shopRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return shop.ID, nil
+
+	case middleware.ShopResourceProduct:
+		product, err := s.productRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return product.ShopID, nil
+
+	case middleware.ShopResourceOrder:
+		order, err := s.orderRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return order.ShopID, nil
+
+	case middleware.ShopResourceShipment:
+		shipment, err := s.shipmentRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return s.ResolveShopID(ctx, middleware.ShopResourceOrder, shipment.OrderID)
+
+	case middleware.ShopResourceSection:
+		section, err := s.sectionRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return section.ShopID, nil
+
+	case middleware.ShopResourceShippingProfile:
+		profile, err := s.profileRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return profile.ShopID, nil
+
+	case middleware.ShopResourceShippingUpgrade:
+		upgrade, err := s.upgradeRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return s.ResolveShopID(ctx, middleware.ShopResourceShippingProfile, upgrade.ShippingProfileID)
+
+	case middleware.ShopResourceReturnPolicy:
+		policy, err := s.policyRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return policy.ShopID, nil
+
+	case middleware.ShopResourceDraftProduct:
+		if s.draftProductRepo == nil {
+			break
+		}
+		product, err := s.draftProductRepo.GetByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return product.ShopID, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrUnknownShopResource, resource)
+}
+
+// ==================== 成员管理 ====================
+
+// ListMembers 店铺成员列表
+func (s *ShopMemberService) ListMembers(ctx context.Context, shopID int64) ([]dto.ShopMemberResp, error) {
+	members, err := s.memberRepo.ListByShop(ctx, shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.ShopMemberResp, 0, len(members))
+	for _, m := range members {
+		resp := dto.ShopMemberResp{
+			UserID:    m.UserID,
+			Role:      m.Role,
+			CreatedAt: m.CreatedAt,
+		}
+		if m.User != nil {
+			resp.Username = m.User.Username
+			resp.Nickname = m.User.Nickname
+		}
+		list = append(list, resp)
+	}
+	return list, nil
+}
+
+// AddMember 添加店铺成员
+func (s *ShopMemberService) AddMember(ctx context.Context, shopID int64, req *dto.AddShopMemberReq) error {
+	if _, err := s.shopRepo.GetByID(ctx, shopID); err != nil {
+		return fmt.Errorf("店铺不存在")
+	}
+	if _, err := s.userRepo.GetByID(ctx, req.UserID); err != nil {
+		return fmt.Errorf("用户不存在")
+	}
+
+	role, err := s.ShopRole(ctx, req.UserID, shopID)
+	if err != nil {
+		return err
+	}
+	if role != "" {
+		return ErrShopMemberExists
+	}
+
+	return s.memberRepo.Create(ctx, &model.ShopMember{
+		UserID: req.UserID,
+		ShopID: shopID,
+		Role:   req.Role,
+	})
+}
+
+// UpdateMemberRole 修改成员角色（不允许降级最后一名 owner）
+func (s *ShopMemberService) UpdateMemberRole(ctx context.Context, shopID, userID int64, role string) error {
+	current, err := s.ShopRole(ctx, userID, shopID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrShopMemberNotFound
+	}
+	if current == role {
+		return nil
+	}
+
+	if err := s.ensureNotLastOwner(ctx, shopID, current); err != nil {
+		return err
+	}
+	return s.memberRepo.UpdateRole(ctx, userID, shopID, role)
+}
+
+// RemoveMember 移除成员（不允许移除最后一名 owner）
+func (s *ShopMemberService) RemoveMember(ctx context.Context, shopID, userID int64) error {
+	current, err := s.ShopRole(ctx, userID, shopID)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return ErrShopMemberNotFound
+	}
+
+	if err := s.ensureNotLastOwner(ctx, shopID, current); err != nil {
+		return err
+	}
+	return s.memberRepo.Delete(ctx, userID, shopID)
+}
+
+// ensureNotLastOwner 变更 owner 前确认店铺仍会保留其他 owner
+func (s *ShopMemberService) ensureNotLastOwner(ctx context.Context, shopID int64, currentRole string) error {
+	if currentRole != model.ShopMemberRoleOwner {
+		return nil
+	}
+	owners, err := s.memberRepo.CountByRole(ctx, shopID, model.ShopMemberRoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrShopLastOwner
+	}
+	return nil
+}
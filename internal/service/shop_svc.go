@@ -1,25 +1,20 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"etsy_dev_v1_202512/internal/api/dto"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/pkg/etsy"
-	"etsy_dev_v1_202512/pkg/net"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 const (
-	EtsyAPIBaseURL     = "https://api.etsy.com/v3/application"
 	ManualSyncCooldown = 1 * time.Hour // 手动同步冷却时间
 )
 
@@ -31,8 +26,17 @@ type ShopService struct {
 	upgradeRepo     repository.ShippingUpgradeRepository
 	policyRepo      repository.ReturnPolicyRepository
 	developerRepo   repository.DeveloperRepository
-	dispatcher      net.Dispatcher
+	etsyClient      *etsy.Client
 	proxyRepo       repository.ProxyRepository
+
+	// 建店时绑定代理（ProxyService 实现），未设置时由首次请求惰性绑定
+	proxyBinder ShopProxyBinder
+}
+
+// ShopProxyBinder 店铺代理挑选与绑定
+type ShopProxyBinder interface {
+	PickBestProxy(ctx context.Context, region string, shopID int64) (*model.Proxy, error)
+	BindBestProxy(ctx context.Context, shop *model.Shop, reason string) (*model.Proxy, error)
 }
 
 func NewShopService(
@@ -43,7 +47,7 @@ func NewShopService(
 	upgradeRepo repository.ShippingUpgradeRepository,
 	policyRepo repository.ReturnPolicyRepository,
 	developerRepo repository.DeveloperRepository,
-	dispatcher net.Dispatcher,
+	etsyClient *etsy.Client,
 	proxyRepo repository.ProxyRepository,
 ) *ShopService {
 	return &ShopService{
@@ -54,11 +58,16 @@ func NewShopService(
 		upgradeRepo:     upgradeRepo,
 		policyRepo:      policyRepo,
 		developerRepo:   developerRepo,
-		dispatcher:      dispatcher,
+		etsyClient:      etsyClient,
 		proxyRepo:       proxyRepo,
 	}
 }
 
+// SetProxyBinder 设置建店时的代理绑定
+func (s *ShopService) SetProxyBinder(binder ShopProxyBinder) {
+	s.proxyBinder = binder
+}
+
 // ==================== 查询方法 ====================
 
 // GetByID 根据ID获取店铺
@@ -69,6 +78,7 @@ func (s *ShopService) GetByID(ctx context.Context, id int64) (*model.Shop, error
 // GetShopList 获取店铺列表
 func (s *ShopService) GetShopList(ctx context.Context, req dto.ShopListReq) (*dto.ShopListResp, error) {
 	filter := repository.ShopFilter{
+		IDs:         req.ShopIDs,
 		ShopName:    req.ShopName,
 		Status:      req.Status,
 		ProxyID:     req.ProxyID,
@@ -146,15 +156,21 @@ func (s *ShopService) CreateShop(ctx context.Context, shop *model.Shop) (*model.
 		return nil, err
 	}
 	shop.DeveloperID = dev.ID
-	// 绑定 proxy
-	proxy, err := s.proxyRepo.FindSpareProxy(ctx, shop.Region)
-	if err != nil {
-		return nil, err
+	// 绑定 proxy：建店前确认同地区有可用代理，建店后绑定并记录历史
+	// 并发下代理被抢占时不阻断授权，由首次请求惰性绑定
+	if s.proxyBinder != nil {
+		if _, err := s.proxyBinder.PickBestProxy(ctx, shop.Region, 0); err != nil {
+			return nil, err
+		}
 	}
-	shop.ProxyID = proxy.ID
 	if err := s.shopRepo.Create(ctx, shop); err != nil {
 		return nil, err
 	}
+	if s.proxyBinder != nil {
+		if _, err := s.proxyBinder.BindBestProxy(ctx, shop, model.ProxyBindReasonShopCreated); err != nil {
+			log.Printf("[ShopService] 店铺 %d 绑定代理失败，等待惰性绑定: %v", shop.ID, err)
+		}
+	}
 	return shop, nil
 }
 
@@ -240,6 +256,26 @@ func (s *ShopService) ResumeShop(ctx context.Context, shopID int64) error {
 	})
 }
 
+// UpdateAISettings 更新店铺 AI Provider / 模型
+func (s *ShopService) UpdateAISettings(ctx context.Context, shopID int64, req dto.ShopAISettingsReq) error {
+	if _, err := s.shopRepo.GetByID(ctx, shopID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("店铺不存在")
+		}
+		return err
+	}
+
+	if req.AIProvider != "" && !IsValidAIProvider(req.AIProvider) {
+		return fmt.Errorf("不支持的 AI Provider: %s", req.AIProvider)
+	}
+
+	return s.shopRepo.UpdateFields(ctx, shopID, map[string]interface{}{
+		"ai_provider":    req.AIProvider,
+		"ai_text_model":  req.AITextModel,
+		"ai_image_model": req.AIImageModel,
+	})
+}
+
 // DeleteShop 删除店铺（仅 ERP 解绑）
 func (s *ShopService) DeleteShop(ctx context.Context, shopID int64) error {
 	shop, err := s.shopRepo.GetByID(ctx, shopID)
@@ -353,25 +389,9 @@ func (s *ShopService) SyncShopFromEtsy(ctx context.Context, shopID int64) error
 		return errors.New("店铺已停用，无法同步")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d", EtsyAPIBaseURL, shop.EtsyShopID)
-	req, err := net.BuildEtsyRequest(ctx, http.MethodGet, url, nil, developer.ApiKey, shop.AccessToken)
+	etsyShop, err := s.etsyClient.GetShop(ctx, EtsyCredentials(shop, developer))
 	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, shopID, req)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
-	}
-
-	var etsyShop etsy.EtsyShopResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyShop); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
+		return err
 	}
 
 	now := time.Now()
@@ -412,26 +432,8 @@ func (s *ShopService) UpdateShopToEtsy(ctx context.Context, shopID int64, req dt
 		DigitalSaleMessage: req.DigitalSaleMessage,
 	}
 
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d", EtsyAPIBaseURL, shop.EtsyShopID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPut, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, shopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if _, err := s.etsyClient.UpdateShop(ctx, EtsyCredentials(shop, developer), etsyReq); err != nil {
+		return err
 	}
 
 	now := time.Now()
@@ -468,30 +470,14 @@ func (s *ShopService) SyncSectionsFromEtsy(ctx context.Context, shopID int64) er
 		return errors.New("店铺已停用，无法同步")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/sections", EtsyAPIBaseURL, shop.EtsyShopID)
-	req, err := net.BuildEtsyRequest(ctx, http.MethodGet, url, nil, developer.ApiKey, shop.AccessToken)
+	etsySections, err := s.etsyClient.ListShopSections(ctx, EtsyCredentials(shop, developer))
 	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, shopID, req)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
-	}
-
-	var etsyResp etsy.EtsyShopSectionsResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsyResp); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
+		return err
 	}
 
 	now := time.Now()
-	sections := make([]model.ShopSection, 0, len(etsyResp.Results))
-	for _, item := range etsyResp.Results {
+	sections := make([]model.ShopSection, 0, len(etsySections))
+	for _, item := range etsySections {
 		sections = append(sections, model.ShopSection{
 			ShopID:             shopID,
 			EtsySectionID:      item.ShopSectionID,
@@ -519,31 +505,9 @@ func (s *ShopService) CreateSectionToEtsy(ctx context.Context, shopID int64, tit
 	etsyReq := etsy.EtsyShopSectionCreateReq{
 		Title: title,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/sections", EtsyAPIBaseURL, shop.EtsyShopID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPost, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return nil, fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, shopID, httpReq)
+	etsySection, err := s.etsyClient.CreateShopSection(ctx, EtsyCredentials(shop, developer), etsyReq)
 	if err != nil {
-		return nil, fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, s.parseEtsyError(resp)
-	}
-
-	var etsySection etsy.EtsyShopSectionResp
-	if err := json.NewDecoder(resp.Body).Decode(&etsySection); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %v", err)
+		return nil, err
 	}
 
 	now := time.Now()
@@ -586,26 +550,8 @@ func (s *ShopService) UpdateSectionToEtsy(ctx context.Context, sectionID int64,
 	etsyReq := etsy.EtsyShopSectionUpdateReq{
 		Title: title,
 	}
-	body, err := json.Marshal(etsyReq)
-	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
-	}
-
-	url := fmt.Sprintf("%s/shops/%d/sections/%d", EtsyAPIBaseURL, shop.EtsyShopID, section.EtsySectionID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodPut, url, bytes.NewReader(body), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.dispatcher.Send(ctx, section.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if _, err := s.etsyClient.UpdateShopSection(ctx, EtsyCredentials(shop, developer), section.EtsySectionID, etsyReq); err != nil {
+		return err
 	}
 
 	now := time.Now()
@@ -634,20 +580,8 @@ func (s *ShopService) DeleteSectionFromEtsy(ctx context.Context, sectionID int64
 		return errors.New("仅正常状态的店铺可以删除分区")
 	}
 
-	url := fmt.Sprintf("%s/shops/%d/sections/%d", EtsyAPIBaseURL, shop.EtsyShopID, section.EtsySectionID)
-	httpReq, err := net.BuildEtsyRequest(ctx, http.MethodDelete, url, nil, developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return fmt.Errorf("构建请求失败: %v", err)
-	}
-
-	resp, err := s.dispatcher.Send(ctx, section.ShopID, httpReq)
-	if err != nil {
-		return fmt.Errorf("请求 Etsy API 失败: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return s.parseEtsyError(resp)
+	if err := s.etsyClient.DeleteShopSection(ctx, EtsyCredentials(shop, developer), section.EtsySectionID); err != nil {
+		return err
 	}
 
 	return s.sectionRepo.Delete(ctx, sectionID)
@@ -676,25 +610,20 @@ func (s *ShopService) getShopWithDeveloper(ctx context.Context, shopID int64) (*
 	return shop, developer, nil
 }
 
-func (s *ShopService) parseEtsyError(resp *http.Response) error {
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("Etsy API 错误 (状态码: %d)", resp.StatusCode)
+// EtsyCredentials 店铺调用 Etsy 的凭证（developer 为空时使用 shop 预加载的开发者）
+func EtsyCredentials(shop *model.Shop, developer *model.Developer) etsy.Credentials {
+	if developer == nil {
+		developer = shop.Developer
 	}
-
-	var etsyErr etsy.EtsyErrorResp
-	if err := json.Unmarshal(body, &etsyErr); err != nil {
-		return fmt.Errorf("Etsy API 错误 (状态码: %d): %s", resp.StatusCode, string(body))
+	cred := etsy.Credentials{
+		ShopID:      shop.ID,
+		EtsyShopID:  shop.EtsyShopID,
+		AccessToken: shop.AccessToken,
 	}
-
-	if etsyErr.ErrorDescription != "" {
-		return fmt.Errorf("Etsy API 错误: %s", etsyErr.ErrorDescription)
+	if developer != nil {
+		cred.APIKey = developer.ApiKey
 	}
-	if etsyErr.Error != "" {
-		return fmt.Errorf("Etsy API 错误: %s", etsyErr.Error)
-	}
-
-	return fmt.Errorf("Etsy API 错误 (状态码: %d)", resp.StatusCode)
+	return cred
 }
 
 // ==================== DTO 转换方法 ====================
@@ -721,6 +650,9 @@ func (s *ShopService) convertToResp(shop *model.Shop) dto.ShopResp {
 		UpdatedAt:            shop.UpdatedAt,
 		ProxyID:              shop.ProxyID,
 		DeveloperID:          shop.DeveloperID,
+		AIProvider:           shop.AIProvider,
+		AITextModel:          shop.AITextModel,
+		AIImageModel:         shop.AIImageModel,
 	}
 
 	switch shop.Status {
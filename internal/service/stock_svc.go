@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Etsy 单个 offering 的库存上限
+const maxOfferingQuantity = 999
+
+// 库存推送结果
+const (
+	StockPushPushed      = "pushed"      // 已推送数量
+	StockPushDeactivated = "deactivated" // 无可售库存，已下架
+	StockPushSkipped     = "skipped"     // 未上传 Etsy 或数量无变化
+	StockPushFailed      = "failed"
+)
+
+// StockPushResult 单个关联 listing 的推送结果
+type StockPushResult struct {
+	ProductID int64  `json:"product_id"`
+	ShopID    int64  `json:"shop_id"`
+	ListingID int64  `json:"listing_id"`
+	Quantity  int    `json:"quantity"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// StockService 跨店铺主库存服务
+// 主库存按 LocalSKU 关联各店铺的 Product / ProductVariant，库存变动后把数量推送到所有关联 listing
+type StockService struct {
+	stockRepo      repository.StockRepository
+	productRepo    repository.ProductRepository
+	productService *ProductService
+
+	// 同一 SKU 的推送串行执行，推送时读取最新库存，避免旧值覆盖新值
+	pushLocks sync.Map // sku -> *sync.Mutex
+}
+
+// NewStockService 创建主库存服务
+func NewStockService(
+	stockRepo repository.StockRepository,
+	productRepo repository.ProductRepository,
+	productService *ProductService,
+) *StockService {
+	return &StockService{
+		stockRepo:      stockRepo,
+		productRepo:    productRepo,
+		productService: productService,
+	}
+}
+
+// ==================== 主库存管理 ====================
+
+// ListItems 主库存列表
+func (s *StockService) ListItems(ctx context.Context, keyword string, page, pageSize int) ([]model.StockItem, int64, error) {
+	return s.stockRepo.List(ctx, keyword, page, pageSize)
+}
+
+// GetItem 主库存详情（含关联商品）
+func (s *StockService) GetItem(ctx context.Context, id int64) (*model.StockItem, []model.Product, error) {
+	item, err := s.stockRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("库存不存在: %v", err)
+	}
+	products, err := s.productRepo.ListByLocalSKU(ctx, item.LocalSKU)
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, products, nil
+}
+
+// CreateItem 创建主库存，初始数量记为一条盘点流水
+func (s *StockService) CreateItem(ctx context.Context, req *dto.CreateStockItemReq) (*model.StockItem, error) {
+	sku := strings.TrimSpace(req.LocalSKU)
+	existing, err := s.stockRepo.GetBySKU(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("SKU %s 已存在", sku)
+	}
+
+	item := &model.StockItem{LocalSKU: sku, Name: req.Name}
+	if err := s.stockRepo.Create(ctx, item); err != nil {
+		return nil, err
+	}
+	if req.OnHand == 0 {
+		return item, nil
+	}
+	return s.stockRepo.SetOnHand(ctx, item.ID, item.Version, req.OnHand, &model.StockMovement{
+		Reason: model.StockReasonCount,
+		Note:   "初始库存",
+	})
+}
+
+// AdjustStock 人工增减库存并推送
+func (s *StockService) AdjustStock(ctx context.Context, id int64, delta int, note string) (*model.StockItem, []StockPushResult, error) {
+	if delta == 0 {
+		return nil, nil, fmt.Errorf("变动数量不能为 0")
+	}
+	item, err := s.stockRepo.ApplyDelta(ctx, &model.StockMovement{
+		StockItemID: id,
+		Delta:       delta,
+		Reason:      model.StockReasonAdjust,
+		Note:        note,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, s.pushItem(ctx, item.LocalSKU), nil
+}
+
+// CountStock 盘点：按版本号直接设置库存并推送
+func (s *StockService) CountStock(ctx context.Context, id int64, onHand int, expectedVersion int64, note string) (*model.StockItem, []StockPushResult, error) {
+	item, err := s.stockRepo.SetOnHand(ctx, id, expectedVersion, onHand, &model.StockMovement{
+		Reason: model.StockReasonCount,
+		Note:   note,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return item, s.pushItem(ctx, item.LocalSKU), nil
+}
+
+// ListMovements 库存流水
+func (s *StockService) ListMovements(ctx context.Context, id int64, page, pageSize int) ([]model.StockMovement, int64, error) {
+	return s.stockRepo.ListMovements(ctx, id, page, pageSize)
+}
+
+// PushStock 手动把当前库存推送到所有关联 listing
+func (s *StockService) PushStock(ctx context.Context, id int64) ([]StockPushResult, error) {
+	item, err := s.stockRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("库存不存在: %v", err)
+	}
+	return s.pushItem(ctx, item.LocalSKU), nil
+}
+
+// ==================== 订单扣减 ====================
+
+// ConsumeOrderItem 新订单项按 SKU 扣减主库存并推送（实现 OrderStockConsumer）
+// 未建主库存的 SKU 忽略；同一 Etsy transaction 只扣减一次
+func (s *StockService) ConsumeOrderItem(ctx context.Context, shopID int64, item *model.OrderItem) error {
+	sku := strings.TrimSpace(item.SKU)
+	if sku == "" || item.Quantity <= 0 {
+		return nil
+	}
+	stock, err := s.stockRepo.GetBySKU(ctx, sku)
+	if err != nil || stock == nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s:%d", model.StockRefTypeEtsyTransaction, item.EtsyTransactionID)
+	_, err = s.stockRepo.ApplyDelta(ctx, &model.StockMovement{
+		StockItemID:    stock.ID,
+		Delta:          -item.Quantity,
+		Reason:         model.StockReasonOrder,
+		RefType:        model.StockRefTypeEtsyTransaction,
+		RefID:          item.EtsyTransactionID,
+		ShopID:         shopID,
+		IdempotencyKey: &key,
+	})
+	if errors.Is(err, repository.ErrStockMovementExists) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("扣减库存 %s 失败: %w", sku, err)
+	}
+
+	s.pushItem(ctx, sku)
+	return nil
+}
+
+// ==================== 推送 ====================
+
+// pushItem 推送 SKU 最新库存到所有关联 listing，结果记录到主库存
+func (s *StockService) pushItem(ctx context.Context, sku string) []StockPushResult {
+	lock, _ := s.pushLocks.LoadOrStore(sku, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	item, err := s.stockRepo.GetBySKU(ctx, sku)
+	if err != nil || item == nil {
+		return nil
+	}
+	products, err := s.productRepo.ListByLocalSKU(ctx, sku)
+	if err != nil {
+		s.recordPush(ctx, item.ID, err.Error())
+		return nil
+	}
+
+	quantity := min(max(item.OnHand, 0), maxOfferingQuantity)
+	results := make([]StockPushResult, 0, len(products))
+	var errs []string
+	for i := range products {
+		result := s.pushProduct(ctx, &products[i], sku, quantity)
+		if result.Status == StockPushFailed {
+			errs = append(errs, fmt.Sprintf("listing %d: %s", result.ListingID, result.Error))
+			log.Printf("[StockService] 推送库存失败: SKU=%s, Listing=%d, Err=%s", sku, result.ListingID, result.Error)
+		}
+		results = append(results, result)
+	}
+	s.recordPush(ctx, item.ID, strings.Join(errs, "; "))
+	return results
+}
+
+func (s *StockService) recordPush(ctx context.Context, id int64, errMsg string) {
+	if len(errMsg) > 500 {
+		errMsg = errMsg[:500]
+	}
+	_ = s.stockRepo.UpdateFields(ctx, id, map[string]interface{}{
+		"last_pushed_at":  time.Now(),
+		"last_push_error": errMsg,
+	})
+}
+
+// pushProduct 更新单个 listing 中关联 SKU 的变体数量
+//   - 商品本身的 LocalSKU 匹配：整个 listing 共用该库存
+//   - 变体 LocalSKU 匹配：仅更新对应变体；listing 未按属性区分库存时所有变体共用数量
+//   - 推送后无可售库存时 Etsy 不接受，改为下架；补货后需人工重新上架
+func (s *StockService) pushProduct(ctx context.Context, product *model.Product, sku string, quantity int) StockPushResult {
+	result := StockPushResult{ProductID: product.ID, ShopID: product.ShopID, ListingID: product.ListingID, Quantity: quantity}
+	if product.ListingID == 0 {
+		result.Status = StockPushSkipped
+		return result
+	}
+
+	// 从未拉取过库存的商品先同步一次，保证推送的是完整矩阵
+	if len(product.Variants) == 0 {
+		synced, err := s.productService.SyncListingInventory(ctx, product.ID)
+		if err != nil {
+			result.Status, result.Error = StockPushFailed, err.Error()
+			return result
+		}
+		product = synced
+	}
+
+	req := &dto.UpdateProductInventoryReq{
+		PriceOnProperty:    product.PriceOnProperty,
+		QuantityOnProperty: product.QuantityOnProperty,
+		SkuOnProperty:      product.SkuOnProperty,
+	}
+	wholeListing := product.LocalSKU == sku || len(product.QuantityOnProperty) == 0
+	changed, sellable := false, false
+	for _, v := range product.Variants {
+		item := toVariantReq(&v)
+		if (wholeListing || v.LocalSKU == sku) && item.Quantity != quantity {
+			item.Quantity = quantity
+			changed = true
+		}
+		if variantEnabled(item) && item.Quantity > 0 {
+			sellable = true
+		}
+		req.Variants = append(req.Variants, item)
+	}
+
+	switch {
+	case !sellable:
+		if product.State == model.ProductStateActive {
+			if err := s.productService.DeactivateListing(ctx, product.ID); err != nil {
+				result.Status, result.Error = StockPushFailed, err.Error()
+				return result
+			}
+		}
+		result.Status = StockPushDeactivated
+	case !changed:
+		result.Status = StockPushSkipped
+	default:
+		if _, err := s.productService.UpdateProductInventory(ctx, product.ID, req); err != nil {
+			result.Status, result.Error = StockPushFailed, err.Error()
+			return result
+		}
+		result.Status = StockPushPushed
+	}
+	return result
+}
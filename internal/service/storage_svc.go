@@ -3,12 +3,21 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -45,7 +54,8 @@ type StorageConfig struct {
 	SecretKey string
 	Endpoint  string // 自定义端点 (腾讯云COS等)
 	CDNDomain string // CDN域名 (可选)
-	BasePath  string // 基础路径前缀
+	BasePath  string // 基础路径前缀（local 时为磁盘目录）
+	Private   bool   // 私有读（local 时访问必须携带签名，SecretKey 作为签名密钥）
 }
 
 // ==================== 工厂方法 ====================
@@ -113,6 +123,12 @@ func (s *StorageService) GetProvider() StorageProvider {
 	return s.provider
 }
 
+// LocalProvider 本地存储 Provider（非 local 时返回 nil）
+func (s *StorageService) LocalProvider() *LocalStorage {
+	local, _ := s.provider.(*LocalStorage)
+	return local
+}
+
 // ==================== S3 实现 ====================
 
 type S3Storage struct {
@@ -369,30 +385,106 @@ func (s *COSStorage) extractKey(url string) string {
 
 // ==================== 本地存储 (开发测试用) ====================
 
+// LocalStorage 磁盘存储
+// Key 为内容哈希（sha256），相同内容只落盘一次，按上传次数引用计数，最后一次 Delete 才删除文件；
+// 访问 URL 由 router 注册的静态路由提供，GetSignedURL 生成 HMAC 签名的限时 URL
 type LocalStorage struct {
 	basePath string
 	baseURL  string
+	secret   []byte
+	private  bool // 私有读：访问必须携带有效签名
+
+	mu sync.Mutex // 保护文件与引用计数的读改写
 }
 
+// localRefDir 引用计数目录（以 . 开头，不可经静态路由访问）
+const localRefDir = ".refs"
+
 func NewLocalStorage(cfg *StorageConfig) (*LocalStorage, error) {
 	basePath := cfg.BasePath
 	if basePath == "" {
 		basePath = "./uploads"
 	}
-	baseURL := cfg.Endpoint
+	baseURL := strings.TrimRight(cfg.Endpoint, "/")
 	if baseURL == "" {
 		baseURL = "http://localhost:8080/uploads"
 	}
 
+	secret := cfg.SecretKey
+	if secret == "" {
+		if cfg.Private {
+			return nil, fmt.Errorf("本地私有存储必须配置签名密钥 (SecretKey)")
+		}
+		// 未配置时使用随机密钥：签名 URL 仅在本进程生命周期内有效
+		secret = uuid.New().String()
+	}
+
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %v", err)
+	}
+
 	return &LocalStorage{
 		basePath: basePath,
 		baseURL:  baseURL,
+		secret:   []byte(secret),
+		private:  cfg.Private,
 	}, nil
 }
 
 func (s *LocalStorage) Upload(ctx context.Context, data []byte, filename string, contentType string) (string, error) {
-	// 本地存储实现略，开发环境可用
-	return "", fmt.Errorf("本地存储暂未完整实现")
+	if len(data) == 0 {
+		return "", fmt.Errorf("文件内容为空")
+	}
+	if contentType == "" {
+		contentType = detectContentType(data)
+	}
+
+	key := s.generateKey(data, filename, contentType)
+	fullPath := filepath.Join(s.basePath, filepath.FromSlash(key))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 内容寻址：已存在即为相同内容，直接复用并增加引用
+	if _, err := os.Stat(fullPath); err == nil {
+		refs, err := s.readRefs(key)
+		if err != nil {
+			return "", err
+		}
+		if err := s.writeRefs(key, refs+1); err != nil {
+			return "", err
+		}
+		return s.getPublicURL(key), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	// 先写临时文件再 rename，避免并发读到半截文件
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("保存文件失败: %v", err)
+	}
+	if err := s.writeRefs(key, 1); err != nil {
+		return "", err
+	}
+
+	return s.getPublicURL(key), nil
 }
 
 func (s *LocalStorage) UploadFromURL(ctx context.Context, sourceURL string, filename string) (string, error) {
@@ -404,11 +496,157 @@ func (s *LocalStorage) UploadFromURL(ctx context.Context, sourceURL string, file
 }
 
 func (s *LocalStorage) Delete(ctx context.Context, url string) error {
+	key := s.extractKey(url)
+	if key == "" {
+		return fmt.Errorf("无法解析文件路径")
+	}
+
+	fullPath, err := s.ResolvePath(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 相同内容被多次上传（如多个商品共用一张图）时只减少引用
+	refs, err := s.readRefs(key)
+	if err != nil {
+		return err
+	}
+	if refs > 1 {
+		return s.writeRefs(key, refs-1)
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件失败: %v", err)
+	}
+	if err := os.Remove(s.refPath(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除引用计数失败: %v", err)
+	}
+	return nil
+}
+
+// refPath 引用计数文件路径
+func (s *LocalStorage) refPath(key string) string {
+	return filepath.Join(s.basePath, localRefDir, filepath.FromSlash(key))
+}
+
+// readRefs 读取引用计数；无计数文件（旧文件）按 1 处理
+func (s *LocalStorage) readRefs(key string) (int, error) {
+	raw, err := os.ReadFile(s.refPath(key))
+	if os.IsNotExist(err) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("读取引用计数失败: %v", err)
+	}
+	refs, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil || refs < 1 {
+		return 1, nil
+	}
+	return refs, nil
+}
+
+func (s *LocalStorage) writeRefs(key string, refs int) error {
+	refPath := s.refPath(key)
+	if err := os.MkdirAll(filepath.Dir(refPath), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(refPath, []byte(strconv.Itoa(refs)), 0o644); err != nil {
+		return fmt.Errorf("写入引用计数失败: %v", err)
+	}
 	return nil
 }
 
 func (s *LocalStorage) GetSignedURL(ctx context.Context, url string, expires time.Duration) (string, error) {
-	return url, nil // 本地存储无需签名
+	key := s.extractKey(url)
+	if key == "" {
+		return "", fmt.Errorf("无法解析文件路径")
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+	return fmt.Sprintf("%s?expires=%d&sig=%s", s.getPublicURL(key), expiresAt, s.sign(key, expiresAt)), nil
+}
+
+// RoutePrefix 静态文件路由前缀（取自 baseURL 的 path 部分）
+func (s *LocalStorage) RoutePrefix() string {
+	u, err := neturl.Parse(s.baseURL)
+	if err != nil || u.Path == "" {
+		return "/uploads"
+	}
+	return u.Path
+}
+
+// VerifyAccess 校验访问签名
+// 未携带签名时：公开存储放行，私有存储拒绝
+func (s *LocalStorage) VerifyAccess(key, expires, sig string) error {
+	if sig == "" && expires == "" {
+		if s.private {
+			return fmt.Errorf("缺少访问签名")
+		}
+		return nil
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的过期时间")
+	}
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("访问链接已过期")
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(key, expiresAt))) {
+		return fmt.Errorf("签名无效")
+	}
+	return nil
+}
+
+// ResolvePath 将 Key 解析为磁盘路径（拒绝越界访问与 . 开头的内部文件）
+func (s *LocalStorage) ResolvePath(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key || strings.HasPrefix(key, ".") || strings.Contains(key, "/.") {
+		return "", fmt.Errorf("非法文件路径")
+	}
+	return filepath.Join(s.basePath, filepath.FromSlash(strings.TrimPrefix(clean, "/"))), nil
+}
+
+func (s *LocalStorage) sign(key string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(fmt.Sprintf("%s:%d", key, expiresAt)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateKey 内容寻址 Key：ab/cd/<sha256><ext>
+func (s *LocalStorage) generateKey(data []byte, filename, contentType string) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		} else {
+			ext = ".jpg"
+		}
+	}
+
+	return fmt.Sprintf("%s/%s/%s%s", hash[:2], hash[2:4], hash, ext)
+}
+
+func (s *LocalStorage) getPublicURL(key string) string {
+	return fmt.Sprintf("%s/%s", s.baseURL, key)
+}
+
+func (s *LocalStorage) extractKey(url string) string {
+	if !strings.HasPrefix(url, s.baseURL+"/") {
+		return ""
+	}
+	key := strings.TrimPrefix(url, s.baseURL+"/")
+	if idx := strings.Index(key, "?"); idx != -1 {
+		key = key[:idx]
+	}
+	return key
 }
 
 // ==================== 工具函数 ====================
@@ -0,0 +1,238 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"etsy_dev_v1_202512/internal/model"
+	"etsy_dev_v1_202512/internal/repository"
+	"etsy_dev_v1_202512/internal/service"
+)
+
+// ==================== DraftJobWorker 草稿 AI 处理队列 ====================
+
+// DraftJobWorker 从 draft_jobs 领取任务并执行
+// 租约 + 心跳保证同一任务同一时刻只有一个 Worker 执行；进程退出后租约过期，任务会被重新领取
+type DraftJobWorker struct {
+	jobRepo      repository.DraftJobRepository
+	draftService *service.DraftService
+	owner        string
+
+	concurrency   int
+	pollInterval  time.Duration
+	leaseDuration time.Duration
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDraftJobWorker 创建草稿队列 Worker
+func NewDraftJobWorker(jobRepo repository.DraftJobRepository, draftService *service.DraftService) *DraftJobWorker {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &DraftJobWorker{
+		jobRepo:       jobRepo,
+		draftService:  draftService,
+		owner:         fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8]),
+		concurrency:   3,                // 同时处理的任务数（每个任务内部按店铺并发）
+		pollInterval:  2 * time.Second,  // 轮询间隔
+		leaseDuration: 60 * time.Second, // 租约时长，心跳间隔为其 1/3
+		backoffBase:   30 * time.Second, // 重试退避基数（指数增长）
+		backoffMax:    10 * time.Minute, // 重试退避上限
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// SetConcurrency 设置并发数
+func (w *DraftJobWorker) SetConcurrency(n int) {
+	if n > 0 {
+		w.concurrency = n
+	}
+}
+
+// SetPollInterval 设置轮询间隔
+func (w *DraftJobWorker) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		w.pollInterval = d
+	}
+}
+
+// SetBackoff 设置重试退避基数与上限
+func (w *DraftJobWorker) SetBackoff(base, limit time.Duration) {
+	if base > 0 {
+		w.backoffBase = base
+	}
+	if limit > 0 {
+		w.backoffMax = limit
+	}
+}
+
+// Start 启动 Worker（先恢复孤儿任务）
+func (w *DraftJobWorker) Start() {
+	ctx, cancel := context.WithTimeout(w.ctx, time.Minute)
+	if err := w.draftService.RecoverOrphans(ctx); err != nil {
+		log.Printf("[DraftJobWorker] 孤儿任务恢复失败: %v", err)
+	}
+	cancel()
+
+	w.wg.Add(1)
+	go w.loop()
+
+	log.Printf("[DraftJobWorker] 已启动 (owner=%s, 并发=%d)", w.owner, w.concurrency)
+}
+
+// Stop 停止领取新任务，并等待执行中的任务释放租约
+func (w *DraftJobWorker) Stop() {
+	w.cancel()
+	w.wg.Wait()
+	log.Println("[DraftJobWorker] 已停止")
+}
+
+// loop 轮询领取任务
+func (w *DraftJobWorker) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	sem := make(chan struct{}, w.concurrency)
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			// 已达最大执行次数的过期任务不会被领取，需定期放弃
+			if err := w.draftService.RecoverExpiredJobs(w.ctx); err != nil && w.ctx.Err() == nil {
+				log.Printf("[DraftJobWorker] 处理过期租约失败: %v", err)
+			}
+		case <-w.draftService.JobReady():
+		}
+
+		free := cap(sem) - len(sem)
+		if free <= 0 {
+			continue
+		}
+
+		jobs, err := w.jobRepo.Acquire(w.ctx, w.owner, w.leaseDuration, free)
+		if err != nil {
+			if w.ctx.Err() == nil {
+				log.Printf("[DraftJobWorker] 领取任务失败: %v", err)
+			}
+			continue
+		}
+
+		for i := range jobs {
+			job := jobs[i]
+			sem <- struct{}{}
+			w.wg.Add(1)
+			go func() {
+				defer w.wg.Done()
+				defer func() { <-sem }()
+				w.run(&job)
+			}()
+		}
+	}
+}
+
+// run 执行单个任务并根据结果完成 / 重试 / 放弃
+func (w *DraftJobWorker) run(job *model.DraftJob) {
+	jobCtx, cancel := context.WithCancel(w.ctx)
+	leaseLost := make(chan struct{})
+	go w.heartbeat(jobCtx, cancel, job.ID, leaseLost)
+
+	err := w.draftService.ProcessJob(jobCtx, job)
+	cancel()
+
+	// 状态回写不受 Worker 停止影响
+	ctx, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+
+	select {
+	case <-leaseLost:
+		err = repository.ErrDraftJobLeaseLost
+	default:
+	}
+	if errors.Is(err, repository.ErrDraftJobLeaseLost) {
+		log.Printf("[DraftJobWorker] 任务 %d 租约丢失，放弃本次执行", job.TaskID)
+		return
+	}
+
+	switch {
+	case err == nil:
+		if err := w.jobRepo.Complete(ctx, job.ID, w.owner); err != nil {
+			log.Printf("[DraftJobWorker] 任务 %d 完成状态回写失败: %v", job.TaskID, err)
+		}
+
+	case w.ctx.Err() != nil:
+		// 服务关闭：立即释放租约，由下一个 Worker 续跑
+		if err := w.jobRepo.Retry(ctx, job.ID, w.owner, "服务关闭，任务重新排队", time.Now()); err != nil {
+			log.Printf("[DraftJobWorker] 任务 %d 释放租约失败: %v", job.TaskID, err)
+		}
+
+	case service.IsPermanentDraftError(err) || job.Attempts >= job.MaxAttempts:
+		log.Printf("[DraftJobWorker] 任务 %d 失败且不再重试 (第 %d/%d 次): %v", job.TaskID, job.Attempts, job.MaxAttempts, err)
+		if killErr := w.jobRepo.Kill(ctx, job.ID, w.owner, err.Error()); killErr != nil {
+			log.Printf("[DraftJobWorker] 任务 %d 状态回写失败: %v", job.TaskID, killErr)
+			return
+		}
+		w.draftService.FailTask(ctx, job.TaskID, err.Error())
+
+	default:
+		nextRunAt := time.Now().Add(w.backoff(job.Attempts))
+		log.Printf("[DraftJobWorker] 任务 %d 失败，%s 重试 (第 %d/%d 次): %v",
+			job.TaskID, nextRunAt.Format(time.RFC3339), job.Attempts, job.MaxAttempts, err)
+		if retryErr := w.jobRepo.Retry(ctx, job.ID, w.owner, err.Error(), nextRunAt); retryErr != nil {
+			log.Printf("[DraftJobWorker] 任务 %d 状态回写失败: %v", job.TaskID, retryErr)
+			return
+		}
+		w.draftService.MarkJobRetrying(ctx, job, err.Error(), nextRunAt)
+	}
+}
+
+// heartbeat 定时续租；租约丢失时取消任务执行
+func (w *DraftJobWorker) heartbeat(ctx context.Context, cancel context.CancelFunc, jobID int64, leaseLost chan<- struct{}) {
+	ticker := time.NewTicker(w.leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.jobRepo.Heartbeat(ctx, jobID, w.owner, w.leaseDuration)
+			if errors.Is(err, repository.ErrDraftJobLeaseLost) {
+				close(leaseLost)
+				cancel()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Printf("[DraftJobWorker] 任务队列 %d 心跳失败: %v", jobID, err)
+			}
+		}
+	}
+}
+
+// backoff 指数退避：base * 2^(attempts-1)，不超过上限
+func (w *DraftJobWorker) backoff(attempts int) time.Duration {
+	d := w.backoffBase
+	for i := 1; i < attempts && d < w.backoffMax; i++ {
+		d *= 2
+	}
+	if d > w.backoffMax {
+		d = w.backoffMax
+	}
+	return d
+}
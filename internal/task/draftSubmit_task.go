@@ -1,18 +1,19 @@
 package task
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
-	"etsy_dev_v1_202512/pkg/net"
+	"etsy_dev_v1_202512/internal/service"
+	"etsy_dev_v1_202512/pkg/etsy"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/robfig/cron/v3"
 )
@@ -29,6 +30,60 @@ type StorageProvider interface {
 	Delete(ctx context.Context, url string) error
 }
 
+// ==================== 提交错误分类 ====================
+
+// submitError 提交失败原因，retryable 区分可重试（5xx/429/网络/代理）与不可重试（4xx 校验）错误
+type submitError struct {
+	err       error
+	retryable bool
+	dead      bool // Etsy 已产生副作用但本地未记录，直接进入死信
+}
+
+func (e *submitError) Error() string { return e.err.Error() }
+func (e *submitError) Unwrap() error { return e.err }
+
+func retryableSubmitErr(format string, args ...interface{}) error {
+	return &submitError{err: fmt.Errorf(format, args...), retryable: true}
+}
+
+func permanentSubmitErr(format string, args ...interface{}) error {
+	return &submitError{err: fmt.Errorf(format, args...), retryable: false}
+}
+
+// deadSubmitErr Etsy 已创建资源但本地记录失败：重试会重复创建，需人工核对
+func deadSubmitErr(format string, args ...interface{}) error {
+	return &submitError{err: fmt.Errorf(format, args...), dead: true}
+}
+
+func isDeadSubmitErr(err error) bool {
+	var se *submitError
+	return errors.As(err, &se) && se.dead
+}
+
+// isRetryableSubmitErr 未分类的错误（数据库等）按可重试处理
+func isRetryableSubmitErr(err error) bool {
+	var se *submitError
+	if errors.As(err, &se) {
+		return se.retryable
+	}
+	return true
+}
+
+// etsySubmitErr 按 Etsy 调用结果分类
+// 网络 / 代理错误、429 / 5xx 可重试；401 可能是 Token 过期（等待 Token 刷新任务）也按可重试；其余 4xx 为参数校验错误
+// 2xx 但响应无法解析时 Etsy 已创建资源，重试会重复创建，需人工核对
+func etsySubmitErr(err error) error {
+	if errors.Is(err, etsy.ErrDecodeResponse) {
+		return &submitError{err: err, retryable: false}
+	}
+	etsyErr, ok := etsy.AsEtsyError(err)
+	if !ok {
+		return &submitError{err: err, retryable: true}
+	}
+	retryable := etsyErr.Retryable() || etsyErr.StatusCode == http.StatusUnauthorized
+	return &submitError{err: err, retryable: retryable}
+}
+
 // ==================== DraftSubmitTask 草稿提交任务 ====================
 
 // DraftSubmitTask 定时扫描已确认的草稿并提交到 Etsy
@@ -36,15 +91,22 @@ type DraftSubmitTask struct {
 	draftProductRepo repository.DraftProductRepository
 	draftTaskRepo    repository.DraftTaskRepository
 	draftImageRepo   repository.DraftImageRepository
+	imageUploadRepo  repository.DraftImageUploadRepository
 	productRepo      repository.ProductRepository
 	shopRepo         repository.ShopRepository
-	dispatcher       net.Dispatcher
+	etsyClient       *etsy.Client
 	notifier         Notifier
 	cron             *cron.Cron
 
 	// 并发控制
 	concurrencyLimit int
 	sleepTime        time.Duration
+
+	// 重试策略
+	maxAttempts int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	submitLease time.Duration
 }
 
 // NewDraftSubmitTask 创建草稿提交任务
@@ -52,22 +114,28 @@ func NewDraftSubmitTask(
 	draftProductRepo repository.DraftProductRepository,
 	draftTaskRepo repository.DraftTaskRepository,
 	draftImageRepo repository.DraftImageRepository,
+	imageUploadRepo repository.DraftImageUploadRepository,
 	productRepo repository.ProductRepository,
 	shopRepo repository.ShopRepository,
-	dispatcher net.Dispatcher,
+	etsyClient *etsy.Client,
 	notifier Notifier,
 ) *DraftSubmitTask {
 	return &DraftSubmitTask{
 		draftProductRepo: draftProductRepo,
 		draftTaskRepo:    draftTaskRepo,
 		draftImageRepo:   draftImageRepo,
+		imageUploadRepo:  imageUploadRepo,
 		productRepo:      productRepo,
 		shopRepo:         shopRepo,
-		dispatcher:       dispatcher,
+		etsyClient:       etsyClient,
 		notifier:         notifier,
 		cron:             cron.New(cron.WithSeconds()),
 		concurrencyLimit: 5,                      // 草稿提交并发上限（API 限制）
 		sleepTime:        200 * time.Millisecond, // 协程启动间隔
+		maxAttempts:      5,                      // 最大提交次数，超过进入死信
+		backoffBase:      time.Minute,            // 重试退避基数（指数增长）
+		backoffMax:       time.Hour,              // 重试退避上限
+		submitLease:      10 * time.Minute,       // 处理中租约，进程中断后到期自动重新领取
 	}
 }
 
@@ -77,6 +145,19 @@ func (t *DraftSubmitTask) SetConcurrency(limit int, sleep time.Duration) {
 	t.sleepTime = sleep
 }
 
+// SetRetryPolicy 设置重试策略
+func (t *DraftSubmitTask) SetRetryPolicy(maxAttempts int, backoffBase, backoffMax time.Duration) {
+	if maxAttempts > 0 {
+		t.maxAttempts = maxAttempts
+	}
+	if backoffBase > 0 {
+		t.backoffBase = backoffBase
+	}
+	if backoffMax > 0 {
+		t.backoffMax = backoffMax
+	}
+}
+
 // Start 启动定时任务
 func (t *DraftSubmitTask) Start() {
 	// 定时策略：每分钟执行
@@ -101,6 +182,11 @@ func (t *DraftSubmitTask) Stop() {
 	log.Println("[DraftSubmitTask] 已停止")
 }
 
+// SubmitNow 立即执行一轮提交（同步执行，供手动触发）
+func (t *DraftSubmitTask) SubmitNow(ctx context.Context) {
+	t.execute(ctx)
+}
+
 // execute 执行一次任务
 func (t *DraftSubmitTask) execute(ctx context.Context) {
 	// 查询待提交的草稿商品
@@ -136,6 +222,13 @@ func (t *DraftSubmitTask) execute(ctx context.Context) {
 		default:
 		}
 
+		// 领取失败说明已被其他轮次/实例处理
+		claimed, err := t.draftProductRepo.ClaimSubmit(ctx, draft.ID, time.Now().Add(t.submitLease))
+		if err != nil || !claimed {
+			continue
+		}
+		draft.SubmitAttempts++
+
 		sem <- struct{}{}
 		wg.Add(1)
 		time.Sleep(t.sleepTime)
@@ -145,13 +238,16 @@ func (t *DraftSubmitTask) execute(ctx context.Context) {
 			defer func() { <-sem }()
 
 			err := t.submitDraft(ctx, &d)
+			if err != nil {
+				t.handleFailure(ctx, &d, err)
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
 
 			if err != nil {
 				failCount++
-				log.Printf("[DraftSubmitTask] 草稿 %d 提交失败: %v", d.ID, err)
+				log.Printf("[DraftSubmitTask] 草稿 %d 提交失败 (第 %d/%d 次): %v", d.ID, d.SubmitAttempts, t.maxAttempts, err)
 			} else {
 				successCount++
 				log.Printf("[DraftSubmitTask] 草稿 %d 提交成功", d.ID)
@@ -165,81 +261,81 @@ func (t *DraftSubmitTask) execute(ctx context.Context) {
 
 // submitDraft 提交单个草稿到 Etsy
 func (t *DraftSubmitTask) submitDraft(ctx context.Context, draft *model.DraftProduct) error {
-	// 更新状态为提交中
-	t.draftProductRepo.UpdateSyncStatus(ctx, draft.ID, model.DraftSyncStatusPending)
-
 	// 1. 获取店铺信息
 	shop, err := t.shopRepo.GetByID(ctx, draft.ShopID)
 	if err != nil {
-		t.markFailed(ctx, draft, fmt.Sprintf("店铺不存在: %v", err))
-		return err
+		return permanentSubmitErr("店铺不存在: %v", err)
 	}
 
+	// Token 刷新任务可能恢复授权，按可重试处理
 	if shop.TokenStatus != model.ShopTokenStatusValid {
-		errMsg := "店铺授权已失效"
-		t.markFailed(ctx, draft, errMsg)
-		return fmt.Errorf(errMsg)
+		return retryableSubmitErr("店铺授权已失效")
 	}
 
 	// 2. 获取开发者信息
 	developer, err := t.shopRepo.GetDeveloperByShopID(ctx, shop.ID)
 	if err != nil {
-		t.markFailed(ctx, draft, fmt.Sprintf("开发者不存在: %v", err))
-		return err
-	}
-
-	// 3. 创建 Etsy 草稿 Listing
-	listingID, err := t.createEtsyListing(ctx, shop, developer, draft)
-	if err != nil {
-		t.markFailed(ctx, draft, fmt.Sprintf("创建Listing失败: %v", err))
-		return err
+		return permanentSubmitErr("开发者不存在: %v", err)
 	}
 
-	// 4. 上传图片（需要先有 listing）
-	if len(draft.SelectedImages) > 0 {
-		for i, imgURL := range draft.SelectedImages {
-			_, err := t.uploadImage(ctx, shop, developer, listingID, imgURL, i+1)
-			if err != nil {
-				log.Printf("[DraftSubmitTask] 图片上传失败: %v", err)
-				// 继续处理，不中断
-			}
+	// 3. 创建 Etsy 草稿 Listing（重试时复用已创建的 Listing，避免重复创建）
+	if draft.ListingID == 0 {
+		listingID, err := t.createEtsyListing(ctx, shop, developer, draft)
+		if err != nil {
+			return fmt.Errorf("创建Listing失败: %w", err)
+		}
+		// Listing 已在 Etsy 创建，即使本轮被取消也要落库，否则重试会重复创建
+		if err := t.draftProductRepo.UpdateListingID(context.WithoutCancel(ctx), draft.ID, listingID); err != nil {
+			return deadSubmitErr("Etsy Listing %d 已创建但保存失败，需人工核对: %v", listingID, err)
+		}
+		draft.ListingID = listingID
+	}
+
+	// 4. 创建正式 Product 记录（图片需关联）
+	if draft.ProductID == 0 {
+		product := &model.Product{
+			ShopID:            draft.ShopID,
+			ListingID:         draft.ListingID,
+			Title:             draft.Title,
+			Description:       draft.Description,
+			Tags:              draft.Tags,
+			State:             model.ProductStateDraft,
+			PriceAmount:       draft.PriceAmount,
+			PriceDivisor:      draft.PriceDivisor,
+			CurrencyCode:      draft.CurrencyCode,
+			Quantity:          draft.Quantity,
+			TaxonomyID:        draft.TaxonomyID,
+			ShippingProfileID: draft.ShippingProfileID,
+			ReturnPolicyID:    draft.ReturnPolicyID,
+			SyncStatus:        int(model.ProductSyncStatusSynced),
+		}
+		if err := t.productRepo.Create(ctx, product); err != nil {
+			return fmt.Errorf("Product入库失败: %w", err)
 		}
+		if err := t.draftProductRepo.UpdateProductID(ctx, draft.ID, product.ID); err != nil {
+			return err
+		}
+		draft.ProductID = product.ID
 	}
 
-	// 5. 更新草稿状态
-	t.draftProductRepo.MarkSubmitted(ctx, draft.ID, listingID)
-
-	// 6. 创建正式 Product 记录
-	product := &model.Product{
-		ShopID:            draft.ShopID,
-		ListingID:         listingID,
-		Title:             draft.Title,
-		Description:       draft.Description,
-		Tags:              draft.Tags,
-		State:             model.ProductStateDraft,
-		PriceAmount:       draft.PriceAmount,
-		PriceDivisor:      draft.PriceDivisor,
-		CurrencyCode:      draft.CurrencyCode,
-		Quantity:          draft.Quantity,
-		TaxonomyID:        draft.TaxonomyID,
-		ShippingProfileID: draft.ShippingProfileID,
-		ReturnPolicyID:    draft.ReturnPolicyID,
-		SyncStatus:        int(model.ProductSyncStatusSynced),
+	// 5. 上传图片（逐张记录状态，已成功的不重传）
+	if err := t.uploadImages(ctx, shop, developer, draft); err != nil {
+		return err
 	}
-	if err := t.productRepo.Create(ctx, product); err != nil {
-		log.Printf("[DraftSubmitTask] Product入库失败: %v", err)
-	} else {
-		t.draftProductRepo.UpdateProductID(ctx, draft.ID, product.ID)
+
+	// 6. 所有选中图片上传成功后才标记为已提交
+	if err := t.draftProductRepo.MarkSubmitted(ctx, draft.ID, draft.ListingID); err != nil {
+		return err
 	}
 
 	// 7. 通知用户
 	if t.notifier != nil {
 		task, _ := t.draftTaskRepo.GetByID(ctx, draft.TaskID)
 		if task != nil {
-			t.notifier.NotifyUser(task.UserID, "draft_submitted", map[string]interface{}{
+			t.notifier.NotifyUser(task.UserID, model.NotificationEventDraftSubmitted, map[string]interface{}{
 				"draft_id":   draft.ID,
-				"product_id": product.ID,
-				"listing_id": listingID,
+				"product_id": draft.ProductID,
+				"listing_id": draft.ListingID,
 				"shop_id":    draft.ShopID,
 			})
 		}
@@ -248,67 +344,155 @@ func (t *DraftSubmitTask) submitDraft(ctx context.Context, draft *model.DraftPro
 	return nil
 }
 
-// createEtsyListing 调用 Etsy API 创建草稿
-func (t *DraftSubmitTask) createEtsyListing(
+// uploadImages 上传草稿选中的图片，并为成功的图片创建 ProductImage
+// 任一图片失败时返回错误（有不可重试错误时整体不可重试），成功的图片下次不再上传
+func (t *DraftSubmitTask) uploadImages(
 	ctx context.Context,
 	shop *model.Shop,
 	developer *model.Developer,
 	draft *model.DraftProduct,
-) (int64, error) {
-	payload := map[string]interface{}{
-		"quantity":    draft.Quantity,
-		"title":       draft.Title,
-		"description": draft.Description,
-		"price": map[string]interface{}{
-			"amount":        draft.PriceAmount,
-			"divisor":       draft.PriceDivisor,
-			"currency_code": draft.CurrencyCode,
-		},
-		"taxonomy_id":         draft.TaxonomyID,
-		"shipping_profile_id": draft.ShippingProfileID,
-		"who_made":            "i_did",
-		"when_made":           "made_to_order",
-		"is_supply":           false,
+) error {
+	uploads, err := t.ensureImageUploads(ctx, draft)
+	if err != nil {
+		return err
 	}
 
-	if draft.ReturnPolicyID > 0 {
-		payload["return_policy_id"] = draft.ReturnPolicyID
-	}
-	if len(draft.Tags) > 0 {
-		payload["tags"] = []string(draft.Tags)
-	}
+	var (
+		failed    int
+		lastErr   error
+		permanent bool
+	)
+	for _, up := range uploads {
+		if up.Status == model.ImageUploadStatusUploaded {
+			continue
+		}
 
-	apiURL := fmt.Sprintf("https://openapi.etsy.com/v3/application/shops/%d/listings", shop.EtsyShopID)
-	bodyBytes, _ := json.Marshal(payload)
+		// 上次已传到 Etsy 但未入库的图片不再重传
+		imageID, etsyURL := up.EtsyImageID, up.EtsyImageURL
+		if imageID == 0 {
+			var err error
+			imageID, etsyURL, err = t.uploadImage(ctx, shop, developer, draft.ListingID, up.SourceURL, up.Rank)
+			if err != nil {
+				failed++
+				lastErr = err
+				if !isRetryableSubmitErr(err) {
+					permanent = true
+				}
+				t.imageUploadRepo.MarkFailed(ctx, up.ID, truncateErrMsg(err.Error()))
+				log.Printf("[DraftSubmitTask] 草稿 %d 图片 %d 上传失败: %v", draft.ID, up.Rank, err)
+				continue
+			}
+			if err := t.imageUploadRepo.MarkEtsyUploaded(context.WithoutCancel(ctx), up.ID, imageID, etsyURL); err != nil {
+				return deadSubmitErr("Etsy Listing %d 图片 %d 已上传但保存失败，需人工核对: %v", draft.ListingID, imageID, err)
+			}
+		}
 
-	req, err := net.BuildEtsyRequest(ctx, http.MethodPost, apiURL, bytes.NewReader(bodyBytes), developer.ApiKey, shop.AccessToken)
-	if err != nil {
-		return 0, err
-	}
+		productImage := &model.ProductImage{
+			ProductID:     draft.ProductID,
+			EtsyImageID:   imageID,
+			Rank:          up.Rank,
+			EtsyUrl:       etsyURL,
+			IsAiGenerated: true,
+			SyncStatus:    int(model.ProductSyncStatusSynced),
+		}
+		if len(up.SourceURL) <= 255 {
+			productImage.LocalPath = up.SourceURL
+		}
+		if err := t.productRepo.CreateImage(ctx, productImage); err != nil {
+			failed++
+			lastErr = retryableSubmitErr("ProductImage入库失败: %v", err)
+			t.imageUploadRepo.MarkFailed(ctx, up.ID, truncateErrMsg(lastErr.Error()))
+			log.Printf("[DraftSubmitTask] 草稿 %d 图片 %d 入库失败: %v", draft.ID, up.Rank, err)
+			continue
+		}
 
-	resp, err := t.dispatcher.Send(ctx, shop.ID, req)
-	if err != nil {
-		return 0, err
+		if err := t.imageUploadRepo.MarkUploaded(context.WithoutCancel(ctx), up.ID, imageID, productImage.ID); err != nil {
+			return deadSubmitErr("Etsy Listing %d 图片 %d 已上传但保存失败，需人工核对: %v", draft.ListingID, imageID, err)
+		}
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
+	if failed > 0 {
+		return &submitError{
+			err:       fmt.Errorf("%d/%d 张图片上传失败: %w", failed, len(uploads), lastErr),
+			retryable: !permanent,
+		}
+	}
+	return nil
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		return 0, fmt.Errorf("Etsy API 错误 [%d]: %s", resp.StatusCode, string(respBody))
+// ensureImageUploads 按选中图片生成上传记录（排序从 1 开始）
+// 用户修改了未上传成功位置的图片时，重置该位置的记录
+func (t *DraftSubmitTask) ensureImageUploads(ctx context.Context, draft *model.DraftProduct) ([]model.DraftImageUpload, error) {
+	existing, err := t.imageUploadRepo.GetByDraftProductID(ctx, draft.ID)
+	if err != nil {
+		return nil, err
+	}
+	byRank := make(map[int]model.DraftImageUpload, len(existing))
+	for _, up := range existing {
+		byRank[up.Rank] = up
+	}
+
+	uploads := make([]model.DraftImageUpload, 0, len(draft.SelectedImages))
+	for i, imgURL := range draft.SelectedImages {
+		rank := i + 1
+		up, ok := byRank[rank]
+		switch {
+		case !ok:
+			up = model.DraftImageUpload{
+				DraftProductID: draft.ID,
+				Rank:           rank,
+				SourceURL:      imgURL,
+				Status:         model.ImageUploadStatusPending,
+			}
+			if err := t.imageUploadRepo.Create(ctx, &up); err != nil {
+				return nil, err
+			}
+		case up.SourceURL != imgURL && up.Status != model.ImageUploadStatusUploaded:
+			if err := t.imageUploadRepo.ResetSource(ctx, up.ID, imgURL); err != nil {
+				return nil, err
+			}
+			up.SourceURL = imgURL
+			up.Status = model.ImageUploadStatusPending
+		}
+		uploads = append(uploads, up)
 	}
+	return uploads, nil
+}
 
-	var result struct {
-		ListingID int64 `json:"listing_id"`
+// createEtsyListing 调用 Etsy API 创建草稿
+func (t *DraftSubmitTask) createEtsyListing(
+	ctx context.Context,
+	shop *model.Shop,
+	developer *model.Developer,
+	draft *model.DraftProduct,
+) (int64, error) {
+	etsyReq := etsy.EtsyListingCreateReq{
+		Quantity:    draft.Quantity,
+		Title:       draft.Title,
+		Description: draft.Description,
+		Price: etsy.PriceDTO{
+			Amount:       draft.PriceAmount,
+			Divisor:      draft.PriceDivisor,
+			CurrencyCode: draft.CurrencyCode,
+		},
+		TaxonomyID:        draft.TaxonomyID,
+		ShippingProfileID: draft.ShippingProfileID,
+		WhoMade:           "i_did",
+		WhenMade:          "made_to_order",
+		IsSupply:          false,
+		ReturnPolicyID:    draft.ReturnPolicyID,
+		Tags:              []string(draft.Tags),
 	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return 0, err
+
+	listing, err := t.etsyClient.CreateDraftListing(ctx, service.EtsyCredentials(shop, developer), etsyReq)
+	if err != nil {
+		return 0, etsySubmitErr(err)
 	}
 
-	return result.ListingID, nil
+	return listing.ListingID, nil
 }
 
-// uploadImage 上传图片到 Etsy
+// uploadImage 上传图片到 Etsy，返回 listing_image_id 与 Etsy CDN 地址
 func (t *DraftSubmitTask) uploadImage(
 	ctx context.Context,
 	shop *model.Shop,
@@ -316,65 +500,108 @@ func (t *DraftSubmitTask) uploadImage(
 	listingID int64,
 	imageURL string,
 	rank int,
-) (int64, error) {
+) (int64, string, error) {
 	// 1. 下载图片
-	imgResp, err := http.Get(imageURL)
+	dlReq, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
 	if err != nil {
-		return 0, fmt.Errorf("下载图片失败: %v", err)
+		return 0, "", permanentSubmitErr("图片地址无效: %v", err)
 	}
-	defer imgResp.Body.Close()
-
-	imageData, err := io.ReadAll(imgResp.Body)
+	imgResp, err := http.DefaultClient.Do(dlReq)
 	if err != nil {
-		return 0, fmt.Errorf("读取图片失败: %v", err)
+		return 0, "", retryableSubmitErr("下载图片失败: %v", err)
 	}
+	defer imgResp.Body.Close()
 
-	// 2. 构建 multipart 请求
-	apiURL := fmt.Sprintf("https://openapi.etsy.com/v3/application/shops/%d/listings/%d/images",
-		shop.EtsyShopID, listingID)
+	if imgResp.StatusCode == http.StatusNotFound {
+		return 0, "", permanentSubmitErr("下载图片失败: 图片不存在")
+	}
+	if imgResp.StatusCode != http.StatusOK {
+		return 0, "", retryableSubmitErr("下载图片失败 [%d]", imgResp.StatusCode)
+	}
 
-	multipartReq := &net.MultipartRequest{
-		URL: apiURL,
-		Headers: map[string]string{
-			"x-api-key":     developer.ApiKey,
-			"Authorization": "Bearer " + shop.AccessToken,
-		},
-		Files: map[string]net.FileData{
-			"image": {
-				Data:     imageData,
-				Filename: fmt.Sprintf("image_%d.jpg", rank),
-			},
-		},
-		Fields: map[string]string{
-			"rank": fmt.Sprintf("%d", rank),
-		},
+	imageData, err := io.ReadAll(imgResp.Body)
+	if err != nil {
+		return 0, "", retryableSubmitErr("读取图片失败: %v", err)
 	}
 
-	resp, err := t.dispatcher.SendMultipart(ctx, shop.ID, multipartReq)
+	// 2. 上传到 Etsy
+	image, err := t.etsyClient.UploadListingImage(ctx, service.EtsyCredentials(shop, developer), listingID, etsy.EtsyListingImageUploadReq{
+		Data:     imageData,
+		Filename: fmt.Sprintf("image_%d.jpg", rank),
+		Rank:     rank,
+	})
 	if err != nil {
-		return 0, err
+		return 0, "", etsySubmitErr(err)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
+	return image.ListingImageID, image.URLFullxFull, nil
+}
+
+// handleFailure 按错误类型与尝试次数：退避重试 / 标记失败 / 进入死信
+func (t *DraftSubmitTask) handleFailure(ctx context.Context, draft *model.DraftProduct, err error) {
+	// 本轮超时也要回写状态，否则需等待租约到期
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	errMsg := truncateErrMsg(err.Error())
+
+	switch {
+	case isDeadSubmitErr(err):
+		t.draftProductRepo.MarkDead(ctx, draft.ID, errMsg)
+		t.notifyFailure(ctx, draft, model.NotificationEventDraftSubmitDead, errMsg)
 
-	if resp.StatusCode != http.StatusCreated {
-		return 0, fmt.Errorf("上传图片失败 [%d]: %s", resp.StatusCode, string(respBody))
+	case !isRetryableSubmitErr(err):
+		t.draftProductRepo.MarkFailed(ctx, draft.ID, errMsg)
+		t.notifyFailure(ctx, draft, model.NotificationEventDraftSubmitFailed, errMsg)
+
+	case draft.SubmitAttempts >= t.maxAttempts:
+		t.draftProductRepo.MarkDead(ctx, draft.ID, errMsg)
+		t.notifyFailure(ctx, draft, model.NotificationEventDraftSubmitDead, errMsg)
+
+	default:
+		t.draftProductRepo.ScheduleRetry(ctx, draft.ID, errMsg, time.Now().Add(t.backoff(draft.SubmitAttempts)))
 	}
+}
 
-	var result struct {
-		ListingImageID int64 `json:"listing_image_id"`
+// notifyFailure 通知用户草稿需要人工处理
+func (t *DraftSubmitTask) notifyFailure(ctx context.Context, draft *model.DraftProduct, event, errMsg string) {
+	if t.notifier == nil {
+		return
 	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return 0, err
+	task, _ := t.draftTaskRepo.GetByID(ctx, draft.TaskID)
+	if task == nil {
+		return
 	}
+	t.notifier.NotifyUser(task.UserID, event, map[string]interface{}{
+		"draft_id": draft.ID,
+		"shop_id":  draft.ShopID,
+		"attempts": draft.SubmitAttempts,
+		"error":    errMsg,
+	})
+}
 
-	return result.ListingImageID, nil
+// truncateErrMsg 截断错误信息以适配 1024 长度的错误字段（不截断 UTF-8 字符）
+func truncateErrMsg(msg string) string {
+	if len(msg) <= 1024 {
+		return msg
+	}
+	n := 1024
+	for n > 0 && !utf8.RuneStart(msg[n]) {
+		n--
+	}
+	return msg[:n]
 }
 
-// markFailed 标记失败
-func (t *DraftSubmitTask) markFailed(ctx context.Context, draft *model.DraftProduct, errMsg string) {
-	t.draftProductRepo.MarkFailed(ctx, draft.ID, errMsg)
+// backoff 指数退避：base * 2^(attempts-1)，不超过上限
+func (t *DraftSubmitTask) backoff(attempts int) time.Duration {
+	d := t.backoffBase
+	for i := 1; i < attempts && d < t.backoffMax; i++ {
+		d *= 2
+	}
+	if d > t.backoffMax {
+		d = t.backoffMax
+	}
+	return d
 }
 
 // ==================== DraftCleanupTask 过期清理任务 ====================
@@ -24,6 +24,9 @@ type OrderSyncTask struct {
 	// 并发控制
 	concurrencyLimit int
 	sleepTime        time.Duration
+
+	notifier     service.ShopNotifier
+	quotaChecker QuotaChecker
 }
 
 // NewOrderSyncTask 创建订单同步任务
@@ -46,6 +49,16 @@ func (t *OrderSyncTask) SetConcurrency(limit int, sleep time.Duration) {
 	t.sleepTime = sleep
 }
 
+// SetNotifier 设置同步失败通知
+func (t *OrderSyncTask) SetNotifier(notifier service.ShopNotifier) {
+	t.notifier = notifier
+}
+
+// SetQuotaChecker 设置 API 配额检查（配额不足的店铺推迟同步）
+func (t *OrderSyncTask) SetQuotaChecker(checker QuotaChecker) {
+	t.quotaChecker = checker
+}
+
 // Start 启动定时任务
 func (t *OrderSyncTask) Start() {
 	// 首次执行
@@ -91,6 +104,8 @@ func (t *OrderSyncTask) syncAllShops(ctx context.Context) {
 		return
 	}
 
+	shops = deferLowQuotaShops(ctx, t.quotaChecker, shops, "OrderSyncTask")
+
 	if len(shops) == 0 {
 		log.Println("[OrderSyncTask] 无活跃店铺需要同步")
 		return
@@ -126,10 +141,13 @@ func (t *OrderSyncTask) syncAllShops(ctx context.Context) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
+			// 默认从检查点增量同步
 			resp, err := t.orderService.SyncOrders(ctx, &dto.SyncOrdersRequest{
-				ShopID:    shopID,
-				ForceSync: false,
+				ShopID: shopID,
 			})
+			if err != nil {
+				notifySyncFailed(ctx, t.notifier, shopID, "order", err)
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -162,10 +180,12 @@ func (t *OrderSyncTask) syncAllShops(ctx context.Context) {
 // ==================== 手动触发 ====================
 
 // SyncShopNow 立即同步单个店铺订单
-func (t *OrderSyncTask) SyncShopNow(ctx context.Context, shopID int64, forceSync bool) (*dto.SyncOrdersResponse, error) {
+// fullResync=true 时忽略检查点全量重新拉取；forceSync=true 时覆盖本地较新的订单，两者互不隐含
+func (t *OrderSyncTask) SyncShopNow(ctx context.Context, shopID int64, fullResync, forceSync bool) (*dto.SyncOrdersResponse, error) {
 	return t.orderService.SyncOrders(ctx, &dto.SyncOrdersRequest{
-		ShopID:    shopID,
-		ForceSync: forceSync,
+		ShopID:     shopID,
+		ForceSync:  forceSync,
+		FullResync: fullResync,
 	})
 }
 
@@ -27,6 +27,9 @@ type ProductSyncTask struct {
 	concurrencyLimit int
 	batchSize        int
 	sleepTime        time.Duration
+
+	notifier     service.ShopNotifier
+	quotaChecker QuotaChecker
 }
 
 // NewProductSyncTask 创建商品同步任务
@@ -51,6 +54,16 @@ func (t *ProductSyncTask) SetConcurrency(limit, batchSize int, sleep time.Durati
 	t.sleepTime = sleep
 }
 
+// SetNotifier 设置同步失败通知
+func (t *ProductSyncTask) SetNotifier(notifier service.ShopNotifier) {
+	t.notifier = notifier
+}
+
+// SetQuotaChecker 设置 API 配额检查（配额不足的店铺推迟同步）
+func (t *ProductSyncTask) SetQuotaChecker(checker QuotaChecker) {
+	t.quotaChecker = checker
+}
+
 // Start 启动定时任务
 func (t *ProductSyncTask) Start() {
 	// 首次执行（延迟 60 秒，等待店铺同步完成）
@@ -105,6 +118,8 @@ func (t *ProductSyncTask) syncAllShops(ctx context.Context, fullSync bool) {
 		return
 	}
 
+	shops = deferLowQuotaShops(ctx, t.quotaChecker, shops, "ProductSyncTask")
+
 	if len(shops) == 0 {
 		log.Println("[ProductSyncTask] 无活跃店铺需要同步")
 		return
@@ -142,6 +157,9 @@ func (t *ProductSyncTask) syncAllShops(ctx context.Context, fullSync bool) {
 			defer func() { <-sem }()
 
 			newCount, updatedCount, err := t.syncShopProducts(ctx, shopID)
+			if err != nil {
+				notifySyncFailed(ctx, t.notifier, shopID, "product", err)
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -106,5 +106,14 @@ func (m *ProxyMonitor) Execute(ctx context.Context) {
 	}
 
 	wg.Wait()
+
+	// 3. 巡检结果与期间的业务请求一并计算评分并落库
+	if err := m.proxyService.FlushMetrics(ctx); err != nil {
+		log.Printf("[ProxyMonitor] Failed to flush proxy scores: %v\n", err)
+	}
+	// 4. 检查多个代理是否共用同一出口 IP（店铺关联风险，结果记入日志）
+	if _, err := m.proxyService.DetectSharedExitIPs(ctx); err != nil {
+		log.Printf("[ProxyMonitor] Failed to detect shared exit IPs: %v\n", err)
+	}
 	log.Println("[ProxyMonitor] Check finished.")
 }
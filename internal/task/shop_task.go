@@ -32,6 +32,9 @@ type ShopSyncTask struct {
 	syncProfile bool
 	syncPolicy  bool
 	syncSection bool
+
+	notifier     service.ShopNotifier
+	quotaChecker QuotaChecker
 }
 
 // NewShopSyncTask 创建店铺同步任务
@@ -68,6 +71,16 @@ func (t *ShopSyncTask) SetSyncOptions(profile, policy, section bool) {
 	t.syncSection = section
 }
 
+// SetNotifier 设置同步失败通知
+func (t *ShopSyncTask) SetNotifier(notifier service.ShopNotifier) {
+	t.notifier = notifier
+}
+
+// SetQuotaChecker 设置 API 配额检查（配额不足的店铺推迟同步）
+func (t *ShopSyncTask) SetQuotaChecker(checker QuotaChecker) {
+	t.quotaChecker = checker
+}
+
 // Start 启动定时任务
 func (t *ShopSyncTask) Start() {
 	// 首次执行（延迟 30 秒）
@@ -114,6 +127,8 @@ func (t *ShopSyncTask) syncAllShops(ctx context.Context) {
 		return
 	}
 
+	shops = deferLowQuotaShops(ctx, t.quotaChecker, shops, "ShopSyncTask")
+
 	if len(shops) == 0 {
 		log.Println("[ShopSyncTask] 无活跃店铺需要同步")
 		return
@@ -146,6 +161,7 @@ func (t *ShopSyncTask) syncAllShops(ctx context.Context) {
 
 			if err := t.syncSingleShop(ctx, shopID); err != nil {
 				log.Printf("[ShopSyncTask] 店铺 %s(%d) 同步失败: %v", shopName, shopID, err)
+				notifySyncFailed(ctx, t.notifier, shopID, "shop", err)
 				mu.Lock()
 				failCount++
 				mu.Unlock()
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"etsy_dev_v1_202512/internal/api/dto"
+	"etsy_dev_v1_202512/internal/model"
 	"etsy_dev_v1_202512/internal/repository"
 	"etsy_dev_v1_202512/internal/service"
 )
@@ -35,6 +36,17 @@ type TaskManagerDeps struct {
 	ProductService  *service.ProductService
 	OrderService    *service.OrderService
 	ShipmentService ShipmentTracker
+
+	// 同步失败通知（可选）
+	Notifier service.ShopNotifier
+
+	// Etsy 配额检查（可选，配额偏低的开发者下的店铺推迟定时同步）
+	QuotaChecker QuotaChecker
+}
+
+// QuotaChecker Etsy API 配额检查
+type QuotaChecker interface {
+	LowQuotaDeveloperIDs(ctx context.Context) (map[int64]bool, error)
 }
 
 // TaskManagerConfig 任务管理器配置
@@ -99,18 +111,24 @@ func NewTaskManager(deps *TaskManagerDeps, cfg *TaskManagerConfig) *TaskManager
 		)
 		tm.shopTask.SetConcurrency(cfg.ShopConcurrency, 200*time.Millisecond)
 		tm.shopTask.SetSyncOptions(cfg.ShopSyncProfile, cfg.ShopSyncPolicy, cfg.ShopSyncSection)
+		tm.shopTask.SetNotifier(deps.Notifier)
+		tm.shopTask.SetQuotaChecker(deps.QuotaChecker)
 	}
 
 	// Product 同步任务
 	if cfg.ProductEnabled && deps.ProductService != nil {
 		tm.productTask = NewProductSyncTask(deps.ShopRepo, deps.ProductService)
 		tm.productTask.SetConcurrency(cfg.ProductConcurrency, cfg.ProductBatchSize, 300*time.Millisecond)
+		tm.productTask.SetNotifier(deps.Notifier)
+		tm.productTask.SetQuotaChecker(deps.QuotaChecker)
 	}
 
 	// Order 同步任务
 	if cfg.OrderEnabled && deps.OrderService != nil {
 		tm.orderTask = NewOrderSyncTask(deps.ShopRepo, deps.OrderService)
 		tm.orderTask.SetConcurrency(cfg.OrderConcurrency, 200*time.Millisecond)
+		tm.orderTask.SetNotifier(deps.Notifier)
+		tm.orderTask.SetQuotaChecker(deps.QuotaChecker)
 	}
 
 	// Tracking 同步任务
@@ -196,12 +214,12 @@ func (tm *TaskManager) TriggerAllProductsSync(fullSync bool) {
 	}
 }
 
-// TriggerOrderSync 触发订单同步
-func (tm *TaskManager) TriggerOrderSync(ctx context.Context, shopID int64, forceSync bool) (*dto.SyncOrdersResponse, error) {
+// TriggerOrderSync 触发订单同步（默认从检查点增量同步）
+func (tm *TaskManager) TriggerOrderSync(ctx context.Context, shopID int64, fullResync, forceSync bool) (*dto.SyncOrdersResponse, error) {
 	if tm.orderTask == nil {
 		return nil, ErrTaskDisabled
 	}
-	return tm.orderTask.SyncShopNow(ctx, shopID, forceSync)
+	return tm.orderTask.SyncShopNow(ctx, shopID, fullResync, forceSync)
 }
 
 // TriggerAllOrdersSync 触发所有订单同步
@@ -230,6 +248,54 @@ func (tm *TaskManager) Status() map[string]bool {
 	}
 }
 
+// ==================== 通知 ====================
+
+// notifySyncFailed 通知店铺成员定时同步失败（重复失败由通知中心按店铺 + 同步类型去重）
+func notifySyncFailed(ctx context.Context, notifier service.ShopNotifier, shopID int64, resource string, err error) {
+	if notifier == nil {
+		return
+	}
+	nerr := notifier.NotifyShop(context.WithoutCancel(ctx), shopID, model.NotificationEventSyncFailed, map[string]interface{}{
+		"resource": resource,
+		"error":    err.Error(),
+	})
+	if nerr != nil {
+		log.Printf("[TaskManager] 店铺 %d 同步失败通知发送失败: %v", shopID, nerr)
+	}
+}
+
+// ==================== 配额 ====================
+
+// deferLowQuotaShops 过滤掉开发者配额偏低的店铺（推迟到下一轮定时同步，手动触发不受影响）
+func deferLowQuotaShops(ctx context.Context, checker QuotaChecker, shops []model.Shop, taskName string) []model.Shop {
+	if checker == nil || len(shops) == 0 {
+		return shops
+	}
+
+	low, err := checker.LowQuotaDeveloperIDs(ctx)
+	if err != nil {
+		log.Printf("[%s] 查询 API 配额失败，按正常同步: %v", taskName, err)
+		return shops
+	}
+	if len(low) == 0 {
+		return shops
+	}
+
+	result := make([]model.Shop, 0, len(shops))
+	deferred := 0
+	for _, shop := range shops {
+		if low[shop.DeveloperID] {
+			deferred++
+			continue
+		}
+		result = append(result, shop)
+	}
+	if deferred > 0 {
+		log.Printf("[%s] %d 个店铺所属开发者 API 配额不足，推迟同步", taskName, deferred)
+	}
+	return result
+}
+
 // ==================== 错误定义 ====================
 
 type TaskError string
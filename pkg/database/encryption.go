@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"etsy_dev_v1_202512/pkg/utils"
+)
+
+// EncryptedSerializerName 字段加密序列化器名称，用法：gorm:"type:text;serializer:encrypted"
+const EncryptedSerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(EncryptedSerializerName, EncryptedSerializer{})
+}
+
+// EncryptedSerializer 字符串字段透明加解密（使用 utils 全局密钥环）
+type EncryptedSerializer struct{}
+
+// Scan 读取时解密；不符合密文格式的历史明文原样返回
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var raw string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("加密字段 %s 类型不支持: %T", field.Name, dbValue)
+	}
+
+	plaintext := raw
+	if utils.IsEncrypted(raw) {
+		ring := utils.GetKeyRing()
+		if ring == nil {
+			return fmt.Errorf("解密字段 %s 失败: %w", field.Name, utils.ErrNoEncryptionKeys)
+		}
+		var err error
+		if plaintext, err = ring.Decrypt(raw); err != nil {
+			return fmt.Errorf("解密字段 %s 失败: %w", field.Name, err)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value 写入时使用主密钥加密
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("加密字段 %s 必须为 string", field.Name)
+	}
+	return encryptValue(field.Name, plaintext)
+}
+
+// encryptValue 非空值一律加密，不按前缀跳过：字段值本身可能以 enc: 开头，是否为密文只在读取时判断
+func encryptValue(name, plaintext string) (string, error) {
+	if plaintext == "" {
+		return plaintext, nil
+	}
+	ring := utils.GetKeyRing()
+	if ring == nil {
+		return "", fmt.Errorf("加密字段 %s 失败: %w", name, utils.ErrNoEncryptionKeys)
+	}
+	return ring.Encrypt(plaintext)
+}
+
+// IsEncryptedField 字段是否使用加密序列化器
+func IsEncryptedField(field *schema.Field) bool {
+	return field.TagSettings["SERIALIZER"] == EncryptedSerializerName
+}
+
+// RegisterEncryptionCallbacks 注册加密回调
+// GORM 的 map 更新（Updates(map) / Update(column, value)）不经过序列化器，需在更新前手动加密对应列
+func RegisterEncryptionCallbacks(db *gorm.DB) error {
+	return db.Callback().Update().Before("gorm:update").Register("encryption:map_values", encryptMapValues)
+}
+
+func encryptMapValues(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	values, ok := db.Statement.Dest.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	// 复制一份，避免修改调用方的 map
+	var encrypted map[string]interface{}
+	for k, v := range values {
+		field := db.Statement.Schema.LookUpField(k)
+		plaintext, isString := v.(string)
+		if field == nil || !isString || !IsEncryptedField(field) {
+			continue
+		}
+
+		ciphertext, err := encryptValue(field.Name, plaintext)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		if encrypted == nil {
+			encrypted = make(map[string]interface{}, len(values))
+			for key, val := range values {
+				encrypted[key] = val
+			}
+		}
+		encrypted[k] = ciphertext
+	}
+
+	if encrypted != nil {
+		db.Statement.Dest = encrypted
+	}
+}
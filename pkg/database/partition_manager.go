@@ -23,7 +23,7 @@ func NewPartitionManager(db *gorm.DB, config *PartitionConfig) *PartitionManager
 
 // ==================== 初始化 ====================
 
-// InitPartitionTables 初始化分区主表
+// InitPartitionTables 初始化分区主表（已存在的表重新执行脚本中的 ADD COLUMN IF NOT EXISTS）
 func (m *PartitionManager) InitPartitionTables(ctx context.Context) error {
 	for _, table := range m.config.Tables {
 		exists, err := m.tableExists(ctx, table.TableName)
@@ -31,16 +31,18 @@ func (m *PartitionManager) InitPartitionTables(ctx context.Context) error {
 			return fmt.Errorf("检查表 %s 失败: %w", table.TableName, err)
 		}
 
+		// 脚本全部幂等（IF NOT EXISTS），已存在的表重新执行以补齐后续新增的列和索引
 		if exists {
-			log.Printf("[Partition] 表 %s 已存在", table.TableName)
-			continue
+			log.Printf("[Partition] 表 %s 已存在，同步列与索引 ...", table.TableName)
+		} else {
+			log.Printf("[Partition] 创建分区表 %s ...", table.TableName)
 		}
-
-		log.Printf("[Partition] 创建分区表 %s ...", table.TableName)
 		if err := m.db.WithContext(ctx).Exec(table.SQLContent).Error; err != nil {
-			return fmt.Errorf("创建表 %s 失败: %w", table.TableName, err)
+			return fmt.Errorf("初始化表 %s 失败: %w", table.TableName, err)
+		}
+		if !exists {
+			log.Printf("[Partition] 表 %s 创建成功", table.TableName)
 		}
-		log.Printf("[Partition] 表 %s 创建成功", table.TableName)
 	}
 	return nil
 }
@@ -10,10 +10,13 @@ CREATE TABLE IF NOT EXISTS ai_call_logs (
                                             created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
                                             updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
                                             deleted_at TIMESTAMPTZ,
+    created_by BIGINT,
+    updated_by BIGINT,
 
     -- 关联
                                             shop_id BIGINT,
                                             task_id BIGINT,
+    user_id BIGINT,
 
     -- 调用信息
                                             call_type VARCHAR(32),
@@ -36,9 +39,15 @@ CREATE TABLE IF NOT EXISTS ai_call_logs (
     PRIMARY KEY (id, created_at)
     ) PARTITION BY RANGE (created_at);
 
+-- 增量列（已存在的表在启动时补齐）
+ALTER TABLE ai_call_logs ADD COLUMN IF NOT EXISTS created_by BIGINT;
+ALTER TABLE ai_call_logs ADD COLUMN IF NOT EXISTS updated_by BIGINT;
+ALTER TABLE ai_call_logs ADD COLUMN IF NOT EXISTS user_id BIGINT;
+
 -- 索引
 CREATE INDEX IF NOT EXISTS idx_ai_call_logs_shop_id ON ai_call_logs (shop_id);
 CREATE INDEX IF NOT EXISTS idx_ai_call_logs_task_id ON ai_call_logs (task_id);
+CREATE INDEX IF NOT EXISTS idx_ai_call_logs_user_id ON ai_call_logs (user_id);
 CREATE INDEX IF NOT EXISTS idx_ai_call_logs_call_type ON ai_call_logs (call_type);
 CREATE INDEX IF NOT EXISTS idx_ai_call_logs_status ON ai_call_logs (status);
 CREATE INDEX IF NOT EXISTS idx_ai_call_logs_deleted_at ON ai_call_logs (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
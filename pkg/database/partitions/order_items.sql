@@ -44,6 +44,10 @@ CREATE TABLE IF NOT EXISTS order_items (
     PRIMARY KEY (id, created_at)
     ) PARTITION BY RANGE (created_at);
 
+-- 增量列（已存在的表在启动时补齐）
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS created_by BIGINT;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS updated_by BIGINT;
+
 -- 索引
 CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
 CREATE INDEX IF NOT EXISTS idx_order_items_etsy_tx_id ON order_items (etsy_transaction_id);
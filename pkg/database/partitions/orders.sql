@@ -63,6 +63,10 @@ CREATE TABLE IF NOT EXISTS orders (
     PRIMARY KEY (id, created_at)
     ) PARTITION BY RANGE (created_at);
 
+-- 增量列（已存在的表在启动时补齐）
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS created_by BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_by BIGINT;
+
 -- 索引
 CREATE INDEX IF NOT EXISTS idx_orders_shop_id ON orders (shop_id);
 CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
@@ -43,6 +43,8 @@ CREATE TABLE IF NOT EXISTS shipments (
     etsy_synced BOOLEAN DEFAULT FALSE,
     etsy_synced_at TIMESTAMPTZ,
     etsy_sync_error TEXT,
+    etsy_receipt_shipping_id BIGINT DEFAULT 0,
+    etsy_sync_response JSONB,
 
     -- 最后跟踪信息
     last_tracking_status VARCHAR(64),
@@ -57,6 +59,12 @@ CREATE TABLE IF NOT EXISTS shipments (
     PRIMARY KEY (id, created_at)
     ) PARTITION BY RANGE (created_at);
 
+-- 增量列（已存在的表在启动时补齐）
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS created_by BIGINT;
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS updated_by BIGINT;
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS etsy_receipt_shipping_id BIGINT DEFAULT 0;
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS etsy_sync_response JSONB;
+
 -- 索引
 CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments (order_id);
 CREATE INDEX IF NOT EXISTS idx_shipments_tracking_number ON shipments (tracking_number);
@@ -29,6 +29,10 @@ CREATE TABLE IF NOT EXISTS tracking_events (
     PRIMARY KEY (id, created_at)
     ) PARTITION BY RANGE (created_at);
 
+-- 增量列（已存在的表在启动时补齐）
+ALTER TABLE tracking_events ADD COLUMN IF NOT EXISTS created_by BIGINT;
+ALTER TABLE tracking_events ADD COLUMN IF NOT EXISTS updated_by BIGINT;
+
 -- 索引
 CREATE INDEX IF NOT EXISTS idx_tracking_events_shipment_id ON tracking_events (shipment_id);
 CREATE INDEX IF NOT EXISTS idx_tracking_events_occurred_at ON tracking_events (occurred_at);
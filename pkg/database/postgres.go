@@ -45,6 +45,11 @@ func ConnectDB() *gorm.DB {
 		log.Fatalf("数据库连接失败 (Database Connection Failed): %v", err)
 	}
 
+	// 加密字段的 map 更新不经过序列化器，需注册回调补充加密
+	if err := RegisterEncryptionCallbacks(db); err != nil {
+		log.Fatalf("注册加密回调失败: %v", err)
+	}
+
 	// 获取底层的 sqlDB 对象，用于设置连接池参数
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -77,15 +82,24 @@ func InitDatabase() *gorm.DB {
 		// Manager
 		&model.SysUser{}, &model.ShopMember{},
 		// Account
-		&model.Proxy{}, &model.Developer{}, &model.DomainPool{},
+		&model.Proxy{}, &model.ProxyScoreSnapshot{}, &model.ProxyBinding{}, &model.Developer{}, &model.DomainPool{},
 		// Shop
-		&model.Shop{},
+		&model.Shop{}, &model.OAuthState{},
 		// Shipping
 		&model.ShippingProfile{}, &model.ShippingDestination{}, &model.ShippingUpgrade{}, &model.ReturnPolicy{},
 		// Product
 		&model.Product{}, &model.ProductImage{}, &model.ProductVariant{},
+		// Stock
+		&model.StockItem{}, &model.StockMovement{},
 		// Draft
 		&model.DraftTask{}, &model.DraftProduct{}, &model.DraftImage{},
+		&model.DraftJob{}, &model.DraftTaskEvent{}, &model.DraftImageUpload{},
+		// Sync
+		&model.SyncState{},
+		// AI
+		&model.AIBudget{},
+		// Notification
+		&model.Notification{},
 		// 注意：以下表已分区，不在此处
 		// - Order, OrderItem
 		// - Shipment, TrackingEvent
@@ -0,0 +1,199 @@
+package etsy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"etsy_dev_v1_202512/pkg/net"
+)
+
+// DefaultBaseURL Etsy Open API v3 地址
+const DefaultBaseURL = "https://api.etsy.com/v3/application"
+
+// Credentials 调用凭证
+// ShopID 为本地店铺 ID（Dispatcher 按它绑定代理），EtsyShopID 用于拼接路径
+type Credentials struct {
+	ShopID      int64
+	EtsyShopID  int64
+	APIKey      string
+	AccessToken string // 公开接口（taxonomy 等）可为空
+}
+
+// Client Etsy v3 类型化客户端
+// 所有请求经 net.Dispatcher 发送（代理绑定、按 API Key 限流、429 重试）
+type Client struct {
+	dispatcher   net.Dispatcher
+	baseURL      string
+	authReporter AuthReporter
+}
+
+// AuthReporter 鉴权结果上报，业务层据此识别被吊销的 API Key
+type AuthReporter interface {
+	// ReportAuthFailure 请求因 Key / Token 失效被拒绝
+	ReportAuthFailure(ctx context.Context, cred Credentials, failure AuthFailure, err *EtsyError)
+	// ReportAuthSuccess Etsy 接受了该 Key（包括业务错误响应，如 404）
+	ReportAuthSuccess(ctx context.Context, apiKey string)
+}
+
+// ClientOption Client 可选配置
+type ClientOption func(*Client)
+
+// WithBaseURL 替换 API 地址（用于本地模拟环境）
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithAuthReporter 设置鉴权结果上报
+func WithAuthReporter(reporter AuthReporter) ClientOption {
+	return func(c *Client) {
+		c.authReporter = reporter
+	}
+}
+
+// NewClient 创建 Etsy 客户端
+func NewClient(dispatcher net.Dispatcher, opts ...ClientOption) *Client {
+	c := &Client{
+		dispatcher: dispatcher,
+		baseURL:    DefaultBaseURL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL 当前 API 地址
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Ping 检测 API Key 可用性（随机代理，不占用店铺绑定的代理）
+// GET /v3/application/openapi-ping
+func (c *Client) Ping(ctx context.Context, apiKey string) (*EtsyPingResp, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/openapi-ping", nil)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %w", err)
+	}
+	req.Header.Set("x-api-key", apiKey)
+
+	resp, err := c.dispatcher.Ping(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 Etsy API 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out EtsyPingResp
+	err = decodeResponse(resp, &out)
+	c.observeAuth(ctx, Credentials{APIKey: apiKey}, err)
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ==================== 请求封装 ====================
+
+// get GET 请求并解析 JSON
+func (c *Client) get(ctx context.Context, cred Credentials, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, cred, http.MethodGet, path, query, nil, out)
+}
+
+// do 发送 JSON 请求；body 为 nil 时不带请求体，out 为 nil 时丢弃响应体
+func (c *Client) do(ctx context.Context, cred Credentials, method, path string, query url.Values, body, out interface{}) error {
+	apiURL := c.baseURL + path
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		// bytes.Reader 会设置 GetBody，Dispatcher 重试时可重放请求体
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := net.BuildEtsyRequest(ctx, method, apiURL, reader, cred.APIKey, cred.AccessToken)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+	if cred.AccessToken == "" {
+		req.Header.Del("Authorization")
+	}
+
+	resp, err := c.dispatcher.Send(ctx, cred.ShopID, req)
+	if err != nil {
+		return fmt.Errorf("请求 Etsy API 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	err = decodeResponse(resp, out)
+	c.observeAuth(ctx, cred, err)
+	return err
+}
+
+// upload 发送 multipart 请求（图片 / 文件上传）
+func (c *Client) upload(ctx context.Context, cred Credentials, path string, files map[string]net.FileData, fields map[string]string, out interface{}) error {
+	headers := map[string]string{"x-api-key": cred.APIKey}
+	if cred.AccessToken != "" {
+		headers["Authorization"] = "Bearer " + cred.AccessToken
+	}
+
+	resp, err := c.dispatcher.SendMultipart(ctx, cred.ShopID, &net.MultipartRequest{
+		URL:     c.baseURL + path,
+		Headers: headers,
+		Files:   files,
+		Fields:  fields,
+	})
+	if err != nil {
+		return fmt.Errorf("请求 Etsy API 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	err = decodeResponse(resp, out)
+	c.observeAuth(ctx, cred, err)
+	return err
+}
+
+// observeAuth 上报本次响应的鉴权结果；网络错误、429 与 5xx 无法判断 Key 状态，不上报
+func (c *Client) observeAuth(ctx context.Context, cred Credentials, err error) {
+	if c.authReporter == nil || cred.APIKey == "" {
+		return
+	}
+	etsyErr, ok := AsEtsyError(err)
+	switch {
+	case err == nil:
+		c.authReporter.ReportAuthSuccess(ctx, cred.APIKey)
+	case !ok || etsyErr.Retryable():
+		return
+	case etsyErr.AuthFailure() != AuthFailureNone:
+		c.authReporter.ReportAuthFailure(ctx, cred, etsyErr.AuthFailure(), etsyErr)
+	default:
+		c.authReporter.ReportAuthSuccess(ctx, cred.APIKey)
+	}
+}
+
+// decodeResponse 非 2xx 返回 *EtsyError；否则解析到 out
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newEtsyError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: %v", ErrDecodeResponse, err)
+	}
+	return nil
+}
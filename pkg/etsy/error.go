@@ -0,0 +1,133 @@
+package etsy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody 错误响应体最多保留的字节数
+const maxErrorBody = 4096
+
+// ErrDecodeResponse 2xx 响应体无法解析：请求已被 Etsy 执行，写操作不应直接重发
+var ErrDecodeResponse = errors.New("解析 Etsy 响应失败")
+
+// EtsyError Etsy 接口返回的非 2xx 响应
+type EtsyError struct {
+	StatusCode  int
+	Code        string // error
+	Description string // error_description
+	Body        string // 无法按 EtsyErrorResp 解析时的原始响应体
+}
+
+func (e *EtsyError) Error() string {
+	msg := e.Description
+	if msg == "" {
+		msg = e.Code
+	}
+	if msg == "" {
+		msg = e.Body
+	}
+	if msg == "" {
+		return fmt.Sprintf("Etsy API 错误 [%d]", e.StatusCode)
+	}
+	return fmt.Sprintf("Etsy API 错误 [%d]: %s", e.StatusCode, msg)
+}
+
+// Retryable 429 与 5xx 可稍后重试
+func (e *EtsyError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// AuthFailure 鉴权失败类型
+type AuthFailure int
+
+const (
+	AuthFailureNone   AuthFailure = iota // 非鉴权错误
+	AuthFailureAPIKey                    // API Key 无效 / 被吊销，同 Key 下所有店铺受影响
+	AuthFailureToken                     // 店铺 Token 无效 / 过期，仅影响单个店铺
+)
+
+func (f AuthFailure) String() string {
+	switch f {
+	case AuthFailureAPIKey:
+		return "api_key"
+	case AuthFailureToken:
+		return "token"
+	default:
+		return "none"
+	}
+}
+
+// AuthFailure 区分 API Key 失效与用户 Token 失效
+// Etsy 对 Key 问题返回 "Invalid API key" / "API key not found or not active" 等（401/403），
+// Token 问题返回 401 invalid_token；403 且未提及 Key / Token 的（如权限不足）不视为鉴权失败
+func (e *EtsyError) AuthFailure() AuthFailure {
+	if e.StatusCode != http.StatusUnauthorized && e.StatusCode != http.StatusForbidden {
+		return AuthFailureNone
+	}
+	text := strings.ToLower(e.Code + " " + e.Description + " " + e.Body)
+	switch {
+	case strings.Contains(text, "api key") || strings.Contains(text, "api_key") || strings.Contains(text, "invalid_client"):
+		return AuthFailureAPIKey
+	case e.StatusCode == http.StatusUnauthorized || strings.Contains(text, "token"):
+		return AuthFailureToken
+	default:
+		return AuthFailureNone
+	}
+}
+
+// ClassifyAuthFailure 错误链中 Etsy 响应的鉴权失败类型
+func ClassifyAuthFailure(err error) AuthFailure {
+	if etsyErr, ok := AsEtsyError(err); ok {
+		return etsyErr.AuthFailure()
+	}
+	return AuthFailureNone
+}
+
+// newEtsyError 从响应解析 EtsyErrorResp
+func newEtsyError(resp *http.Response) *EtsyError {
+	e := &EtsyError{StatusCode: resp.StatusCode}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil || len(body) == 0 {
+		return e
+	}
+
+	var payload EtsyErrorResp
+	if err := json.Unmarshal(body, &payload); err != nil || (payload.Error == "" && payload.ErrorDescription == "") {
+		e.Body = string(body)
+		return e
+	}
+	e.Code = payload.Error
+	e.Description = payload.ErrorDescription
+	return e
+}
+
+// ParseErrorResponse 解析非 2xx 响应（供不经 Client 发送的请求使用，如 OAuth Token 接口）
+func ParseErrorResponse(resp *http.Response) *EtsyError {
+	return newEtsyError(resp)
+}
+
+// AsEtsyError 提取错误链中的 *EtsyError
+func AsEtsyError(err error) (*EtsyError, bool) {
+	var etsyErr *EtsyError
+	if errors.As(err, &etsyErr) {
+		return etsyErr, true
+	}
+	return nil, false
+}
+
+// IsStatus 错误是否为指定状态码的 Etsy 响应
+func IsStatus(err error, statusCode int) bool {
+	etsyErr, ok := AsEtsyError(err)
+	return ok && etsyErr.StatusCode == statusCode
+}
+
+// IsNotFound 资源在 Etsy 已不存在
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
@@ -263,6 +263,16 @@ type EtsyMailClassResp struct {
 	Name         string `json:"name"`
 }
 
+// EtsyReceiptShipmentCreateReq Etsy 订单发货请求
+// POST /v3/application/shops/{shop_id}/receipts/{receipt_id}/tracking
+// 响应为完整的 ShopReceipt
+type EtsyReceiptShipmentCreateReq struct {
+	TrackingCode string `json:"tracking_code"`
+	CarrierName  string `json:"carrier_name"`
+	SendBcc      bool   `json:"send_bcc,omitempty"`
+	NoteToBuyer  string `json:"note_to_buyer,omitempty"`
+}
+
 // EtsyReturnPolicyResp Etsy 退货政策 API 响应
 // GET /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
 type EtsyReturnPolicyResp struct {
@@ -303,6 +313,296 @@ type EtsyReturnPolicyConsolidateReq struct {
 	DestinationReturnPolicyID int64 `json:"destination_return_policy_id"`
 }
 
+// ToFloat 转换为实际金额
+func (m EtsyMoney) ToFloat() float64 {
+	if m.Divisor == 0 {
+		return 0
+	}
+	return float64(m.Amount) / float64(m.Divisor)
+}
+
+// ==================== Receipt / Transaction ====================
+
+// EtsyReceiptResp Etsy 订单 API 响应
+// GET /v3/application/shops/{shop_id}/receipts/{receipt_id}
+type EtsyReceiptResp struct {
+	ReceiptID          int64                     `json:"receipt_id"`
+	ReceiptType        int                       `json:"receipt_type"`
+	SellerUserID       int64                     `json:"seller_user_id"`
+	SellerEmail        string                    `json:"seller_email"`
+	BuyerUserID        int64                     `json:"buyer_user_id"`
+	BuyerEmail         string                    `json:"buyer_email"`
+	Name               string                    `json:"name"`
+	FirstLine          string                    `json:"first_line"`
+	SecondLine         string                    `json:"second_line"`
+	City               string                    `json:"city"`
+	State              string                    `json:"state"`
+	Zip                string                    `json:"zip"`
+	Status             string                    `json:"status"`
+	FormattedAddress   string                    `json:"formatted_address"`
+	CountryISO         string                    `json:"country_iso"`
+	PaymentMethod      string                    `json:"payment_method"`
+	PaymentEmail       string                    `json:"payment_email"`
+	MessageFromSeller  string                    `json:"message_from_seller"`
+	MessageFromBuyer   string                    `json:"message_from_buyer"`
+	MessageFromPayment string                    `json:"message_from_payment"`
+	IsPaid             bool                      `json:"is_paid"`
+	IsShipped          bool                      `json:"is_shipped"`
+	CreateTimestamp    int64                     `json:"create_timestamp"`
+	CreatedTimestamp   int64                     `json:"created_timestamp"`
+	UpdateTimestamp    int64                     `json:"update_timestamp"`
+	UpdatedTimestamp   int64                     `json:"updated_timestamp"`
+	IsGift             bool                      `json:"is_gift"`
+	GiftMessage        string                    `json:"gift_message"`
+	GrandTotal         EtsyMoney                 `json:"grandtotal"`
+	Subtotal           EtsyMoney                 `json:"subtotal"`
+	TotalPrice         EtsyMoney                 `json:"total_price"`
+	TotalShippingCost  EtsyMoney                 `json:"total_shipping_cost"`
+	TotalTaxCost       EtsyMoney                 `json:"total_tax_cost"`
+	TotalVatCost       EtsyMoney                 `json:"total_vat_cost"`
+	DiscountAmt        EtsyMoney                 `json:"discount_amt"`
+	GiftWrapPrice      EtsyMoney                 `json:"gift_wrap_price"`
+	Shipments          []EtsyReceiptShipmentResp `json:"shipments"`
+	Transactions       []EtsyTransactionResp     `json:"transactions"`
+}
+
+// EtsyReceiptShipmentResp Etsy 订单发货记录
+type EtsyReceiptShipmentResp struct {
+	ReceiptShippingID             int64  `json:"receipt_shipping_id"`
+	ShipmentNotificationTimestamp int64  `json:"shipment_notification_timestamp"`
+	CarrierName                   string `json:"carrier_name"`
+	TrackingCode                  string `json:"tracking_code"`
+}
+
+// EtsyReceiptsQuery 订单列表筛选条件（时间均为 Unix 秒，0 表示不限）
+// GET /v3/application/shops/{shop_id}/receipts
+type EtsyReceiptsQuery struct {
+	MinCreated      int64
+	MaxCreated      int64
+	MinLastModified int64
+	MaxLastModified int64
+	SortOn          string // created, updated, receipt_id
+	SortOrder       string // asc, desc
+	WasPaid         *bool
+	WasShipped      *bool
+}
+
+// EtsyTransactionResp Etsy 交易（订单项）API 响应
+// GET /v3/application/shops/{shop_id}/receipts/{receipt_id}/transactions
+type EtsyTransactionResp struct {
+	TransactionID    int64                        `json:"transaction_id"`
+	Title            string                       `json:"title"`
+	Description      string                       `json:"description"`
+	SellerUserID     int64                        `json:"seller_user_id"`
+	BuyerUserID      int64                        `json:"buyer_user_id"`
+	CreateTimestamp  int64                        `json:"create_timestamp"`
+	CreatedTimestamp int64                        `json:"created_timestamp"`
+	PaidTimestamp    int64                        `json:"paid_timestamp"`
+	ShippedTimestamp int64                        `json:"shipped_timestamp"`
+	Quantity         int                          `json:"quantity"`
+	ListingImageID   int64                        `json:"listing_image_id"`
+	ReceiptID        int64                        `json:"receipt_id"`
+	IsDigital        bool                         `json:"is_digital"`
+	FileData         string                       `json:"file_data"`
+	ListingID        int64                        `json:"listing_id"`
+	SKU              string                       `json:"sku"`
+	ProductID        int64                        `json:"product_id"`
+	TransactionType  string                       `json:"transaction_type"`
+	Price            EtsyMoney                    `json:"price"`
+	ShippingCost     EtsyMoney                    `json:"shipping_cost"`
+	Variations       []EtsyTransactionVariation   `json:"variations"`
+	ProductData      []EtsyTransactionProductData `json:"product_data"`
+}
+
+// EtsyTransactionVariation 交易变体
+type EtsyTransactionVariation struct {
+	PropertyID     int64  `json:"property_id"`
+	ValueID        int64  `json:"value_id"`
+	FormattedName  string `json:"formatted_name"`
+	FormattedValue string `json:"formatted_value"`
+}
+
+// EtsyTransactionProductData 交易商品属性
+type EtsyTransactionProductData struct {
+	PropertyID   int64    `json:"property_id"`
+	PropertyName string   `json:"property_name"`
+	ValueIDs     []int64  `json:"value_ids"`
+	Values       []string `json:"values"`
+}
+
+// ==================== Listing ====================
+
+// EtsyListingCreateReq Etsy 创建草稿商品请求
+// POST /v3/application/shops/{shop_id}/listings
+type EtsyListingCreateReq struct {
+	Quantity          int      `json:"quantity"`
+	Title             string   `json:"title"`
+	Description       string   `json:"description"`
+	Price             PriceDTO `json:"price"`
+	WhoMade           string   `json:"who_made"`
+	WhenMade          string   `json:"when_made"`
+	TaxonomyID        int64    `json:"taxonomy_id"`
+	ShippingProfileID int64    `json:"shipping_profile_id"`
+	IsSupply          bool     `json:"is_supply"`
+
+	ReturnPolicyID     int64    `json:"return_policy_id,omitempty"`
+	ShopSectionID      int64    `json:"shop_section_id,omitempty"`
+	Tags               []string `json:"tags,omitempty"`
+	Materials          []string `json:"materials,omitempty"`
+	Styles             []string `json:"styles,omitempty"`
+	ImageIDs           []int64  `json:"image_ids,omitempty"`
+	ItemWeight         float64  `json:"item_weight,omitempty"`
+	ItemWeightUnit     string   `json:"item_weight_unit,omitempty"`
+	ItemLength         float64  `json:"item_length,omitempty"`
+	ItemWidth          float64  `json:"item_width,omitempty"`
+	ItemHeight         float64  `json:"item_height,omitempty"`
+	ItemDimensionsUnit string   `json:"item_dimensions_unit,omitempty"`
+}
+
+// EtsyListingUpdateReq Etsy 更新商品请求（nil 字段不提交）
+// PATCH /v3/application/shops/{shop_id}/listings/{listing_id}
+type EtsyListingUpdateReq struct {
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Price       *PriceDTO `json:"price,omitempty"`
+	Quantity    *int      `json:"quantity,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	State       string    `json:"state,omitempty"` // active, inactive
+}
+
+// Empty 是否没有需要提交的字段
+func (r EtsyListingUpdateReq) Empty() bool {
+	return r.Title == nil && r.Description == nil && r.Price == nil &&
+		r.Quantity == nil && len(r.Tags) == 0 && r.State == ""
+}
+
+// EtsyListingImageUploadReq Etsy 上传商品图片请求（multipart）
+// POST /v3/application/shops/{shop_id}/listings/{listing_id}/images
+type EtsyListingImageUploadReq struct {
+	Data     []byte
+	Filename string
+	Rank     int
+	AltText  string
+}
+
+// EtsyListingImageResp Etsy 商品图片 API 响应
+type EtsyListingImageResp struct {
+	ListingImageID int64  `json:"listing_image_id"`
+	ListingID      int64  `json:"listing_id"`
+	Rank           int    `json:"rank"`
+	URLFullxFull   string `json:"url_fullxfull"`
+	URL570xN       string `json:"url_570xN"`
+	URL75x75       string `json:"url_75x75"`
+	FullHeight     int    `json:"full_height"`
+	FullWidth      int    `json:"full_width"`
+	HexCode        string `json:"hex_code"`
+	AltText        string `json:"alt_text"`
+}
+
+// ==================== Listing Inventory ====================
+
+// EtsyListingInventoryResp Etsy 商品库存 API 响应
+// GET /v3/application/listings/{listing_id}/inventory
+type EtsyListingInventoryResp struct {
+	Products           []EtsyInventoryProduct `json:"products"`
+	PriceOnProperty    []int64                `json:"price_on_property"`
+	QuantityOnProperty []int64                `json:"quantity_on_property"`
+	SkuOnProperty      []int64                `json:"sku_on_property"`
+}
+
+// EtsyInventoryProduct 库存中的单个变体（Etsy Product）
+type EtsyInventoryProduct struct {
+	ProductID      int64                    `json:"product_id"`
+	SKU            string                   `json:"sku"`
+	IsDeleted      bool                     `json:"is_deleted"`
+	Offerings      []EtsyInventoryOffering  `json:"offerings"`
+	PropertyValues []EtsyInventoryPropValue `json:"property_values"`
+}
+
+// EtsyInventoryOffering 变体报价
+type EtsyInventoryOffering struct {
+	OfferingID int64    `json:"offering_id"`
+	Price      PriceDTO `json:"price"`
+	Quantity   int      `json:"quantity"`
+	IsEnabled  bool     `json:"is_enabled"`
+	IsDeleted  bool     `json:"is_deleted"`
+}
+
+// EtsyInventoryPropValue 变体属性值
+type EtsyInventoryPropValue struct {
+	PropertyID   int64    `json:"property_id"`
+	PropertyName string   `json:"property_name"`
+	ScaleID      *int64   `json:"scale_id"`
+	ValueIDs     []int64  `json:"value_ids"`
+	Values       []string `json:"values"`
+}
+
+// EtsyListingInventoryUpdateReq Etsy 更新商品库存请求（全量覆盖）
+// PUT /v3/application/listings/{listing_id}/inventory
+type EtsyListingInventoryUpdateReq struct {
+	Products           []EtsyInventoryProductReq `json:"products"`
+	PriceOnProperty    []int64                   `json:"price_on_property"`
+	QuantityOnProperty []int64                   `json:"quantity_on_property"`
+	SkuOnProperty      []int64                   `json:"sku_on_property"`
+}
+
+// EtsyInventoryProductReq 更新库存时的变体
+type EtsyInventoryProductReq struct {
+	SKU            string                     `json:"sku"`
+	PropertyValues []EtsyInventoryPropValue   `json:"property_values"`
+	Offerings      []EtsyInventoryOfferingReq `json:"offerings"`
+}
+
+// EtsyInventoryOfferingReq 更新库存时的报价（price 为实际金额，非 amount/divisor）
+type EtsyInventoryOfferingReq struct {
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	IsEnabled bool    `json:"is_enabled"`
+}
+
+// ==================== Taxonomy ====================
+
+// EtsyTaxonomyNodeResp Etsy 类目节点
+// GET /v3/application/seller-taxonomy/nodes
+type EtsyTaxonomyNodeResp struct {
+	ID                  int64                  `json:"id"`
+	Level               int                    `json:"level"`
+	Name                string                 `json:"name"`
+	ParentID            int64                  `json:"parent_id"`
+	Children            []EtsyTaxonomyNodeResp `json:"children"`
+	FullPathTaxonomyIDs []int64                `json:"full_path_taxonomy_ids"`
+}
+
+// EtsyTaxonomyPropertyResp Etsy 类目属性（用于变体 / 属性选择）
+// GET /v3/application/seller-taxonomy/nodes/{taxonomy_id}/properties
+type EtsyTaxonomyPropertyResp struct {
+	PropertyID         int64                   `json:"property_id"`
+	Name               string                  `json:"name"`
+	DisplayName        string                  `json:"display_name"`
+	Scales             []EtsyTaxonomyScale     `json:"scales"`
+	IsRequired         bool                    `json:"is_required"`
+	SupportsAttributes bool                    `json:"supports_attributes"`
+	SupportsVariations bool                    `json:"supports_variations"`
+	IsMultivalued      bool                    `json:"is_multivalued"`
+	PossibleValues     []EtsyTaxonomyPropValue `json:"possible_values"`
+	SelectedValues     []EtsyTaxonomyPropValue `json:"selected_values"`
+}
+
+// EtsyTaxonomyScale 属性度量单位
+type EtsyTaxonomyScale struct {
+	ScaleID     int64  `json:"scale_id"`
+	DisplayName string `json:"display_name"`
+	Description string `json:"description"`
+}
+
+// EtsyTaxonomyPropValue 属性可选值
+type EtsyTaxonomyPropValue struct {
+	ValueID int64   `json:"value_id"`
+	Name    string  `json:"name"`
+	ScaleID *int64  `json:"scale_id"`
+	EqualTo []int64 `json:"equal_to"`
+}
+
 // ======================= old ======================
 
 // PriceDTO 1. 价格嵌套结构
@@ -340,6 +640,7 @@ type ProductListingDTO struct {
 	PersonalizationCharCountMax int      `json:"personalization_char_count_max"`
 	PersonalizationInstructions string   `json:"personalization_instructions"`
 	ListingType                 string   `json:"listing_type"`
+	Views                       int      `json:"views"`
 	Tags                        []string `json:"tags"`
 	Materials                   []string `json:"materials"`
 	ShippingProfileID           int64    `json:"shipping_profile_id"`
@@ -0,0 +1,124 @@
+package etsy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"etsy_dev_v1_202512/pkg/net"
+)
+
+// ==================== Listing ====================
+
+// CreateDraftListing 创建草稿商品
+// POST /v3/application/shops/{shop_id}/listings
+func (c *Client) CreateDraftListing(ctx context.Context, cred Credentials, req EtsyListingCreateReq) (*ProductListingDTO, error) {
+	var out ProductListingDTO
+	if err := c.do(ctx, cred, http.MethodPost, shopPath(cred)+"/listings", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetListing 获取单个商品
+// GET /v3/application/listings/{listing_id}
+func (c *Client) GetListing(ctx context.Context, cred Credentials, listingID int64) (*ProductListingDTO, error) {
+	var out ProductListingDTO
+	if err := c.get(ctx, cred, fmt.Sprintf("/listings/%d", listingID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateListing 更新商品（仅提交非空字段）
+// PATCH /v3/application/shops/{shop_id}/listings/{listing_id}
+func (c *Client) UpdateListing(ctx context.Context, cred Credentials, listingID int64, req EtsyListingUpdateReq) (*ProductListingDTO, error) {
+	var out ProductListingDTO
+	path := fmt.Sprintf("%s/listings/%d", shopPath(cred), listingID)
+	if err := c.do(ctx, cred, http.MethodPatch, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteListing 删除商品
+// DELETE /v3/application/listings/{listing_id}
+func (c *Client) DeleteListing(ctx context.Context, cred Credentials, listingID int64) error {
+	return c.do(ctx, cred, http.MethodDelete, fmt.Sprintf("/listings/%d", listingID), nil, nil, nil)
+}
+
+// ShopListings 店铺商品分页迭代器，state 为空时使用 Etsy 默认（active）
+// GET /v3/application/shops/{shop_id}/listings
+func (c *Client) ShopListings(cred Credentials, state string) *Pager[ProductListingDTO] {
+	query := url.Values{}
+	if state != "" {
+		query.Set("state", state)
+	}
+	return newPager[ProductListingDTO](c, cred, shopPath(cred)+"/listings", query)
+}
+
+// ==================== Listing Image ====================
+
+// UploadListingImage 上传商品图片
+// POST /v3/application/shops/{shop_id}/listings/{listing_id}/images
+func (c *Client) UploadListingImage(ctx context.Context, cred Credentials, listingID int64, req EtsyListingImageUploadReq) (*EtsyListingImageResp, error) {
+	fields := map[string]string{}
+	if req.Rank > 0 {
+		fields["rank"] = strconv.Itoa(req.Rank)
+	}
+	if req.AltText != "" {
+		fields["alt_text"] = req.AltText
+	}
+
+	var out EtsyListingImageResp
+	path := fmt.Sprintf("%s/listings/%d/images", shopPath(cred), listingID)
+	files := map[string]net.FileData{
+		"image": {Data: req.Data, Filename: req.Filename},
+	}
+	if err := c.upload(ctx, cred, path, files, fields, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListListingImages 获取商品全部图片
+// GET /v3/application/listings/{listing_id}/images
+func (c *Client) ListListingImages(ctx context.Context, cred Credentials, listingID int64) ([]EtsyListingImageResp, error) {
+	var out listResp[EtsyListingImageResp]
+	if err := c.get(ctx, cred, fmt.Sprintf("/listings/%d/images", listingID), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// DeleteListingImage 删除商品图片
+// DELETE /v3/application/shops/{shop_id}/listings/{listing_id}/images/{listing_image_id}
+func (c *Client) DeleteListingImage(ctx context.Context, cred Credentials, listingID, imageID int64) error {
+	path := fmt.Sprintf("%s/listings/%d/images/%d", shopPath(cred), listingID, imageID)
+	return c.do(ctx, cred, http.MethodDelete, path, nil, nil, nil)
+}
+
+// ==================== Listing Inventory ====================
+
+// GetListingInventory 获取商品库存（变体 / SKU / 价格）
+// GET /v3/application/listings/{listing_id}/inventory
+func (c *Client) GetListingInventory(ctx context.Context, cred Credentials, listingID int64) (*EtsyListingInventoryResp, error) {
+	var out EtsyListingInventoryResp
+	if err := c.get(ctx, cred, fmt.Sprintf("/listings/%d/inventory", listingID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateListingInventory 全量覆盖商品库存（未提交的变体会被删除）
+// PUT /v3/application/listings/{listing_id}/inventory
+func (c *Client) UpdateListingInventory(ctx context.Context, cred Credentials, listingID int64, req EtsyListingInventoryUpdateReq) (*EtsyListingInventoryResp, error) {
+	var out EtsyListingInventoryResp
+	path := fmt.Sprintf("/listings/%d/inventory", listingID)
+	if err := c.do(ctx, cred, http.MethodPut, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,137 @@
+package etsy
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultPageLimit Etsy 列表接口单页上限
+const DefaultPageLimit = 100
+
+// listResp Etsy 列表接口通用响应
+type listResp[T any] struct {
+	Count   int `json:"count"`
+	Results []T `json:"results"`
+}
+
+// Pager limit/offset 分页迭代器
+//
+//	pager := client.ShopReceipts(cred, query)
+//	for pager.Next(ctx) {
+//		handle(pager.Page())
+//	}
+//	if err := pager.Err(); err != nil { ... }
+type Pager[T any] struct {
+	client *Client
+	cred   Credentials
+	path   string
+	query  url.Values
+
+	limit  int
+	offset int
+	delay  time.Duration
+
+	page    []T
+	fetched bool
+	done    bool
+	err     error
+}
+
+func newPager[T any](c *Client, cred Credentials, path string, query url.Values) *Pager[T] {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = append([]string(nil), v...)
+	}
+	return &Pager[T]{
+		client: c,
+		cred:   cred,
+		path:   path,
+		query:  q,
+		limit:  DefaultPageLimit,
+	}
+}
+
+// WithLimit 设置单页数量（1~100）
+func (p *Pager[T]) WithLimit(limit int) *Pager[T] {
+	if limit > 0 && limit <= DefaultPageLimit {
+		p.limit = limit
+	}
+	return p
+}
+
+// WithOffset 设置起始偏移
+func (p *Pager[T]) WithOffset(offset int) *Pager[T] {
+	if offset > 0 {
+		p.offset = offset
+	}
+	return p
+}
+
+// WithDelay 设置翻页间隔（避免短时间内打满每秒配额）
+func (p *Pager[T]) WithDelay(d time.Duration) *Pager[T] {
+	p.delay = d
+	return p
+}
+
+// Next 拉取下一页；没有更多数据或出错时返回 false
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	if p.fetched {
+		p.offset += len(p.page)
+		if p.delay > 0 {
+			timer := time.NewTimer(p.delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				p.err = ctx.Err()
+				return false
+			case <-timer.C:
+			}
+		}
+	}
+
+	p.query.Set("limit", strconv.Itoa(p.limit))
+	p.query.Set("offset", strconv.Itoa(p.offset))
+
+	var resp listResp[T]
+	if err := p.client.get(ctx, p.cred, p.path, p.query, &resp); err != nil {
+		p.err = err
+		return false
+	}
+
+	p.fetched = true
+	p.page = resp.Results
+	if len(resp.Results) < p.limit || p.offset+len(resp.Results) >= resp.Count {
+		p.done = true
+	}
+	return len(resp.Results) > 0
+}
+
+// Page 当前页数据
+func (p *Pager[T]) Page() []T {
+	return p.page
+}
+
+// Offset 当前页起始偏移
+func (p *Pager[T]) Offset() int {
+	return p.offset
+}
+
+// Err 迭代中断的错误
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// All 拉取全部分页
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	for p.Next(ctx) {
+		all = append(all, p.Page()...)
+	}
+	return all, p.Err()
+}
@@ -0,0 +1,74 @@
+package etsy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ShopReceipts 店铺订单分页迭代器
+// GET /v3/application/shops/{shop_id}/receipts
+func (c *Client) ShopReceipts(cred Credentials, q EtsyReceiptsQuery) *Pager[EtsyReceiptResp] {
+	return newPager[EtsyReceiptResp](c, cred, shopPath(cred)+"/receipts", q.values())
+}
+
+// GetReceipt 获取单个订单
+// GET /v3/application/shops/{shop_id}/receipts/{receipt_id}
+func (c *Client) GetReceipt(ctx context.Context, cred Credentials, receiptID int64) (*EtsyReceiptResp, error) {
+	var out EtsyReceiptResp
+	path := fmt.Sprintf("%s/receipts/%d", shopPath(cred), receiptID)
+	if err := c.get(ctx, cred, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListReceiptTransactions 获取订单的全部交易明细
+// GET /v3/application/shops/{shop_id}/receipts/{receipt_id}/transactions
+func (c *Client) ListReceiptTransactions(ctx context.Context, cred Credentials, receiptID int64) ([]EtsyTransactionResp, error) {
+	var out listResp[EtsyTransactionResp]
+	path := fmt.Sprintf("%s/receipts/%d/transactions", shopPath(cred), receiptID)
+	if err := c.get(ctx, cred, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// CreateReceiptShipment 提交订单物流信息，返回更新后的订单
+// POST /v3/application/shops/{shop_id}/receipts/{receipt_id}/tracking
+func (c *Client) CreateReceiptShipment(ctx context.Context, cred Credentials, receiptID int64, req EtsyReceiptShipmentCreateReq) (*EtsyReceiptResp, error) {
+	var out EtsyReceiptResp
+	path := fmt.Sprintf("%s/receipts/%d/tracking", shopPath(cred), receiptID)
+	if err := c.do(ctx, cred, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (q EtsyReceiptsQuery) values() url.Values {
+	v := url.Values{}
+	setUnix := func(key string, ts int64) {
+		if ts > 0 {
+			v.Set(key, strconv.FormatInt(ts, 10))
+		}
+	}
+	setUnix("min_created", q.MinCreated)
+	setUnix("max_created", q.MaxCreated)
+	setUnix("min_last_modified", q.MinLastModified)
+	setUnix("max_last_modified", q.MaxLastModified)
+	if q.SortOn != "" {
+		v.Set("sort_on", q.SortOn)
+	}
+	if q.SortOrder != "" {
+		v.Set("sort_order", q.SortOrder)
+	}
+	if q.WasPaid != nil {
+		v.Set("was_paid", strconv.FormatBool(*q.WasPaid))
+	}
+	if q.WasShipped != nil {
+		v.Set("was_shipped", strconv.FormatBool(*q.WasShipped))
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package etsy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ListReturnPolicies 获取店铺全部退货政策
+// GET /v3/application/shops/{shop_id}/policies/return
+func (c *Client) ListReturnPolicies(ctx context.Context, cred Credentials) ([]EtsyReturnPolicyResp, error) {
+	var out EtsyReturnPoliciesResp
+	if err := c.get(ctx, cred, shopPath(cred)+"/policies/return", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// GetReturnPolicy 获取单个退货政策
+// GET /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
+func (c *Client) GetReturnPolicy(ctx context.Context, cred Credentials, policyID int64) (*EtsyReturnPolicyResp, error) {
+	var out EtsyReturnPolicyResp
+	if err := c.get(ctx, cred, returnPolicyPath(cred, policyID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateReturnPolicy 创建退货政策
+// POST /v3/application/shops/{shop_id}/policies/return
+func (c *Client) CreateReturnPolicy(ctx context.Context, cred Credentials, req EtsyReturnPolicyCreateReq) (*EtsyReturnPolicyResp, error) {
+	var out EtsyReturnPolicyResp
+	if err := c.do(ctx, cred, http.MethodPost, shopPath(cred)+"/policies/return", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateReturnPolicy 更新退货政策
+// PUT /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
+func (c *Client) UpdateReturnPolicy(ctx context.Context, cred Credentials, policyID int64, req EtsyReturnPolicyUpdateReq) (*EtsyReturnPolicyResp, error) {
+	var out EtsyReturnPolicyResp
+	if err := c.do(ctx, cred, http.MethodPut, returnPolicyPath(cred, policyID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteReturnPolicy 删除退货政策
+// DELETE /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
+func (c *Client) DeleteReturnPolicy(ctx context.Context, cred Credentials, policyID int64) error {
+	return c.do(ctx, cred, http.MethodDelete, returnPolicyPath(cred, policyID), nil, nil, nil)
+}
+
+// ConsolidateReturnPolicies 合并退货政策（源政策下的商品迁移到目标政策后删除源政策）
+// POST /v3/application/shops/{shop_id}/policies/return/consolidate
+func (c *Client) ConsolidateReturnPolicies(ctx context.Context, cred Credentials, req EtsyReturnPolicyConsolidateReq) (*EtsyReturnPolicyResp, error) {
+	var out EtsyReturnPolicyResp
+	path := shopPath(cred) + "/policies/return/consolidate"
+	if err := c.do(ctx, cred, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// returnPolicyPath /shops/{shop_id}/policies/return/{return_policy_id}
+func returnPolicyPath(cred Credentials, policyID int64) string {
+	return fmt.Sprintf("%s/policies/return/%d", shopPath(cred), policyID)
+}
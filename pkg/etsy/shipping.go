@@ -0,0 +1,136 @@
+package etsy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ==================== Shipping Profile ====================
+
+// ListShippingProfiles 获取店铺全部运费模板（含目的地和升级选项）
+// GET /v3/application/shops/{shop_id}/shipping-profiles
+func (c *Client) ListShippingProfiles(ctx context.Context, cred Credentials) ([]EtsyShippingProfileResp, error) {
+	var out EtsyShippingProfilesResp
+	if err := c.get(ctx, cred, shopPath(cred)+"/shipping-profiles", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// GetShippingProfile 获取单个运费模板
+// GET /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func (c *Client) GetShippingProfile(ctx context.Context, cred Credentials, profileID int64) (*EtsyShippingProfileResp, error) {
+	var out EtsyShippingProfileResp
+	if err := c.get(ctx, cred, profilePath(cred, profileID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateShippingProfile 创建运费模板
+// POST /v3/application/shops/{shop_id}/shipping-profiles
+func (c *Client) CreateShippingProfile(ctx context.Context, cred Credentials, req EtsyShippingProfileCreateReq) (*EtsyShippingProfileResp, error) {
+	var out EtsyShippingProfileResp
+	if err := c.do(ctx, cred, http.MethodPost, shopPath(cred)+"/shipping-profiles", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateShippingProfile 更新运费模板
+// PUT /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func (c *Client) UpdateShippingProfile(ctx context.Context, cred Credentials, profileID int64, req EtsyShippingProfileUpdateReq) (*EtsyShippingProfileResp, error) {
+	var out EtsyShippingProfileResp
+	if err := c.do(ctx, cred, http.MethodPut, profilePath(cred, profileID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteShippingProfile 删除运费模板
+// DELETE /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func (c *Client) DeleteShippingProfile(ctx context.Context, cred Credentials, profileID int64) error {
+	return c.do(ctx, cred, http.MethodDelete, profilePath(cred, profileID), nil, nil, nil)
+}
+
+// ==================== Shipping Destination ====================
+
+// CreateShippingDestination 创建运费目的地
+// POST /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations
+func (c *Client) CreateShippingDestination(ctx context.Context, cred Credentials, profileID int64, req EtsyShippingDestinationCreateReq) (*EtsyShippingDestinationResp, error) {
+	var out EtsyShippingDestinationResp
+	path := profilePath(cred, profileID) + "/destinations"
+	if err := c.do(ctx, cred, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateShippingDestination 更新运费目的地
+// PUT /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations/{shipping_profile_destination_id}
+func (c *Client) UpdateShippingDestination(ctx context.Context, cred Credentials, profileID, destinationID int64, req EtsyShippingDestinationUpdateReq) (*EtsyShippingDestinationResp, error) {
+	var out EtsyShippingDestinationResp
+	path := fmt.Sprintf("%s/destinations/%d", profilePath(cred, profileID), destinationID)
+	if err := c.do(ctx, cred, http.MethodPut, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteShippingDestination 删除运费目的地
+// DELETE /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations/{shipping_profile_destination_id}
+func (c *Client) DeleteShippingDestination(ctx context.Context, cred Credentials, profileID, destinationID int64) error {
+	path := fmt.Sprintf("%s/destinations/%d", profilePath(cred, profileID), destinationID)
+	return c.do(ctx, cred, http.MethodDelete, path, nil, nil, nil)
+}
+
+// ==================== Shipping Upgrade ====================
+
+// CreateShippingUpgrade 创建加急配送选项
+// POST /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades
+func (c *Client) CreateShippingUpgrade(ctx context.Context, cred Credentials, profileID int64, req EtsyShippingUpgradeCreateReq) (*EtsyShippingUpgradeResp, error) {
+	var out EtsyShippingUpgradeResp
+	path := profilePath(cred, profileID) + "/upgrades"
+	if err := c.do(ctx, cred, http.MethodPost, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateShippingUpgrade 更新加急配送选项
+// PUT /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades/{upgrade_id}
+func (c *Client) UpdateShippingUpgrade(ctx context.Context, cred Credentials, profileID, upgradeID int64, req EtsyShippingUpgradeUpdateReq) (*EtsyShippingUpgradeResp, error) {
+	var out EtsyShippingUpgradeResp
+	path := fmt.Sprintf("%s/upgrades/%d", profilePath(cred, profileID), upgradeID)
+	if err := c.do(ctx, cred, http.MethodPut, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteShippingUpgrade 删除加急配送选项
+// DELETE /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades/{upgrade_id}
+func (c *Client) DeleteShippingUpgrade(ctx context.Context, cred Credentials, profileID, upgradeID int64) error {
+	path := fmt.Sprintf("%s/upgrades/%d", profilePath(cred, profileID), upgradeID)
+	return c.do(ctx, cred, http.MethodDelete, path, nil, nil, nil)
+}
+
+// ==================== Shipping Carrier ====================
+
+// ListShippingCarriers 获取发货国家可用的物流承运商
+// GET /v3/application/shipping-carriers
+func (c *Client) ListShippingCarriers(ctx context.Context, cred Credentials, originCountryISO string) ([]EtsyShippingCarrierResp, error) {
+	var out EtsyShippingCarriersResp
+	query := url.Values{"origin_country_iso": {originCountryISO}}
+	if err := c.get(ctx, cred, "/shipping-carriers", query, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// profilePath /shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func profilePath(cred Credentials, profileID int64) string {
+	return fmt.Sprintf("%s/shipping-profiles/%d", shopPath(cred), profileID)
+}
@@ -0,0 +1,74 @@
+package etsy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ==================== Shop ====================
+
+// GetShop 获取店铺信息
+// GET /v3/application/shops/{shop_id}
+func (c *Client) GetShop(ctx context.Context, cred Credentials) (*EtsyShopResp, error) {
+	var out EtsyShopResp
+	if err := c.get(ctx, cred, shopPath(cred), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateShop 更新店铺信息
+// PUT /v3/application/shops/{shop_id}
+func (c *Client) UpdateShop(ctx context.Context, cred Credentials, req EtsyShopUpdateReq) (*EtsyShopResp, error) {
+	var out EtsyShopResp
+	if err := c.do(ctx, cred, http.MethodPut, shopPath(cred), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ==================== Shop Section ====================
+
+// ListShopSections 获取店铺分区（Etsy 不分页，一次返回全部）
+// GET /v3/application/shops/{shop_id}/sections
+func (c *Client) ListShopSections(ctx context.Context, cred Credentials) ([]EtsyShopSectionResp, error) {
+	var out EtsyShopSectionsResp
+	if err := c.get(ctx, cred, shopPath(cred)+"/sections", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// CreateShopSection 创建店铺分区
+// POST /v3/application/shops/{shop_id}/sections
+func (c *Client) CreateShopSection(ctx context.Context, cred Credentials, req EtsyShopSectionCreateReq) (*EtsyShopSectionResp, error) {
+	var out EtsyShopSectionResp
+	if err := c.do(ctx, cred, http.MethodPost, shopPath(cred)+"/sections", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateShopSection 更新店铺分区
+// PUT /v3/application/shops/{shop_id}/sections/{shop_section_id}
+func (c *Client) UpdateShopSection(ctx context.Context, cred Credentials, sectionID int64, req EtsyShopSectionUpdateReq) (*EtsyShopSectionResp, error) {
+	var out EtsyShopSectionResp
+	path := fmt.Sprintf("%s/sections/%d", shopPath(cred), sectionID)
+	if err := c.do(ctx, cred, http.MethodPut, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteShopSection 删除店铺分区
+// DELETE /v3/application/shops/{shop_id}/sections/{shop_section_id}
+func (c *Client) DeleteShopSection(ctx context.Context, cred Credentials, sectionID int64) error {
+	path := fmt.Sprintf("%s/sections/%d", shopPath(cred), sectionID)
+	return c.do(ctx, cred, http.MethodDelete, path, nil, nil, nil)
+}
+
+// shopPath /shops/{shop_id}
+func shopPath(cred Credentials) string {
+	return fmt.Sprintf("/shops/%d", cred.EtsyShopID)
+}
@@ -0,0 +1,27 @@
+package etsy
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetSellerTaxonomyNodes 获取卖家类目树（仅需 API Key）
+// GET /v3/application/seller-taxonomy/nodes
+func (c *Client) GetSellerTaxonomyNodes(ctx context.Context, cred Credentials) ([]EtsyTaxonomyNodeResp, error) {
+	var out listResp[EtsyTaxonomyNodeResp]
+	if err := c.get(ctx, cred, "/seller-taxonomy/nodes", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
+
+// GetTaxonomyProperties 获取类目支持的属性（变体 / 属性选择依据）
+// GET /v3/application/seller-taxonomy/nodes/{taxonomy_id}/properties
+func (c *Client) GetTaxonomyProperties(ctx context.Context, cred Credentials, taxonomyID int64) ([]EtsyTaxonomyPropertyResp, error) {
+	var out listResp[EtsyTaxonomyPropertyResp]
+	path := fmt.Sprintf("/seller-taxonomy/nodes/%d/properties", taxonomyID)
+	if err := c.get(ctx, cred, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Results, nil
+}
@@ -0,0 +1,211 @@
+package etsysim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault 注入的故障响应
+type Fault struct {
+	Method     string // 空表示任意方法
+	Path       string // 路径前缀（如 /v3/application/shops/1/listings），空表示任意路径
+	Status     int
+	Body       string // 空时返回 {"error": "..."}
+	RetryAfter int    // Retry-After 响应头（秒）；0 时仅 429 写入，<0 表示不写
+	Times      int    // 生效次数，0 表示一直生效
+}
+
+// RecordedRequest 已收到的请求
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  string
+	APIKey string
+	At     time.Time
+}
+
+// keyQuota 单个 API Key 的配额计数
+type keyQuota struct {
+	second     int64
+	usedSecond int
+	day        int64
+	usedToday  int
+}
+
+// InjectFault 追加故障规则，按注入顺序匹配
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fault := f
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults 清除全部故障规则
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests 已收到的请求（按到达顺序）
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// CountRequests 统计匹配 method（空表示任意）与路径前缀的请求数
+func (s *Server) CountRequests(method, pathPrefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, req := range s.requests {
+		if (method == "" || req.Method == method) && strings.HasPrefix(req.Path, pathPrefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// ResetRequests 清空请求记录
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// record 记录请求并返回本次延迟
+func (s *Server) record(r *http.Request) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		APIKey: r.Header.Get("x-api-key"),
+		At:     time.Now(),
+	})
+
+	delay := s.latency
+	if s.jitter > 0 {
+		delay += time.Duration(s.rnd.Int63n(int64(s.jitter)))
+	}
+	return delay
+}
+
+// injectFault 命中故障规则或随机错误时写入响应并返回 true
+func (s *Server) injectFault(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	var hit *Fault
+	for i, f := range s.faults {
+		if (f.Method != "" && f.Method != r.Method) || !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+		fault := *f
+		hit = &fault
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		break
+	}
+	if hit == nil && s.errorRate > 0 && s.rnd.Float64() < s.errorRate {
+		hit = &Fault{Status: s.errorStatus}
+	}
+	s.mu.Unlock()
+
+	if hit == nil {
+		return false
+	}
+	if hit.RetryAfter >= 0 && (hit.RetryAfter > 0 || hit.Status == http.StatusTooManyRequests) {
+		w.Header().Set("Retry-After", strconv.Itoa(hit.RetryAfter))
+	}
+	if hit.Body != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(hit.Status)
+		_, _ = w.Write([]byte(hit.Body))
+		return true
+	}
+	writeError(w, hit.Status, http.StatusText(hit.Status))
+	return true
+}
+
+// RevokeAPIKey 吊销 API Key（模拟开发者账号被封），之后该 Key 的接口请求返回 403、换取 Token 返回 invalid_client
+func (s *Server) RevokeAPIKey(apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[apiKey] = true
+}
+
+// RestoreAPIKey 恢复已吊销的 API Key
+func (s *Server) RestoreAPIKey(apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.revoked, apiKey)
+}
+
+// rejectRevokedKey 已吊销的 Key 返回 403
+func (s *Server) rejectRevokedKey(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	revoked := s.revoked[r.Header.Get("x-api-key")]
+	s.mu.Unlock()
+	if revoked {
+		writeError(w, http.StatusForbidden, "Invalid API key: the key has been revoked")
+	}
+	return revoked
+}
+
+// consumeQuota 扣减 API Key 配额并写入配额响应头，超限时返回 429
+func (s *Server) consumeQuota(w http.ResponseWriter, r *http.Request) bool {
+	apiKey := r.Header.Get("x-api-key")
+	if apiKey == "" {
+		// 交给路由返回 401
+		return true
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	q := s.quotas[apiKey]
+	if q == nil {
+		q = &keyQuota{}
+		s.quotas[apiKey] = q
+	}
+	if sec := now.Unix(); sec != q.second {
+		q.second, q.usedSecond = sec, 0
+	}
+	if day := now.Unix() / 86400; day != q.day {
+		q.day, q.usedToday = day, 0
+	}
+	q.usedSecond++
+	q.usedToday++
+	s.total++
+
+	perSecond := s.limitPerSecond
+	if perSecond <= 0 {
+		perSecond = 10
+	}
+	limited := (s.limitPerSecond > 0 && q.usedSecond > s.limitPerSecond) ||
+		(s.limitPerDay > 0 && q.usedToday > s.limitPerDay) ||
+		(s.rateLimitEvery > 0 && s.total%s.rateLimitEvery == 0)
+	remainingSecond := max(perSecond-q.usedSecond, 0)
+	remainingToday := max(s.limitPerDay-q.usedToday, 0)
+	s.mu.Unlock()
+
+	h := w.Header()
+	h.Set("x-limit-per-second", strconv.Itoa(perSecond))
+	h.Set("x-remaining-this-second", strconv.Itoa(remainingSecond))
+	if s.limitPerDay > 0 {
+		h.Set("x-limit-per-day", strconv.Itoa(s.limitPerDay))
+		h.Set("x-remaining-today", strconv.Itoa(remainingToday))
+	}
+	if limited {
+		h.Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "You have exceeded your quota")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,419 @@
+package etsysim
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// Etsy 商品状态
+var listingStates = map[string]bool{
+	"active": true, "inactive": true, "draft": true, "expired": true, "sold_out": true,
+}
+
+// putListingLocked 写入商品并补全默认字段、默认库存
+func (s *Server) putListingLocked(sh *shop, dto etsy.ProductListingDTO) *listing {
+	if dto.ListingID == 0 {
+		dto.ListingID = s.id()
+	}
+	now := time.Now().Unix()
+	dto.ShopID = sh.info.ShopID
+	dto.UserID = sh.info.UserID
+	if dto.State == "" {
+		dto.State = "draft"
+	}
+	if dto.CreationTimestamp == 0 {
+		dto.CreationTimestamp = now
+	}
+	dto.CreatedTimestamp = dto.CreationTimestamp
+	dto.OriginalCreationTimestamp = dto.CreationTimestamp
+	dto.LastModifiedTimestamp = now
+	dto.UpdatedTimestamp = now
+	dto.StateTimestamp = now
+	dto.URL = fmt.Sprintf("https://www.etsy.com/listing/%d", dto.ListingID)
+	dto.ListingType = "physical"
+	if dto.Price.Divisor == 0 {
+		dto.Price.Divisor = 100
+	}
+	if dto.Price.CurrencyCode == "" {
+		dto.Price.CurrencyCode = sh.info.CurrencyCode
+	}
+
+	l := &listing{dto: dto}
+	l.inventory = etsy.EtsyListingInventoryResp{
+		Products: []etsy.EtsyInventoryProduct{{
+			ProductID: s.id(),
+			Offerings: []etsy.EtsyInventoryOffering{{
+				OfferingID: s.id(),
+				Price:      dto.Price,
+				Quantity:   dto.Quantity,
+				IsEnabled:  true,
+			}},
+			PropertyValues: []etsy.EtsyInventoryPropValue{},
+		}},
+		PriceOnProperty:    []int64{},
+		QuantityOnProperty: []int64{},
+		SkuOnProperty:      []int64{},
+	}
+	s.listings[dto.ListingID] = l
+	return l
+}
+
+// shopListing 取路径中的商品并确认属于该店铺
+func (s *Server) shopListing(w http.ResponseWriter, r *http.Request, sh *shop) (*listing, bool) {
+	id, ok := pathID(w, r, "listing_id")
+	if !ok {
+		return nil, false
+	}
+	l := s.listings[id]
+	if l == nil || l.dto.ShopID != sh.info.ShopID {
+		writeError(w, http.StatusNotFound, "Listing not found")
+		return nil, false
+	}
+	return l, true
+}
+
+// ==================== 商品 ====================
+
+// handleCreateListing POST /v3/application/shops/{shop_id}/listings
+func (s *Server) handleCreateListing(w http.ResponseWriter, r *http.Request, sh *shop) {
+	var req etsy.EtsyListingCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	var missing []string
+	if strings.TrimSpace(req.Title) == "" {
+		missing = append(missing, "title")
+	}
+	if req.Quantity <= 0 {
+		missing = append(missing, "quantity")
+	}
+	if req.Price.Amount <= 0 {
+		missing = append(missing, "price")
+	}
+	if req.WhoMade == "" {
+		missing = append(missing, "who_made")
+	}
+	if req.WhenMade == "" {
+		missing = append(missing, "when_made")
+	}
+	if req.TaxonomyID <= 0 {
+		missing = append(missing, "taxonomy_id")
+	}
+	if len(missing) > 0 {
+		writeError(w, http.StatusBadRequest, "Missing or invalid required fields: "+strings.Join(missing, ", "))
+		return
+	}
+	if len(req.Tags) > 13 {
+		writeError(w, http.StatusBadRequest, "A listing may have at most 13 tags")
+		return
+	}
+	if req.ShippingProfileID > 0 && sh.profiles[req.ShippingProfileID] == nil {
+		writeError(w, http.StatusBadRequest, "shipping_profile_id does not belong to this shop")
+		return
+	}
+	if req.ReturnPolicyID > 0 && sh.policies[req.ReturnPolicyID] == nil {
+		writeError(w, http.StatusBadRequest, "return_policy_id does not belong to this shop")
+		return
+	}
+
+	l := s.putListingLocked(sh, etsy.ProductListingDTO{
+		Title:              req.Title,
+		Description:        req.Description,
+		Quantity:           req.Quantity,
+		Price:              req.Price,
+		WhoMade:            req.WhoMade,
+		WhenMade:           req.WhenMade,
+		TaxonomyID:         req.TaxonomyID,
+		ShippingProfileID:  req.ShippingProfileID,
+		ReturnPolicyID:     req.ReturnPolicyID,
+		ShopSectionID:      req.ShopSectionID,
+		IsSupply:           req.IsSupply,
+		Tags:               req.Tags,
+		Materials:          req.Materials,
+		Style:              req.Styles,
+		ItemWeight:         req.ItemWeight,
+		ItemWeightUnit:     req.ItemWeightUnit,
+		ItemLength:         req.ItemLength,
+		ItemWidth:          req.ItemWidth,
+		ItemHeight:         req.ItemHeight,
+		ItemDimensionsUnit: req.ItemDimensionsUnit,
+		ShouldAutoRenew:    true,
+		Language:           "en-US",
+	})
+	writeJSON(w, http.StatusCreated, l.dto)
+}
+
+// handleListListings 按 state 过滤（默认 active），按 ID 升序分页
+// GET /v3/application/shops/{shop_id}/listings
+func (s *Server) handleListListings(w http.ResponseWriter, r *http.Request, sh *shop) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "active"
+	}
+	if !listingStates[state] {
+		writeError(w, http.StatusBadRequest, "Invalid state: "+state)
+		return
+	}
+
+	var matched []etsy.ProductListingDTO
+	for _, l := range s.shopListings(sh.info.ShopID) {
+		if l.dto.State == state {
+			matched = append(matched, l.dto)
+		}
+	}
+	limit, offset := pagination(r)
+	writeJSON(w, http.StatusOK, listResult(paginate(matched, limit, offset), len(matched)))
+}
+
+// handleGetListing GET /v3/application/listings/{listing_id}
+func (s *Server) handleGetListing(w http.ResponseWriter, r *http.Request, sh *shop, l *listing) {
+	writeJSON(w, http.StatusOK, l.dto)
+}
+
+// handleUpdateListing 仅更新提交的字段；激活商品需至少一张图片
+// PATCH /v3/application/shops/{shop_id}/listings/{listing_id}
+func (s *Server) handleUpdateListing(w http.ResponseWriter, r *http.Request, sh *shop) {
+	l, ok := s.shopListing(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyListingUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.State != "" {
+		if req.State != "active" && req.State != "inactive" {
+			writeError(w, http.StatusBadRequest, "state must be active or inactive")
+			return
+		}
+		if req.State == "active" && len(l.images) == 0 {
+			writeError(w, http.StatusBadRequest, "Listing must have at least one image to be activated")
+			return
+		}
+	}
+	if req.Quantity != nil && *req.Quantity <= 0 {
+		writeError(w, http.StatusBadRequest, "quantity must be greater than 0")
+		return
+	}
+	if req.Price != nil && req.Price.Amount <= 0 {
+		writeError(w, http.StatusBadRequest, "price must be greater than 0")
+		return
+	}
+
+	now := time.Now().Unix()
+	if req.Title != nil {
+		l.dto.Title = *req.Title
+	}
+	if req.Description != nil {
+		l.dto.Description = *req.Description
+	}
+	if req.Price != nil {
+		l.dto.Price = *req.Price
+	}
+	if req.Quantity != nil {
+		l.dto.Quantity = *req.Quantity
+	}
+	if len(req.Tags) > 0 {
+		l.dto.Tags = req.Tags
+	}
+	if req.State != "" && req.State != l.dto.State {
+		l.dto.State = req.State
+		l.dto.StateTimestamp = now
+	}
+	l.dto.LastModifiedTimestamp = now
+	l.dto.UpdatedTimestamp = now
+	writeJSON(w, http.StatusOK, l.dto)
+}
+
+// handleDeleteListing DELETE /v3/application/listings/{listing_id}
+func (s *Server) handleDeleteListing(w http.ResponseWriter, r *http.Request, sh *shop, l *listing) {
+	delete(s.listings, l.dto.ListingID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ==================== 图片 ====================
+
+// handleUploadImage multipart 字段：image（必填）、rank、alt_text
+// POST /v3/application/shops/{shop_id}/listings/{listing_id}/images
+func (s *Server) handleUploadImage(w http.ResponseWriter, r *http.Request, sh *shop) {
+	l, ok := s.shopListing(w, r, sh)
+	if !ok {
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid multipart body: "+err.Error())
+		return
+	}
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "image is required")
+		return
+	}
+	file.Close()
+	if header.Size == 0 {
+		writeError(w, http.StatusBadRequest, "image is empty")
+		return
+	}
+	if len(l.images) >= 10 {
+		writeError(w, http.StatusBadRequest, "A listing may have at most 10 images")
+		return
+	}
+
+	rank := len(l.images) + 1
+	if v, err := strconv.Atoi(r.FormValue("rank")); err == nil && v > 0 && v <= rank {
+		rank = v
+	}
+	id := s.id()
+	image := etsy.EtsyListingImageResp{
+		ListingImageID: id,
+		ListingID:      l.dto.ListingID,
+		Rank:           rank,
+		URLFullxFull:   fmt.Sprintf("https://i.etsystatic.com/sim/il_fullxfull.%d.jpg", id),
+		URL570xN:       fmt.Sprintf("https://i.etsystatic.com/sim/il_570xN.%d.jpg", id),
+		URL75x75:       fmt.Sprintf("https://i.etsystatic.com/sim/il_75x75.%d.jpg", id),
+		FullHeight:     1000,
+		FullWidth:      1000,
+		AltText:        r.FormValue("alt_text"),
+	}
+
+	// 插入到指定位置，后续图片顺延
+	pos := rank - 1
+	l.images = append(l.images, etsy.EtsyListingImageResp{})
+	copy(l.images[pos+1:], l.images[pos:])
+	l.images[pos] = image
+	renumberImages(l)
+	writeJSON(w, http.StatusCreated, image)
+}
+
+// handleListImages GET /v3/application/listings/{listing_id}/images
+func (s *Server) handleListImages(w http.ResponseWriter, r *http.Request, sh *shop, l *listing) {
+	writeJSON(w, http.StatusOK, listResult(l.images, len(l.images)))
+}
+
+// handleDeleteImage DELETE /v3/application/shops/{shop_id}/listings/{listing_id}/images/{listing_image_id}
+func (s *Server) handleDeleteImage(w http.ResponseWriter, r *http.Request, sh *shop) {
+	l, ok := s.shopListing(w, r, sh)
+	if !ok {
+		return
+	}
+	imageID, ok := pathID(w, r, "listing_image_id")
+	if !ok {
+		return
+	}
+	for i, img := range l.images {
+		if img.ListingImageID == imageID {
+			l.images = append(l.images[:i], l.images[i+1:]...)
+			renumberImages(l)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Listing image not found")
+}
+
+func renumberImages(l *listing) {
+	for i := range l.images {
+		l.images[i].Rank = i + 1
+	}
+}
+
+// ==================== 库存 ====================
+
+// handleGetInventory GET /v3/application/listings/{listing_id}/inventory
+func (s *Server) handleGetInventory(w http.ResponseWriter, r *http.Request, sh *shop, l *listing) {
+	writeJSON(w, http.StatusOK, l.inventory)
+}
+
+// handleUpdateInventory 全量覆盖库存，并回写商品的数量（启用变体之和）与价格（最低价）
+// PUT /v3/application/listings/{listing_id}/inventory
+func (s *Server) handleUpdateInventory(w http.ResponseWriter, r *http.Request, sh *shop, l *listing) {
+	var req etsy.EtsyListingInventoryUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.Products) == 0 {
+		writeError(w, http.StatusBadRequest, "products must not be empty")
+		return
+	}
+
+	currency := sh.info.CurrencyCode
+	inv := etsy.EtsyListingInventoryResp{
+		PriceOnProperty:    nonNil(req.PriceOnProperty),
+		QuantityOnProperty: nonNil(req.QuantityOnProperty),
+		SkuOnProperty:      nonNil(req.SkuOnProperty),
+	}
+	seen := make(map[string]bool)
+	quantity, minPrice := 0, int64(math.MaxInt64)
+	for i, p := range req.Products {
+		if len(p.Offerings) != 1 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("products[%d] must have exactly one offering", i))
+			return
+		}
+		offer := p.Offerings[0]
+		if offer.Price <= 0 || offer.Quantity < 0 || offer.Quantity > 999 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("products[%d] has invalid price or quantity", i))
+			return
+		}
+		key := propertyKey(p.PropertyValues)
+		if seen[key] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("products[%d] duplicates property values of another product", i))
+			return
+		}
+		seen[key] = true
+
+		amount := int64(math.Round(offer.Price * 100))
+		inv.Products = append(inv.Products, etsy.EtsyInventoryProduct{
+			ProductID: s.id(),
+			SKU:       p.SKU,
+			Offerings: []etsy.EtsyInventoryOffering{{
+				OfferingID: s.id(),
+				Price:      etsy.PriceDTO{Amount: amount, Divisor: 100, CurrencyCode: currency},
+				Quantity:   offer.Quantity,
+				IsEnabled:  offer.IsEnabled,
+			}},
+			PropertyValues: nonNil(p.PropertyValues),
+		})
+		if offer.IsEnabled {
+			quantity += offer.Quantity
+			minPrice = min(minPrice, amount)
+		}
+	}
+	if quantity == 0 {
+		writeError(w, http.StatusBadRequest, "At least one enabled offering must have quantity greater than 0")
+		return
+	}
+
+	l.inventory = inv
+	l.dto.Quantity = quantity
+	l.dto.Price = etsy.PriceDTO{Amount: minPrice, Divisor: 100, CurrencyCode: currency}
+	l.dto.HasVariations = len(inv.Products) > 1
+	l.dto.LastModifiedTimestamp = time.Now().Unix()
+	l.dto.UpdatedTimestamp = l.dto.LastModifiedTimestamp
+	writeJSON(w, http.StatusOK, l.inventory)
+}
+
+// propertyKey 变体属性组合的唯一键
+func propertyKey(values []etsy.EtsyInventoryPropValue) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, fmt.Sprintf("%d=%s", v.PropertyID, strings.Join(v.Values, "|")))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
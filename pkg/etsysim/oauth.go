@@ -0,0 +1,130 @@
+package etsysim
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// handleConnect 模拟授权页：直接视为卖家已同意，302 回调 redirect_uri?code=...&state=...
+// 可选参数 shop_id 指定授权的店铺，缺省时新建一个店铺
+// GET /oauth/connect
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	clientID, redirectURI, challenge := q.Get("client_id"), q.Get("redirect_uri"), q.Get("code_challenge")
+	if q.Get("response_type") != "code" || clientID == "" || redirectURI == "" || challenge == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "response_type=code, client_id, redirect_uri and code_challenge are required")
+		return
+	}
+	if method := q.Get("code_challenge_method"); method != "S256" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "code_challenge_method must be S256")
+		return
+	}
+	callback, err := url.Parse(redirectURI)
+	if err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid redirect_uri")
+		return
+	}
+
+	s.mu.Lock()
+	var sh *shop
+	if shopID, err := strconv.ParseInt(q.Get("shop_id"), 10, 64); err == nil {
+		sh = s.shops[shopID]
+		if sh == nil {
+			sh = s.addShopLocked(shopInfo(shopID))
+		}
+	} else {
+		sh = s.addShopLocked(shopInfo(0))
+	}
+	code := randomString(32)
+	s.codes[code] = &authCode{
+		shopID:      sh.info.ShopID,
+		clientID:    clientID,
+		redirectURI: redirectURI,
+		challenge:   challenge,
+		expiresAt:   time.Now().Add(5 * time.Minute),
+	}
+	s.mu.Unlock()
+
+	values := callback.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	callback.RawQuery = values.Encode()
+	http.Redirect(w, r, callback.String(), http.StatusFound)
+}
+
+// handleToken 换取 / 刷新 Token
+// POST /v3/public/oauth/token
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if r.PostForm.Get("client_id") == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "client_id is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.revoked[r.PostForm.Get("client_id")] {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "API key has been revoked")
+		return
+	}
+
+	var sh *shop
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := s.codes[r.PostForm.Get("code")]
+		if code == nil || time.Now().After(code.expiresAt) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code is invalid or expired")
+			return
+		}
+		delete(s.codes, r.PostForm.Get("code"))
+		if code.clientID != r.PostForm.Get("client_id") || code.redirectURI != r.PostForm.Get("redirect_uri") {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "client_id or redirect_uri does not match the authorization request")
+			return
+		}
+		if pkceChallenge(r.PostForm.Get("code_verifier")) != code.challenge {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match code_challenge")
+			return
+		}
+		sh = s.shops[code.shopID]
+
+	case "refresh_token":
+		refreshToken := r.PostForm.Get("refresh_token")
+		shopID, ok := s.refresh[refreshToken]
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh_token is invalid")
+			return
+		}
+		delete(s.refresh, refreshToken)
+		sh = s.shops[shopID]
+
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be authorization_code or refresh_token")
+		return
+	}
+
+	if sh == nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "shop no longer exists")
+		return
+	}
+	access, refresh := s.issueTokenLocked(sh, s.tokenTTL)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"expires_in":    int(s.tokenTTL.Seconds()),
+		"refresh_token": refresh,
+	})
+}
+
+// pkceChallenge Base64UrlEncode(SHA256(verifier))
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
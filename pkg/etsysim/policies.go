@@ -0,0 +1,153 @@
+package etsysim
+
+import (
+	"net/http"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// Etsy 允许的退货期限（天）
+var returnDeadlines = map[int]bool{7: true, 14: true, 21: true, 30: true, 45: true, 60: true, 90: true}
+
+// validatePolicy 校验退货政策取值，失败时已写入 400
+func validatePolicy(w http.ResponseWriter, acceptsReturns, acceptsExchanges bool, deadline int) bool {
+	if !acceptsReturns && !acceptsExchanges {
+		writeError(w, http.StatusBadRequest, "A return policy must accept returns or exchanges")
+		return false
+	}
+	if !returnDeadlines[deadline] {
+		writeError(w, http.StatusBadRequest, "return_deadline must be one of 7, 14, 21, 30, 45, 60, 90")
+		return false
+	}
+	return true
+}
+
+// findPolicy 取路径中的退货政策
+func findPolicy(w http.ResponseWriter, r *http.Request, sh *shop) (*etsy.EtsyReturnPolicyResp, bool) {
+	id, ok := pathID(w, r, "return_policy_id")
+	if !ok {
+		return nil, false
+	}
+	policy := sh.policies[id]
+	if policy == nil {
+		writeError(w, http.StatusNotFound, "Return policy not found")
+		return nil, false
+	}
+	return policy, true
+}
+
+// hasSamePolicy 店铺内是否已存在相同取值的政策（Etsy 返回 409）
+func hasSamePolicy(sh *shop, excludeID int64, acceptsReturns, acceptsExchanges bool, deadline int) bool {
+	for _, p := range sh.policies {
+		if p.ReturnPolicyID != excludeID && p.AcceptsReturns == acceptsReturns &&
+			p.AcceptsExchanges == acceptsExchanges && p.ReturnDeadline == deadline {
+			return true
+		}
+	}
+	return false
+}
+
+// handleListPolicies GET /v3/application/shops/{shop_id}/policies/return
+func (s *Server) handleListPolicies(w http.ResponseWriter, r *http.Request, sh *shop) {
+	policies := sortedValues(sh.policies)
+	writeJSON(w, http.StatusOK, listResult(policies, len(policies)))
+}
+
+// handleGetPolicy GET /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
+func (s *Server) handleGetPolicy(w http.ResponseWriter, r *http.Request, sh *shop) {
+	if policy, ok := findPolicy(w, r, sh); ok {
+		writeJSON(w, http.StatusOK, policy)
+	}
+}
+
+// handleCreatePolicy POST /v3/application/shops/{shop_id}/policies/return
+func (s *Server) handleCreatePolicy(w http.ResponseWriter, r *http.Request, sh *shop) {
+	var req etsy.EtsyReturnPolicyCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !validatePolicy(w, req.AcceptsReturns, req.AcceptsExchanges, req.ReturnDeadline) {
+		return
+	}
+	if hasSamePolicy(sh, 0, req.AcceptsReturns, req.AcceptsExchanges, req.ReturnDeadline) {
+		writeError(w, http.StatusConflict, "A return policy with the same values already exists")
+		return
+	}
+
+	id := s.id()
+	sh.policies[id] = &etsy.EtsyReturnPolicyResp{
+		ReturnPolicyID:   id,
+		ShopID:           sh.info.ShopID,
+		AcceptsReturns:   req.AcceptsReturns,
+		AcceptsExchanges: req.AcceptsExchanges,
+		ReturnDeadline:   req.ReturnDeadline,
+	}
+	writeJSON(w, http.StatusCreated, sh.policies[id])
+}
+
+// handleUpdatePolicy PUT /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
+func (s *Server) handleUpdatePolicy(w http.ResponseWriter, r *http.Request, sh *shop) {
+	policy, ok := findPolicy(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyReturnPolicyUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !validatePolicy(w, req.AcceptsReturns, req.AcceptsExchanges, req.ReturnDeadline) {
+		return
+	}
+	if hasSamePolicy(sh, policy.ReturnPolicyID, req.AcceptsReturns, req.AcceptsExchanges, req.ReturnDeadline) {
+		writeError(w, http.StatusConflict, "A return policy with the same values already exists")
+		return
+	}
+
+	policy.AcceptsReturns = req.AcceptsReturns
+	policy.AcceptsExchanges = req.AcceptsExchanges
+	policy.ReturnDeadline = req.ReturnDeadline
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// handleDeletePolicy 仍被商品使用的政策不可删除（需先 consolidate）
+// DELETE /v3/application/shops/{shop_id}/policies/return/{return_policy_id}
+func (s *Server) handleDeletePolicy(w http.ResponseWriter, r *http.Request, sh *shop) {
+	policy, ok := findPolicy(w, r, sh)
+	if !ok {
+		return
+	}
+	for _, l := range s.shopListings(sh.info.ShopID) {
+		if l.dto.ReturnPolicyID == policy.ReturnPolicyID {
+			writeError(w, http.StatusConflict, "Return policy is assigned to listings, consolidate it first")
+			return
+		}
+	}
+	delete(sh.policies, policy.ReturnPolicyID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleConsolidatePolicies 源政策下的商品迁移到目标政策，然后删除源政策
+// POST /v3/application/shops/{shop_id}/policies/return/consolidate
+func (s *Server) handleConsolidatePolicies(w http.ResponseWriter, r *http.Request, sh *shop) {
+	var req etsy.EtsyReturnPolicyConsolidateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	source, dest := sh.policies[req.SourceReturnPolicyID], sh.policies[req.DestinationReturnPolicyID]
+	if source == nil || dest == nil {
+		writeError(w, http.StatusNotFound, "Return policy not found")
+		return
+	}
+	if source == dest {
+		writeError(w, http.StatusBadRequest, "source and destination must be different")
+		return
+	}
+
+	for _, l := range s.shopListings(sh.info.ShopID) {
+		if l.dto.ReturnPolicyID == source.ReturnPolicyID {
+			l.dto.ReturnPolicyID = dest.ReturnPolicyID
+		}
+	}
+	delete(sh.policies, source.ReturnPolicyID)
+	writeJSON(w, http.StatusOK, dest)
+}
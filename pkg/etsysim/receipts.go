@@ -0,0 +1,151 @@
+package etsysim
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// handleListReceipts 支持时间 / 支付 / 发货过滤与排序（默认按创建时间倒序）
+// GET /v3/application/shops/{shop_id}/receipts
+func (s *Server) handleListReceipts(w http.ResponseWriter, r *http.Request, sh *shop) {
+	q := r.URL.Query()
+	unix := func(key string) (int64, bool) {
+		v := q.Get(key)
+		if v == "" {
+			return 0, true
+		}
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ts < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid "+key)
+			return 0, false
+		}
+		return ts, true
+	}
+	boolean := func(key string) (*bool, bool) {
+		v := q.Get(key)
+		if v == "" {
+			return nil, true
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid "+key)
+			return nil, false
+		}
+		return &b, true
+	}
+
+	minCreated, ok1 := unix("min_created")
+	maxCreated, ok2 := unix("max_created")
+	minModified, ok3 := unix("min_last_modified")
+	maxModified, ok4 := unix("max_last_modified")
+	wasPaid, ok5 := boolean("was_paid")
+	wasShipped, ok6 := boolean("was_shipped")
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6) {
+		return
+	}
+
+	var matched []etsy.EtsyReceiptResp
+	for _, rc := range sortedValues(sh.receipts) {
+		switch {
+		case minCreated > 0 && rc.CreateTimestamp < minCreated,
+			maxCreated > 0 && rc.CreateTimestamp > maxCreated,
+			minModified > 0 && rc.UpdateTimestamp < minModified,
+			maxModified > 0 && rc.UpdateTimestamp > maxModified,
+			wasPaid != nil && rc.IsPaid != *wasPaid,
+			wasShipped != nil && rc.IsShipped != *wasShipped:
+			continue
+		}
+		matched = append(matched, rc)
+	}
+
+	sortKey := func(rc etsy.EtsyReceiptResp) int64 {
+		switch q.Get("sort_on") {
+		case "updated":
+			return rc.UpdateTimestamp
+		case "receipt_id":
+			return rc.ReceiptID
+		default:
+			return rc.CreateTimestamp
+		}
+	}
+	asc := strings.EqualFold(q.Get("sort_order"), "asc")
+	sort.SliceStable(matched, func(i, j int) bool {
+		ki, kj := sortKey(matched[i]), sortKey(matched[j])
+		if ki == kj {
+			return matched[i].ReceiptID < matched[j].ReceiptID
+		}
+		return (ki < kj) == asc
+	})
+
+	limit, offset := pagination(r)
+	writeJSON(w, http.StatusOK, listResult(paginate(matched, limit, offset), len(matched)))
+}
+
+// handleGetReceipt GET /v3/application/shops/{shop_id}/receipts/{receipt_id}
+func (s *Server) handleGetReceipt(w http.ResponseWriter, r *http.Request, sh *shop) {
+	if rc, ok := findReceipt(w, r, sh); ok {
+		writeJSON(w, http.StatusOK, rc)
+	}
+}
+
+// handleListTransactions GET /v3/application/shops/{shop_id}/receipts/{receipt_id}/transactions
+func (s *Server) handleListTransactions(w http.ResponseWriter, r *http.Request, sh *shop) {
+	if rc, ok := findReceipt(w, r, sh); ok {
+		writeJSON(w, http.StatusOK, listResult(rc.Transactions, len(rc.Transactions)))
+	}
+}
+
+// handleCreateShipment 追加物流记录并标记已发货，返回完整订单
+// POST /v3/application/shops/{shop_id}/receipts/{receipt_id}/tracking
+func (s *Server) handleCreateShipment(w http.ResponseWriter, r *http.Request, sh *shop) {
+	rc, ok := findReceipt(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyReceiptShipmentCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.TrackingCode == "" || req.CarrierName == "" {
+		writeError(w, http.StatusBadRequest, "tracking_code and carrier_name are required")
+		return
+	}
+	if !rc.IsPaid {
+		writeError(w, http.StatusBadRequest, "Cannot ship an unpaid receipt")
+		return
+	}
+
+	now := time.Now().Unix()
+	rc.Shipments = append(rc.Shipments, etsy.EtsyReceiptShipmentResp{
+		ReceiptShippingID:             s.id(),
+		ShipmentNotificationTimestamp: now,
+		CarrierName:                   req.CarrierName,
+		TrackingCode:                  req.TrackingCode,
+	})
+	rc.IsShipped = true
+	rc.Status = "Completed"
+	rc.UpdateTimestamp = now
+	rc.UpdatedTimestamp = now
+	for i := range rc.Transactions {
+		rc.Transactions[i].ShippedTimestamp = now
+	}
+	writeJSON(w, http.StatusOK, rc)
+}
+
+func findReceipt(w http.ResponseWriter, r *http.Request, sh *shop) (*etsy.EtsyReceiptResp, bool) {
+	id, ok := pathID(w, r, "receipt_id")
+	if !ok {
+		return nil, false
+	}
+	rc := sh.receipts[id]
+	if rc == nil {
+		writeError(w, http.StatusNotFound, "Receipt not found")
+		return nil, false
+	}
+	return rc, true
+}
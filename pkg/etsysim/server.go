@@ -0,0 +1,423 @@
+// Package etsysim 内存版 Etsy Open API v3 模拟服务
+// 覆盖项目实际调用的接口（OAuth、店铺、分区、商品、图片、库存、订单、运费模板、退货政策），
+// 支持延迟、429 与错误注入，可作为独立进程（cmd/etsy-sim）或 httptest.Server 使用。
+package etsysim
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 路径前缀
+const (
+	ApplicationPath = "/v3/application"
+	TokenPath       = "/v3/public/oauth/token"
+	ConnectPath     = "/oauth/connect"
+)
+
+// Server 模拟服务（实现 http.Handler）
+type Server struct {
+	mu sync.Mutex
+	*store
+
+	mux *http.ServeMux
+	rnd *rand.Rand
+
+	// 配置
+	latency        time.Duration
+	jitter         time.Duration
+	limitPerSecond int // 0 表示不限制每秒请求
+	limitPerDay    int
+	rateLimitEvery int // 每 N 个请求返回一次 429，0 表示关闭
+	errorRate      float64
+	errorStatus    int
+	lenientAuth    bool
+	tokenTTL       time.Duration
+
+	// 运行状态
+	faults   []*Fault
+	requests []RecordedRequest
+	total    int
+	quotas   map[string]*keyQuota
+	revoked  map[string]bool // 已吊销的 API Key
+}
+
+// Option Server 可选配置
+type Option func(*Server)
+
+// WithLatency 每个请求固定延迟 base，外加 [0, jitter) 随机抖动
+func WithLatency(base, jitter time.Duration) Option {
+	return func(s *Server) {
+		s.latency = base
+		s.jitter = jitter
+	}
+}
+
+// WithRateLimit 按 API Key 限制每秒请求数，超出返回 429
+func WithRateLimit(perSecond int) Option {
+	return func(s *Server) {
+		s.limitPerSecond = perSecond
+	}
+}
+
+// WithDailyLimit 按 API Key 限制每日请求数（同时写入 x-limit-per-day 响应头）
+func WithDailyLimit(perDay int) Option {
+	return func(s *Server) {
+		s.limitPerDay = perDay
+	}
+}
+
+// WithRateLimitEvery 每 n 个 API 请求注入一次 429
+func WithRateLimitEvery(n int) Option {
+	return func(s *Server) {
+		s.rateLimitEvery = n
+	}
+}
+
+// WithErrorRate 按比例随机注入错误响应（status 为空时使用 500）
+func WithErrorRate(rate float64, status int) Option {
+	return func(s *Server) {
+		s.errorRate = rate
+		if status > 0 {
+			s.errorStatus = status
+		}
+	}
+}
+
+// WithLenientAuth 接受任意 Bearer Token，访问未知店铺时自动创建
+// 适合直接对接已有数据库（店铺 Token 并非由模拟服务签发）
+func WithLenientAuth() Option {
+	return func(s *Server) {
+		s.lenientAuth = true
+	}
+}
+
+// WithTokenTTL 设置签发 Token 的有效期
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// WithSeed 固定随机种子（抖动 / 随机错误可复现）
+func WithSeed(seed int64) Option {
+	return func(s *Server) {
+		s.rnd = rand.New(rand.NewSource(seed))
+	}
+}
+
+// New 创建模拟服务
+func New(opts ...Option) *Server {
+	s := &Server{
+		store:       newStore(),
+		mux:         http.NewServeMux(),
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		limitPerDay: 10000,
+		errorStatus: http.StatusInternalServerError,
+		tokenTTL:    time.Hour,
+		quotas:      make(map[string]*keyQuota),
+		revoked:     make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.routes()
+	return s
+}
+
+// routes 注册全部接口
+func (s *Server) routes() {
+	// OAuth
+	s.mux.HandleFunc("GET "+ConnectPath, s.handleConnect)
+	s.mux.HandleFunc("POST "+TokenPath, s.handleToken)
+
+	// 仅需 API Key
+	s.keyRoute("GET /openapi-ping", s.handlePing)
+	s.keyRoute("GET /seller-taxonomy/nodes", s.handleTaxonomyNodes)
+	s.keyRoute("GET /seller-taxonomy/nodes/{taxonomy_id}/properties", s.handleTaxonomyProperties)
+	s.keyRoute("GET /shipping-carriers", s.handleShippingCarriers)
+
+	// 店铺 & 分区
+	s.shopRoute("GET /shops/{shop_id}", s.handleGetShop)
+	s.shopRoute("PUT /shops/{shop_id}", s.handleUpdateShop)
+	s.shopRoute("GET /shops/{shop_id}/sections", s.handleListSections)
+	s.shopRoute("POST /shops/{shop_id}/sections", s.handleCreateSection)
+	s.shopRoute("PUT /shops/{shop_id}/sections/{shop_section_id}", s.handleUpdateSection)
+	s.shopRoute("DELETE /shops/{shop_id}/sections/{shop_section_id}", s.handleDeleteSection)
+
+	// 商品
+	s.shopRoute("POST /shops/{shop_id}/listings", s.handleCreateListing)
+	s.shopRoute("GET /shops/{shop_id}/listings", s.handleListListings)
+	s.shopRoute("PATCH /shops/{shop_id}/listings/{listing_id}", s.handleUpdateListing)
+	s.shopRoute("POST /shops/{shop_id}/listings/{listing_id}/images", s.handleUploadImage)
+	s.shopRoute("DELETE /shops/{shop_id}/listings/{listing_id}/images/{listing_image_id}", s.handleDeleteImage)
+	s.listingRoute("GET /listings/{listing_id}", s.handleGetListing)
+	s.listingRoute("DELETE /listings/{listing_id}", s.handleDeleteListing)
+	s.listingRoute("GET /listings/{listing_id}/images", s.handleListImages)
+	s.listingRoute("GET /listings/{listing_id}/inventory", s.handleGetInventory)
+	s.listingRoute("PUT /listings/{listing_id}/inventory", s.handleUpdateInventory)
+
+	// 订单
+	s.shopRoute("GET /shops/{shop_id}/receipts", s.handleListReceipts)
+	s.shopRoute("GET /shops/{shop_id}/receipts/{receipt_id}", s.handleGetReceipt)
+	s.shopRoute("GET /shops/{shop_id}/receipts/{receipt_id}/transactions", s.handleListTransactions)
+	s.shopRoute("POST /shops/{shop_id}/receipts/{receipt_id}/tracking", s.handleCreateShipment)
+
+	// 运费模板
+	s.shopRoute("GET /shops/{shop_id}/shipping-profiles", s.handleListProfiles)
+	s.shopRoute("POST /shops/{shop_id}/shipping-profiles", s.handleCreateProfile)
+	s.shopRoute("GET /shops/{shop_id}/shipping-profiles/{shipping_profile_id}", s.handleGetProfile)
+	s.shopRoute("PUT /shops/{shop_id}/shipping-profiles/{shipping_profile_id}", s.handleUpdateProfile)
+	s.shopRoute("DELETE /shops/{shop_id}/shipping-profiles/{shipping_profile_id}", s.handleDeleteProfile)
+	s.shopRoute("POST /shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations", s.handleCreateDestination)
+	s.shopRoute("PUT /shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations/{destination_id}", s.handleUpdateDestination)
+	s.shopRoute("DELETE /shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations/{destination_id}", s.handleDeleteDestination)
+	s.shopRoute("POST /shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades", s.handleCreateUpgrade)
+	s.shopRoute("PUT /shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades/{upgrade_id}", s.handleUpdateUpgrade)
+	s.shopRoute("DELETE /shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades/{upgrade_id}", s.handleDeleteUpgrade)
+
+	// 退货政策
+	s.shopRoute("GET /shops/{shop_id}/policies/return", s.handleListPolicies)
+	s.shopRoute("POST /shops/{shop_id}/policies/return", s.handleCreatePolicy)
+	s.shopRoute("POST /shops/{shop_id}/policies/return/consolidate", s.handleConsolidatePolicies)
+	s.shopRoute("GET /shops/{shop_id}/policies/return/{return_policy_id}", s.handleGetPolicy)
+	s.shopRoute("PUT /shops/{shop_id}/policies/return/{return_policy_id}", s.handleUpdatePolicy)
+	s.shopRoute("DELETE /shops/{shop_id}/policies/return/{return_policy_id}", s.handleDeletePolicy)
+}
+
+// ServeHTTP 记录请求 -> 延迟 -> 故障注入 -> Key 吊销 -> 配额 -> 路由
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	delay := s.record(r)
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if s.injectFault(w, r) {
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, ApplicationPath+"/") && (s.rejectRevokedKey(w, r) || !s.consumeQuota(w, r)) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// ==================== 路由封装 ====================
+
+type (
+	shopHandler    func(w http.ResponseWriter, r *http.Request, sh *shop)
+	listingHandler func(w http.ResponseWriter, r *http.Request, sh *shop, l *listing)
+)
+
+// keyRoute 仅校验 x-api-key 的接口
+func (s *Server) keyRoute(pattern string, h http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+ApplicationPath+path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") == "" {
+			writeError(w, http.StatusUnauthorized, "Missing x-api-key header")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		h(w, r)
+	})
+}
+
+// shopRoute 店铺维度接口：校验 Token 并确认 Token 属于路径中的店铺
+func (s *Server) shopRoute(pattern string, h shopHandler) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+ApplicationPath+path, func(w http.ResponseWriter, r *http.Request) {
+		shopID, ok := pathID(w, r, "shop_id")
+		if !ok {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		tok, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		sh := s.shops[shopID]
+		if sh == nil && s.lenientAuth {
+			sh = s.addShopLocked(shopInfo(shopID))
+		}
+		if sh == nil {
+			writeError(w, http.StatusNotFound, "Shop not found")
+			return
+		}
+		if tok != nil && tok.shopID != shopID {
+			writeError(w, http.StatusForbidden, "The access token does not grant access to this shop")
+			return
+		}
+		h(w, r, sh)
+	})
+}
+
+// listingRoute 商品维度接口（路径不含 shop_id）：按商品所属店铺校验 Token
+func (s *Server) listingRoute(pattern string, h listingHandler) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+ApplicationPath+path, func(w http.ResponseWriter, r *http.Request) {
+		listingID, ok := pathID(w, r, "listing_id")
+		if !ok {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		tok, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		l := s.listings[listingID]
+		if l == nil {
+			writeError(w, http.StatusNotFound, "Listing not found")
+			return
+		}
+		if tok != nil && tok.shopID != l.dto.ShopID {
+			writeError(w, http.StatusForbidden, "The access token does not grant access to this listing")
+			return
+		}
+		h(w, r, s.shops[l.dto.ShopID], l)
+	})
+}
+
+// authenticate 校验 x-api-key 与 Bearer Token；宽松模式下返回 nil token 表示不限店铺
+// 调用方需持有 s.mu
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*token, bool) {
+	if r.Header.Get("x-api-key") == "" {
+		writeError(w, http.StatusUnauthorized, "Missing x-api-key header")
+		return nil, false
+	}
+	accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || accessToken == "" {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", "Missing access token")
+		return nil, false
+	}
+	if s.lenientAuth {
+		return nil, true
+	}
+
+	tok := s.tokens[accessToken]
+	if tok == nil || time.Now().After(tok.expiresAt) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", "access token is expired or invalid")
+		return nil, false
+	}
+	return tok, true
+}
+
+// ==================== 响应工具 ====================
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出 Etsy 风格错误 {"error": "..."}
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// writeOAuthError 输出 OAuth 风格错误 {"error": "...", "error_description": "..."}
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+// listResult Etsy 列表响应 {"count": n, "results": [...]}
+func listResult[T any](items []T, count int) map[string]interface{} {
+	if items == nil {
+		items = []T{}
+	}
+	return map[string]interface{}{"count": count, "results": items}
+}
+
+// pathID 解析路径中的数字 ID，失败时已写入 400
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "Invalid "+name)
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeBody 解析 JSON 请求体，失败时已写入 400
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// pagination 解析 limit / offset（limit 默认 25，最大 100）
+func pagination(r *http.Request) (limit, offset int) {
+	limit, offset = 25, 0
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = min(v, 100)
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v > 0 {
+		offset = v
+	}
+	return limit, offset
+}
+
+// paginate 截取一页
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
+
+// ==================== httptest ====================
+
+// TestServer 基于 httptest.Server 的模拟服务
+type TestServer struct {
+	*Server
+	HTTP *httptest.Server
+}
+
+// NewTestServer 启动测试用模拟服务，用完需 Close
+func NewTestServer(opts ...Option) *TestServer {
+	s := New(opts...)
+	return &TestServer{Server: s, HTTP: httptest.NewServer(s)}
+}
+
+// URL 服务根地址
+func (t *TestServer) URL() string {
+	return t.HTTP.URL
+}
+
+// BaseURL Open API 地址（etsy.WithBaseURL）
+func (t *TestServer) BaseURL() string {
+	return t.HTTP.URL + ApplicationPath
+}
+
+// TokenURL OAuth Token 地址
+func (t *TestServer) TokenURL() string {
+	return t.HTTP.URL + TokenPath
+}
+
+// ConnectURL OAuth 授权页地址
+func (t *TestServer) ConnectURL() string {
+	return t.HTTP.URL + ConnectPath
+}
+
+// Close 关闭服务
+func (t *TestServer) Close() {
+	t.HTTP.Close()
+}
@@ -0,0 +1,398 @@
+package etsysim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// newProfileLocked 创建运费模板及其首个目的地
+func (s *Server) newProfileLocked(sh *shop, req etsy.EtsyShippingProfileCreateReq) *etsy.EtsyShippingProfileResp {
+	profileID := s.id()
+	profile := &etsy.EtsyShippingProfileResp{
+		ShippingProfileID:          profileID,
+		Title:                      req.Title,
+		UserID:                     sh.info.UserID,
+		MinProcessingDays:          req.MinProcessingDays,
+		MaxProcessingDays:          req.MaxProcessingDays,
+		ProcessingDaysDisplayLabel: processingLabel(req.MinProcessingDays, req.MaxProcessingDays),
+		OriginCountryISO:           req.OriginCountryISO,
+		OriginPostalCode:           req.OriginPostalCode,
+		ProfileType:                "manual",
+		ShippingProfileUpgrades:    []etsy.EtsyShippingUpgradeResp{},
+	}
+	profile.ShippingProfileDestinations = []etsy.EtsyShippingDestinationResp{
+		s.newDestinationLocked(sh, profile, etsy.EtsyShippingDestinationCreateReq{
+			DestinationCountryISO: req.DestinationCountryISO,
+			DestinationRegion:     req.DestinationRegion,
+			PrimaryCost:           req.PrimaryCost,
+			SecondaryCost:         req.SecondaryCost,
+			ShippingCarrierID:     req.ShippingCarrierID,
+			MailClass:             req.MailClass,
+			MinDeliveryDays:       req.MinDeliveryDays,
+			MaxDeliveryDays:       req.MaxDeliveryDays,
+		}),
+	}
+	sh.profiles[profileID] = profile
+	return profile
+}
+
+func (s *Server) newDestinationLocked(sh *shop, profile *etsy.EtsyShippingProfileResp, req etsy.EtsyShippingDestinationCreateReq) etsy.EtsyShippingDestinationResp {
+	currency := sh.info.CurrencyCode
+	return etsy.EtsyShippingDestinationResp{
+		ShippingProfileDestinationID: s.id(),
+		ShippingProfileID:            profile.ShippingProfileID,
+		OriginCountryISO:             profile.OriginCountryISO,
+		DestinationCountryISO:        req.DestinationCountryISO,
+		DestinationRegion:            orDefault(req.DestinationRegion, "none"),
+		PrimaryCost:                  money(req.PrimaryCost, currency),
+		SecondaryCost:                money(req.SecondaryCost, currency),
+		ShippingCarrierID:            req.ShippingCarrierID,
+		MailClass:                    req.MailClass,
+		MinDeliveryDays:              req.MinDeliveryDays,
+		MaxDeliveryDays:              req.MaxDeliveryDays,
+	}
+}
+
+// findProfile 取路径中的运费模板
+func findProfile(w http.ResponseWriter, r *http.Request, sh *shop) (*etsy.EtsyShippingProfileResp, bool) {
+	id, ok := pathID(w, r, "shipping_profile_id")
+	if !ok {
+		return nil, false
+	}
+	profile := sh.profiles[id]
+	if profile == nil {
+		writeError(w, http.StatusNotFound, "Shipping profile not found")
+		return nil, false
+	}
+	return profile, true
+}
+
+// ==================== 运费模板 ====================
+
+// handleListProfiles GET /v3/application/shops/{shop_id}/shipping-profiles
+func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profiles := sortedValues(sh.profiles)
+	writeJSON(w, http.StatusOK, listResult(profiles, len(profiles)))
+}
+
+// handleGetProfile GET /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request, sh *shop) {
+	if profile, ok := findProfile(w, r, sh); ok {
+		writeJSON(w, http.StatusOK, profile)
+	}
+}
+
+// handleCreateProfile POST /v3/application/shops/{shop_id}/shipping-profiles
+func (s *Server) handleCreateProfile(w http.ResponseWriter, r *http.Request, sh *shop) {
+	var req etsy.EtsyShippingProfileCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	switch {
+	case strings.TrimSpace(req.Title) == "" || req.OriginCountryISO == "":
+		writeError(w, http.StatusBadRequest, "title and origin_country_iso are required")
+		return
+	case req.DestinationCountryISO == "" && req.DestinationRegion == "":
+		writeError(w, http.StatusBadRequest, "Either destination_country_iso or destination_region is required")
+		return
+	case req.MinProcessingDays <= 0 || req.MaxProcessingDays < req.MinProcessingDays:
+		writeError(w, http.StatusBadRequest, "Invalid min_processing_time / max_processing_time")
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.newProfileLocked(sh, req))
+}
+
+// handleUpdateProfile PUT /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, ok := findProfile(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyShippingProfileUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	minDays, maxDays := profile.MinProcessingDays, profile.MaxProcessingDays
+	if req.MinProcessingDays > 0 {
+		minDays = req.MinProcessingDays
+	}
+	if req.MaxProcessingDays > 0 {
+		maxDays = req.MaxProcessingDays
+	}
+	if maxDays < minDays {
+		writeError(w, http.StatusBadRequest, "max_processing_time must not be less than min_processing_time")
+		return
+	}
+
+	setString(&profile.Title, req.Title)
+	setString(&profile.OriginCountryISO, req.OriginCountryISO)
+	setString(&profile.OriginPostalCode, req.OriginPostalCode)
+	profile.MinProcessingDays, profile.MaxProcessingDays = minDays, maxDays
+	profile.ProcessingDaysDisplayLabel = processingLabel(minDays, maxDays)
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// handleDeleteProfile 仍被商品使用的模板不可删除
+// DELETE /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}
+func (s *Server) handleDeleteProfile(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, ok := findProfile(w, r, sh)
+	if !ok {
+		return
+	}
+	for _, l := range s.shopListings(sh.info.ShopID) {
+		if l.dto.ShippingProfileID == profile.ShippingProfileID {
+			writeError(w, http.StatusConflict, "Shipping profile is assigned to listings and cannot be deleted")
+			return
+		}
+	}
+	delete(sh.profiles, profile.ShippingProfileID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ==================== 目的地 ====================
+
+// handleCreateDestination POST /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations
+func (s *Server) handleCreateDestination(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, ok := findProfile(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyShippingDestinationCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.DestinationCountryISO == "" && req.DestinationRegion == "" {
+		writeError(w, http.StatusBadRequest, "Either destination_country_iso or destination_region is required")
+		return
+	}
+
+	dest := s.newDestinationLocked(sh, profile, req)
+	profile.ShippingProfileDestinations = append(profile.ShippingProfileDestinations, dest)
+	writeJSON(w, http.StatusCreated, dest)
+}
+
+// handleUpdateDestination PUT /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations/{destination_id}
+func (s *Server) handleUpdateDestination(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, dest, ok := findDestination(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyShippingDestinationUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	currency := sh.info.CurrencyCode
+	setString(&dest.DestinationCountryISO, req.DestinationCountryISO)
+	setString(&dest.DestinationRegion, req.DestinationRegion)
+	setString(&dest.MailClass, req.MailClass)
+	if req.PrimaryCost > 0 {
+		dest.PrimaryCost = money(req.PrimaryCost, currency)
+	}
+	if req.SecondaryCost > 0 {
+		dest.SecondaryCost = money(req.SecondaryCost, currency)
+	}
+	if req.ShippingCarrierID > 0 {
+		dest.ShippingCarrierID = req.ShippingCarrierID
+	}
+	if req.MinDeliveryDays > 0 {
+		dest.MinDeliveryDays = req.MinDeliveryDays
+	}
+	if req.MaxDeliveryDays > 0 {
+		dest.MaxDeliveryDays = req.MaxDeliveryDays
+	}
+	dest.OriginCountryISO = profile.OriginCountryISO
+	writeJSON(w, http.StatusOK, dest)
+}
+
+// handleDeleteDestination 模板至少保留一个目的地
+// DELETE /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/destinations/{destination_id}
+func (s *Server) handleDeleteDestination(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, dest, ok := findDestination(w, r, sh)
+	if !ok {
+		return
+	}
+	if len(profile.ShippingProfileDestinations) == 1 {
+		writeError(w, http.StatusBadRequest, "A shipping profile must have at least one destination")
+		return
+	}
+	dests := profile.ShippingProfileDestinations[:0]
+	for _, d := range profile.ShippingProfileDestinations {
+		if d.ShippingProfileDestinationID != dest.ShippingProfileDestinationID {
+			dests = append(dests, d)
+		}
+	}
+	profile.ShippingProfileDestinations = dests
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func findDestination(w http.ResponseWriter, r *http.Request, sh *shop) (*etsy.EtsyShippingProfileResp, *etsy.EtsyShippingDestinationResp, bool) {
+	profile, ok := findProfile(w, r, sh)
+	if !ok {
+		return nil, nil, false
+	}
+	id, ok := pathID(w, r, "destination_id")
+	if !ok {
+		return nil, nil, false
+	}
+	for i := range profile.ShippingProfileDestinations {
+		if profile.ShippingProfileDestinations[i].ShippingProfileDestinationID == id {
+			return profile, &profile.ShippingProfileDestinations[i], true
+		}
+	}
+	writeError(w, http.StatusNotFound, "Shipping profile destination not found")
+	return nil, nil, false
+}
+
+// ==================== 加急选项 ====================
+
+// handleCreateUpgrade POST /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades
+func (s *Server) handleCreateUpgrade(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, ok := findProfile(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyShippingUpgradeCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.UpgradeName) == "" {
+		writeError(w, http.StatusBadRequest, "upgrade_name is required")
+		return
+	}
+	if req.Type != 0 && req.Type != 1 {
+		writeError(w, http.StatusBadRequest, "type must be 0 (domestic) or 1 (international)")
+		return
+	}
+
+	currency := sh.info.CurrencyCode
+	upgrade := etsy.EtsyShippingUpgradeResp{
+		UpgradeID:         s.id(),
+		ShippingProfileID: profile.ShippingProfileID,
+		UpgradeName:       req.UpgradeName,
+		Type:              req.Type,
+		Price:             money(req.Price, currency),
+		SecondaryCost:     money(req.SecondaryCost, currency),
+		ShippingCarrierID: req.ShippingCarrierID,
+		MailClass:         req.MailClass,
+		MinDeliveryDays:   req.MinDeliveryDays,
+		MaxDeliveryDays:   req.MaxDeliveryDays,
+	}
+	profile.ShippingProfileUpgrades = append(profile.ShippingProfileUpgrades, upgrade)
+	writeJSON(w, http.StatusCreated, upgrade)
+}
+
+// handleUpdateUpgrade PUT /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades/{upgrade_id}
+func (s *Server) handleUpdateUpgrade(w http.ResponseWriter, r *http.Request, sh *shop) {
+	_, upgrade, ok := findUpgrade(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyShippingUpgradeUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	currency := sh.info.CurrencyCode
+	setString(&upgrade.UpgradeName, req.UpgradeName)
+	setString(&upgrade.MailClass, req.MailClass)
+	if req.Type > 0 {
+		upgrade.Type = req.Type
+	}
+	if req.Price > 0 {
+		upgrade.Price = money(req.Price, currency)
+	}
+	if req.SecondaryCost > 0 {
+		upgrade.SecondaryCost = money(req.SecondaryCost, currency)
+	}
+	if req.ShippingCarrierID > 0 {
+		upgrade.ShippingCarrierID = req.ShippingCarrierID
+	}
+	if req.MinDeliveryDays > 0 {
+		upgrade.MinDeliveryDays = req.MinDeliveryDays
+	}
+	if req.MaxDeliveryDays > 0 {
+		upgrade.MaxDeliveryDays = req.MaxDeliveryDays
+	}
+	writeJSON(w, http.StatusOK, upgrade)
+}
+
+// handleDeleteUpgrade DELETE /v3/application/shops/{shop_id}/shipping-profiles/{shipping_profile_id}/upgrades/{upgrade_id}
+func (s *Server) handleDeleteUpgrade(w http.ResponseWriter, r *http.Request, sh *shop) {
+	profile, upgrade, ok := findUpgrade(w, r, sh)
+	if !ok {
+		return
+	}
+	upgrades := profile.ShippingProfileUpgrades[:0]
+	for _, u := range profile.ShippingProfileUpgrades {
+		if u.UpgradeID != upgrade.UpgradeID {
+			upgrades = append(upgrades, u)
+		}
+	}
+	profile.ShippingProfileUpgrades = upgrades
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func findUpgrade(w http.ResponseWriter, r *http.Request, sh *shop) (*etsy.EtsyShippingProfileResp, *etsy.EtsyShippingUpgradeResp, bool) {
+	profile, ok := findProfile(w, r, sh)
+	if !ok {
+		return nil, nil, false
+	}
+	id, ok := pathID(w, r, "upgrade_id")
+	if !ok {
+		return nil, nil, false
+	}
+	for i := range profile.ShippingProfileUpgrades {
+		if profile.ShippingProfileUpgrades[i].UpgradeID == id {
+			return profile, &profile.ShippingProfileUpgrades[i], true
+		}
+	}
+	writeError(w, http.StatusNotFound, "Shipping profile upgrade not found")
+	return nil, nil, false
+}
+
+// ==================== 承运商 ====================
+
+// handleShippingCarriers GET /v3/application/shipping-carriers
+func (s *Server) handleShippingCarriers(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("origin_country_iso") == "" {
+		writeError(w, http.StatusBadRequest, "origin_country_iso is required")
+		return
+	}
+	carriers := []etsy.EtsyShippingCarrierResp{
+		{
+			ShippingCarrierID: 1, Name: "USPS",
+			DomesticClasses:      []etsy.EtsyMailClassResp{{MailClassKey: "priority", Name: "Priority Mail"}, {MailClassKey: "first_class", Name: "First-Class Mail"}},
+			InternationalClasses: []etsy.EtsyMailClassResp{{MailClassKey: "priority_international", Name: "Priority Mail International"}},
+		},
+		{
+			ShippingCarrierID: 2, Name: "UPS",
+			DomesticClasses:      []etsy.EtsyMailClassResp{{MailClassKey: "ground", Name: "UPS Ground"}},
+			InternationalClasses: []etsy.EtsyMailClassResp{},
+		},
+		{
+			ShippingCarrierID: 3, Name: "FedEx",
+			DomesticClasses:      []etsy.EtsyMailClassResp{{MailClassKey: "home_delivery", Name: "FedEx Home Delivery"}},
+			InternationalClasses: []etsy.EtsyMailClassResp{{MailClassKey: "international_economy", Name: "FedEx International Economy"}},
+		},
+	}
+	writeJSON(w, http.StatusOK, listResult(carriers, len(carriers)))
+}
+
+func processingLabel(minDays, maxDays int) string {
+	if minDays == maxDays {
+		return strconv.Itoa(minDays) + " business days"
+	}
+	return strconv.Itoa(minDays) + "-" + strconv.Itoa(maxDays) + " business days"
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package etsysim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// ==================== 店铺 ====================
+
+// handleGetShop GET /v3/application/shops/{shop_id}
+func (s *Server) handleGetShop(w http.ResponseWriter, r *http.Request, sh *shop) {
+	info := sh.info
+	info.ListingActiveCount = 0
+	for _, l := range s.shopListings(sh.info.ShopID) {
+		if l.dto.State == "active" {
+			info.ListingActiveCount++
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handleUpdateShop PUT /v3/application/shops/{shop_id}
+func (s *Server) handleUpdateShop(w http.ResponseWriter, r *http.Request, sh *shop) {
+	var req etsy.EtsyShopUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	setString(&sh.info.Title, req.Title)
+	setString(&sh.info.Announcement, req.Announcement)
+	setString(&sh.info.SaleMessage, req.SaleMessage)
+	setString(&sh.info.DigitalSaleMessage, req.DigitalSaleMessage)
+	sh.info.UpdateTimestamp = time.Now().Unix()
+	s.handleGetShop(w, r, sh)
+}
+
+// ==================== 分区 ====================
+
+// handleListSections GET /v3/application/shops/{shop_id}/sections
+func (s *Server) handleListSections(w http.ResponseWriter, r *http.Request, sh *shop) {
+	sections := sortedValues(sh.sections)
+	for i := range sections {
+		sections[i].ActiveListingCount = s.activeInSection(sh.info.ShopID, sections[i].ShopSectionID)
+	}
+	writeJSON(w, http.StatusOK, listResult(sections, len(sections)))
+}
+
+// handleCreateSection POST /v3/application/shops/{shop_id}/sections
+func (s *Server) handleCreateSection(w http.ResponseWriter, r *http.Request, sh *shop) {
+	var req etsy.EtsyShopSectionCreateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		writeError(w, http.StatusBadRequest, "title is required")
+		return
+	}
+	for _, sec := range sh.sections {
+		if strings.EqualFold(sec.Title, req.Title) {
+			writeError(w, http.StatusConflict, "A section with this title already exists")
+			return
+		}
+	}
+
+	id := s.id()
+	sh.sections[id] = &etsy.EtsyShopSectionResp{
+		ShopSectionID: id,
+		Title:         req.Title,
+		Rank:          len(sh.sections) + 1,
+		UserID:        sh.info.UserID,
+	}
+	writeJSON(w, http.StatusCreated, sh.sections[id])
+}
+
+// handleUpdateSection PUT /v3/application/shops/{shop_id}/sections/{shop_section_id}
+func (s *Server) handleUpdateSection(w http.ResponseWriter, r *http.Request, sh *shop) {
+	sec, ok := findSection(w, r, sh)
+	if !ok {
+		return
+	}
+	var req etsy.EtsyShopSectionUpdateReq
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		writeError(w, http.StatusBadRequest, "title is required")
+		return
+	}
+	sec.Title = req.Title
+	writeJSON(w, http.StatusOK, sec)
+}
+
+// handleDeleteSection DELETE /v3/application/shops/{shop_id}/sections/{shop_section_id}
+// 分区下的商品移出分区
+func (s *Server) handleDeleteSection(w http.ResponseWriter, r *http.Request, sh *shop) {
+	sec, ok := findSection(w, r, sh)
+	if !ok {
+		return
+	}
+	for _, l := range s.shopListings(sh.info.ShopID) {
+		if l.dto.ShopSectionID == sec.ShopSectionID {
+			l.dto.ShopSectionID = 0
+		}
+	}
+	delete(sh.sections, sec.ShopSectionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func findSection(w http.ResponseWriter, r *http.Request, sh *shop) (*etsy.EtsyShopSectionResp, bool) {
+	id, ok := pathID(w, r, "shop_section_id")
+	if !ok {
+		return nil, false
+	}
+	sec := sh.sections[id]
+	if sec == nil {
+		writeError(w, http.StatusNotFound, "Shop section not found: "+strconv.FormatInt(id, 10))
+		return nil, false
+	}
+	return sec, true
+}
+
+func (s *Server) activeInSection(shopID, sectionID int64) int {
+	n := 0
+	for _, l := range s.shopListings(shopID) {
+		if l.dto.ShopSectionID == sectionID && l.dto.State == "active" {
+			n++
+		}
+	}
+	return n
+}
+
+// setString 非空时覆盖
+func setString(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
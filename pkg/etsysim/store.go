@@ -0,0 +1,323 @@
+package etsysim
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// store 内存数据（所有访问需持有 Server.mu）
+type store struct {
+	nextID   int64
+	shops    map[int64]*shop
+	listings map[int64]*listing
+	tokens   map[string]*token // access_token -> token
+	refresh  map[string]int64  // refresh_token -> shopID
+	codes    map[string]*authCode
+	apps     map[string]int64 // api key -> application_id
+}
+
+type shop struct {
+	info     etsy.EtsyShopResp
+	sections map[int64]*etsy.EtsyShopSectionResp
+	receipts map[int64]*etsy.EtsyReceiptResp
+	profiles map[int64]*etsy.EtsyShippingProfileResp
+	policies map[int64]*etsy.EtsyReturnPolicyResp
+}
+
+type listing struct {
+	dto       etsy.ProductListingDTO
+	images    []etsy.EtsyListingImageResp
+	inventory etsy.EtsyListingInventoryResp
+}
+
+type token struct {
+	shopID    int64
+	expiresAt time.Time
+}
+
+type authCode struct {
+	shopID      int64
+	clientID    string
+	redirectURI string
+	challenge   string
+	expiresAt   time.Time
+}
+
+func newStore() *store {
+	return &store{
+		nextID:   1000000,
+		shops:    make(map[int64]*shop),
+		listings: make(map[int64]*listing),
+		tokens:   make(map[string]*token),
+		refresh:  make(map[string]int64),
+		codes:    make(map[string]*authCode),
+		apps:     make(map[string]int64),
+	}
+}
+
+// id 生成全局递增 ID
+func (st *store) id() int64 {
+	st.nextID++
+	return st.nextID
+}
+
+// addShopLocked 新增店铺（ShopID 为 0 时自动分配）
+func (st *store) addShopLocked(info etsy.EtsyShopResp) *shop {
+	if info.ShopID == 0 {
+		info = shopInfo(st.id())
+	}
+	if info.UserID == 0 {
+		info.UserID = info.ShopID + 1
+	}
+	if info.ShopName == "" {
+		info.ShopName = fmt.Sprintf("SimShop%d", info.ShopID)
+	}
+	if info.CurrencyCode == "" {
+		info.CurrencyCode = "USD"
+	}
+	if info.URL == "" {
+		info.URL = "https://www.etsy.com/shop/" + info.ShopName
+	}
+	now := time.Now().Unix()
+	if info.CreateTimestamp == 0 {
+		info.CreateTimestamp = now
+	}
+	info.UpdateTimestamp = now
+
+	sh := &shop{
+		info:     info,
+		sections: make(map[int64]*etsy.EtsyShopSectionResp),
+		receipts: make(map[int64]*etsy.EtsyReceiptResp),
+		profiles: make(map[int64]*etsy.EtsyShippingProfileResp),
+		policies: make(map[int64]*etsy.EtsyReturnPolicyResp),
+	}
+	st.shops[info.ShopID] = sh
+	return sh
+}
+
+// issueTokenLocked 为店铺签发一对 access / refresh token（格式与 Etsy 一致：{user_id}.{随机串}）
+func (st *store) issueTokenLocked(sh *shop, ttl time.Duration) (string, string) {
+	access := fmt.Sprintf("%d.%s", sh.info.UserID, randomString(24))
+	refresh := fmt.Sprintf("%d.%s", sh.info.UserID, randomString(24))
+	st.tokens[access] = &token{shopID: sh.info.ShopID, expiresAt: time.Now().Add(ttl)}
+	st.refresh[refresh] = sh.info.ShopID
+	return access, refresh
+}
+
+// shopListings 店铺下的全部商品（按 ID 升序）
+func (st *store) shopListings(shopID int64) []*listing {
+	var out []*listing
+	for _, id := range sortedKeys(st.listings) {
+		if l := st.listings[id]; l.dto.ShopID == shopID {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// shopInfo 默认店铺信息
+func shopInfo(shopID int64) etsy.EtsyShopResp {
+	return etsy.EtsyShopResp{ShopID: shopID}
+}
+
+// sortedKeys map 的 key 升序
+func sortedKeys[V any](m map[int64]V) []int64 {
+	keys := make([]int64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// sortedValues map 的值（按 key 升序）
+func sortedValues[V any](m map[int64]*V) []V {
+	out := make([]V, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		out = append(out, *m[k])
+	}
+	return out
+}
+
+func randomString(n int) string {
+	b := make([]byte, n/2)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func money(amount int64, currency string) etsy.EtsyMoney {
+	return etsy.EtsyMoney{Amount: int(amount), Divisor: 100, CurrencyCode: currency}
+}
+
+// ==================== 数据预置（测试 / 演示） ====================
+
+// AddShop 预置店铺，ShopID 为 0 时自动分配，返回 ShopID
+func (s *Server) AddShop(info etsy.EtsyShopResp) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addShopLocked(info).info.ShopID
+}
+
+// IssueToken 为已存在的店铺直接签发 Token（跳过 OAuth 授权页）
+func (s *Server) IssueToken(shopID int64) (accessToken, refreshToken string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.shops[shopID]
+	if sh == nil {
+		return "", "", fmt.Errorf("店铺 %d 不存在", shopID)
+	}
+	accessToken, refreshToken = s.issueTokenLocked(sh, s.tokenTTL)
+	return accessToken, refreshToken, nil
+}
+
+// ExpireToken 使 access token 立即过期（测试 Token 刷新）
+func (s *Server) ExpireToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tok := s.tokens[accessToken]; tok != nil {
+		tok.expiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+// AddListing 预置商品，返回 ListingID
+func (s *Server) AddListing(shopID int64, dto etsy.ProductListingDTO) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.shops[shopID]
+	if sh == nil {
+		return 0, fmt.Errorf("店铺 %d 不存在", shopID)
+	}
+	return s.putListingLocked(sh, dto).dto.ListingID, nil
+}
+
+// Listing 获取商品当前状态
+func (s *Server) Listing(listingID int64) (etsy.ProductListingDTO, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.listings[listingID]
+	if l == nil {
+		return etsy.ProductListingDTO{}, false
+	}
+	return l.dto, true
+}
+
+// AddReceipt 预置订单，ReceiptID 为 0 时自动分配，返回 ReceiptID
+func (s *Server) AddReceipt(shopID int64, receipt etsy.EtsyReceiptResp) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.shops[shopID]
+	if sh == nil {
+		return 0, fmt.Errorf("店铺 %d 不存在", shopID)
+	}
+
+	if receipt.ReceiptID == 0 {
+		receipt.ReceiptID = s.id()
+	}
+	now := time.Now().Unix()
+	if receipt.CreateTimestamp == 0 {
+		receipt.CreateTimestamp = now
+	}
+	if receipt.UpdateTimestamp == 0 {
+		receipt.UpdateTimestamp = receipt.CreateTimestamp
+	}
+	receipt.CreatedTimestamp = receipt.CreateTimestamp
+	receipt.UpdatedTimestamp = receipt.UpdateTimestamp
+	receipt.SellerUserID = sh.info.UserID
+	if receipt.Status == "" {
+		receipt.Status = "Paid"
+	}
+	for i := range receipt.Transactions {
+		tx := &receipt.Transactions[i]
+		if tx.TransactionID == 0 {
+			tx.TransactionID = s.id()
+		}
+		tx.ReceiptID = receipt.ReceiptID
+		tx.SellerUserID = sh.info.UserID
+		tx.BuyerUserID = receipt.BuyerUserID
+		if tx.CreateTimestamp == 0 {
+			tx.CreateTimestamp = receipt.CreateTimestamp
+		}
+		tx.CreatedTimestamp = tx.CreateTimestamp
+	}
+
+	sh.receipts[receipt.ReceiptID] = &receipt
+	return receipt.ReceiptID, nil
+}
+
+// Receipt 获取订单当前状态
+func (s *Server) Receipt(shopID, receiptID int64) (etsy.EtsyReceiptResp, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh := s.shops[shopID]
+	if sh == nil || sh.receipts[receiptID] == nil {
+		return etsy.EtsyReceiptResp{}, false
+	}
+	return *sh.receipts[receiptID], true
+}
+
+// SeedDemo 预置一个演示店铺（分区、运费模板、退货政策、商品、订单），返回店铺 ID 与 Token
+func (s *Server) SeedDemo() (shopID int64, accessToken, refreshToken string) {
+	shopID = s.AddShop(etsy.EtsyShopResp{ShopName: "SimDemoShop", Title: "Etsy Simulator Demo"})
+
+	s.mu.Lock()
+	sh := s.shops[shopID]
+	sectionID := s.id()
+	sh.sections[sectionID] = &etsy.EtsyShopSectionResp{ShopSectionID: sectionID, Title: "Featured", Rank: 1, UserID: sh.info.UserID}
+	profile := s.newProfileLocked(sh, etsy.EtsyShippingProfileCreateReq{
+		Title: "Standard", OriginCountryISO: "US", MinProcessingDays: 1, MaxProcessingDays: 3,
+		PrimaryCost: 500, SecondaryCost: 200, DestinationCountryISO: "US",
+	})
+	policyID := s.id()
+	sh.policies[policyID] = &etsy.EtsyReturnPolicyResp{ReturnPolicyID: policyID, ShopID: shopID, AcceptsReturns: true, AcceptsExchanges: true, ReturnDeadline: 30}
+	s.mu.Unlock()
+
+	var listingIDs []int64
+	for i := 1; i <= 3; i++ {
+		id, _ := s.AddListing(shopID, etsy.ProductListingDTO{
+			Title:             fmt.Sprintf("Demo Listing %d", i),
+			Description:       "Seeded by etsy-sim",
+			State:             "active",
+			Quantity:          10 * i,
+			Price:             etsy.PriceDTO{Amount: int64(1000 * i), Divisor: 100, CurrencyCode: "USD"},
+			ShopSectionID:     sectionID,
+			ShippingProfileID: profile.ShippingProfileID,
+			ReturnPolicyID:    policyID,
+			TaxonomyID:        1,
+			WhoMade:           "i_did",
+			WhenMade:          "made_to_order",
+		})
+		listingIDs = append(listingIDs, id)
+	}
+
+	for i, listingID := range listingIDs {
+		price := money(int64(1000*(i+1)), "USD")
+		_, _ = s.AddReceipt(shopID, etsy.EtsyReceiptResp{
+			BuyerUserID: int64(500 + i),
+			BuyerEmail:  fmt.Sprintf("buyer%d@example.com", i+1),
+			Name:        fmt.Sprintf("Buyer %d", i+1),
+			FirstLine:   "1 Main St",
+			City:        "Brooklyn",
+			State:       "NY",
+			Zip:         "11201",
+			CountryISO:  "US",
+			IsPaid:      true,
+			GrandTotal:  price,
+			Subtotal:    price,
+			TotalPrice:  price,
+			Transactions: []etsy.EtsyTransactionResp{{
+				Title:     fmt.Sprintf("Demo Listing %d", i+1),
+				Quantity:  1,
+				ListingID: listingID,
+				Price:     price,
+			}},
+		})
+	}
+
+	accessToken, refreshToken, _ = s.IssueToken(shopID)
+	return shopID, accessToken, refreshToken
+}
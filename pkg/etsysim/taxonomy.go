@@ -0,0 +1,52 @@
+package etsysim
+
+import (
+	"net/http"
+
+	"etsy_dev_v1_202512/pkg/etsy"
+)
+
+// handlePing GET /v3/application/openapi-ping
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("x-api-key")
+	appID, ok := s.apps[apiKey]
+	if !ok {
+		appID = s.id()
+		s.apps[apiKey] = appID
+	}
+	writeJSON(w, http.StatusOK, etsy.EtsyPingResp{ApplicationID: appID})
+}
+
+// handleTaxonomyNodes GET /v3/application/seller-taxonomy/nodes
+func (s *Server) handleTaxonomyNodes(w http.ResponseWriter, r *http.Request) {
+	nodes := []etsy.EtsyTaxonomyNodeResp{
+		{ID: 1, Level: 0, Name: "Accessories", FullPathTaxonomyIDs: []int64{1}, Children: []etsy.EtsyTaxonomyNodeResp{
+			{ID: 2, Level: 1, Name: "Hats & Caps", ParentID: 1, FullPathTaxonomyIDs: []int64{1, 2}},
+		}},
+		{ID: 66, Level: 0, Name: "Home & Living", FullPathTaxonomyIDs: []int64{66}, Children: []etsy.EtsyTaxonomyNodeResp{
+			{ID: 67, Level: 1, Name: "Home Decor", ParentID: 66, FullPathTaxonomyIDs: []int64{66, 67}},
+		}},
+	}
+	writeJSON(w, http.StatusOK, listResult(nodes, len(nodes)))
+}
+
+// handleTaxonomyProperties 任意类目均返回颜色 / 尺寸两个可做变体的属性
+// GET /v3/application/seller-taxonomy/nodes/{taxonomy_id}/properties
+func (s *Server) handleTaxonomyProperties(w http.ResponseWriter, r *http.Request) {
+	if _, ok := pathID(w, r, "taxonomy_id"); !ok {
+		return
+	}
+	props := []etsy.EtsyTaxonomyPropertyResp{
+		{
+			PropertyID: 200, Name: "color", DisplayName: "Primary color",
+			SupportsAttributes: true, SupportsVariations: true,
+			PossibleValues: []etsy.EtsyTaxonomyPropValue{{ValueID: 1, Name: "Black"}, {ValueID: 2, Name: "White"}, {ValueID: 3, Name: "Red"}},
+		},
+		{
+			PropertyID: 100, Name: "size", DisplayName: "Size",
+			SupportsAttributes: true, SupportsVariations: true,
+			PossibleValues: []etsy.EtsyTaxonomyPropValue{{ValueID: 11, Name: "S"}, {ValueID: 12, Name: "M"}, {ValueID: 13, Name: "L"}},
+		},
+	}
+	writeJSON(w, http.StatusOK, listResult(props, len(props)))
+}
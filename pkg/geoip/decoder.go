@@ -0,0 +1,202 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// mmdb 数据段类型
+const (
+	typeExtended = 0
+	typePointer  = 1
+	typeString   = 2
+	typeDouble   = 3
+	typeBytes    = 4
+	typeUint16   = 5
+	typeUint32   = 6
+	typeMap      = 7
+	typeInt32    = 8
+	typeUint64   = 9
+	typeUint128  = 10
+	typeArray    = 11
+	typeBool     = 14
+	typeFloat    = 15
+)
+
+// maxDecodeDepth 防止损坏文件中的循环指针
+const maxDecodeDepth = 64
+
+// decoder 数据段解码，指针偏移相对 buf 起始
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+// decode 解码 offset 处的值，返回值与下一个值的偏移
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("geoip: data nested too deep")
+	}
+
+	typeNum, size, offset, err := d.decodeCtrl(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typeNum == typePointer {
+		target, next, err := d.decodePointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(target)
+		return value, next, err
+	}
+
+	switch typeNum {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("geoip: map key is %T, not string", key)
+			}
+			value, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[k] = value
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		list := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, value)
+			offset = next
+		}
+		return list, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buf)) || end < offset {
+		return nil, 0, fmt.Errorf("geoip: value at %d exceeds data section", offset)
+	}
+	b := d.buf[offset:end]
+
+	switch typeNum {
+	case typeString:
+		return string(b), end, nil
+	case typeBytes:
+		return append([]byte(nil), b...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("geoip: invalid double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("geoip: invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), end, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("geoip: invalid uint size %d", size)
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, end, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("geoip: invalid int32 size %d", size)
+		}
+		var n uint32
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		return int64(int32(n)), end, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(b), end, nil
+	default:
+		return nil, 0, fmt.Errorf("geoip: unknown data type %d", typeNum)
+	}
+}
+
+// decodeCtrl 解析控制字节：高 3 位为类型（0 表示扩展类型），低 5 位为长度
+func (d *decoder) decodeCtrl(offset uint) (typeNum, size, next uint, err error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("geoip: offset %d exceeds data section", offset)
+	}
+	ctrl := d.buf[offset]
+	offset++
+
+	typeNum = uint(ctrl >> 5)
+	if typeNum == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, fmt.Errorf("geoip: truncated extended type")
+		}
+		typeNum = uint(d.buf[offset]) + 7
+		offset++
+	}
+
+	size = uint(ctrl & 0x1F)
+	if typeNum == typePointer || size < 29 {
+		return typeNum, size, offset, nil
+	}
+
+	extra := size - 28 // 29/30/31 分别后跟 1/2/3 字节长度
+	if offset+extra > uint(len(d.buf)) {
+		return 0, 0, 0, fmt.Errorf("geoip: truncated size")
+	}
+	var n uint
+	for _, c := range d.buf[offset : offset+extra] {
+		n = n<<8 | uint(c)
+	}
+	switch extra {
+	case 1:
+		size = 29 + n
+	case 2:
+		size = 285 + n
+	default:
+		size = 65821 + n
+	}
+	return typeNum, size, offset + extra, nil
+}
+
+// decodePointer 指针：size 的高 2 位决定后续字节数，低 3 位为值的高位
+func (d *decoder) decodePointer(size, offset uint) (target, next uint, err error) {
+	n := (size >> 3) & 0x3
+	length := n + 1
+	if offset+length > uint(len(d.buf)) {
+		return 0, 0, fmt.Errorf("geoip: truncated pointer")
+	}
+	var v uint
+	if n != 3 {
+		v = size & 0x7
+	}
+	for _, c := range d.buf[offset : offset+length] {
+		v = v<<8 | uint(c)
+	}
+	switch n {
+	case 1:
+		v += 2048
+	case 2:
+		v += 526336
+	}
+	return v, offset + length, nil
+}
@@ -0,0 +1,85 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+)
+
+// Location IP 的国家与网络归属
+type Location struct {
+	Country string // ISO 3166-1 alpha-2，大写
+	ASN     uint
+	ASOrg   string
+}
+
+// Locate 从 Country / City / ASN 库记录中提取国家与 ASN，未收录时 found=false
+// 国家优先取 country.iso_code，缺失时用 registered_country.iso_code
+func (r *Reader) Locate(ip net.IP) (loc Location, found bool, err error) {
+	record, err := r.Lookup(ip)
+	if err != nil || record == nil {
+		return loc, false, err
+	}
+	m, ok := record.(map[string]interface{})
+	if !ok {
+		return loc, false, nil
+	}
+
+	for _, key := range []string{"country", "registered_country"} {
+		if c, ok := m[key].(map[string]interface{}); ok {
+			if code, _ := c["iso_code"].(string); code != "" {
+				loc.Country = strings.ToUpper(code)
+				break
+			}
+		}
+	}
+	loc.ASN = uint(toUint(m["autonomous_system_number"]))
+	loc.ASOrg, _ = m["autonomous_system_organization"].(string)
+	return loc, true, nil
+}
+
+// Locator 组合多个库查询（GeoLite2 的国家库与 ASN 库是分开的文件）
+type Locator struct {
+	readers []*Reader
+}
+
+// NewLocator 组合已加载的库，按顺序取第一个有值的字段
+func NewLocator(readers ...*Reader) *Locator {
+	return &Locator{readers: readers}
+}
+
+// OpenLocator 按路径加载库，空路径跳过
+func OpenLocator(paths ...string) (*Locator, error) {
+	l := &Locator{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		r, err := Open(path)
+		if err != nil {
+			return nil, err
+		}
+		l.readers = append(l.readers, r)
+	}
+	return l, nil
+}
+
+// Locate 查询 IP 的国家与 ASN，各库均未收录时返回空 Location
+func (l *Locator) Locate(ip net.IP) (Location, error) {
+	var result Location
+	for _, r := range l.readers {
+		loc, found, err := r.Locate(ip)
+		if err != nil {
+			return result, err
+		}
+		if !found {
+			continue
+		}
+		if result.Country == "" {
+			result.Country = loc.Country
+		}
+		if result.ASN == 0 {
+			result.ASN, result.ASOrg = loc.ASN, loc.ASOrg
+		}
+	}
+	return result, nil
+}
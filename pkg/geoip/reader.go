@@ -0,0 +1,191 @@
+// Package geoip 本地 MaxMind 格式（.mmdb）IP 库读取
+// 只实现按 IP 查询记录所需的部分：元数据、搜索树（24/28/32 位记录）与数据段解码，
+// 兼容 GeoLite2 / GeoIP2 的 Country、City、ASN 库
+package geoip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// metadataMarker 元数据段起始标记，位于文件末尾 128KB 内
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+const metadataMaxSize = 128 * 1024
+
+var (
+	// ErrInvalidDatabase 文件不是合法的 mmdb
+	ErrInvalidDatabase = errors.New("geoip: invalid mmdb database")
+	// ErrIPv6Lookup IPv4 库不支持查询 IPv6 地址
+	ErrIPv6Lookup = errors.New("geoip: cannot look up IPv6 address in IPv4-only database")
+)
+
+// Metadata 库元数据
+type Metadata struct {
+	DatabaseType string
+	NodeCount    uint
+	RecordSize   uint
+	IPVersion    uint
+	BuildEpoch   uint64
+	Languages    []string
+}
+
+// Reader mmdb 读取器，加载后只读，可并发使用
+type Reader struct {
+	buf       []byte
+	tree      []byte
+	data      []byte
+	Metadata  Metadata
+	ipv4Start uint // IPv6 库中 IPv4 子树（::/96）的起始节点
+}
+
+// Open 读取 mmdb 文件
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes 从内存解析 mmdb
+func FromBytes(buf []byte) (*Reader, error) {
+	start := len(buf) - metadataMaxSize
+	if start < 0 {
+		start = 0
+	}
+	idx := bytes.LastIndex(buf[start:], metadataMarker)
+	if idx < 0 {
+		return nil, ErrInvalidDatabase
+	}
+	metaStart := start + idx + len(metadataMarker)
+
+	raw, _, err := (&decoder{buf: buf[metaStart:]}).decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: decode metadata: %w", err)
+	}
+	meta, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidDatabase
+	}
+
+	r := &Reader{buf: buf}
+	r.Metadata.DatabaseType, _ = meta["database_type"].(string)
+	r.Metadata.NodeCount = uint(toUint(meta["node_count"]))
+	r.Metadata.RecordSize = uint(toUint(meta["record_size"]))
+	r.Metadata.IPVersion = uint(toUint(meta["ip_version"]))
+	r.Metadata.BuildEpoch = toUint(meta["build_epoch"])
+	if langs, ok := meta["languages"].([]interface{}); ok {
+		for _, l := range langs {
+			if s, ok := l.(string); ok {
+				r.Metadata.Languages = append(r.Metadata.Languages, s)
+			}
+		}
+	}
+
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("geoip: unsupported record size %d", r.Metadata.RecordSize)
+	}
+	if r.Metadata.IPVersion != 4 && r.Metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("geoip: unsupported ip version %d", r.Metadata.IPVersion)
+	}
+
+	treeSize := int(r.Metadata.NodeCount * r.Metadata.RecordSize / 4)
+	dataStart := treeSize + 16 // 搜索树与数据段之间有 16 字节分隔
+	dataEnd := metaStart - len(metadataMarker)
+	if dataStart > dataEnd {
+		return nil, ErrInvalidDatabase
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[dataStart:dataEnd]
+
+	if r.Metadata.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.Metadata.NodeCount; i++ {
+			node = r.readNode(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Lookup 查询 IP 对应的记录，未收录时返回 nil
+// 记录为解码后的原始结构：map[string]interface{}、[]interface{}、string、uint64、int64、float64、bool 等
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+	offset, found, err := r.lookupOffset(ip)
+	if err != nil || !found {
+		return nil, err
+	}
+	value, _, err := (&decoder{buf: r.data}).decode(offset)
+	return value, err
+}
+
+func (r *Reader) lookupOffset(ip net.IP) (uint, bool, error) {
+	bits := ip.To4()
+	node := uint(0)
+	switch {
+	case bits != nil && r.Metadata.IPVersion == 6:
+		node = r.ipv4Start
+	case bits == nil:
+		if r.Metadata.IPVersion == 4 {
+			return 0, false, ErrIPv6Lookup
+		}
+		bits = ip.To16()
+		if bits == nil {
+			return 0, false, fmt.Errorf("geoip: invalid ip %q", ip)
+		}
+	}
+
+	nodeCount := r.Metadata.NodeCount
+	for i := 0; i < len(bits)*8 && node < nodeCount; i++ {
+		bit := uint(bits[i/8]>>(7-uint(i%8))) & 1
+		node = r.readNode(node, bit)
+	}
+	switch {
+	case node == nodeCount:
+		return 0, false, nil
+	case node > nodeCount:
+		offset := node - nodeCount - 16
+		if offset >= uint(len(r.data)) {
+			return 0, false, ErrInvalidDatabase
+		}
+		return offset, true, nil
+	default:
+		return 0, false, ErrInvalidDatabase
+	}
+}
+
+// readNode 读取节点的左（bit=0）/右（bit=1）记录
+func (r *Reader) readNode(node, bit uint) uint {
+	switch r.Metadata.RecordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		b := r.tree[node*8+bit*4:]
+		return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+	}
+}
+
+func toUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		if n > 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
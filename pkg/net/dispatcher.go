@@ -3,7 +3,6 @@ package net
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -16,15 +15,30 @@ import (
 
 // ProxyProvider 定义“提供代理”的行为标准
 type ProxyProvider interface {
-	// GetProxy 根据业务唯一键 (shopID) 获取一个可用的代理地址
+	// GetProxy 根据业务唯一键 (shopID) 获取一个可用的代理
 	// 若 shop ID = 0，则随机返回一个可用代理
-	GetProxy(ctx context.Context, shopID int64) (*url.URL, error)
+	GetProxy(ctx context.Context, shopID int64) (*ProxyEndpoint, error)
 
 	// ReportError 上报该业务键对应的代理已失效
 	// 业务层实现需在此方法中执行：解绑旧代理、标记故障、触发巡检等
 	ReportError(ctx context.Context, shopID int64)
 }
 
+// ProxySample 一次经代理发出的请求结果
+type ProxySample struct {
+	ShopID     int64
+	ProxyID    int64 // 取自 ProxyEndpoint.ProxyID，0 表示未知
+	ProxyURL   *url.URL
+	Latency    time.Duration // 到收到响应头为止
+	StatusCode int           // 网络错误时为 0
+	Err        error
+}
+
+// ProxyMetricsReporter 代理请求结果上报（业务层据此计算代理质量评分）
+type ProxyMetricsReporter interface {
+	ReportProxySample(ctx context.Context, sample ProxySample)
+}
+
 // Dispatcher 网络调度器 (通用组件)
 type Dispatcher interface {
 	// Send 发送 HTTP 请求
@@ -33,65 +47,199 @@ type Dispatcher interface {
 	Send(ctx context.Context, shopID int64, req *http.Request) (*http.Response, error)
 	SendMultipart(ctx context.Context, shopID int64, req *MultipartRequest) (*http.Response, error)
 	Ping(ctx context.Context, req *http.Request) (*http.Response, error)
+	// Quota 当前进程观测到的 API Key 配额（来自 Etsy 响应头）
+	Quota(apiKey string) (QuotaSnapshot, bool)
 }
 
 // httpDispatcher 是 Dispatcher 接口的具体实现
 // 注意：它是私有的，外部只能通过 NewDispatcher 获取接口
 type httpDispatcher struct {
-	provider       ProxyProvider
-	transportCache sync.Map
-	maxRetries     int
+	provider   ProxyProvider
+	transports *transportPool
+	maxRetries int
+
+	// Etsy 配额：按 API Key 限流
+	limiters       sync.Map // apiKey -> *keyLimiter
+	quotaReporter  QuotaReporter
+	metrics        ProxyMetricsReporter
+	reportInterval time.Duration
+	maxRetryWait   time.Duration // 单次 Retry-After 等待上限，超过则直接返回响应
+
+	// direct 直连模式：不向 ProxyProvider 取代理（本地模拟环境 / 测试）
+	direct bool
 }
 
 var _ Dispatcher = (*httpDispatcher)(nil)
 
-func NewDispatcher(provider ProxyProvider) Dispatcher {
-	return &httpDispatcher{
-		provider:   provider,
-		maxRetries: 2,
+// DispatcherOption Dispatcher 可选配置
+type DispatcherOption func(*httpDispatcher)
+
+// WithQuotaReporter 设置配额快照上报（用于持久化每日剩余配额）
+func WithQuotaReporter(reporter QuotaReporter) DispatcherOption {
+	return func(d *httpDispatcher) {
+		d.quotaReporter = reporter
+	}
+}
+
+// WithProxyMetricsReporter 设置代理请求结果上报（直连模式不上报）
+func WithProxyMetricsReporter(reporter ProxyMetricsReporter) DispatcherOption {
+	return func(d *httpDispatcher) {
+		d.metrics = reporter
+	}
+}
+
+// WithMaxRetries 设置最大重试次数
+func WithMaxRetries(n int) DispatcherOption {
+	return func(d *httpDispatcher) {
+		d.maxRetries = n
+	}
+}
+
+// WithTransportIdleTTL 设置 Transport 缓存的闲置回收时间
+func WithTransportIdleTTL(ttl time.Duration) DispatcherOption {
+	return func(d *httpDispatcher) {
+		d.transports.idleTTL = ttl
 	}
 }
 
-// Send 发送 HTTP 请求 (自动处理重试与代理切换)
+// WithoutProxy 直连模式，请求不经过代理，provider 可为 nil
+func WithoutProxy() DispatcherOption {
+	return func(d *httpDispatcher) {
+		d.direct = true
+	}
+}
+
+func NewDispatcher(provider ProxyProvider, opts ...DispatcherOption) Dispatcher {
+	d := &httpDispatcher{
+		provider:       provider,
+		transports:     newTransportPool(defaultTransportIdleTTL),
+		maxRetries:     2,
+		reportInterval: defaultQuotaReportInterval,
+		maxRetryWait:   time.Minute,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Send 发送 HTTP 请求 (自动处理重试、代理切换与 Etsy 限流)
 // shopID: 标识谁在发请求 (如 "shop_1024")
+// 带 x-api-key 的请求按 Key 令牌桶限流；幂等请求遇到 429/502/503 时按 Retry-After 重试，
+// 网络错误时切换代理重试（非幂等请求不重试）
 func (d *httpDispatcher) Send(ctx context.Context, shopID int64, req *http.Request) (*http.Response, error) {
 	var lastErr error
 
+	var limiter *keyLimiter
+	if apiKey := req.Header.Get(headerAPIKey); apiKey != "" {
+		limiter = d.limiterFor(apiKey)
+	}
+
 	for i := 0; i <= d.maxRetries; i++ {
+		// 重试时重置请求体
+		if i > 0 && req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("reset request body error: %v", err)
+			}
+			req.Body = body
+		}
+
+		// 0. 等待该 API Key 的令牌
+		if limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
 		// 1. 通过接口回调，获取代理 (惰性绑定逻辑在业务层实现)
-		proxyURL, err := d.provider.GetProxy(ctx, shopID)
+		endpoint, err := d.proxyFor(ctx, shopID)
 		if err != nil {
-			return nil, fmt.Errorf("proxy provider error: %v", err)
+			return nil, err
 		}
 
 		// 2. 获取/复用 Transport
-		client := d.getClient(proxyURL)
+		client, err := d.getClient(endpoint)
+		if err != nil {
+			return nil, err
+		}
 
 		// 3. 发送请求
+		start := time.Now()
 		resp, err := client.Do(req)
+		d.observeProxy(ctx, shopID, endpoint, start, resp, err)
 
 		// 成功
 		if err == nil {
-			return resp, nil
+			if limiter != nil {
+				d.observeQuota(ctx, limiter, resp)
+			}
+			if !isRetryableStatus(resp.StatusCode) {
+				return resp, nil
+			}
+
+			wait := retryAfter(resp, i)
+			if resp.StatusCode == http.StatusTooManyRequests && limiter != nil {
+				// 同 Key 的其他店铺请求一并暂停
+				limiter.pause(wait)
+			}
+			if i == d.maxRetries || !isIdempotent(req.Method) || wait > d.maxRetryWait {
+				return resp, nil
+			}
+
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("http status %d", resp.StatusCode)
+			// 429 已暂停该 Key 的令牌桶，下一轮等待令牌即可
+			if limiter == nil || resp.StatusCode != http.StatusTooManyRequests {
+				if err := sleepContext(ctx, wait); err != nil {
+					return nil, err
+				}
+			}
+			continue
 		}
 
 		// 失败
 		lastErr = err
 
-		// 还有重试机会时，报错并触发切换
-		if i < d.maxRetries {
+		// 还有重试机会时，报错并触发切换（直连模式无代理可换）
+		if i < d.maxRetries && !d.direct {
 			// 回调业务层：这个 Key 对应的代理坏了，请处理
 			d.provider.ReportError(ctx, shopID)
 			// 清理本地 Transport 缓存
-			if proxyURL != nil {
-				d.transportCache.Delete(proxyURL.String())
+			if endpoint != nil {
+				d.transports.remove(endpoint)
 			}
 		}
+		// 非幂等请求可能已送达 Etsy，重发会重复创建，直接返回
+		if !isIdempotent(req.Method) {
+			return nil, fmt.Errorf("request failed: %v", err)
+		}
 	}
 
 	return nil, fmt.Errorf("request failed after retries: %v", lastErr)
 }
 
+// observeQuota 记录响应头中的配额信息，并按间隔上报
+func (d *httpDispatcher) observeQuota(ctx context.Context, limiter *keyLimiter, resp *http.Response) {
+	snapshot, report := limiter.observe(resp.Header, d.reportInterval)
+	if report && d.quotaReporter != nil {
+		d.quotaReporter.ReportQuota(ctx, snapshot)
+	}
+}
+
+// observeProxy 上报经代理发出的请求结果
+func (d *httpDispatcher) observeProxy(ctx context.Context, shopID int64, endpoint *ProxyEndpoint, start time.Time, resp *http.Response, err error) {
+	if d.metrics == nil || endpoint == nil || endpoint.URL == nil {
+		return
+	}
+	sample := ProxySample{ShopID: shopID, ProxyID: endpoint.ProxyID, ProxyURL: endpoint.URL, Latency: time.Since(start), Err: err}
+	if resp != nil {
+		sample.StatusCode = resp.StatusCode
+	}
+	d.metrics.ReportProxySample(ctx, sample)
+}
+
 // FileData 文件数据
 type FileData struct {
 	Data     []byte
@@ -156,43 +304,44 @@ func (d *httpDispatcher) SendMultipart(ctx context.Context, shopID int64, req *M
 
 // Ping 随机选择端口 Ping 测试
 func (d *httpDispatcher) Ping(ctx context.Context, req *http.Request) (*http.Response, error) {
-	proxyURL, err := d.provider.GetProxy(ctx, 0)
+	endpoint, err := d.proxyFor(ctx, 0)
 	if err != nil {
-		return nil, fmt.Errorf("proxy provider error: %v", err)
+		return nil, err
+	}
+	client, err := d.getClient(endpoint)
+	if err != nil {
+		return nil, err
 	}
-	client := d.getClient(proxyURL)
+	start := time.Now()
 	resp, err := client.Do(req)
+	d.observeProxy(ctx, 0, endpoint, start, resp, err)
 	if err == nil {
 		return resp, nil
 	}
-	return nil, fmt.Errorf("request failed after retries: %v", err)
+	return nil, fmt.Errorf("request failed: %v", err)
 }
 
-// getClient 内部复用逻辑
-func (d *httpDispatcher) getClient(proxyURL *url.URL) *http.Client {
-	// 缓存 Key: "http://user:pass@ip:port"
-	cacheKey := proxyURL.String()
-
-	if val, ok := d.transportCache.Load(cacheKey); ok {
-		return &http.Client{
-			Transport: val.(*http.Transport),
-			Timeout:   30 * time.Second,
-		}
+// proxyFor 获取代理，直连模式返回 nil
+func (d *httpDispatcher) proxyFor(ctx context.Context, shopID int64) (*ProxyEndpoint, error) {
+	if d.direct {
+		return nil, nil
 	}
-
-	// 缓存未命中，创建新 Transport
-	tr := &http.Transport{
-		Proxy:           http.ProxyURL(proxyURL),
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // 可选
-		MaxIdleConns:    100,
-		IdleConnTimeout: 90 * time.Second,
+	endpoint, err := d.provider.GetProxy(ctx, shopID)
+	if err != nil {
+		return nil, fmt.Errorf("proxy provider error: %v", err)
 	}
+	return endpoint, nil
+}
 
-	// 3. 存入缓存 (LoadOrStore 防止并发重复创建)
-	actual, _ := d.transportCache.LoadOrStore(cacheKey, tr)
-
+// getClient 内部复用逻辑
+// Transport 按代理地址（含认证信息）与证书策略缓存，直连共用一个，闲置超时后回收
+func (d *httpDispatcher) getClient(endpoint *ProxyEndpoint) (*http.Client, error) {
+	tr, err := d.transports.get(endpoint)
+	if err != nil {
+		return nil, err
+	}
 	return &http.Client{
-		Transport: actual.(*http.Transport),
+		Transport: tr,
 		Timeout:   30 * time.Second,
-	}
+	}, nil
 }
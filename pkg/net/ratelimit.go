@@ -0,0 +1,205 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ==================== Etsy 配额 ====================
+//
+// 同一 Developer（x-api-key）下的所有店铺共享 Etsy 配额：
+//   - x-limit-per-second / x-remaining-this-second：每秒请求上限
+//   - x-limit-per-day / x-remaining-today：每日请求上限（滚动 24 小时）
+// Dispatcher 按 API Key 维护令牌桶，并根据响应头动态调整速率。
+
+const (
+	headerAPIKey          = "x-api-key"
+	headerLimitPerSecond  = "x-limit-per-second"
+	headerRemainingSecond = "x-remaining-this-second"
+	headerLimitPerDay     = "x-limit-per-day"
+	headerRemainingToday  = "x-remaining-today"
+
+	// defaultLimitPerSecond Etsy 默认每秒 10 次
+	defaultLimitPerSecond = 10
+	// defaultQuotaReportInterval 配额快照上报间隔（剩余配额偏低时每次都上报）
+	defaultQuotaReportInterval = 30 * time.Second
+)
+
+// QuotaSnapshot API Key 配额快照
+type QuotaSnapshot struct {
+	APIKey          string
+	LimitPerSecond  int
+	RemainingSecond int
+	LimitPerDay     int
+	RemainingToday  int
+	UpdatedAt       time.Time
+}
+
+// LowDaily 每日剩余配额是否低于比例阈值
+func (q QuotaSnapshot) LowDaily(ratio float64) bool {
+	if q.LimitPerDay <= 0 || q.RemainingToday < 0 {
+		return false
+	}
+	return float64(q.RemainingToday) <= float64(q.LimitPerDay)*ratio
+}
+
+// QuotaReporter 配额快照上报（业务层负责持久化）
+type QuotaReporter interface {
+	ReportQuota(ctx context.Context, snapshot QuotaSnapshot)
+}
+
+// keyLimiter 单个 API Key 的令牌桶与配额状态
+type keyLimiter struct {
+	mu           sync.Mutex
+	limiter      *rate.Limiter
+	pausedUntil  time.Time // 429 后整个 Key 暂停到该时间
+	snapshot     QuotaSnapshot
+	lastReported time.Time
+}
+
+// wait 等待令牌（含 429 暂停期）
+func (k *keyLimiter) wait(ctx context.Context) error {
+	k.mu.Lock()
+	pause := time.Until(k.pausedUntil)
+	k.mu.Unlock()
+
+	if pause > 0 {
+		if err := sleepContext(ctx, pause); err != nil {
+			return err
+		}
+	}
+	return k.limiter.Wait(ctx)
+}
+
+// pause 暂停该 Key 的全部请求
+func (k *keyLimiter) pause(d time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if until := time.Now().Add(d); until.After(k.pausedUntil) {
+		k.pausedUntil = until
+	}
+}
+
+// observe 根据响应头更新速率与配额，返回需要上报的快照
+func (k *keyLimiter) observe(h http.Header, reportInterval time.Duration) (QuotaSnapshot, bool) {
+	perSecond, hasPerSecond := headerInt(h, headerLimitPerSecond)
+	remainingToday, hasToday := headerInt(h, headerRemainingToday)
+	if !hasPerSecond && !hasToday {
+		return QuotaSnapshot{}, false
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if hasPerSecond && perSecond > 0 && perSecond != k.snapshot.LimitPerSecond {
+		k.limiter.SetLimit(rate.Limit(perSecond))
+		k.limiter.SetBurst(perSecond)
+	}
+	if hasPerSecond {
+		k.snapshot.LimitPerSecond = perSecond
+	}
+	if v, ok := headerInt(h, headerRemainingSecond); ok {
+		k.snapshot.RemainingSecond = v
+	}
+	if v, ok := headerInt(h, headerLimitPerDay); ok {
+		k.snapshot.LimitPerDay = v
+	}
+	if hasToday {
+		k.snapshot.RemainingToday = remainingToday
+	}
+	k.snapshot.UpdatedAt = now
+
+	if now.Sub(k.lastReported) < reportInterval && !k.snapshot.LowDaily(0.1) {
+		return QuotaSnapshot{}, false
+	}
+	k.lastReported = now
+	return k.snapshot, true
+}
+
+// limiterFor 获取 API Key 对应的令牌桶（惰性创建）
+func (d *httpDispatcher) limiterFor(apiKey string) *keyLimiter {
+	if v, ok := d.limiters.Load(apiKey); ok {
+		return v.(*keyLimiter)
+	}
+	k := &keyLimiter{
+		limiter:  rate.NewLimiter(rate.Limit(defaultLimitPerSecond), defaultLimitPerSecond),
+		snapshot: QuotaSnapshot{APIKey: apiKey, RemainingToday: -1},
+	}
+	actual, _ := d.limiters.LoadOrStore(apiKey, k)
+	return actual.(*keyLimiter)
+}
+
+// Quota 当前进程内观测到的配额快照
+func (d *httpDispatcher) Quota(apiKey string) (QuotaSnapshot, bool) {
+	v, ok := d.limiters.Load(apiKey)
+	if !ok {
+		return QuotaSnapshot{}, false
+	}
+	k := v.(*keyLimiter)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.snapshot, !k.snapshot.UpdatedAt.IsZero()
+}
+
+// ==================== 重试策略 ====================
+
+// isRetryableStatus 可重试的 HTTP 状态码
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests ||
+		code == http.StatusBadGateway ||
+		code == http.StatusServiceUnavailable
+}
+
+// isIdempotent 幂等请求才允许按状态码或网络错误重试
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析 Retry-After（秒数或 HTTP 日期），缺省时指数退避
+func retryAfter(resp *http.Response, attempt int) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+	return time.Duration(1<<attempt) * time.Second
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
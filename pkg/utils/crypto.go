@@ -1 +1,329 @@
 package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ==================== 信封加密 ====================
+//
+// 每个值使用随机生成的数据密钥 (DEK) 做 AES-256-GCM 加密，DEK 再由密钥环中的主密钥 (KEK) 加密。
+// 密文格式：enc:v<版本>:<base64(nonce|加密后的DEK)>:<base64(nonce|加密后的数据)>
+// 轮换主密钥时只需用新 KEK 重新包装 DEK，数据部分保持不变。
+
+const (
+	cipherPrefix = "enc:"
+	keySize      = 32
+)
+
+var (
+	ErrNoEncryptionKeys  = errors.New("未配置加密密钥 (ENCRYPTION_KEYS / ENCRYPTION_KEY_FILE)")
+	ErrUnknownKeyVersion = errors.New("未知的密钥版本")
+	ErrInvalidCiphertext = errors.New("密文格式错误")
+)
+
+// KeyRing 版本化密钥环，新数据使用主密钥加密，旧版本密钥仅用于解密
+type KeyRing struct {
+	primary uint32
+	keys    map[uint32][]byte
+}
+
+// NewKeyRing 创建密钥环，每个密钥必须为 32 字节
+func NewKeyRing(primary uint32, keys map[uint32][]byte) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoEncryptionKeys
+	}
+	ring := &KeyRing{primary: primary, keys: make(map[uint32][]byte, len(keys))}
+	for version, key := range keys {
+		if version == 0 {
+			return nil, fmt.Errorf("密钥版本必须大于 0")
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("密钥 v%d 长度必须为 %d 字节，实际 %d", version, keySize, len(key))
+		}
+		ring.keys[version] = append([]byte(nil), key...)
+	}
+	if _, ok := ring.keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: 主密钥 v%d 不在密钥环中", ErrUnknownKeyVersion, primary)
+	}
+	return ring, nil
+}
+
+// PrimaryVersion 主密钥版本
+func (k *KeyRing) PrimaryVersion() uint32 {
+	return k.primary
+}
+
+// Versions 密钥环中的全部版本（升序）
+func (k *KeyRing) Versions() []uint32 {
+	versions := make([]uint32, 0, len(k.keys))
+	for v := range k.keys {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// Encrypt 使用主密钥加密，空字符串原样返回
+func (k *KeyRing) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", fmt.Errorf("生成数据密钥失败: %w", err)
+	}
+
+	data, err := sealGCM(dek, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := sealGCM(k.keys[k.primary], dek)
+	if err != nil {
+		return "", err
+	}
+
+	return formatCiphertext(k.primary, wrapped, data), nil
+}
+
+// Decrypt 解密；不符合密文格式的历史明文（包括恰好以 enc: 开头的）原样返回，便于存量数据平滑迁移
+func (k *KeyRing) Decrypt(value string) (string, error) {
+	version, wrapped, data, err := parseCiphertext(value)
+	if err != nil {
+		return value, nil
+	}
+	dek, err := k.unwrap(version, wrapped)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := openGCM(dek, data)
+	if err != nil {
+		return "", fmt.Errorf("解密数据失败: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap 将值迁移到主密钥：明文直接加密，旧版本密文用主密钥重新包装 DEK
+// 返回值已是主密钥密文时 changed 为 false
+func (k *KeyRing) Rewrap(value string) (result string, changed bool, err error) {
+	if value == "" {
+		return value, false, nil
+	}
+	version, wrapped, data, err := parseCiphertext(value)
+	if err != nil {
+		result, err = k.Encrypt(value)
+		return result, err == nil, err
+	}
+	if version == k.primary {
+		return value, false, nil
+	}
+
+	dek, err := k.unwrap(version, wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	rewrapped, err := sealGCM(k.keys[k.primary], dek)
+	if err != nil {
+		return "", false, err
+	}
+	return formatCiphertext(k.primary, rewrapped, data), true, nil
+}
+
+// NeedsRewrap 值是否需要迁移到主密钥（明文或旧版本密文）
+func (k *KeyRing) NeedsRewrap(value string) bool {
+	if value == "" {
+		return false
+	}
+	version, ok := CiphertextVersion(value)
+	return !ok || version != k.primary
+}
+
+func (k *KeyRing) unwrap(version uint32, wrapped []byte) ([]byte, error) {
+	kek, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: v%d", ErrUnknownKeyVersion, version)
+	}
+	dek, err := openGCM(kek, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("解密数据密钥失败 (v%d): %w", version, err)
+	}
+	return dek, nil
+}
+
+// IsEncrypted 值是否为密文：以 enc: 开头且版本、两段 base64 格式完整
+// 仅凭前缀判断会把恰好以 enc: 开头的明文当成密文
+func IsEncrypted(value string) bool {
+	_, _, _, err := parseCiphertext(value)
+	return err == nil
+}
+
+// CiphertextVersion 密文使用的密钥版本
+func CiphertextVersion(value string) (uint32, bool) {
+	version, _, _, err := parseCiphertext(value)
+	return version, err == nil
+}
+
+func formatCiphertext(version uint32, wrapped, data []byte) string {
+	return fmt.Sprintf("%sv%d:%s:%s", cipherPrefix, version,
+		base64.StdEncoding.EncodeToString(wrapped),
+		base64.StdEncoding.EncodeToString(data))
+}
+
+func parseCiphertext(value string) (version uint32, wrapped, data []byte, err error) {
+	if !strings.HasPrefix(value, cipherPrefix) {
+		return 0, nil, nil, ErrInvalidCiphertext
+	}
+	parts := strings.Split(strings.TrimPrefix(value, cipherPrefix), ":")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "v") {
+		return 0, nil, nil, ErrInvalidCiphertext
+	}
+	v, err := strconv.ParseUint(parts[0][1:], 10, 32)
+	if err != nil {
+		return 0, nil, nil, ErrInvalidCiphertext
+	}
+	if wrapped, err = base64.StdEncoding.DecodeString(parts[1]); err != nil {
+		return 0, nil, nil, ErrInvalidCiphertext
+	}
+	if data, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		return 0, nil, nil, ErrInvalidCiphertext
+	}
+	return uint32(v), wrapped, data, nil
+}
+
+// sealGCM AES-GCM 加密，输出 nonce|ciphertext
+func sealGCM(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成 nonce 失败: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openGCM 解密 sealGCM 的输出
+func openGCM(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ==================== 密钥加载 ====================
+
+// keyFile 密钥文件格式：{"primary": 2, "keys": {"1": "<base64>", "2": "<base64>"}}
+type keyFile struct {
+	Primary uint32            `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// LoadKeyRing 从环境变量加载密钥环
+// ENCRYPTION_KEY_FILE 指定密钥文件时优先使用文件；
+// 否则读取 ENCRYPTION_KEYS（格式 "1:<base64>,2:<base64>"）与 ENCRYPTION_PRIMARY_VERSION（默认最大版本）
+func LoadKeyRing() (*KeyRing, error) {
+	if path := os.Getenv("ENCRYPTION_KEY_FILE"); path != "" {
+		return LoadKeyRingFile(path)
+	}
+
+	raw := strings.TrimSpace(os.Getenv("ENCRYPTION_KEYS"))
+	if raw == "" {
+		return nil, ErrNoEncryptionKeys
+	}
+
+	encoded := make(map[string]string)
+	for _, item := range strings.Split(raw, ",") {
+		version, key, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS 格式错误: %q", item)
+		}
+		encoded[version] = key
+	}
+
+	var primary uint32
+	if v := os.Getenv("ENCRYPTION_PRIMARY_VERSION"); v != "" {
+		p, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_PRIMARY_VERSION 格式错误: %w", err)
+		}
+		primary = uint32(p)
+	}
+	return buildKeyRing(primary, encoded)
+}
+
+// LoadKeyRingFile 从 JSON 密钥文件加载密钥环
+func LoadKeyRingFile(path string) (*KeyRing, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	var f keyFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %w", err)
+	}
+	return buildKeyRing(f.Primary, f.Keys)
+}
+
+// buildKeyRing 解析 base64 密钥，primary 为 0 时取最大版本
+func buildKeyRing(primary uint32, encoded map[string]string) (*KeyRing, error) {
+	keys := make(map[uint32][]byte, len(encoded))
+	for v, enc := range encoded {
+		version, err := strconv.ParseUint(strings.TrimPrefix(v, "v"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("密钥版本格式错误: %q", v)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(enc))
+		if err != nil {
+			return nil, fmt.Errorf("密钥 v%d 不是合法的 base64: %w", version, err)
+		}
+		keys[uint32(version)] = key
+	}
+	if primary == 0 {
+		for v := range keys {
+			if v > primary {
+				primary = v
+			}
+		}
+	}
+	return NewKeyRing(primary, keys)
+}
+
+// ==================== 全局密钥环 ====================
+
+var defaultKeyRing atomic.Pointer[KeyRing]
+
+// SetKeyRing 设置全局密钥环（启动时调用，供 GORM 加密序列化器使用）
+func SetKeyRing(ring *KeyRing) {
+	defaultKeyRing.Store(ring)
+}
+
+// GetKeyRing 全局密钥环，未设置时返回 nil
+func GetKeyRing() *KeyRing {
+	return defaultKeyRing.Load()
+}
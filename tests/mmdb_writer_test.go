@@ -0,0 +1,307 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"time"
+
+	"etsy_dev_v1_202512/pkg/geoip"
+)
+
+// mmdb 数据段类型（与 pkg/geoip 解码器一致）
+const (
+	mmdbTypeString = 2
+	mmdbTypeDouble = 3
+	mmdbTypeBytes  = 4
+	mmdbTypeUint16 = 5
+	mmdbTypeUint32 = 6
+	mmdbTypeMap    = 7
+	mmdbTypeInt32  = 8
+	mmdbTypeUint64 = 9
+	mmdbTypeArray  = 11
+	mmdbTypeBool   = 14
+	mmdbTypeFloat  = 15
+)
+
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// mmdbWriter 生成 mmdb 文件（24/28/32 位记录，不做数据去重），用于构造测试用的小型 GeoIP 库
+type mmdbWriter struct {
+	databaseType string
+	ipVersion    int
+	recordSize   int
+	buildEpoch   uint64
+	root         *writerNode
+	data         []interface{}
+}
+
+type writerNode struct {
+	children [2]*writerNode
+	data     int // 叶子节点的数据下标 + 1，0 表示非叶子
+}
+
+// newMMDBWriter 创建写入器，ipVersion 为 4 或 6，recordSize 为 24、28 或 32
+// IPv6 库中的 IPv4 地址写入 ::/96 子树
+func newMMDBWriter(databaseType string, ipVersion, recordSize int) *mmdbWriter {
+	if ipVersion != 4 {
+		ipVersion = 6
+	}
+	return &mmdbWriter{
+		databaseType: databaseType,
+		ipVersion:    ipVersion,
+		recordSize:   recordSize,
+		buildEpoch:   uint64(time.Now().Unix()),
+		root:         &writerNode{},
+	}
+}
+
+// Insert 写入网段记录，后写入的网段覆盖重叠部分
+// 支持的值类型：map[string]interface{}、[]interface{}、[]string、string、[]byte、bool、
+// float32、float64、int、int32、uint、uint16、uint32、uint64
+func (w *mmdbWriter) Insert(network *net.IPNet, value interface{}) error {
+	ip, ones := network.IP, 0
+	prefix, bits := network.Mask.Size()
+	switch {
+	case ip.To4() != nil && bits == 32:
+		ip = ip.To4()
+		ones = prefix
+		if w.ipVersion == 6 {
+			ip = append(make(net.IP, 12), ip...)
+			ones += 96
+		}
+	case w.ipVersion == 4:
+		return geoip.ErrIPv6Lookup
+	default:
+		ip = ip.To16()
+		ones = prefix
+	}
+
+	w.data = append(w.data, value)
+	idx := len(w.data)
+
+	node := w.root
+	for i := 0; i < ones; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		child := node.children[bit]
+		if child == nil {
+			child = &writerNode{}
+			node.children[bit] = child
+		} else if child.data != 0 && i < ones-1 {
+			// 在已有叶子内部写入更小网段：拆分叶子，两侧继承原数据
+			child.children = [2]*writerNode{{data: child.data}, {data: child.data}}
+			child.data = 0
+		}
+		node = child
+	}
+	node.children = [2]*writerNode{}
+	node.data = idx
+	return nil
+}
+
+// WriteTo 输出 mmdb 文件
+func (w *mmdbWriter) WriteTo(out io.Writer) (int64, error) {
+	// 1. 数据段
+	var data bytes.Buffer
+	offsets := make([]uint32, len(w.data))
+	for i, v := range w.data {
+		offsets[i] = uint32(data.Len())
+		if err := encodeMMDBValue(&data, v); err != nil {
+			return 0, err
+		}
+	}
+
+	// 2. 按层序给内部节点编号
+	var nodes []*writerNode
+	index := map[*writerNode]uint32{}
+	queue := []*writerNode{w.root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		index[n] = uint32(len(nodes))
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			if c != nil && c.data == 0 && (c.children[0] != nil || c.children[1] != nil) {
+				queue = append(queue, c)
+			}
+		}
+	}
+	nodeCount := uint32(len(nodes))
+
+	record := func(c *writerNode) uint32 {
+		switch {
+		case c == nil, c.data == 0 && c.children[0] == nil && c.children[1] == nil:
+			return nodeCount
+		case c.data != 0:
+			return nodeCount + 16 + offsets[c.data-1]
+		default:
+			return index[c]
+		}
+	}
+
+	maxRecord := uint64(1)<<uint(w.recordSize) - 1
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		left, right := record(n.children[0]), record(n.children[1])
+		if uint64(left) > maxRecord || uint64(right) > maxRecord {
+			return 0, fmt.Errorf("mmdb: record exceeds %d bits", w.recordSize)
+		}
+		switch w.recordSize {
+		case 24:
+			buf.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+		case 28:
+			// 第 4 字节高半字节为左记录的高 4 位，低半字节为右记录的高 4 位
+			buf.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left),
+				byte(left>>24)<<4 | byte(right>>24)&0x0F,
+				byte(right >> 16), byte(right >> 8), byte(right)})
+		default:
+			_ = binary.Write(&buf, binary.BigEndian, left)
+			_ = binary.Write(&buf, binary.BigEndian, right)
+		}
+	}
+	buf.Write(make([]byte, 16))
+	buf.Write(data.Bytes())
+
+	// 3. 元数据
+	buf.Write(mmdbMetadataMarker)
+	err := encodeMMDBValue(&buf, map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 w.buildEpoch,
+		"database_type":               w.databaseType,
+		"description":                 map[string]interface{}{"en": w.databaseType},
+		"ip_version":                  uint16(w.ipVersion),
+		"languages":                   []string{"en"},
+		"node_count":                  nodeCount,
+		"record_size":                 uint16(w.recordSize),
+	})
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// Bytes 输出 mmdb 内容
+func (w *mmdbWriter) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeMMDBValue(buf *bytes.Buffer, v interface{}) error {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeMMDBCtrl(buf, mmdbTypeMap, len(keys))
+		for _, k := range keys {
+			writeMMDBCtrl(buf, mmdbTypeString, len(k))
+			buf.WriteString(k)
+			if err := encodeMMDBValue(buf, x[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		writeMMDBCtrl(buf, mmdbTypeArray, len(x))
+		for _, item := range x {
+			if err := encodeMMDBValue(buf, item); err != nil {
+				return err
+			}
+		}
+	case []string:
+		writeMMDBCtrl(buf, mmdbTypeArray, len(x))
+		for _, s := range x {
+			writeMMDBCtrl(buf, mmdbTypeString, len(s))
+			buf.WriteString(s)
+		}
+	case string:
+		writeMMDBCtrl(buf, mmdbTypeString, len(x))
+		buf.WriteString(x)
+	case []byte:
+		writeMMDBCtrl(buf, mmdbTypeBytes, len(x))
+		buf.Write(x)
+	case bool:
+		size := 0
+		if x {
+			size = 1
+		}
+		writeMMDBCtrl(buf, mmdbTypeBool, size)
+	case float64:
+		writeMMDBCtrl(buf, mmdbTypeDouble, 8)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(x))
+	case float32:
+		writeMMDBCtrl(buf, mmdbTypeFloat, 4)
+		_ = binary.Write(buf, binary.BigEndian, math.Float32bits(x))
+	case int32:
+		writeMMDBCtrl(buf, mmdbTypeInt32, 4)
+		_ = binary.Write(buf, binary.BigEndian, x)
+	case int:
+		if x < 0 {
+			return encodeMMDBValue(buf, int32(x))
+		}
+		return encodeMMDBValue(buf, uint64(x))
+	case uint:
+		return encodeMMDBValue(buf, uint64(x))
+	case uint16:
+		writeMMDBUint(buf, mmdbTypeUint16, uint64(x))
+	case uint32:
+		writeMMDBUint(buf, mmdbTypeUint32, uint64(x))
+	case uint64:
+		if x <= math.MaxUint32 {
+			writeMMDBUint(buf, mmdbTypeUint32, x)
+		} else {
+			writeMMDBUint(buf, mmdbTypeUint64, x)
+		}
+	default:
+		return fmt.Errorf("mmdb: unsupported value type %T", v)
+	}
+	return nil
+}
+
+// writeMMDBUint 无符号整数按最短字节编码
+func writeMMDBUint(buf *bytes.Buffer, typeNum int, n uint64) {
+	var b []byte
+	for v := n; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	writeMMDBCtrl(buf, typeNum, len(b))
+	buf.Write(b)
+}
+
+func writeMMDBCtrl(buf *bytes.Buffer, typeNum, size int) {
+	var ctrl byte
+	if typeNum <= 7 {
+		ctrl = byte(typeNum) << 5
+	}
+	var ext []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		ext = []byte{byte(size - 29)}
+	case size < 65821:
+		ctrl |= 30
+		n := size - 285
+		ext = []byte{byte(n >> 8), byte(n)}
+	default:
+		ctrl |= 31
+		n := size - 65821
+		ext = []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+	buf.WriteByte(ctrl)
+	if typeNum > 7 {
+		buf.WriteByte(byte(typeNum - 7))
+	}
+	buf.Write(ext)
+}